@@ -0,0 +1,240 @@
+package auth_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/ldap/ldaptest"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testBaseDN = "ou=people,dc=example,dc=com"
+
+type stubAuthenticator struct {
+	name      string
+	principal auth.Principal
+	err       error
+}
+
+func (s *stubAuthenticator) Name() string { return s.name }
+
+func (s *stubAuthenticator) Authenticate(context.Context, string, string) (auth.Principal, error) {
+	return s.principal, s.err
+}
+
+type stubUserStore map[string]repository.User
+
+func (s stubUserStore) GetUserByUsername(_ context.Context, username string) (repository.User, error) {
+	user, ok := s[username]
+	if !ok {
+		return repository.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func TestChain(t *testing.T) {
+	outage := errors.New("directory unreachable")
+	rejecting := func(name string) *stubAuthenticator {
+		return &stubAuthenticator{name: name, err: auth.ErrInvalidCredentials}
+	}
+
+	testCases := []struct {
+		name         string
+		chain        []*stubAuthenticator
+		wantProvider string
+		wantErr      error
+	}{
+		{"First Match Wins", []*stubAuthenticator{{name: "ldap", principal: auth.Principal{Provider: "ldap"}}, {name: "local", principal: auth.Principal{Provider: "local"}}}, "ldap", nil},
+		{"Falls Through Rejection", []*stubAuthenticator{rejecting("ldap"), {name: "local", principal: auth.Principal{Provider: "local"}}}, "local", nil},
+		{"Falls Through Outage", []*stubAuthenticator{{name: "ldap", err: outage}, {name: "local", principal: auth.Principal{Provider: "local"}}}, "local", nil},
+		{"All Reject", []*stubAuthenticator{rejecting("ldap"), rejecting("local")}, "", auth.ErrInvalidCredentials},
+		{"Outage Is Not A Wrong Password", []*stubAuthenticator{{name: "ldap", err: outage}, rejecting("local")}, "", outage},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var chain auth.Chain
+			for _, a := range tc.chain {
+				chain = append(chain, a)
+			}
+
+			principal, err := chain.Authenticate(context.Background(), "alice", "secret")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if principal.Provider != tc.wantProvider {
+				t.Errorf("expected provider %q, got %q", tc.wantProvider, principal.Provider)
+			}
+		})
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	ldapOnly := auth.NewChain(&stubAuthenticator{name: auth.ProviderLDAP})
+	if auth.Includes(ldapOnly, auth.ProviderLocal) {
+		t.Error("expected LDAP-only chain not to include the local provider")
+	}
+	if !auth.Includes(auth.NewChain(ldapOnly, auth.NewLocalAuthenticator(nil, nil)), auth.ProviderLocal) {
+		t.Error("expected nested chain to include the local provider")
+	}
+}
+
+func TestLocalAuthenticator(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	hasher := auth.NewPasswordHasher(testPasswordParams)
+	current, err := hasher.Hash("password123")
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	local := auth.NewLocalAuthenticator(stubUserStore{
+		"alice": {ID: 3, Username: "alice", Password: string(hash)},
+		"bob":   {ID: 4, Username: "bob", Password: current},
+	}, hasher)
+	ctx := context.Background()
+
+	principal, err := local.Authenticate(ctx, "alice", "password123")
+	if err != nil {
+		t.Fatalf("expected password to be accepted, got %v", err)
+	}
+	if principal.UserID != 3 || principal.Provider != auth.ProviderLocal || !principal.NeedsRehash {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+
+	principal, err = local.Authenticate(ctx, "bob", "password123")
+	if err != nil {
+		t.Fatalf("expected password to be accepted, got %v", err)
+	}
+	if principal.UserID != 4 || principal.NeedsRehash {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+
+	if _, err := local.Authenticate(ctx, "alice", "wrong"); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected wrong password to be rejected, got %v", err)
+	}
+	if _, err := local.Authenticate(ctx, "carol", "password123"); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected unknown user to be rejected, got %v", err)
+	}
+}
+
+func TestLDAPAuthenticator(t *testing.T) {
+	dir := ldaptest.NewDirectory(t,
+		ldaptest.Entry{DN: "cn=reader,dc=example,dc=com", Password: "reader-pass"},
+		ldaptest.Entry{
+			DN:         "uid=alice,ou=people,dc=example,dc=com",
+			Password:   "wonderland",
+			Attributes: map[string][]string{"uid": {"alice"}, "mail": {"alice@example.com"}},
+		},
+		ldaptest.Entry{DN: "uid=twin,ou=people,dc=example,dc=com", Attributes: map[string][]string{"uid": {"twin"}}},
+		ldaptest.Entry{DN: "uid=twin,ou=staff,ou=people,dc=example,dc=com", Attributes: map[string][]string{"uid": {"twin"}}},
+	)
+
+	ldapAuth := auth.NewLDAPAuthenticator(auth.LDAPConfig{
+		URL:          dir.URL(),
+		BaseDN:       testBaseDN,
+		BindDN:       "cn=reader,dc=example,dc=com",
+		BindPassword: "reader-pass",
+	})
+	ctx := context.Background()
+
+	principal, err := ldapAuth.Authenticate(ctx, "alice", "wonderland")
+	if err != nil {
+		t.Fatalf("expected bind to succeed, got %v", err)
+	}
+	want := auth.Principal{
+		Provider:      auth.ProviderLDAP,
+		Subject:       "uid=alice,ou=people,dc=example,dc=com",
+		Username:      "alice",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+	}
+	if principal != want {
+		t.Errorf("expected %+v, got %+v", want, principal)
+	}
+
+	for _, tc := range []struct{ username, password string }{
+		{"alice", "wrong"},
+		{"alice", ""},
+		{"nobody", "wonderland"},
+		// Filter syntax in the username is matched literally.
+		{"*", "wonderland"},
+		{"ali*", "wonderland"},
+	} {
+		if _, err := ldapAuth.Authenticate(ctx, tc.username, tc.password); !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Errorf("%s/%q: expected invalid credentials, got %v", tc.username, tc.password, err)
+		}
+	}
+
+	if _, err := ldapAuth.Authenticate(ctx, "twin", "x"); err == nil || errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected ambiguous username to be an error, got %v", err)
+	}
+}
+
+func TestLDAPAuthenticatorAnonymousSearch(t *testing.T) {
+	dir := ldaptest.NewDirectory(t, ldaptest.Entry{
+		DN:         "uid=alice,ou=people,dc=example,dc=com",
+		Password:   "wonderland",
+		Attributes: map[string][]string{"uid": {"alice"}, "mail": {"alice@example.com"}},
+	})
+
+	ldapAuth := auth.NewLDAPAuthenticator(auth.LDAPConfig{URL: dir.URL(), BaseDN: testBaseDN, EmailAttribute: "Mail"})
+
+	principal, err := ldapAuth.Authenticate(context.Background(), "alice", "wonderland")
+	if err != nil {
+		t.Fatalf("expected bind to succeed, got %v", err)
+	}
+	if principal.Email != "alice@example.com" {
+		t.Errorf("expected attribute names to be case insensitive, got email %q", principal.Email)
+	}
+	if binds := dir.Binds(); binds != 1 {
+		t.Errorf("expected only the user bind, got %d binds", binds)
+	}
+}
+
+func TestLDAPAuthenticatorUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	ldapAuth := auth.NewLDAPAuthenticator(auth.LDAPConfig{URL: "ldap://" + addr, BaseDN: testBaseDN})
+
+	_, err = ldapAuth.Authenticate(context.Background(), "alice", "wonderland")
+	if err == nil || errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("expected a connection error, got %v", err)
+	}
+}
+
+func TestAuthenticatorFromEnv(t *testing.T) {
+	t.Setenv("AUTH_PROVIDERS", "ldap,local")
+	t.Setenv("LDAP_URL", "")
+
+	if _, err := auth.AuthenticatorFromEnv(stubUserStore{}, auth.NewPasswordHasher(testPasswordParams)); err == nil {
+		t.Error("expected missing LDAP settings to be an error")
+	}
+
+	t.Setenv("LDAP_URL", "ldap://ldap.example.com")
+	t.Setenv("LDAP_BASE_DN", testBaseDN)
+
+	a, err := auth.AuthenticatorFromEnv(stubUserStore{}, auth.NewPasswordHasher(testPasswordParams))
+	if err != nil {
+		t.Fatalf("AuthenticatorFromEnv failed: %v", err)
+	}
+	if a.Name() != "ldap,local" {
+		t.Errorf("expected chain ldap,local, got %s", a.Name())
+	}
+
+	t.Setenv("AUTH_PROVIDERS", "kerberos")
+	if _, err := auth.AuthenticatorFromEnv(stubUserStore{}, auth.NewPasswordHasher(testPasswordParams)); err == nil {
+		t.Error("expected unknown provider to be an error")
+	}
+}
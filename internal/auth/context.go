@@ -0,0 +1,25 @@
+package auth
+
+import "context"
+
+type contextKey struct{}
+
+type Identity struct {
+	UserID    int64
+	SessionID int64
+	// Role is only known on routes behind a role check, which has to load the
+	// user anyway. It is empty elsewhere.
+	Role Role
+}
+
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(contextKey{}).(Identity)
+	if !ok || identity.UserID == 0 {
+		return Identity{}, false
+	}
+	return identity, true
+}
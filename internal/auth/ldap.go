@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldapTimeout applies to the whole exchange with the directory when the
+// request context has no deadline.
+const ldapTimeout = 10 * time.Second
+
+type LDAPConfig struct {
+	// URL is an ldap:// or ldaps:// URL. Plain ldap:// sends passwords in
+	// clear text and should only be used on a trusted network.
+	URL    string
+	BaseDN string
+	// BindDN and BindPassword are the service account used to find the
+	// user's entry. Both empty means an anonymous search.
+	BindDN         string
+	BindPassword   string
+	UserAttribute  string
+	EmailAttribute string
+}
+
+// LDAPConfigFromEnv reads LDAP_URL, LDAP_BASE_DN, LDAP_BIND_DN,
+// LDAP_BIND_PASSWORD, LDAP_USER_ATTRIBUTE (default uid) and
+// LDAP_EMAIL_ATTRIBUTE (default mail).
+func LDAPConfigFromEnv() (LDAPConfig, error) {
+	cfg := LDAPConfig{
+		URL:            os.Getenv("LDAP_URL"),
+		BaseDN:         os.Getenv("LDAP_BASE_DN"),
+		BindDN:         os.Getenv("LDAP_BIND_DN"),
+		BindPassword:   os.Getenv("LDAP_BIND_PASSWORD"),
+		UserAttribute:  os.Getenv("LDAP_USER_ATTRIBUTE"),
+		EmailAttribute: os.Getenv("LDAP_EMAIL_ATTRIBUTE"),
+	}
+
+	if cfg.URL == "" || cfg.BaseDN == "" {
+		return LDAPConfig{}, errors.New("LDAP_URL and LDAP_BASE_DN are required for the ldap provider")
+	}
+
+	return cfg, nil
+}
+
+// LDAPAuthenticator finds the user's entry with a search and then binds as
+// that entry with the given password.
+type LDAPAuthenticator struct {
+	cfg LDAPConfig
+}
+
+func NewLDAPAuthenticator(cfg LDAPConfig) *LDAPAuthenticator {
+	if cfg.UserAttribute == "" {
+		cfg.UserAttribute = "uid"
+	}
+	if cfg.EmailAttribute == "" {
+		cfg.EmailAttribute = "mail"
+	}
+
+	return &LDAPAuthenticator{cfg: cfg}
+}
+
+func (a *LDAPAuthenticator) Name() string {
+	return ProviderLDAP
+}
+
+func (a *LDAPAuthenticator) Authenticate(ctx context.Context, username, password string) (Principal, error) {
+	if username == "" || password == "" {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(ldapTimeout)
+	}
+
+	conn, err := ldap.DialURL(a.cfg.URL, ldap.DialWithDialer(&net.Dialer{Deadline: deadline}))
+	if err != nil {
+		return Principal{}, err
+	}
+	defer conn.Close()
+	conn.SetTimeout(time.Until(deadline))
+
+	// Without a service account the search runs anonymously, which needs no
+	// bind at all in LDAPv3.
+	if a.cfg.BindDN != "" || a.cfg.BindPassword != "" {
+		if err := conn.Bind(a.cfg.BindDN, a.cfg.BindPassword); err != nil {
+			return Principal{}, fmt.Errorf("service bind: %w", err)
+		}
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		a.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf("(%s=%s)", ldap.EscapeFilter(a.cfg.UserAttribute), ldap.EscapeFilter(username)),
+		[]string{a.cfg.UserAttribute, a.cfg.EmailAttribute},
+		nil,
+	))
+	if result != nil && len(result.Entries) > 1 {
+		return Principal{}, fmt.Errorf("username %q matches more than one entry", username)
+	}
+	if err != nil {
+		return Principal{}, fmt.Errorf("search: %w", err)
+	}
+	if len(result.Entries) == 0 {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	entry := result.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return Principal{}, ErrInvalidCredentials
+		}
+		return Principal{}, fmt.Errorf("user bind: %w", err)
+	}
+
+	name := entry.GetEqualFoldAttributeValue(a.cfg.UserAttribute)
+	if name == "" {
+		name = username
+	}
+
+	return Principal{
+		Provider: ProviderLDAP,
+		// DNs compare case insensitively.
+		Subject:  strings.ToLower(entry.DN),
+		Username: name,
+		Email:    entry.GetEqualFoldAttributeValue(a.cfg.EmailAttribute),
+		// The directory is administered, so its addresses are trusted.
+		EmailVerified: true,
+	}, nil
+}
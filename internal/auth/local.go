@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+// UserStore is the part of the repository that LocalAuthenticator reads.
+type UserStore interface {
+	GetUserByUsername(ctx context.Context, username string) (repository.User, error)
+}
+
+// LocalAuthenticator checks passwords against the hashes in the users table.
+type LocalAuthenticator struct {
+	users  UserStore
+	hasher *PasswordHasher
+	// dummyHash is compared against when the username does not exist, so the
+	// response time does not tell whether an account is registered.
+	dummyHash func() string
+}
+
+func NewLocalAuthenticator(users UserStore, hasher *PasswordHasher) *LocalAuthenticator {
+	return &LocalAuthenticator{
+		users:  users,
+		hasher: hasher,
+		dummyHash: sync.OnceValue(func() string {
+			hash, err := hasher.Hash("not-a-real-password")
+			if err != nil {
+				panic(err)
+			}
+			return hash
+		}),
+	}
+}
+
+func (a *LocalAuthenticator) Name() string {
+	return ProviderLocal
+}
+
+func (a *LocalAuthenticator) Authenticate(ctx context.Context, username, password string) (Principal, error) {
+	user, err := a.users.GetUserByUsername(ctx, username)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Principal{}, err
+	}
+
+	// Unknown users are checked against a dummy hash so both failures take
+	// about as long.
+	passwordHash := user.Password
+	if err != nil {
+		passwordHash = a.dummyHash()
+	}
+
+	match, needsRehash := a.hasher.Verify(passwordHash, password)
+	if !match || user.ID == 0 {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	return Principal{
+		Provider:    ProviderLocal,
+		UserID:      user.ID,
+		Username:    user.Username,
+		Email:       user.Email.String,
+		NeedsRehash: needsRehash,
+	}, nil
+}
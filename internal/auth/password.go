@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	argon2idPrefix = "$argon2id$"
+	saltLength     = 16
+	keyLength      = 32
+)
+
+var (
+	// DefaultPasswordParams follow the second recommended option of RFC 9106
+	// for memory constrained environments.
+	DefaultPasswordParams = PasswordParams{Memory: 64 * 1024, Iterations: 3, Parallelism: 4}
+
+	ErrUnknownHashFormat = errors.New("unknown password hash format")
+)
+
+var hashEncoding = base64.RawStdEncoding
+
+// PasswordParams are the argon2id cost parameters. Memory is in KiB.
+type PasswordParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// PasswordParamsFromEnv reads ARGON2_MEMORY_KIB, ARGON2_ITERATIONS and
+// ARGON2_PARALLELISM, keeping the default for each one that is unset or
+// invalid.
+func PasswordParamsFromEnv() PasswordParams {
+	params := DefaultPasswordParams
+	if n, ok := uintFromEnv("ARGON2_MEMORY_KIB", 32); ok && n >= 8*1024 {
+		params.Memory = uint32(n)
+	}
+	if n, ok := uintFromEnv("ARGON2_ITERATIONS", 32); ok && n >= 1 {
+		params.Iterations = uint32(n)
+	}
+	if n, ok := uintFromEnv("ARGON2_PARALLELISM", 8); ok && n >= 1 {
+		params.Parallelism = uint8(n)
+	}
+	return params
+}
+
+// PasswordHasher creates argon2id hashes and verifies those as well as older
+// bcrypt hashes. Hashes use the PHC string format, for example
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>, so each one records the
+// parameters it was made with.
+type PasswordHasher struct {
+	params PasswordParams
+}
+
+func NewPasswordHasher(params PasswordParams) *PasswordHasher {
+	return &PasswordHasher{params: params}
+}
+
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	p := h.params
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, keyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		p.Memory, p.Iterations, p.Parallelism,
+		hashEncoding.EncodeToString(salt), hashEncoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether password matches hash, and whether the hash should
+// be replaced because it uses another algorithm or weaker parameters than
+// the hasher. A hash in an unknown format never matches.
+func (h *PasswordHasher) Verify(hash, password string) (match, needsRehash bool) {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		params, salt, key, err := parseArgon2id(hash)
+		if err != nil {
+			return false, false
+		}
+
+		candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(candidate, key) != 1 {
+			return false, false
+		}
+
+		weaker := params.Memory < h.params.Memory ||
+			params.Iterations < h.params.Iterations ||
+			params.Parallelism < h.params.Parallelism ||
+			len(key) < keyLength
+		return true, weaker
+
+	case strings.HasPrefix(hash, "$2"):
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+			return false, false
+		}
+		return true, true
+
+	default:
+		return false, false
+	}
+}
+
+func parseArgon2id(hash string) (PasswordParams, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return PasswordParams{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return PasswordParams{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	var params PasswordParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return PasswordParams{}, nil, nil, ErrUnknownHashFormat
+	}
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return PasswordParams{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	salt, err := hashEncoding.DecodeString(parts[4])
+	if err != nil {
+		return PasswordParams{}, nil, nil, ErrUnknownHashFormat
+	}
+	key, err := hashEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return PasswordParams{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	return params, salt, key, nil
+}
+
+func uintFromEnv(name string, bits int) (uint64, bool) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(value, 10, bits)
+	return n, err == nil
+}
@@ -0,0 +1,101 @@
+package auth_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testPasswordParams keep tests fast. Production uses DefaultPasswordParams.
+var testPasswordParams = auth.PasswordParams{Memory: 8 * 1024, Iterations: 1, Parallelism: 1}
+
+func TestPasswordHashRoundTrip(t *testing.T) {
+	hasher := auth.NewPasswordHasher(testPasswordParams)
+
+	hash, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=8192,t=1,p=1$") {
+		t.Errorf("unexpected hash format %q", hash)
+	}
+
+	if match, needsRehash := hasher.Verify(hash, "correct horse"); !match || needsRehash {
+		t.Errorf("expected match without rehash, got match=%v needsRehash=%v", match, needsRehash)
+	}
+	if match, _ := hasher.Verify(hash, "wrong horse"); match {
+		t.Error("expected wrong password not to match")
+	}
+
+	other, _ := hasher.Hash("correct horse")
+	if other == hash {
+		t.Error("expected a fresh salt for every hash")
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	weak := auth.NewPasswordHasher(testPasswordParams)
+	strong := auth.NewPasswordHasher(auth.PasswordParams{Memory: 16 * 1024, Iterations: 2, Parallelism: 1})
+
+	weakHash, _ := weak.Hash("secret")
+	strongHash, _ := strong.Hash("secret")
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+
+	testCases := []struct {
+		name        string
+		hasher      *auth.PasswordHasher
+		hash        string
+		needsRehash bool
+	}{
+		{"Legacy Bcrypt", strong, string(bcryptHash), true},
+		{"Weaker Parameters", strong, weakHash, true},
+		{"Current Parameters", strong, strongHash, false},
+		{"Stronger Parameters Are Kept", weak, strongHash, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			match, needsRehash := tc.hasher.Verify(tc.hash, "secret")
+			if !match {
+				t.Fatal("expected password to match")
+			}
+			if needsRehash != tc.needsRehash {
+				t.Errorf("expected needsRehash=%v, got %v", tc.needsRehash, needsRehash)
+			}
+		})
+	}
+}
+
+func TestPasswordVerifyRejectsMalformedHashes(t *testing.T) {
+	hasher := auth.NewPasswordHasher(testPasswordParams)
+	hash, _ := hasher.Hash("secret")
+	parts := strings.Split(hash, "$")
+
+	for _, malformed := range []string{
+		"",
+		"!sso:0123456789abcdef",
+		"$argon2i$v=19$m=8192,t=1,p=1$" + parts[4] + "$" + parts[5],
+		"$argon2id$v=16$m=8192,t=1,p=1$" + parts[4] + "$" + parts[5],
+		"$argon2id$v=19$m=0,t=1,p=1$" + parts[4] + "$" + parts[5],
+		"$argon2id$v=19$m=8192,t=1,p=1$" + parts[4] + "$",
+		"$argon2id$v=19$m=8192,t=2,p=1$" + parts[4] + "$" + parts[5],
+	} {
+		if match, _ := hasher.Verify(malformed, "secret"); match {
+			t.Errorf("expected %q not to match", malformed)
+		}
+	}
+}
+
+func TestPasswordParamsFromEnv(t *testing.T) {
+	t.Setenv("ARGON2_MEMORY_KIB", "131072")
+	t.Setenv("ARGON2_ITERATIONS", "not-a-number")
+	t.Setenv("ARGON2_PARALLELISM", "2")
+
+	params := auth.PasswordParamsFromEnv()
+	want := auth.PasswordParams{Memory: 131072, Iterations: auth.DefaultPasswordParams.Iterations, Parallelism: 2}
+	if params != want {
+		t.Errorf("expected %+v, got %+v", want, params)
+	}
+}
@@ -0,0 +1,316 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Violation codes reported by Policy. Clients can match on them to show a
+// translated message next to the field.
+const (
+	ViolationRequired          = "required"
+	ViolationTooShort          = "too_short"
+	ViolationTooLong           = "too_long"
+	ViolationInvalidCharacters = "invalid_characters"
+	ViolationMixedScripts      = "mixed_scripts"
+	ViolationReserved          = "reserved"
+	ViolationTaken             = "taken"
+	ViolationBreached          = "breached"
+	ViolationMatchesUsername   = "matches_username"
+	ViolationInvalid           = "invalid"
+)
+
+// DefaultReservedUsernames cannot be registered, nor anything that looks like
+// them, such as "Admin" or "аdmin" with a Cyrillic a.
+var DefaultReservedUsernames = []string{
+	"admin", "administrator", "root", "system", "moderator", "support",
+	"security", "help", "everyone", "here", "me", "null", "undefined",
+}
+
+// Violation is a rule that a field of a request breaks.
+type Violation struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// PolicyConfig holds the username and password rules.
+type PolicyConfig struct {
+	UsernameMinLength int
+	UsernameMaxLength int
+	ReservedUsernames []string
+	PasswordMinLength int
+	PasswordMaxLength int
+	// BreachedPasswords holds upper case hex SHA-1 digests of passwords that
+	// appeared in known breaches.
+	BreachedPasswords map[string]struct{}
+}
+
+// DefaultPolicyConfig follows NIST SP 800-63B for passwords: a minimum length,
+// no composition rules, and a check against breached passwords when a list is
+// configured.
+func DefaultPolicyConfig() PolicyConfig {
+	return PolicyConfig{
+		UsernameMinLength: 3,
+		UsernameMaxLength: 32,
+		ReservedUsernames: DefaultReservedUsernames,
+		PasswordMinLength: 8,
+		PasswordMaxLength: 256,
+	}
+}
+
+// PolicyConfigFromEnv reads USERNAME_MIN_LENGTH, USERNAME_MAX_LENGTH,
+// RESERVED_USERNAMES, PASSWORD_MIN_LENGTH and BREACHED_PASSWORDS_FILE on top
+// of the defaults. RESERVED_USERNAMES is a comma separated list added to the
+// default one. It fails only when the breached password file cannot be read.
+func PolicyConfigFromEnv() (PolicyConfig, error) {
+	cfg := DefaultPolicyConfig()
+	if n, ok := uintFromEnv("USERNAME_MIN_LENGTH", 16); ok && n >= 1 {
+		cfg.UsernameMinLength = int(n)
+	}
+	if n, ok := uintFromEnv("USERNAME_MAX_LENGTH", 16); ok && int(n) >= cfg.UsernameMinLength {
+		cfg.UsernameMaxLength = int(n)
+	}
+	if n, ok := uintFromEnv("PASSWORD_MIN_LENGTH", 16); ok && n >= 1 && int(n) <= cfg.PasswordMaxLength {
+		cfg.PasswordMinLength = int(n)
+	}
+
+	if value := os.Getenv("RESERVED_USERNAMES"); value != "" {
+		reserved := append([]string(nil), cfg.ReservedUsernames...)
+		for name := range strings.SplitSeq(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				reserved = append(reserved, name)
+			}
+		}
+		cfg.ReservedUsernames = reserved
+	}
+
+	if path := os.Getenv("BREACHED_PASSWORDS_FILE"); path != "" {
+		breached, err := LoadBreachedPasswords(path)
+		if err != nil {
+			return PolicyConfig{}, err
+		}
+		cfg.BreachedPasswords = breached
+	}
+
+	return cfg, nil
+}
+
+// LoadBreachedPasswords reads a file with one entry per line. An entry is
+// either a plain password or the hex SHA-1 digest of one, optionally followed
+// by ":count" as in the Have I Been Pwned downloads. Empty lines and lines
+// starting with # are skipped.
+func LoadBreachedPasswords(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open breached password list: %w", err)
+	}
+	defer f.Close()
+
+	breached := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		breached[breachedEntryDigest(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breached password list: %w", err)
+	}
+
+	return breached, nil
+}
+
+func breachedEntryDigest(line string) string {
+	digest, count, hasCount := strings.Cut(line, ":")
+	if len(digest) == sha1.Size*2 && isHex(digest) {
+		if _, err := strconv.ParseUint(count, 10, 64); !hasCount || err == nil {
+			return strings.ToUpper(digest)
+		}
+	}
+	return passwordDigest(line)
+}
+
+func passwordDigest(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Policy validates usernames and passwords chosen by users. Uniqueness needs
+// the database, so it is left to the caller, which compares UsernameKey.
+type Policy struct {
+	cfg      PolicyConfig
+	reserved map[string]struct{}
+}
+
+func NewPolicy(cfg PolicyConfig) *Policy {
+	reserved := make(map[string]struct{}, len(cfg.ReservedUsernames))
+	for _, name := range cfg.ReservedUsernames {
+		reserved[UsernameKey(name)] = struct{}{}
+	}
+
+	return &Policy{cfg: cfg, reserved: reserved}
+}
+
+// CheckUsername returns the rules that username breaks. Usernames are made of
+// letters, digits, '.', '_' and '-', start with a letter or digit, and do not
+// mix scripts that can be confused with each other, such as Latin and
+// Cyrillic.
+func (p *Policy) CheckUsername(username string) []Violation {
+	const field = "username"
+
+	length := utf8.RuneCountInString(username)
+	switch {
+	case length == 0:
+		return []Violation{{field, ViolationRequired, "Username is required"}}
+	case length < p.cfg.UsernameMinLength:
+		return []Violation{{field, ViolationTooShort, fmt.Sprintf("Username must be at least %d characters", p.cfg.UsernameMinLength)}}
+	case length > p.cfg.UsernameMaxLength:
+		return []Violation{{field, ViolationTooLong, fmt.Sprintf("Username must be at most %d characters", p.cfg.UsernameMaxLength)}}
+	}
+
+	if !validUsernameCharacters(username) {
+		return []Violation{{field, ViolationInvalidCharacters,
+			"Username may only contain letters, digits, '.', '_' and '-', and must start with a letter or digit"}}
+	}
+
+	if mixesScripts(username) {
+		return []Violation{{field, ViolationMixedScripts, "Username must not mix letters from different alphabets"}}
+	}
+
+	if _, ok := p.reserved[UsernameKey(username)]; ok {
+		return []Violation{{field, ViolationReserved, "Username is reserved"}}
+	}
+
+	return nil
+}
+
+// CheckPassword returns the rules that password breaks. username may be empty
+// when it is not known.
+func (p *Policy) CheckPassword(field, password, username string) []Violation {
+	length := utf8.RuneCountInString(password)
+	switch {
+	case length == 0:
+		return []Violation{{field, ViolationRequired, "Password is required"}}
+	case length < p.cfg.PasswordMinLength:
+		return []Violation{{field, ViolationTooShort, fmt.Sprintf("Password must be at least %d characters", p.cfg.PasswordMinLength)}}
+	case length > p.cfg.PasswordMaxLength:
+		return []Violation{{field, ViolationTooLong, fmt.Sprintf("Password must be at most %d characters", p.cfg.PasswordMaxLength)}}
+	}
+
+	if username != "" && UsernameKey(password) == UsernameKey(username) {
+		return []Violation{{field, ViolationMatchesUsername, "Password must not be the same as the username"}}
+	}
+
+	if _, ok := p.cfg.BreachedPasswords[passwordDigest(password)]; ok {
+		return []Violation{{field, ViolationBreached, "Password has appeared in a data breach, choose another one"}}
+	}
+
+	return nil
+}
+
+func validUsernameCharacters(username string) bool {
+	for i, r := range username {
+		switch {
+		case unicode.IsLetter(r), unicode.Is(unicode.Nd, r):
+		case i > 0 && (r == '.' || r == '_' || r == '-'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// mixesScripts reports whether the letters of s come from more than one
+// script. Han, Hiragana, Katakana, Hangul and Bopomofo may be combined with
+// each other and with Latin, as Chinese, Japanese and Korean names often
+// are. Letters outside the known scripts, such as mathematical alphanumerics,
+// count as mixing.
+func mixesScripts(s string) bool {
+	var first *unicode.RangeTable
+	cjk, latin := false, false
+
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		script := scriptOf(r)
+		switch {
+		case script == nil:
+			return true
+		case isCJKScript(script):
+			cjk = true
+		case script == unicode.Latin:
+			latin = true
+		default:
+			if first != nil && first != script {
+				return true
+			}
+			first = script
+		}
+	}
+
+	return first != nil && (cjk || latin)
+}
+
+var knownScripts = []*unicode.RangeTable{
+	unicode.Latin, unicode.Greek, unicode.Cyrillic, unicode.Armenian, unicode.Georgian,
+	unicode.Hebrew, unicode.Arabic, unicode.Devanagari, unicode.Bengali, unicode.Tamil,
+	unicode.Thai, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul,
+	unicode.Bopomofo,
+}
+
+func scriptOf(r rune) *unicode.RangeTable {
+	for _, script := range knownScripts {
+		if unicode.Is(script, r) {
+			return script
+		}
+	}
+	return nil
+}
+
+func isCJKScript(script *unicode.RangeTable) bool {
+	switch script {
+	case unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Bopomofo:
+		return true
+	}
+	return false
+}
+
+// UsernameKey folds case and replaces characters that look like a Latin
+// letter with that letter, so "Alice", "ALICE" and "аlice" with a Cyrillic a
+// share a key. Usernames are unique by key. For plain ASCII names the key is
+// the lower case name.
+func UsernameKey(username string) string {
+	var b strings.Builder
+	b.Grow(len(username))
+	for _, r := range username {
+		if latin, ok := confusables[r]; ok {
+			r = latin
+		} else if r >= '！' && r <= '～' {
+			// Fullwidth forms of ASCII.
+			r -= '！' - '!'
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
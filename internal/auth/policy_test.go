@@ -0,0 +1,126 @@
+package auth_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+)
+
+func TestCheckUsername(t *testing.T) {
+	policy := auth.NewPolicy(auth.DefaultPolicyConfig())
+
+	testCases := []struct {
+		username string
+		wantCode string
+	}{
+		{"alice", ""},
+		{"alice.smith_2-b", ""},
+		{"José", ""},
+		{"Дмитрий", ""},
+		{"李alice", ""},
+		{"", auth.ViolationRequired},
+		{"al", auth.ViolationTooShort},
+		{"a123456789012345678901234567890123", auth.ViolationTooLong},
+		{"_alice", auth.ViolationInvalidCharacters},
+		{"alice smith", auth.ViolationInvalidCharacters},
+		{"alice\u0301", auth.ViolationInvalidCharacters}, // combining accent
+		{"pаypal", auth.ViolationMixedScripts},
+		{"𝐚𝐥𝐢𝐜𝐞", auth.ViolationMixedScripts},
+		{"ADMIN", auth.ViolationReserved},
+		{"аdmіn", auth.ViolationMixedScripts},
+		{"ѕуѕтем", auth.ViolationReserved},
+		{"ｒｏｏｔ", auth.ViolationReserved},
+	}
+
+	for _, tc := range testCases {
+		violations := policy.CheckUsername(tc.username)
+		switch {
+		case tc.wantCode == "" && len(violations) > 0:
+			t.Errorf("%q: expected no violations, got %+v", tc.username, violations)
+		case tc.wantCode != "" && (len(violations) != 1 || violations[0].Code != tc.wantCode || violations[0].Field != "username"):
+			t.Errorf("%q: expected %s, got %+v", tc.username, tc.wantCode, violations)
+		}
+	}
+}
+
+func TestUsernameKey(t *testing.T) {
+	for _, name := range []string{"Alice", "ALICE", "аlice", "ａｌｉｃｅ", "ΑLIСE"} {
+		if key := auth.UsernameKey(name); key != "alice" {
+			t.Errorf("%q: expected key alice, got %q", name, key)
+		}
+	}
+	if key := auth.UsernameKey("Dmitry.K_2"); key != "dmitry.k_2" {
+		t.Errorf("expected ASCII names to be lower cased, got %q", key)
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "breached.txt")
+	content := "# top passwords\npassword123\n\n" +
+		// SHA-1 of "letmein1", as in the Have I Been Pwned downloads.
+		"D04C1675B232C6ECE69ED95E189E95D589F217B0:42\r\n"
+	if err := os.WriteFile(list, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write breached password list: %v", err)
+	}
+
+	breached, err := auth.LoadBreachedPasswords(list)
+	if err != nil {
+		t.Fatalf("LoadBreachedPasswords failed: %v", err)
+	}
+	cfg := auth.DefaultPolicyConfig()
+	cfg.BreachedPasswords = breached
+	policy := auth.NewPolicy(cfg)
+
+	testCases := []struct {
+		password string
+		wantCode string
+	}{
+		{"correct horse battery", ""},
+		{"", auth.ViolationRequired},
+		{"short", auth.ViolationTooShort},
+		{"Alice.Smith", auth.ViolationMatchesUsername},
+		{"password123", auth.ViolationBreached},
+		{"letmein1", auth.ViolationBreached},
+	}
+
+	for _, tc := range testCases {
+		violations := policy.CheckPassword("password", tc.password, "alice.smith")
+		switch {
+		case tc.wantCode == "" && len(violations) > 0:
+			t.Errorf("%q: expected no violations, got %+v", tc.password, violations)
+		case tc.wantCode != "" && (len(violations) != 1 || violations[0].Code != tc.wantCode):
+			t.Errorf("%q: expected %s, got %+v", tc.password, tc.wantCode, violations)
+		}
+	}
+}
+
+func TestPolicyConfigFromEnv(t *testing.T) {
+	t.Setenv("USERNAME_MIN_LENGTH", "5")
+	t.Setenv("RESERVED_USERNAMES", " staff , bot")
+	t.Setenv("BREACHED_PASSWORDS_FILE", "")
+
+	cfg, err := auth.PolicyConfigFromEnv()
+	if err != nil {
+		t.Fatalf("PolicyConfigFromEnv failed: %v", err)
+	}
+
+	policy := auth.NewPolicy(cfg)
+	if v := policy.CheckUsername("alice"); len(v) != 0 {
+		t.Errorf("expected alice to be valid, got %+v", v)
+	}
+	if v := policy.CheckUsername("bob1"); len(v) != 1 || v[0].Code != auth.ViolationTooShort {
+		t.Errorf("expected bob1 to be too short, got %+v", v)
+	}
+	for _, name := range []string{"Staff", "admin"} {
+		if v := policy.CheckUsername(name); len(v) != 1 || v[0].Code != auth.ViolationReserved {
+			t.Errorf("expected %s to be reserved, got %+v", name, v)
+		}
+	}
+
+	t.Setenv("BREACHED_PASSWORDS_FILE", filepath.Join(t.TempDir(), "missing.txt"))
+	if _, err := auth.PolicyConfigFromEnv(); err == nil {
+		t.Error("expected a missing breached password file to be an error")
+	}
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+const (
+	resetCodeLength = 10
+
+	// Crockford's base32 alphabet leaves out I, L, O and U, which are easy to
+	// misread when a code is typed from an email.
+	resetCodeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+// NewResetCode returns a password reset code formatted for humans, such as
+// "7KQ2D-M9XA4", and the hash that is stored server side.
+func NewResetCode() (string, string, error) {
+	return newHumanCode()
+}
+
+// NewRecoveryCode returns a two-factor recovery code and its hash. Recovery
+// codes share the format of reset codes.
+func NewRecoveryCode() (string, string, error) {
+	return newHumanCode()
+}
+
+func newHumanCode() (string, string, error) {
+	raw := make([]byte, resetCodeLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	var b strings.Builder
+	for i, v := range raw {
+		if i == resetCodeLength/2 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(resetCodeAlphabet[int(v)%len(resetCodeAlphabet)])
+	}
+
+	code := b.String()
+	return code, HashHumanCode(code), nil
+}
+
+// HashHumanCode hashes a reset or recovery code. It normalizes case and
+// separators first, so a code is accepted however the user typed it.
+func HashHumanCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+
+	return HashRefreshToken(normalized)
+}
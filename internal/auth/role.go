@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Role is the server-wide role of a user. Channel roles are separate.
+type Role string
+
+const (
+	RoleAdmin     Role = "admin"
+	RoleModerator Role = "moderator"
+	RoleUser      Role = "user"
+)
+
+var roleRanks = map[Role]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if _, ok := roleRanks[role]; !ok {
+		return "", fmt.Errorf("unknown role %q", s)
+	}
+	return role, nil
+}
+
+// AtLeast reports whether r grants everything that min grants. Unknown roles
+// grant nothing.
+func (r Role) AtLeast(min Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[min]
+}
+
+// Outranks reports whether r is strictly above other, which is needed to
+// manage users with the other role.
+func (r Role) Outranks(other Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank > roleRanks[other]
+}
+
+// AdminUsernamesFromEnv returns the comma separated usernames in
+// ADMIN_USERNAMES, which are promoted to administrators at startup.
+func AdminUsernamesFromEnv() []string {
+	var usernames []string
+	for name := range strings.SplitSeq(os.Getenv("ADMIN_USERNAMES"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			usernames = append(usernames, name)
+		}
+	}
+	return usernames
+}
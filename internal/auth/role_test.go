@@ -0,0 +1,35 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+)
+
+func TestRoles(t *testing.T) {
+	if _, err := auth.ParseRole("owner"); err == nil {
+		t.Error("expected an unknown role to be rejected")
+	}
+	if role, err := auth.ParseRole("moderator"); err != nil || role != auth.RoleModerator {
+		t.Errorf("expected moderator, got %q, %v", role, err)
+	}
+
+	if !auth.RoleAdmin.AtLeast(auth.RoleModerator) || !auth.RoleModerator.AtLeast(auth.RoleModerator) {
+		t.Error("expected admins and moderators to pass a moderator check")
+	}
+	if auth.RoleUser.AtLeast(auth.RoleModerator) || auth.Role("").AtLeast(auth.RoleUser) {
+		t.Error("expected users and unknown roles to fail a moderator check")
+	}
+	if auth.RoleModerator.Outranks(auth.RoleModerator) || !auth.RoleModerator.Outranks(auth.RoleUser) {
+		t.Error("expected moderators to outrank users only")
+	}
+}
+
+func TestAdminUsernamesFromEnv(t *testing.T) {
+	t.Setenv("ADMIN_USERNAMES", " alice ,, bob")
+
+	got := auth.AdminUsernamesFromEnv()
+	if len(got) != 2 || got[0] != "alice" || got[1] != "bob" {
+		t.Errorf("expected [alice bob], got %v", got)
+	}
+}
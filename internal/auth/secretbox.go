@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+)
+
+var ErrDecrypt = errors.New("failed to decrypt value")
+
+// SecretBox encrypts small values, such as TOTP secrets, before they are
+// stored. It uses AES-256-GCM with a random nonce per value.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox derives the AES key from key with SHA-256, so any key of
+// reasonable length can be used.
+func NewSecretBox(key []byte) *SecretBox {
+	derived := sha256.Sum256(key)
+
+	block, err := aes.NewCipher(derived[:])
+	if err != nil {
+		// A 32-byte key is always valid for AES-256.
+		panic(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+
+	return &SecretBox{
+		aead: aead,
+	}
+}
+
+func (b *SecretBox) Seal(plaintext []byte) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := b.aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func (b *SecretBox) Open(sealed string) ([]byte, error) {
+	raw, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < b.aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	nonce, ciphertext := raw[:b.aead.NonceSize()], raw[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plaintext, nil
+}
+
+// EncryptionKeyFromEnv returns ENCRYPTION_KEY, falling back to AUTH_SECRET.
+// When neither is set it returns a random key and reports it as generated, in
+// which case encrypted values cannot be read after a restart.
+func EncryptionKeyFromEnv() ([]byte, bool) {
+	if key := os.Getenv("ENCRYPTION_KEY"); len(key) >= secretMinLength {
+		return []byte(key), false
+	}
+
+	if secret := os.Getenv("AUTH_SECRET"); len(secret) >= secretMinLength {
+		return []byte("encryption:" + secret), false
+	}
+
+	return RandomSecret(), true
+}
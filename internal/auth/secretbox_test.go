@@ -0,0 +1,60 @@
+package auth_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+)
+
+func TestSecretBoxRoundTrip(t *testing.T) {
+	box := auth.NewSecretBox([]byte("an encryption key used for tests only"))
+
+	sealed, err := box.Seal([]byte("totp secret"))
+	if err != nil {
+		t.Fatalf("Seal returned error: %v", err)
+	}
+
+	again, err := box.Seal([]byte("totp secret"))
+	if err != nil {
+		t.Fatalf("Seal returned error: %v", err)
+	}
+	if sealed == again {
+		t.Error("expected a fresh nonce for every value")
+	}
+
+	plaintext, err := box.Open(sealed)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	if string(plaintext) != "totp secret" {
+		t.Errorf("expected %q, got %q", "totp secret", plaintext)
+	}
+}
+
+func TestSecretBoxRejectsTamperedOrForeignValues(t *testing.T) {
+	box := auth.NewSecretBox([]byte("an encryption key used for tests only"))
+	other := auth.NewSecretBox([]byte("a different key entirely"))
+
+	sealed, err := box.Seal([]byte("totp secret"))
+	if err != nil {
+		t.Fatalf("Seal returned error: %v", err)
+	}
+
+	tampered := []byte(sealed)
+	tampered[len(tampered)-1] ^= 'A' ^ 'B'
+
+	for name, value := range map[string]string{
+		"Tampered": string(tampered),
+		"Garbage":  "not base64!",
+		"Short":    "AAAA",
+	} {
+		if _, err := box.Open(value); !errors.Is(err, auth.ErrDecrypt) {
+			t.Errorf("%s: expected ErrDecrypt, got %v", name, err)
+		}
+	}
+
+	if _, err := other.Open(sealed); !errors.Is(err, auth.ErrDecrypt) {
+		t.Errorf("expected ErrDecrypt with a different key, got %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	secretMinLength        = 32
+	refreshTokenLength     = 32
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token expired")
+
+	// Tokens are plain HS256 JWTs, so the header never changes.
+	tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+)
+
+const purposeMFAChallenge = "mfa"
+
+// Claims are shared by access tokens and short-lived challenge tokens. Purpose
+// is empty for access tokens, so neither kind is accepted in place of the
+// other.
+type Claims struct {
+	UserID    int64  `json:"uid"`
+	SessionID int64  `json:"sid,omitempty"`
+	Purpose   string `json:"pur,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type TokenManager struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewTokenManager(secret []byte, ttl time.Duration) *TokenManager {
+	if ttl <= 0 {
+		ttl = defaultAccessTokenTTL
+	}
+
+	return &TokenManager{
+		secret: secret,
+		ttl:    ttl,
+	}
+}
+
+func (tm *TokenManager) Issue(userID, sessionID int64) (string, time.Time, error) {
+	return tm.issue(Claims{UserID: userID, SessionID: sessionID}, tm.ttl)
+}
+
+func (tm *TokenManager) Parse(token string) (Claims, error) {
+	claims, err := tm.parse(token)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if claims.Purpose != "" || claims.SessionID == 0 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// IssueChallenge returns a token proving that userID passed the password step
+// of a login that still needs a second factor. It cannot be used as an access
+// token.
+func (tm *TokenManager) IssueChallenge(userID int64, ttl time.Duration) (string, time.Time, error) {
+	return tm.issue(Claims{UserID: userID, Purpose: purposeMFAChallenge}, ttl)
+}
+
+func (tm *TokenManager) ParseChallenge(token string) (Claims, error) {
+	claims, err := tm.parse(token)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if claims.Purpose != purposeMFAChallenge {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func (tm *TokenManager) issue(claims Claims, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = expiresAt.Unix()
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + tm.sign(unsigned), expiresAt, nil
+}
+
+func (tm *TokenManager) parse(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return Claims{}, ErrInvalidToken
+	}
+
+	unsigned := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(tm.sign(unsigned))) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.UserID == 0 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrExpiredToken
+	}
+
+	return claims, nil
+}
+
+func (tm *TokenManager) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, tm.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SecretFromEnv returns AUTH_SECRET, or a random secret when it is unset or too
+// short. The boolean reports whether the secret was generated, in which case
+// issued tokens will not survive a restart.
+func SecretFromEnv() ([]byte, bool) {
+	if secret := os.Getenv("AUTH_SECRET"); len(secret) >= secretMinLength {
+		return []byte(secret), false
+	}
+
+	return RandomSecret(), true
+}
+
+func RandomSecret() []byte {
+	secret := make([]byte, secretMinLength)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}
+
+// NewRefreshToken returns an opaque refresh token and the hash that is stored
+// server side. Only the hash ever reaches the database.
+func NewRefreshToken() (string, string, error) {
+	raw := make([]byte, refreshTokenLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashRefreshToken(token), nil
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func RefreshTokenTTLFromEnv() time.Duration {
+	return durationFromEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+}
+
+func AccessTokenTTLFromEnv() time.Duration {
+	return durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+}
+
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+
+	return d
+}
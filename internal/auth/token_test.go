@@ -0,0 +1,150 @@
+package auth_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+)
+
+func TestIssueAndParseToken(t *testing.T) {
+	tm := auth.NewTokenManager(auth.RandomSecret(), time.Hour)
+
+	token, expiresAt, err := tm.Issue(7, 1)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if time.Until(expiresAt) <= 0 {
+		t.Errorf("Expected expiry in the future, got %v", expiresAt)
+	}
+
+	claims, err := tm.Parse(token)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if claims.UserID != 7 {
+		t.Errorf("Expected user ID 7, got %d", claims.UserID)
+	}
+	if claims.SessionID != 1 {
+		t.Errorf("Expected session ID 1, got %d", claims.SessionID)
+	}
+}
+
+func TestParseTamperedToken(t *testing.T) {
+	tm := auth.NewTokenManager(auth.RandomSecret(), time.Hour)
+	other := auth.NewTokenManager(auth.RandomSecret(), time.Hour)
+
+	token, _, err := tm.Issue(7, 1)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	otherToken, _, err := other.Issue(8, 2)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	parts := strings.Split(token, ".")
+	otherParts := strings.Split(otherToken, ".")
+
+	testCases := []struct {
+		name  string
+		token string
+	}{
+		{"Empty", ""},
+		{"Garbage", "abc"},
+		{"Swapped Payload", parts[0] + "." + otherParts[1] + "." + parts[2]},
+		{"Foreign Signature", otherToken},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tm.Parse(tc.token); !errors.Is(err, auth.ErrInvalidToken) {
+				t.Errorf("Expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseExpiredToken(t *testing.T) {
+	tm := auth.NewTokenManager(auth.RandomSecret(), time.Nanosecond)
+
+	token, _, err := tm.Issue(7, 1)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	time.Sleep(time.Second)
+
+	if _, err := tm.Parse(token); !errors.Is(err, auth.ErrExpiredToken) {
+		t.Errorf("Expected ErrExpiredToken, got %v", err)
+	}
+}
+
+func TestNewRefreshToken(t *testing.T) {
+	token, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		t.Fatalf("NewRefreshToken failed: %v", err)
+	}
+	if token == "" || hash == "" {
+		t.Fatal("Expected non-empty token and hash")
+	}
+	if token == hash {
+		t.Error("Expected the stored hash to differ from the token")
+	}
+	if auth.HashRefreshToken(token) != hash {
+		t.Error("Expected HashRefreshToken to match the returned hash")
+	}
+
+	other, _, err := auth.NewRefreshToken()
+	if err != nil {
+		t.Fatalf("NewRefreshToken failed: %v", err)
+	}
+	if other == token {
+		t.Error("Expected refresh tokens to be unique")
+	}
+}
+
+func TestNewResetCode(t *testing.T) {
+	code, hash, err := auth.NewResetCode()
+	if err != nil {
+		t.Fatalf("NewResetCode returned error: %v", err)
+	}
+
+	if len(code) != 11 || code[5] != '-' {
+		t.Errorf("expected code formatted as XXXXX-XXXXX, got %q", code)
+	}
+	if hash != auth.HashHumanCode(code) {
+		t.Error("expected hash to match the code")
+	}
+	if hash != auth.HashHumanCode(" "+strings.ToLower(strings.ReplaceAll(code, "-", ""))+" ") {
+		t.Error("expected hash to ignore case, separators and surrounding spaces")
+	}
+}
+
+func TestChallengeTokenIsNotAnAccessToken(t *testing.T) {
+	tm := auth.NewTokenManager(auth.RandomSecret(), time.Hour)
+
+	challenge, _, err := tm.IssueChallenge(7, time.Minute)
+	if err != nil {
+		t.Fatalf("IssueChallenge returned error: %v", err)
+	}
+
+	claims, err := tm.ParseChallenge(challenge)
+	if err != nil || claims.UserID != 7 {
+		t.Fatalf("expected challenge for user 7, got %+v (%v)", claims, err)
+	}
+
+	if _, err := tm.Parse(challenge); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("expected challenge to be rejected as access token, got %v", err)
+	}
+
+	access, _, err := tm.Issue(7, 1)
+	if err != nil {
+		t.Fatalf("Issue returned error: %v", err)
+	}
+	if _, err := tm.ParseChallenge(access); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Errorf("expected access token to be rejected as challenge, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package auth_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+)
+
+// Test vectors from RFC 6238 appendix B, truncated to 6 digits.
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestTOTPCodeRFCVectors(t *testing.T) {
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tc := range testCases {
+		step := auth.TOTPStep(time.Unix(tc.unix, 0))
+		if got := auth.TOTPCode(rfc6238Secret, step); got != tc.code {
+			t.Errorf("at %d expected %s, got %s", tc.unix, tc.code, got)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	current := auth.TOTPStep(now)
+
+	testCases := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"Current Step", auth.TOTPCode(rfc6238Secret, current), current, true},
+		{"Previous Step", auth.TOTPCode(rfc6238Secret, current-1), current - 1, true},
+		{"Next Step", auth.TOTPCode(rfc6238Secret, current+1), current + 1, true},
+		{"Too Old", auth.TOTPCode(rfc6238Secret, current-2), 0, false},
+		{"With Spaces", " 081 804 ", current, true},
+		{"Wrong Length", "12345", 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			step, ok := auth.ValidateTOTP(rfc6238Secret, tc.code, now)
+			if ok != tc.wantOK || step != tc.wantStep {
+				t.Errorf("expected (%d, %v), got (%d, %v)", tc.wantStep, tc.wantOK, step, ok)
+			}
+		})
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := auth.TOTPURI("Olha Mensagem", "alice", rfc6238Secret)
+
+	if !strings.HasPrefix(uri, "otpauth://totp/Olha%20Mensagem:alice?") {
+		t.Fatalf("unexpected URI prefix: %s", uri)
+	}
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("failed to parse URI: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("secret") != auth.EncodeTOTPSecret(rfc6238Secret) || query.Get("issuer") != "Olha Mensagem" {
+		t.Errorf("unexpected query: %v", query)
+	}
+	if query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("unexpected code parameters: %v", query)
+	}
+}
@@ -0,0 +1,109 @@
+// Package channelsettings validates the fields of a channel that its owner
+// and moderators edit.
+package channelsettings
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+// DirectNamePrefix starts the names of direct conversations, which are stored
+// as channels. Channels cannot use it, so the names never clash.
+const DirectNamePrefix = "dm:"
+
+const (
+	NameMaxLength        = 64
+	DescriptionMaxLength = 500
+	TopicMaxLength       = 250
+
+	CategoryNameMaxLength = 64
+)
+
+// Settings are the editable fields of a channel. Empty description and topic
+// are unset.
+type Settings struct {
+	Name        string
+	Description string
+	Topic       string
+}
+
+func Of(c repository.GetChannelByIDRow) Settings {
+	return Settings{
+		Name:        c.Name,
+		Description: c.Description.String,
+		Topic:       c.Topic.String,
+	}
+}
+
+// Normalize trims the fields, so names that only differ in surrounding spaces
+// are stored the same way.
+func Normalize(s Settings) Settings {
+	return Settings{
+		Name:        strings.TrimSpace(s.Name),
+		Description: strings.TrimSpace(s.Description),
+		Topic:       strings.TrimSpace(s.Topic),
+	}
+}
+
+// Check returns the rules that normalized settings break. Whether the name is
+// taken is left to the caller.
+func Check(s Settings) []auth.Violation {
+	var violations []auth.Violation
+
+	switch {
+	case s.Name == "":
+		violations = append(violations, auth.Violation{Field: "name", Code: auth.ViolationRequired,
+			Message: "Channel name is required"})
+	case utf8.RuneCountInString(s.Name) > NameMaxLength:
+		violations = append(violations, auth.Violation{Field: "name", Code: auth.ViolationTooLong,
+			Message: fmt.Sprintf("Channel name must be at most %d characters", NameMaxLength)})
+	case strings.ContainsFunc(s.Name, unicode.IsControl):
+		violations = append(violations, auth.Violation{Field: "name", Code: auth.ViolationInvalidCharacters,
+			Message: "Channel name must not contain control characters"})
+	case strings.HasPrefix(strings.ToLower(s.Name), DirectNamePrefix):
+		violations = append(violations, auth.Violation{Field: "name", Code: auth.ViolationReserved,
+			Message: fmt.Sprintf("Channel names starting with %q are reserved", DirectNamePrefix)})
+	}
+
+	switch {
+	case utf8.RuneCountInString(s.Description) > DescriptionMaxLength:
+		violations = append(violations, auth.Violation{Field: "description", Code: auth.ViolationTooLong,
+			Message: fmt.Sprintf("Description must be at most %d characters", DescriptionMaxLength)})
+	case strings.ContainsFunc(s.Description, func(r rune) bool { return unicode.IsControl(r) && r != '\n' }):
+		violations = append(violations, auth.Violation{Field: "description", Code: auth.ViolationInvalidCharacters,
+			Message: "Description must not contain control characters"})
+	}
+
+	switch {
+	case utf8.RuneCountInString(s.Topic) > TopicMaxLength:
+		violations = append(violations, auth.Violation{Field: "topic", Code: auth.ViolationTooLong,
+			Message: fmt.Sprintf("Topic must be at most %d characters", TopicMaxLength)})
+	case strings.ContainsFunc(s.Topic, unicode.IsControl):
+		violations = append(violations, auth.Violation{Field: "topic", Code: auth.ViolationInvalidCharacters,
+			Message: "Topic must not contain control characters"})
+	}
+
+	return violations
+}
+
+// CheckCategoryName returns the rules that a trimmed category name breaks.
+// Whether the name is taken is left to the caller.
+func CheckCategoryName(name string) []auth.Violation {
+	switch {
+	case name == "":
+		return []auth.Violation{{Field: "name", Code: auth.ViolationRequired,
+			Message: "Category name is required"}}
+	case utf8.RuneCountInString(name) > CategoryNameMaxLength:
+		return []auth.Violation{{Field: "name", Code: auth.ViolationTooLong,
+			Message: fmt.Sprintf("Category name must be at most %d characters", CategoryNameMaxLength)}}
+	case strings.ContainsFunc(name, unicode.IsControl):
+		return []auth.Violation{{Field: "name", Code: auth.ViolationInvalidCharacters,
+			Message: "Category name must not contain control characters"}}
+	}
+	return nil
+}
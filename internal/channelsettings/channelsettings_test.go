@@ -0,0 +1,65 @@
+package channelsettings_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/channelsettings"
+)
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		name      string
+		settings  channelsettings.Settings
+		wantField string
+		wantCode  string
+	}{
+		{"Valid", channelsettings.Settings{Name: "general", Description: "Line one\nLine two", Topic: "Release on Friday"}, "", ""},
+		{"Name Only", channelsettings.Settings{Name: "general"}, "", ""},
+		{"Blank Name", channelsettings.Settings{Name: "   "}, "name", auth.ViolationRequired},
+		{"Long Name", channelsettings.Settings{Name: strings.Repeat("a", channelsettings.NameMaxLength+1)}, "name", auth.ViolationTooLong},
+		{"Control Character In Name", channelsettings.Settings{Name: "gen\x00eral"}, "name", auth.ViolationInvalidCharacters},
+		{"Direct Conversation Prefix", channelsettings.Settings{Name: "DM:1-2"}, "name", auth.ViolationReserved},
+		{"Long Description", channelsettings.Settings{Name: "general", Description: strings.Repeat("d", channelsettings.DescriptionMaxLength+1)}, "description", auth.ViolationTooLong},
+		{"Long Topic", channelsettings.Settings{Name: "general", Topic: strings.Repeat("t", channelsettings.TopicMaxLength+1)}, "topic", auth.ViolationTooLong},
+		{"Line Break In Topic", channelsettings.Settings{Name: "general", Topic: "one\ntwo"}, "topic", auth.ViolationInvalidCharacters},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := channelsettings.Check(channelsettings.Normalize(tc.settings))
+			switch {
+			case tc.wantCode == "" && len(violations) > 0:
+				t.Errorf("expected no violations, got %+v", violations)
+			case tc.wantCode != "" && (len(violations) != 1 || violations[0].Field != tc.wantField || violations[0].Code != tc.wantCode):
+				t.Errorf("expected %s/%s, got %+v", tc.wantField, tc.wantCode, violations)
+			}
+		})
+	}
+}
+
+func TestCheckCategoryName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		category string
+		wantCode string
+	}{
+		{"Valid", "Engineering", ""},
+		{"Blank", "", auth.ViolationRequired},
+		{"Long", strings.Repeat("c", channelsettings.CategoryNameMaxLength+1), auth.ViolationTooLong},
+		{"Control Character", "Soc\tial", auth.ViolationInvalidCharacters},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := channelsettings.CheckCategoryName(tc.category)
+			switch {
+			case tc.wantCode == "" && len(violations) > 0:
+				t.Errorf("expected no violations, got %+v", violations)
+			case tc.wantCode != "" && (len(violations) != 1 || violations[0].Code != tc.wantCode):
+				t.Errorf("expected %s, got %+v", tc.wantCode, violations)
+			}
+		})
+	}
+}
@@ -28,6 +28,10 @@ func NewDatabase(logger logger.Logger) (*database, error) {
 		return nil, err
 	}
 
+	if err := backfillUsernameKeys(db, logger); err != nil {
+		return nil, err
+	}
+
 	logger.Info("Database initialized successfully")
 	return &database{
 		db: db,
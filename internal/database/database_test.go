@@ -1,7 +1,9 @@
 package database_test
 
 import (
+	"database/sql"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/fortega2/real-time-chat/internal/database"
@@ -17,7 +19,7 @@ func TestNewDatabase(t *testing.T) {
 		os.Setenv("DB_MIGRATIONS_PATH", originalMigrationsPath)
 	}()
 
-	os.Setenv("DB_NAME", ":memory:")
+	os.Setenv("DB_NAME", filepath.Join(t.TempDir(), "chat.db"))
 	os.Setenv("DB_MIGRATIONS_PATH", "migrations")
 
 	mockLogger := logger.NewMockLogger()
@@ -51,7 +53,7 @@ func TestDatabaseClose(t *testing.T) {
 		os.Setenv("DB_MIGRATIONS_PATH", originalMigrationsPath)
 	}()
 
-	os.Setenv("DB_NAME", ":memory:")
+	os.Setenv("DB_NAME", filepath.Join(t.TempDir(), "chat.db"))
 	os.Setenv("DB_MIGRATIONS_PATH", "migrations")
 
 	mockLogger := logger.NewMockLogger()
@@ -70,3 +72,50 @@ func TestDatabaseClose(t *testing.T) {
 		t.Error("Expected ping to fail after close, but it succeeded")
 	}
 }
+
+func TestNewDatabaseBackfillsUsernameKeys(t *testing.T) {
+	t.Setenv("DB_NAME", filepath.Join(t.TempDir(), "chat.db"))
+	t.Setenv("DB_MIGRATIONS_PATH", "migrations")
+
+	db, err := database.NewDatabase(logger.NewMockLogger())
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	// Keys as the SQL lower() of an older migration left them: it does not
+	// fold "Å" or Cyrillic letters, and only the oldest of two names that
+	// differ in case got a key.
+	_, err = db.GetDB().Exec(`INSERT INTO users (id, username, password, username_key) VALUES
+		(1, 'Ålice', 'hash1', 'Ålice'),
+		(2, 'ÅLICE', 'hash2', NULL),
+		(3, 'аdmin', 'hash3', 'аdmin'),
+		(4, 'bob', 'hash4', 'bob'),
+		(5, 'carol', 'hash5', NULL)`)
+	if err != nil {
+		t.Fatalf("Failed to insert users: %v", err)
+	}
+	db.Close()
+
+	db, err = database.NewDatabase(logger.NewMockLogger())
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	expected := map[int64]sql.NullString{
+		1: {String: "ålice", Valid: true},
+		2: {},
+		3: {String: "admin", Valid: true},
+		4: {String: "bob", Valid: true},
+		5: {String: "carol", Valid: true},
+	}
+	for id, want := range expected {
+		var key sql.NullString
+		if err := db.GetDB().QueryRow("SELECT username_key FROM users WHERE id = ?", id).Scan(&key); err != nil {
+			t.Fatalf("Failed to read key of user %d: %v", id, err)
+		}
+		if key != want {
+			t.Errorf("Expected key %+v for user %d, got %+v", want, id, key)
+		}
+	}
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_sessions_user_id;
+DROP TABLE IF EXISTS sessions;
\ No newline at end of file
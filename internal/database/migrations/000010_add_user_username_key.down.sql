@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_username_key;
+ALTER TABLE users DROP COLUMN username_key;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN username_key TEXT;
+
+-- Keys of existing users are filled in by the application after migrating,
+-- since SQL lower() only folds ASCII (see backfillUsernameKeys).
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_key ON users(username_key);
\ No newline at end of file
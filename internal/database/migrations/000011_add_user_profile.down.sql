@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN color;
+ALTER TABLE users DROP COLUMN avatar_url;
+ALTER TABLE users DROP COLUMN bio;
+ALTER TABLE users DROP COLUMN display_name;
\ No newline at end of file
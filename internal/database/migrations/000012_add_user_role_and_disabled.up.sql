@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('admin', 'moderator', 'user'));
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP;
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_channel_invites_user_id;
+DROP TABLE IF EXISTS channel_invites;
+DROP INDEX IF EXISTS idx_channel_members_user_id;
+DROP TABLE IF EXISTS channel_members;
+ALTER TABLE channels DROP COLUMN is_private;
\ No newline at end of file
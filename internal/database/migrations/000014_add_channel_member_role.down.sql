@@ -0,0 +1 @@
+ALTER TABLE channel_members DROP COLUMN role;
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE channel_members ADD COLUMN role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member'));
+
+-- Creators were the only ones allowed to manage their channels so far.
+UPDATE channel_members
+SET role = 'owner'
+WHERE EXISTS (
+    SELECT 1 FROM channels AS c
+    WHERE c.id = channel_members.channel_id AND c.created_by = channel_members.user_id
+);
\ No newline at end of file
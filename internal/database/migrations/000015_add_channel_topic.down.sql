@@ -0,0 +1 @@
+ALTER TABLE channels DROP COLUMN topic;
\ No newline at end of file
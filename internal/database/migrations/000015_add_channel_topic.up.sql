@@ -0,0 +1 @@
+ALTER TABLE channels ADD COLUMN topic TEXT;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE channels DROP COLUMN archived_at;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE channels ADD COLUMN archived_at TIMESTAMP;
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_channels_direct_key;
+
+ALTER TABLE channels DROP COLUMN direct_key;
+
+ALTER TABLE channels DROP COLUMN kind;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE channels ADD COLUMN kind TEXT NOT NULL DEFAULT 'channel' CHECK (kind IN ('channel', 'direct'));
+
+-- Sorted IDs of the participants of a direct conversation, so each set of
+-- users has one conversation.
+ALTER TABLE channels ADD COLUMN direct_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_channels_direct_key ON channels(direct_key);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_channel_invite_links_channel_id;
+
+DROP TABLE IF EXISTS channel_invite_links;
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE channel_members DROP COLUMN personal_position;
+
+ALTER TABLE channel_members DROP COLUMN favorite;
+
+DROP INDEX IF EXISTS idx_channels_category_id;
+
+ALTER TABLE channels DROP COLUMN position;
+
+ALTER TABLE channels DROP COLUMN category_id;
+
+DROP TABLE IF EXISTS channel_categories;
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS channel_categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE channels ADD COLUMN category_id INTEGER REFERENCES channel_categories(id) ON DELETE SET NULL;
+
+ALTER TABLE channels ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_channels_category_id ON channels(category_id);
+
+ALTER TABLE channel_members ADD COLUMN favorite BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE channel_members ADD COLUMN personal_position INTEGER;
\ No newline at end of file
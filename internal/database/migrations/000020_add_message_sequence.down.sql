@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_messages_channel_sequence;
+
+ALTER TABLE messages DROP COLUMN sequence;
\ No newline at end of file
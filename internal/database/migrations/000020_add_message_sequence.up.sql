@@ -0,0 +1,11 @@
+ALTER TABLE messages ADD COLUMN sequence INTEGER NOT NULL DEFAULT 0;
+
+-- Existing messages are numbered per channel in insertion order.
+UPDATE messages
+SET sequence = (
+    SELECT COUNT(*)
+    FROM messages AS earlier
+    WHERE earlier.channel_id = messages.channel_id AND earlier.id <= messages.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_channel_sequence ON messages(channel_id, sequence);
\ No newline at end of file
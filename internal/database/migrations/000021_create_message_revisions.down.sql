@@ -0,0 +1,3 @@
+ALTER TABLE messages DROP COLUMN edited_at;
+
+DROP TABLE IF EXISTS message_revisions;
\ No newline at end of file
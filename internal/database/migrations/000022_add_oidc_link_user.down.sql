@@ -0,0 +1 @@
+ALTER TABLE oidc_login_flows DROP COLUMN link_user_id;
\ No newline at end of file
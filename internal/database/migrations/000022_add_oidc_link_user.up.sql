@@ -0,0 +1 @@
+ALTER TABLE oidc_login_flows ADD COLUMN link_user_id INTEGER;
\ No newline at end of file
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS message_revisions_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    edited_by INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE,
+    FOREIGN KEY (edited_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO message_revisions_old (id, message_id, content, edited_by, created_at)
+SELECT id, message_id, content, edited_by, created_at
+FROM message_revisions
+WHERE edited_by IS NOT NULL;
+
+DROP TABLE message_revisions;
+
+ALTER TABLE message_revisions_old RENAME TO message_revisions;
+
+CREATE INDEX IF NOT EXISTS idx_message_revisions_message_id ON message_revisions(message_id);
\ No newline at end of file
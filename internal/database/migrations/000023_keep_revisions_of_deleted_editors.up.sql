@@ -0,0 +1,21 @@
+-- SQLite cannot change a foreign key in place, so the table is copied. The
+-- history of a message stays when the account that edited it is deleted.
+CREATE TABLE IF NOT EXISTS message_revisions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    edited_by INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE,
+    FOREIGN KEY (edited_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+INSERT INTO message_revisions_new (id, message_id, content, edited_by, created_at)
+SELECT id, message_id, content, edited_by, created_at
+FROM message_revisions;
+
+DROP TABLE message_revisions;
+
+ALTER TABLE message_revisions_new RENAME TO message_revisions;
+
+CREATE INDEX IF NOT EXISTS idx_message_revisions_message_id ON message_revisions(message_id);
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (action, actor_id, subject, ip_address, details)
+VALUES (?, ?, ?, ?, ?);
\ No newline at end of file
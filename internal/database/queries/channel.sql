@@ -1,38 +1,226 @@
--- name: GetAllChannels :many
+-- name: GetChannelByID :one
+SELECT
+    c.id,
+    c.name,
+    c.description,
+    c.created_by,
+    u.username AS created_by_username,
+    c.created_at,
+    c.is_private,
+    c.topic,
+    c.archived_at,
+    c.kind,
+    c.category_id,
+    c.position,
+    (SELECT COUNT(*) FROM channel_members AS cm WHERE cm.channel_id = c.id) AS member_count
+FROM
+    channels AS c
+INNER JOIN
+    users AS u ON u.id = c.created_by
+WHERE
+    c.id = ?;
+
+-- name: ListChannelsForUser :many
 SELECT
     c.id,
     c.name,
     c.description,
     c.created_by,
     u.username AS created_by_username,
-    c.created_at
+    c.created_at,
+    c.is_private,
+    c.topic,
+    c.archived_at,
+    m.joined_at,
+    m.role,
+    c.category_id,
+    c.position,
+    m.favorite,
+    m.personal_position,
+    (SELECT COUNT(*) FROM channel_members AS cm WHERE cm.channel_id = c.id) AS member_count,
+    COALESCE((SELECT MAX(msg.id) FROM messages AS msg WHERE msg.channel_id = c.id), 0) AS last_message_id
 FROM
     channels AS c
 INNER JOIN
     users AS u ON u.id = c.created_by
+LEFT JOIN
+    channel_members AS m ON m.channel_id = c.id AND m.user_id = sqlc.arg(user_id)
+WHERE
+    c.kind = 'channel'
+    AND (c.is_private = FALSE OR m.user_id IS NOT NULL)
+    AND (c.archived_at IS NULL OR CAST(sqlc.arg(include_archived) AS BOOLEAN))
+    AND (c.name LIKE sqlc.arg(name_pattern) ESCAPE '\' OR c.description LIKE sqlc.arg(description_pattern) ESCAPE '\')
+    AND c.id < sqlc.arg(before_id)
 ORDER BY
-    c.id DESC;
+    c.id DESC
+LIMIT sqlc.arg(limit);
 
--- name: GetChannelByID :one
+-- name: ListChannelsForUserByName :many
 SELECT
     c.id,
     c.name,
     c.description,
     c.created_by,
     u.username AS created_by_username,
-    c.created_at
+    c.created_at,
+    c.is_private,
+    c.topic,
+    c.archived_at,
+    m.joined_at,
+    m.role,
+    c.category_id,
+    c.position,
+    m.favorite,
+    m.personal_position,
+    (SELECT COUNT(*) FROM channel_members AS cm WHERE cm.channel_id = c.id) AS member_count,
+    COALESCE((SELECT MAX(msg.id) FROM messages AS msg WHERE msg.channel_id = c.id), 0) AS last_message_id
 FROM
     channels AS c
 INNER JOIN
     users AS u ON u.id = c.created_by
+LEFT JOIN
+    channel_members AS m ON m.channel_id = c.id AND m.user_id = sqlc.arg(user_id)
 WHERE
-    c.id = ?;
+    c.kind = 'channel'
+    AND (c.is_private = FALSE OR m.user_id IS NOT NULL)
+    AND (c.archived_at IS NULL OR CAST(sqlc.arg(include_archived) AS BOOLEAN))
+    AND (c.name LIKE sqlc.arg(name_pattern) ESCAPE '\' OR c.description LIKE sqlc.arg(description_pattern) ESCAPE '\')
+    AND (c.name COLLATE NOCASE, c.id) > (sqlc.arg(after_name), sqlc.arg(after_id))
+ORDER BY
+    c.name COLLATE NOCASE, c.id
+LIMIT sqlc.arg(limit);
+
+-- name: ListChannelsForUserByActivity :many
+SELECT
+    c.id,
+    c.name,
+    c.description,
+    c.created_by,
+    u.username AS created_by_username,
+    c.created_at,
+    c.is_private,
+    c.topic,
+    c.archived_at,
+    m.joined_at,
+    m.role,
+    c.category_id,
+    c.position,
+    m.favorite,
+    m.personal_position,
+    (SELECT COUNT(*) FROM channel_members AS cm WHERE cm.channel_id = c.id) AS member_count,
+    COALESCE((SELECT MAX(msg.id) FROM messages AS msg WHERE msg.channel_id = c.id), 0) AS last_message_id
+FROM
+    channels AS c
+INNER JOIN
+    users AS u ON u.id = c.created_by
+LEFT JOIN
+    channel_members AS m ON m.channel_id = c.id AND m.user_id = sqlc.arg(user_id)
+WHERE
+    c.kind = 'channel'
+    AND (c.is_private = FALSE OR m.user_id IS NOT NULL)
+    AND (c.archived_at IS NULL OR CAST(sqlc.arg(include_archived) AS BOOLEAN))
+    AND (c.name LIKE sqlc.arg(name_pattern) ESCAPE '\' OR c.description LIKE sqlc.arg(description_pattern) ESCAPE '\')
+    AND (COALESCE((SELECT MAX(msg.id) FROM messages AS msg WHERE msg.channel_id = c.id), 0), c.id) < (sqlc.arg(before_message_id), sqlc.arg(before_id))
+ORDER BY
+    last_message_id DESC, c.id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: ListSidebarChannelsForUser :many
+SELECT
+    c.id,
+    c.name,
+    c.description,
+    c.created_by,
+    u.username AS created_by_username,
+    c.created_at,
+    c.is_private,
+    c.topic,
+    c.archived_at,
+    m.joined_at,
+    m.role,
+    c.category_id,
+    c.position,
+    m.favorite,
+    m.personal_position,
+    (SELECT COUNT(*) FROM channel_members AS cm WHERE cm.channel_id = c.id) AS member_count,
+    COALESCE((SELECT MAX(msg.id) FROM messages AS msg WHERE msg.channel_id = c.id), 0) AS last_message_id
+FROM
+    channels AS c
+INNER JOIN
+    users AS u ON u.id = c.created_by
+LEFT JOIN
+    channel_members AS m ON m.channel_id = c.id AND m.user_id = ?
+WHERE
+    c.kind = 'channel'
+    AND m.user_id IS NOT NULL
+    AND c.archived_at IS NULL
+ORDER BY
+    m.personal_position IS NULL, m.personal_position, c.position, c.name COLLATE NOCASE, c.id;
+
+-- name: SetChannelCategory :execrows
+UPDATE channels
+SET category_id = ?, position = ?
+WHERE id = ? AND kind = 'channel';
 
 -- name: CreateChannel :one
-INSERT INTO channels (name, description, created_by)
-VALUES (?, ?, ?)
+INSERT INTO channels (name, description, topic, created_by, is_private)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: UpdateChannel :exec
+UPDATE channels
+SET name = ?, description = ?, topic = ?
+WHERE id = ?;
+
+-- name: SetChannelCreatedBy :exec
+UPDATE channels
+SET created_by = ?
+WHERE id = ?;
+
+-- name: GetChannelIDByName :one
+SELECT id
+FROM channels
+WHERE name = ?;
+
+-- name: GetDirectChannelIDByKey :one
+SELECT id
+FROM channels
+WHERE direct_key = ?;
+
+-- name: CreateDirectChannel :one
+INSERT INTO channels (name, created_by, is_private, kind, direct_key)
+VALUES (?, ?, TRUE, 'direct', ?)
 RETURNING id;
 
--- name: DeleteChannel :exec
+-- name: ListDirectChannelMembersForUser :many
+SELECT
+    c.id AS channel_id,
+    c.created_at,
+    u.id AS user_id,
+    u.username,
+    u.display_name
+FROM
+    channels AS c
+INNER JOIN
+    channel_members AS m ON m.channel_id = c.id
+INNER JOIN
+    users AS u ON u.id = m.user_id
+WHERE
+    c.kind = 'direct'
+    AND c.id IN (SELECT own.channel_id FROM channel_members AS own WHERE own.user_id = ?)
+ORDER BY
+    c.id DESC, u.username;
+
+-- name: ArchiveChannel :exec
+UPDATE channels
+SET archived_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UnarchiveChannel :exec
+UPDATE channels
+SET archived_at = NULL
+WHERE id = ?;
+
+-- name: DeleteChannelByID :execrows
 DELETE FROM channels
-WHERE id = ? AND created_by = ?;
\ No newline at end of file
+WHERE id = ?;
\ No newline at end of file
@@ -0,0 +1,34 @@
+-- name: ListChannelCategories :many
+SELECT *
+FROM channel_categories
+ORDER BY position, name COLLATE NOCASE, id;
+
+-- name: GetChannelCategory :one
+SELECT *
+FROM channel_categories
+WHERE id = ?;
+
+-- name: CreateChannelCategory :one
+INSERT INTO channel_categories (name, position)
+VALUES (?, ?)
+RETURNING *;
+
+-- name: UpdateChannelCategory :one
+UPDATE channel_categories
+SET name = ?, position = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: ClearChannelCategory :exec
+UPDATE channels
+SET category_id = NULL
+WHERE category_id = ?;
+
+-- name: DeleteChannelCategory :execrows
+DELETE FROM channel_categories
+WHERE id = ?;
+
+-- name: GetChannelCategoryIDByName :one
+SELECT id
+FROM channel_categories
+WHERE name = ?;
\ No newline at end of file
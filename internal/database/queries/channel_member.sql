@@ -0,0 +1,145 @@
+-- name: AddChannelMember :exec
+INSERT INTO channel_members (channel_id, user_id, role)
+VALUES (?, ?, ?)
+ON CONFLICT (channel_id, user_id) DO NOTHING;
+
+-- name: GetChannelMember :one
+SELECT *
+FROM channel_members
+WHERE channel_id = ? AND user_id = ?;
+
+-- name: GetChannelMembership :one
+SELECT
+    m.channel_id,
+    m.user_id,
+    m.role,
+    c.archived_at,
+    c.kind
+FROM
+    channel_members AS m
+INNER JOIN
+    channels AS c ON c.id = m.channel_id
+WHERE
+    m.channel_id = ? AND m.user_id = ?;
+
+-- name: ListChannelMembers :many
+SELECT
+    m.user_id,
+    u.username,
+    m.role,
+    m.joined_at
+FROM
+    channel_members AS m
+INNER JOIN
+    users AS u ON u.id = m.user_id
+WHERE
+    m.channel_id = ?
+ORDER BY
+    m.joined_at, m.user_id;
+
+-- name: GetChannelOwner :one
+SELECT user_id
+FROM channel_members
+WHERE channel_id = ? AND role = 'owner';
+
+-- name: UpdateChannelMemberRole :one
+UPDATE channel_members
+SET role = ?
+WHERE channel_id = ? AND user_id = ?
+RETURNING *;
+
+-- name: RemoveChannelMember :execrows
+DELETE FROM channel_members
+WHERE channel_id = ? AND user_id = ?;
+
+-- name: CreateChannelInvite :one
+INSERT INTO channel_invites (channel_id, user_id, invited_by)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: GetChannelInviteForUser :one
+SELECT *
+FROM channel_invites
+WHERE channel_id = ? AND user_id = ?;
+
+-- name: ListChannelInvitesForUser :many
+SELECT
+    i.id,
+    i.channel_id,
+    c.name AS channel_name,
+    i.invited_by,
+    u.username AS invited_by_username,
+    i.created_at
+FROM
+    channel_invites AS i
+INNER JOIN
+    channels AS c ON c.id = i.channel_id
+INNER JOIN
+    users AS u ON u.id = i.invited_by
+WHERE
+    i.user_id = ?
+ORDER BY
+    i.created_at DESC, i.id DESC;
+
+-- name: DeleteChannelInvite :execrows
+DELETE FROM channel_invites
+WHERE id = ? AND user_id = ?;
+
+-- name: DeleteChannelInviteForUser :exec
+DELETE FROM channel_invites
+WHERE channel_id = ? AND user_id = ?;
+
+-- name: CreateChannelInviteLink :one
+INSERT INTO channel_invite_links (channel_id, token_hash, created_by, expires_at, max_uses)
+VALUES (?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListChannelInviteLinks :many
+SELECT
+    l.id,
+    l.channel_id,
+    l.created_by,
+    u.username AS created_by_username,
+    l.expires_at,
+    l.max_uses,
+    l.uses,
+    l.revoked_at,
+    l.created_at
+FROM
+    channel_invite_links AS l
+INNER JOIN
+    users AS u ON u.id = l.created_by
+WHERE
+    l.channel_id = ?
+ORDER BY
+    l.id DESC;
+
+-- name: GetChannelInviteLinkByTokenHash :one
+SELECT *
+FROM channel_invite_links
+WHERE token_hash = ?;
+
+-- name: UseChannelInviteLink :execrows
+UPDATE channel_invite_links
+SET uses = uses + 1
+WHERE id = ? AND revoked_at IS NULL AND (max_uses IS NULL OR uses < max_uses);
+
+-- name: RevokeChannelInviteLink :execrows
+UPDATE channel_invite_links
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = ? AND channel_id = ? AND revoked_at IS NULL;
+
+-- name: SetChannelFavorite :execrows
+UPDATE channel_members
+SET favorite = ?
+WHERE channel_id = ? AND user_id = ?;
+
+-- name: ClearPersonalPositions :exec
+UPDATE channel_members
+SET personal_position = NULL
+WHERE user_id = ?;
+
+-- name: SetPersonalPosition :execrows
+UPDATE channel_members
+SET personal_position = ?
+WHERE channel_id = ? AND user_id = ?;
\ No newline at end of file
@@ -0,0 +1,24 @@
+-- name: GetLoginThrottle :one
+SELECT *
+FROM login_throttles
+WHERE scope = ? AND subject = ?;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (scope, subject, failures, last_failure_at)
+VALUES (sqlc.arg(scope), sqlc.arg(subject), 1, sqlc.arg(failed_at))
+ON CONFLICT (scope, subject) DO UPDATE
+SET failures = CASE
+        WHEN julianday(login_throttles.last_failure_at) > julianday(sqlc.arg(window_start)) THEN login_throttles.failures + 1
+        ELSE 1
+    END,
+    last_failure_at = excluded.last_failure_at
+RETURNING failures;
+
+-- name: SetLoginLockout :exec
+UPDATE login_throttles
+SET locked_until = ?
+WHERE scope = ? AND subject = ?;
+
+-- name: DeleteLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE scope = ? AND subject = ?;
\ No newline at end of file
@@ -1,6 +1,13 @@
--- name: CreateMessage :exec
-INSERT INTO messages (channel_id, user_id, user_color, content)
-VALUES (?, ?, ?, ?);
+-- name: CreateMessage :one
+INSERT INTO messages (channel_id, user_id, user_color, content, sequence)
+VALUES (
+    sqlc.arg(channel_id),
+    sqlc.arg(user_id),
+    sqlc.arg(user_color),
+    sqlc.arg(content),
+    (SELECT COALESCE(MAX(sequence), 0) + 1 FROM messages WHERE channel_id = sqlc.arg(sequence_channel_id))
+)
+RETURNING id, sequence, created_at;
 
 -- name: GetHistoryMessagesByChannel :many
 SELECT
@@ -9,15 +16,94 @@ SELECT
     m.user_id,
     m.user_color,
     u.username AS user_username,
+    u.display_name AS user_display_name,
+    u.avatar_url AS user_avatar_url,
+    u.color AS user_profile_color,
     m.content,
-    m.created_at
+    m.sequence,
+    m.created_at,
+    m.edited_at
 FROM
     messages AS m
 INNER JOIN
     users AS u ON u.id = m.user_id
 WHERE
-    m.channel_id = ?
+    m.channel_id = sqlc.arg(channel_id)
+    AND m.id < sqlc.arg(before_id)
 ORDER BY
-    m.created_at ASC
+    m.id DESC
 LIMIT
-    ?;
+    sqlc.arg(limit);
+
+-- name: GetHistoryMessagesAfter :many
+SELECT
+    m.id,
+    m.channel_id,
+    m.user_id,
+    m.user_color,
+    u.username AS user_username,
+    u.display_name AS user_display_name,
+    u.avatar_url AS user_avatar_url,
+    u.color AS user_profile_color,
+    m.content,
+    m.sequence,
+    m.created_at,
+    m.edited_at
+FROM
+    messages AS m
+INNER JOIN
+    users AS u ON u.id = m.user_id
+WHERE
+    m.channel_id = sqlc.arg(channel_id)
+    AND m.id > sqlc.arg(after_id)
+ORDER BY
+    m.id ASC
+LIMIT
+    sqlc.arg(limit);
+
+-- name: GetMessageByID :one
+SELECT
+    m.id,
+    m.channel_id,
+    m.user_id,
+    m.user_color,
+    u.username AS user_username,
+    u.display_name AS user_display_name,
+    u.avatar_url AS user_avatar_url,
+    u.color AS user_profile_color,
+    m.content,
+    m.sequence,
+    m.created_at,
+    m.edited_at
+FROM
+    messages AS m
+INNER JOIN
+    users AS u ON u.id = m.user_id
+WHERE
+    m.id = ?;
+
+-- name: CreateMessageRevision :exec
+INSERT INTO message_revisions (message_id, content, edited_by)
+VALUES (?, ?, ?);
+
+-- name: UpdateMessageContent :exec
+UPDATE messages
+SET content = ?, edited_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ListMessageRevisions :many
+SELECT
+    r.id,
+    r.message_id,
+    r.content,
+    r.edited_by,
+    u.username AS edited_by_username,
+    r.created_at
+FROM
+    message_revisions AS r
+LEFT JOIN
+    users AS u ON u.id = r.edited_by
+WHERE
+    r.message_id = ?
+ORDER BY
+    r.id ASC;
\ No newline at end of file
@@ -0,0 +1,27 @@
+-- name: GetUserIdentity :one
+SELECT *
+FROM user_identities
+WHERE provider = ? AND subject = ?;
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (user_id, provider, subject, email)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: TouchUserIdentity :exec
+UPDATE user_identities
+SET email = ?, last_login_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: CreateOIDCLoginFlow :exec
+INSERT INTO oidc_login_flows (state_hash, nonce, code_verifier, expires_at, link_user_id)
+VALUES (?, ?, ?, ?, ?);
+
+-- name: ConsumeOIDCLoginFlow :one
+DELETE FROM oidc_login_flows
+WHERE state_hash = ?
+RETURNING *;
+
+-- name: DeleteExpiredOIDCLoginFlows :exec
+DELETE FROM oidc_login_flows
+WHERE expires_at <= ?;
\ No newline at end of file
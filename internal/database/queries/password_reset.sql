@@ -0,0 +1,18 @@
+-- name: CreatePasswordResetCode :exec
+INSERT INTO password_reset_codes (user_id, code_hash, expires_at)
+VALUES (?, ?, ?);
+
+-- name: GetPasswordResetCode :one
+SELECT *
+FROM password_reset_codes
+WHERE user_id = ? AND code_hash = ?;
+
+-- name: ConsumePasswordResetCode :execrows
+UPDATE password_reset_codes
+SET used_at = CURRENT_TIMESTAMP
+WHERE id = ? AND used_at IS NULL;
+
+-- name: InvalidatePasswordResetCodes :exec
+UPDATE password_reset_codes
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND used_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,50 @@
+-- name: CreateSession :one
+INSERT INTO sessions (user_id, refresh_token_hash, expires_at, device_name, user_agent, ip_address, last_seen_at)
+VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: GetSessionByID :one
+SELECT *
+FROM sessions
+WHERE id = ?;
+
+-- name: GetSessionByRefreshTokenHash :one
+SELECT *
+FROM sessions
+WHERE refresh_token_hash = ?;
+
+-- name: ListUnrevokedSessionsByUser :many
+SELECT *
+FROM sessions
+WHERE user_id = ? AND revoked_at IS NULL
+ORDER BY last_seen_at DESC, id DESC;
+
+-- name: RotateSessionRefreshToken :execrows
+UPDATE sessions
+SET refresh_token_hash = sqlc.arg(new_refresh_token_hash),
+    expires_at = sqlc.arg(expires_at),
+    last_seen_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id)
+  AND refresh_token_hash = sqlc.arg(old_refresh_token_hash)
+  AND revoked_at IS NULL;
+
+-- name: TouchSession :exec
+UPDATE sessions
+SET last_seen_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: RevokeSession :exec
+UPDATE sessions
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = ? AND revoked_at IS NULL;
+
+-- name: RevokeUserSession :execrows
+UPDATE sessions
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = ? AND user_id = ? AND revoked_at IS NULL;
+
+-- name: RevokeOtherUserSessions :many
+UPDATE sessions
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE user_id = sqlc.arg(user_id) AND id != sqlc.arg(keep_session_id) AND revoked_at IS NULL
+RETURNING id;
@@ -0,0 +1,46 @@
+-- name: GetUserTOTP :one
+SELECT *
+FROM user_totp
+WHERE user_id = ?;
+
+-- name: UpsertPendingUserTOTP :exec
+INSERT INTO user_totp (user_id, secret_ciphertext)
+VALUES (?, ?)
+ON CONFLICT (user_id) DO UPDATE
+SET secret_ciphertext = excluded.secret_ciphertext,
+    enabled_at = NULL,
+    last_used_step = 0
+WHERE user_totp.enabled_at IS NULL;
+
+-- name: EnableUserTOTP :execrows
+UPDATE user_totp
+SET enabled_at = CURRENT_TIMESTAMP,
+    last_used_step = ?
+WHERE user_id = ? AND enabled_at IS NULL;
+
+-- name: AdvanceUserTOTPStep :execrows
+UPDATE user_totp
+SET last_used_step = sqlc.arg(new_step)
+WHERE user_id = sqlc.arg(user_id) AND last_used_step = sqlc.arg(old_step);
+
+-- name: DeleteUserTOTP :exec
+DELETE FROM user_totp
+WHERE user_id = ?;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO user_recovery_codes (user_id, code_hash)
+VALUES (?, ?);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM user_recovery_codes
+WHERE user_id = ?;
+
+-- name: UseRecoveryCode :execrows
+UPDATE user_recovery_codes
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND code_hash = ? AND used_at IS NULL;
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT COUNT(*)
+FROM user_recovery_codes
+WHERE user_id = ? AND used_at IS NULL;
\ No newline at end of file
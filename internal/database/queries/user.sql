@@ -1,6 +1,6 @@
 -- name: CreateUser :one
-INSERT INTO users (username, password)
-VALUES (?, ?)
+INSERT INTO users (username, password, email, username_key)
+VALUES (?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetUserByID :one
@@ -11,4 +11,69 @@ WHERE id = ?;
 -- name: GetUserByUsername :one
 SELECT *
 FROM users
-WHERE username = ?;
\ No newline at end of file
+WHERE username = ?;
+
+-- name: GetUserByUsernameKey :one
+SELECT *
+FROM users
+WHERE username_key = ?;
+
+-- name: GetUserByEmail :one
+SELECT *
+FROM users
+WHERE email = ?;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = ?
+WHERE id = ?;
+
+-- name: RehashUserPassword :execrows
+UPDATE users
+SET password = sqlc.arg(new_password)
+WHERE id = sqlc.arg(id) AND password = sqlc.arg(old_password);
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET display_name = ?, bio = ?, avatar_url = ?, color = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: ListUsers :many
+SELECT *
+FROM users
+ORDER BY id
+LIMIT ? OFFSET ?;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: PromoteUserToAdmin :execrows
+UPDATE users
+SET role = 'admin'
+WHERE username = ? AND role != 'admin';
+
+-- name: DisableUser :one
+UPDATE users
+SET disabled_at = COALESCE(disabled_at, CURRENT_TIMESTAMP)
+WHERE id = ?
+RETURNING *;
+
+-- name: EnableUser :one
+UPDATE users
+SET disabled_at = NULL
+WHERE id = ?
+RETURNING *;
+
+-- name: ListUsernameKeys :many
+SELECT id, username, username_key
+FROM users
+ORDER BY id;
+
+-- name: SetUsernameKey :exec
+UPDATE users
+SET username_key = ?
+WHERE id = ?;
\ No newline at end of file
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/logger"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+// backfillUsernameKeys sets the username_key of every user to
+// auth.UsernameKey of their name. SQL cannot fold Unicode case or look-alike
+// letters, so the keys are computed here after migrating, on every start.
+// Keys that are already right are left alone.
+//
+// Keys are unique, and users registered before the policy may share one. A
+// user that already holds the right key keeps it, and the others get keys in
+// id order, so the oldest account wins. The accounts that lose stay without a
+// key: they keep their exact username and can still log in, and new names
+// that look like theirs are refused because the winner holds the key.
+func backfillUsernameKeys(db *sql.DB, logger logger.Logger) error {
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := repository.New(tx)
+	users, err := q.ListUsernameKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	held := make(map[string]bool, len(users))
+	var stale []repository.ListUsernameKeysRow
+	for _, user := range users {
+		if key := auth.UsernameKey(user.Username); user.UsernameKey.Valid && user.UsernameKey.String == key {
+			held[key] = true
+			continue
+		}
+		stale = append(stale, user)
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	// Free the outdated keys first, since one of them can be the right key of
+	// another user.
+	for _, user := range stale {
+		if !user.UsernameKey.Valid {
+			continue
+		}
+		if err := q.SetUsernameKey(ctx, repository.SetUsernameKeyParams{ID: user.ID}); err != nil {
+			return err
+		}
+	}
+
+	updated := 0
+	for _, user := range stale {
+		key := auth.UsernameKey(user.Username)
+		if held[key] {
+			logger.Warn("Username shares its key with another account, leaving it without one", "userID", user.ID, "username", user.Username)
+			continue
+		}
+
+		if err := q.SetUsernameKey(ctx, repository.SetUsernameKeyParams{
+			UsernameKey: sql.NullString{String: key, Valid: true},
+			ID:          user.ID,
+		}); err != nil {
+			return err
+		}
+		held[key] = true
+		updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if updated > 0 {
+		logger.Info("Username keys updated", "users", updated)
+	}
+	return nil
+}
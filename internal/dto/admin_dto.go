@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+// AdminUserDTO is a user as administrators see it. DisabledAt is empty for
+// active users.
+type AdminUserDTO struct {
+	ID         int64  `json:"id"`
+	Username   string `json:"username"`
+	Email      string `json:"email"`
+	Role       string `json:"role"`
+	Disabled   bool   `json:"disabled"`
+	DisabledAt string `json:"disabledAt,omitempty"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+func NewAdminUserDTO(user repository.User) AdminUserDTO {
+	response := AdminUserDTO{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email.String,
+		Role:      user.Role,
+		Disabled:  user.DisabledAt.Valid,
+		CreatedAt: user.CreatedAt.Format(time.RFC3339),
+	}
+	if user.DisabledAt.Valid {
+		response.DisabledAt = user.DisabledAt.Time.Format(time.RFC3339)
+	}
+	return response
+}
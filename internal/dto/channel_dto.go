@@ -10,23 +10,38 @@ import (
 type CreateChannelRequestDTO struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
-	UserID      int64  `json:"userId"`
+	Topic       string `json:"topic"`
+	IsPrivate   bool   `json:"isPrivate"`
 }
 
 func (ccr CreateChannelRequestDTO) IsValid() bool {
-	return ccr.Name != "" && ccr.UserID != 0
+	return ccr.Name != ""
 }
 
 type ChannelResponseDTO struct {
 	ID                int64  `json:"id"`
 	Name              string `json:"name"`
 	Description       string `json:"description"`
+	Topic             string `json:"topic"`
 	CreatedBy         int64  `json:"createdBy"`
 	CreatedByUsername string `json:"createdByUsername"`
 	CreatedAt         string `json:"createdAt"`
+	IsPrivate         bool   `json:"isPrivate"`
+	ArchivedAt        string `json:"archivedAt,omitempty"`
+	MemberCount       int64  `json:"memberCount"`
+	// CategoryID is 0 for channels outside any category. Position orders the
+	// channels of a category.
+	CategoryID int64 `json:"categoryId,omitempty"`
+	Position   int64 `json:"position"`
+	// IsMember, Role, Favorite and PersonalPosition are only set in responses
+	// for the current user.
+	IsMember         bool   `json:"isMember"`
+	Role             string `json:"role,omitempty"`
+	Favorite         bool   `json:"favorite"`
+	PersonalPosition *int64 `json:"personalPosition,omitempty"`
 }
 
-func NewChannelResponse[T repository.GetChannelByIDRow | repository.GetAllChannelsRow](channel T) ChannelResponseDTO {
+func NewChannelResponse[T repository.GetChannelByIDRow | repository.ListChannelsForUserRow](channel T) ChannelResponseDTO {
 	setDescription := func(description sql.NullString) string {
 		if description.Valid {
 			return description.String
@@ -34,6 +49,12 @@ func NewChannelResponse[T repository.GetChannelByIDRow | repository.GetAllChanne
 			return ""
 		}
 	}
+	formatNullTime := func(t sql.NullTime) string {
+		if !t.Valid {
+			return ""
+		}
+		return t.Time.Format(time.RFC3339)
+	}
 
 	switch v := any(channel).(type) {
 	case repository.GetChannelByIDRow:
@@ -41,25 +62,240 @@ func NewChannelResponse[T repository.GetChannelByIDRow | repository.GetAllChanne
 			ID:                v.ID,
 			Name:              v.Name,
 			Description:       setDescription(v.Description),
+			Topic:             v.Topic.String,
+			ArchivedAt:        formatNullTime(v.ArchivedAt),
+			MemberCount:       v.MemberCount,
+			CategoryID:        v.CategoryID.Int64,
+			Position:          v.Position,
 			CreatedBy:         v.CreatedBy,
 			CreatedByUsername: v.CreatedByUsername,
 			CreatedAt:         v.CreatedAt.Format(time.RFC3339),
+			IsPrivate:         v.IsPrivate,
+		}
+	case repository.ListChannelsForUserRow:
+		var personalPosition *int64
+		if v.PersonalPosition.Valid {
+			personalPosition = &v.PersonalPosition.Int64
 		}
-	case repository.GetAllChannelsRow:
 		return ChannelResponseDTO{
 			ID:                v.ID,
 			Name:              v.Name,
 			Description:       setDescription(v.Description),
+			Topic:             v.Topic.String,
+			ArchivedAt:        formatNullTime(v.ArchivedAt),
+			MemberCount:       v.MemberCount,
 			CreatedBy:         v.CreatedBy,
 			CreatedByUsername: v.CreatedByUsername,
 			CreatedAt:         v.CreatedAt.Format(time.RFC3339),
+			IsPrivate:         v.IsPrivate,
+			CategoryID:        v.CategoryID.Int64,
+			Position:          v.Position,
+			IsMember:          v.JoinedAt.Valid,
+			Role:              v.Role.String,
+			Favorite:          v.Favorite.Bool,
+			PersonalPosition:  personalPosition,
 		}
 	default:
 		return ChannelResponseDTO{}
 	}
 }
 
+// ChannelListResponseDTO is one page of channels. NextCursor is set when
+// HasMore is, and is passed back as the cursor query param for the next page.
+type ChannelListResponseDTO struct {
+	Channels   []ChannelResponseDTO `json:"channels"`
+	NextCursor string               `json:"nextCursor,omitempty"`
+	HasMore    bool                 `json:"hasMore"`
+}
+
+// UpdateChannelRequestDTO only changes the fields that are present. An empty
+// string clears the description or topic.
+type UpdateChannelRequestDTO struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Topic       *string `json:"topic"`
+}
+
 type DeleteChannelResponseDTO struct {
 	Message   string `json:"message"`
 	ChannelID int64  `json:"channelId"`
 }
+
+type CreateChannelInviteRequestDTO struct {
+	Username string `json:"username"`
+}
+
+type ChannelInviteDTO struct {
+	ID                int64  `json:"id"`
+	ChannelID         int64  `json:"channelId"`
+	ChannelName       string `json:"channelName"`
+	InvitedBy         int64  `json:"invitedBy"`
+	InvitedByUsername string `json:"invitedByUsername"`
+	CreatedAt         string `json:"createdAt"`
+}
+
+func NewChannelInviteDTO(invite repository.ListChannelInvitesForUserRow) ChannelInviteDTO {
+	return ChannelInviteDTO{
+		ID:                invite.ID,
+		ChannelID:         invite.ChannelID,
+		ChannelName:       invite.ChannelName,
+		InvitedBy:         invite.InvitedBy,
+		InvitedByUsername: invite.InvitedByUsername,
+		CreatedAt:         invite.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+type UpdateChannelMemberRoleRequestDTO struct {
+	Role string `json:"role"`
+}
+
+// TransferChannelOwnershipRequestDTO hands a channel to another member. The
+// owner confirms by typing the current name of the channel.
+type TransferChannelOwnershipRequestDTO struct {
+	UserID      int64  `json:"userId"`
+	ConfirmName string `json:"confirmName"`
+}
+
+type ChannelMemberDTO struct {
+	UserID   int64  `json:"userId"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	JoinedAt string `json:"joinedAt"`
+}
+
+func NewChannelMemberDTO(member repository.ListChannelMembersRow) ChannelMemberDTO {
+	return ChannelMemberDTO{
+		UserID:   member.UserID,
+		Username: member.Username,
+		Role:     member.Role,
+		JoinedAt: member.JoinedAt.Format(time.RFC3339),
+	}
+}
+
+// CreateChannelInviteLinkRequestDTO sets the limits of a new invite link. A
+// link without them works until it is revoked.
+type CreateChannelInviteLinkRequestDTO struct {
+	ExpiresAt *time.Time `json:"expiresAt"`
+	MaxUses   *int64     `json:"maxUses"`
+}
+
+// ChannelInviteLinkDTO describes an invite link. Token is only returned when
+// the link is created, since the server keeps nothing but its hash.
+type ChannelInviteLinkDTO struct {
+	ID                int64  `json:"id"`
+	ChannelID         int64  `json:"channelId"`
+	Token             string `json:"token,omitempty"`
+	CreatedBy         int64  `json:"createdBy"`
+	CreatedByUsername string `json:"createdByUsername"`
+	ExpiresAt         string `json:"expiresAt,omitempty"`
+	MaxUses           int64  `json:"maxUses,omitempty"`
+	Uses              int64  `json:"uses"`
+	RevokedAt         string `json:"revokedAt,omitempty"`
+	CreatedAt         string `json:"createdAt"`
+}
+
+func NewChannelInviteLinkDTO(link repository.ListChannelInviteLinksRow) ChannelInviteLinkDTO {
+	formatNullTime := func(t sql.NullTime) string {
+		if !t.Valid {
+			return ""
+		}
+		return t.Time.Format(time.RFC3339)
+	}
+
+	return ChannelInviteLinkDTO{
+		ID:                link.ID,
+		ChannelID:         link.ChannelID,
+		CreatedBy:         link.CreatedBy,
+		CreatedByUsername: link.CreatedByUsername,
+		ExpiresAt:         formatNullTime(link.ExpiresAt),
+		MaxUses:           link.MaxUses.Int64,
+		Uses:              link.Uses,
+		RevokedAt:         formatNullTime(link.RevokedAt),
+		CreatedAt:         link.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+type ChannelCategoryDTO struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Position int64  `json:"position"`
+}
+
+func NewChannelCategoryDTO(category repository.ChannelCategory) ChannelCategoryDTO {
+	return ChannelCategoryDTO{
+		ID:       category.ID,
+		Name:     category.Name,
+		Position: category.Position,
+	}
+}
+
+type CreateChannelCategoryRequestDTO struct {
+	Name     string `json:"name"`
+	Position int64  `json:"position"`
+}
+
+// UpdateChannelCategoryRequestDTO only changes the fields that are present.
+type UpdateChannelCategoryRequestDTO struct {
+	Name     *string `json:"name"`
+	Position *int64  `json:"position"`
+}
+
+// SetChannelCategoryRequestDTO moves a channel into a category, or out of any
+// when CategoryID is null.
+type SetChannelCategoryRequestDTO struct {
+	CategoryID *int64 `json:"categoryId"`
+	Position   int64  `json:"position"`
+}
+
+// ChannelOrderRequestDTO lists channels in the order the current user wants
+// them. Channels left out go back to the default order.
+type ChannelOrderRequestDTO struct {
+	ChannelIDs []int64 `json:"channelIds"`
+}
+
+// ChannelSidebarDTO is the channels of the current user as every client shows
+// them: favorites first, then each category in order, then the channels
+// without one.
+type ChannelSidebarDTO struct {
+	Favorites     []ChannelResponseDTO        `json:"favorites"`
+	Categories    []ChannelSidebarCategoryDTO `json:"categories"`
+	Uncategorized []ChannelResponseDTO        `json:"uncategorized"`
+}
+
+type ChannelSidebarCategoryDTO struct {
+	ChannelCategoryDTO
+	Channels []ChannelResponseDTO `json:"channels"`
+}
+
+// NewChannelSidebarDTO sorts channels, which are already in display order,
+// into the sections of the sidebar. Every category gets a section, even an
+// empty one.
+func NewChannelSidebarDTO(categories []repository.ChannelCategory, channels []repository.ListChannelsForUserRow) ChannelSidebarDTO {
+	sidebar := ChannelSidebarDTO{
+		Favorites:     []ChannelResponseDTO{},
+		Categories:    make([]ChannelSidebarCategoryDTO, len(categories)),
+		Uncategorized: []ChannelResponseDTO{},
+	}
+	sections := make(map[int64]int, len(categories))
+	for i, category := range categories {
+		sidebar.Categories[i] = ChannelSidebarCategoryDTO{
+			ChannelCategoryDTO: NewChannelCategoryDTO(category),
+			Channels:           []ChannelResponseDTO{},
+		}
+		sections[category.ID] = i
+	}
+
+	for _, channel := range channels {
+		response := NewChannelResponse(channel)
+		i, categorized := sections[channel.CategoryID.Int64]
+		switch {
+		case response.Favorite:
+			sidebar.Favorites = append(sidebar.Favorites, response)
+		case channel.CategoryID.Valid && categorized:
+			sidebar.Categories[i].Channels = append(sidebar.Categories[i].Channels, response)
+		default:
+			sidebar.Uncategorized = append(sidebar.Uncategorized, response)
+		}
+	}
+	return sidebar
+}
@@ -33,7 +33,6 @@ func TestCreateChannelRequestDTOIsValid(t *testing.T) {
 			request: dto.CreateChannelRequestDTO{
 				Name:        channelName,
 				Description: description,
-				UserID:      userID,
 			},
 			expected: true,
 		},
@@ -42,25 +41,6 @@ func TestCreateChannelRequestDTOIsValid(t *testing.T) {
 			request: dto.CreateChannelRequestDTO{
 				Name:        "",
 				Description: description,
-				UserID:      userID,
-			},
-			expected: false,
-		},
-		{
-			name: "zero user ID",
-			request: dto.CreateChannelRequestDTO{
-				Name:        channelName,
-				Description: description,
-				UserID:      0,
-			},
-			expected: false,
-		},
-		{
-			name: "empty name and zero user ID",
-			request: dto.CreateChannelRequestDTO{
-				Name:        "",
-				Description: description,
-				UserID:      0,
 			},
 			expected: false,
 		},
@@ -69,7 +49,6 @@ func TestCreateChannelRequestDTOIsValid(t *testing.T) {
 			request: dto.CreateChannelRequestDTO{
 				Name:        channelName,
 				Description: "",
-				UserID:      userID,
 			},
 			expected: true,
 		},
@@ -160,10 +139,10 @@ func validateChannelResponse(t *testing.T, result dto.ChannelResponseDTO, tt Cha
 	}
 }
 
-func TestNewChannelResponseGetAllChannelsRow(t *testing.T) {
+func TestNewChannelResponseListChannelsForUserRow(t *testing.T) {
 	testTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
 
-	input := repository.GetAllChannelsRow{
+	input := repository.ListChannelsForUserRow{
 		ID:                1,
 		Name:              channelName,
 		Description:       sql.NullString{String: description, Valid: true},
@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+// CreateDirectConversationRequestDTO names the other participants. The
+// current user is always one of them.
+type CreateDirectConversationRequestDTO struct {
+	Usernames []string `json:"usernames"`
+}
+
+type DirectParticipantDTO struct {
+	UserID      int64  `json:"userId"`
+	Username    string `json:"username"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// DirectConversationDTO is a direct conversation. Its ID is a channel ID, so
+// history and the WebSocket use the channel endpoints.
+type DirectConversationDTO struct {
+	ID           int64                  `json:"id"`
+	Participants []DirectParticipantDTO `json:"participants"`
+	CreatedAt    string                 `json:"createdAt"`
+}
+
+// NewDirectConversationDTOs groups the participant rows of each conversation,
+// keeping the order of the rows.
+func NewDirectConversationDTOs(rows []repository.ListDirectChannelMembersForUserRow) []DirectConversationDTO {
+	conversations := []DirectConversationDTO{}
+	for _, row := range rows {
+		if n := len(conversations); n == 0 || conversations[n-1].ID != row.ChannelID {
+			conversations = append(conversations, DirectConversationDTO{
+				ID:        row.ChannelID,
+				CreatedAt: row.CreatedAt.Format(time.RFC3339),
+			})
+		}
+		last := &conversations[len(conversations)-1]
+		last.Participants = append(last.Participants, DirectParticipantDTO{
+			UserID:      row.UserID,
+			Username:    row.Username,
+			DisplayName: row.DisplayName.String,
+		})
+	}
+	return conversations
+}
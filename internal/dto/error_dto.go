@@ -0,0 +1,23 @@
+package dto
+
+// FieldErrorDTO describes one rule that a request field breaks. Code is stable
+// and meant for clients, Message is meant for people.
+type FieldErrorDTO struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorDTO is the body of responses that reject a request because
+// of its field values.
+type ValidationErrorDTO struct {
+	Error  string          `json:"error"`
+	Fields []FieldErrorDTO `json:"fields"`
+}
+
+func NewValidationErrorDTO(message string, fields []FieldErrorDTO) ValidationErrorDTO {
+	return ValidationErrorDTO{
+		Error:  message,
+		Fields: fields,
+	}
+}
@@ -1,29 +1,86 @@
 package dto
 
 import (
+	"database/sql"
 	"time"
 
+	"github.com/fortega2/real-time-chat/internal/profile"
 	"github.com/fortega2/real-time-chat/internal/repository"
 )
 
 type MessageDTO struct {
-	ID           int64  `json:"id"`
-	ChannelID    int64  `json:"channelId"`
-	UserID       int64  `json:"userId"`
-	UserUsername string `json:"userUsername"`
-	UserColor    string `json:"userColor"`
-	Content      string `json:"content"`
-	Timestamp    string `json:"timestamp"`
+	ID              int64  `json:"id"`
+	ChannelID       int64  `json:"channelId"`
+	UserID          int64  `json:"userId"`
+	UserUsername    string `json:"userUsername"`
+	UserDisplayName string `json:"userDisplayName,omitempty"`
+	UserAvatarURL   string `json:"userAvatarUrl,omitempty"`
+	UserColor       string `json:"userColor"`
+	Content         string `json:"content"`
+	Sequence        int64  `json:"sequence"`
+	Timestamp       string `json:"timestamp"`
+	Edited          bool   `json:"edited"`
+	EditedAt        string `json:"editedAt,omitempty"`
 }
 
+// NewMessageDTO shows the author with their current profile, so a changed
+// color or display name also applies to older messages.
 func NewMessageDTO(repoMessage repository.GetHistoryMessagesByChannelRow) MessageDTO {
 	return MessageDTO{
-		ID:           repoMessage.ID,
-		ChannelID:    repoMessage.ChannelID,
-		UserID:       repoMessage.UserID,
-		UserUsername: repoMessage.UserUsername,
-		UserColor:    repoMessage.UserColor,
-		Content:      repoMessage.Content,
-		Timestamp:    repoMessage.CreatedAt.Format(time.RFC3339),
+		ID:              repoMessage.ID,
+		ChannelID:       repoMessage.ChannelID,
+		UserID:          repoMessage.UserID,
+		UserUsername:    repoMessage.UserUsername,
+		UserDisplayName: repoMessage.UserDisplayName.String,
+		UserAvatarURL:   repoMessage.UserAvatarUrl.String,
+		UserColor:       profile.ColorOr(repoMessage.UserProfileColor.String, repoMessage.UserID),
+		Content:         repoMessage.Content,
+		Sequence:        repoMessage.Sequence,
+		Timestamp:       repoMessage.CreatedAt.UTC().Format(time.RFC3339),
+		Edited:          repoMessage.EditedAt.Valid,
+		EditedAt:        formatEditedAt(repoMessage.EditedAt),
+	}
+}
+
+func formatEditedAt(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
+
+// MessagePageDTO is one page of history, oldest message first. NextCursor is
+// set when HasMore is: pass it back as before to scroll back, or as after when
+// the page was asked for with after.
+type MessagePageDTO struct {
+	Messages   []MessageDTO `json:"messages"`
+	NextCursor int64        `json:"nextCursor,omitempty"`
+	HasMore    bool         `json:"hasMore"`
+}
+
+// EditMessageRequestDTO replaces the content of a message.
+type EditMessageRequestDTO struct {
+	Content string `json:"content"`
+}
+
+// MessageRevisionDTO is an earlier content of a message, replaced by EditedBy
+// at EditedAt. The editor is left out once their account is deleted.
+type MessageRevisionDTO struct {
+	ID               int64  `json:"id"`
+	MessageID        int64  `json:"messageId"`
+	Content          string `json:"content"`
+	EditedBy         int64  `json:"editedBy,omitempty"`
+	EditedByUsername string `json:"editedByUsername,omitempty"`
+	EditedAt         string `json:"editedAt"`
+}
+
+func NewMessageRevisionDTO(revision repository.ListMessageRevisionsRow) MessageRevisionDTO {
+	return MessageRevisionDTO{
+		ID:               revision.ID,
+		MessageID:        revision.MessageID,
+		Content:          revision.Content,
+		EditedBy:         revision.EditedBy.Int64,
+		EditedByUsername: revision.EditedByUsername.String,
+		EditedAt:         revision.CreatedAt.UTC().Format(time.RFC3339),
 	}
 }
@@ -1,10 +1,13 @@
 package dto_test
 
 import (
+	"database/sql"
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/profile"
 	"github.com/fortega2/real-time-chat/internal/repository"
 )
 
@@ -29,9 +32,17 @@ func assertMessageDTOEquals(t *testing.T, expected, actual dto.MessageDTO) {
 		t.Errorf("Expected Content %s, got %s", expected.Content, actual.Content)
 	}
 
+	if actual.Sequence != expected.Sequence {
+		t.Errorf("Expected Sequence %d, got %d", expected.Sequence, actual.Sequence)
+	}
+
 	if actual.Timestamp != expected.Timestamp {
 		t.Errorf("Expected CreatedAt %s, got %s", expected.Timestamp, actual.Timestamp)
 	}
+
+	if actual.Edited != expected.Edited || actual.EditedAt != expected.EditedAt {
+		t.Errorf("Expected edited %t at %q, got %t at %q", expected.Edited, expected.EditedAt, actual.Edited, actual.EditedAt)
+	}
 }
 
 func TestNewMessageDTO(t *testing.T) {
@@ -48,7 +59,9 @@ func TestNewMessageDTO(t *testing.T) {
 				UserID:       5,
 				UserUsername: "testuser",
 				Content:      "Hello, world!",
+				Sequence:     7,
 				CreatedAt:    time.Date(2023, 12, 25, 10, 30, 0, 0, time.UTC),
+				EditedAt:     sql.NullTime{Time: time.Date(2023, 12, 25, 10, 45, 0, 0, time.UTC), Valid: true},
 			},
 			expectedDTO: dto.MessageDTO{
 				ID:           1,
@@ -56,7 +69,10 @@ func TestNewMessageDTO(t *testing.T) {
 				UserID:       5,
 				UserUsername: "testuser",
 				Content:      "Hello, world!",
+				Sequence:     7,
 				Timestamp:    "2023-12-25T10:30:00Z",
+				Edited:       true,
+				EditedAt:     "2023-12-25T10:45:00Z",
 			},
 		},
 		{
@@ -150,3 +166,41 @@ func TestNewMessageDTOTimeFormat(t *testing.T) {
 		t.Errorf("Failed to parse formatted time: %v", err)
 	}
 }
+
+func TestNewMessageDTOUsesCurrentProfile(t *testing.T) {
+	row := repository.GetHistoryMessagesByChannelRow{
+		ID:              1,
+		UserID:          5,
+		UserUsername:    "testuser",
+		UserColor:       "#000000",
+		UserDisplayName: sql.NullString{String: "Test User", Valid: true},
+		CreatedAt:       time.Date(2023, 12, 25, 10, 30, 0, 0, time.UTC),
+	}
+
+	message := dto.NewMessageDTO(row)
+	if message.UserColor != profile.DefaultColor(5) || message.UserDisplayName != "Test User" {
+		t.Errorf("expected the default color and the display name, got %+v", message)
+	}
+
+	row.UserProfileColor = sql.NullString{String: "#123456", Valid: true}
+	if message := dto.NewMessageDTO(row); message.UserColor != "#123456" {
+		t.Errorf("expected the chosen color instead of the one stored with the message, got %s", message.UserColor)
+	}
+}
+
+func TestNewMessageRevisionDTOWithoutEditor(t *testing.T) {
+	revision := dto.NewMessageRevisionDTO(repository.ListMessageRevisionsRow{
+		ID:        1,
+		MessageID: 2,
+		Content:   "helo",
+		CreatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	})
+
+	data, err := json.Marshal(revision)
+	if err != nil {
+		t.Fatalf("Failed to marshal revision: %v", err)
+	}
+	if got := string(data); got != `{"id":1,"messageId":2,"content":"helo","editedAt":"2024-01-01T12:00:00Z"}` {
+		t.Errorf("Expected the editor to be left out, got %s", got)
+	}
+}
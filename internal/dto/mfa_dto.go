@@ -0,0 +1,33 @@
+package dto
+
+import "time"
+
+// LoginChallengeDTO is returned by login instead of LoginResponseDTO when the
+// account has two-factor authentication enabled.
+type LoginChallengeDTO struct {
+	MFARequired    bool   `json:"mfaRequired"`
+	ChallengeToken string `json:"challengeToken"`
+	ExpiresAt      string `json:"expiresAt"`
+}
+
+func NewLoginChallenge(challengeToken string, expiresAt time.Time) LoginChallengeDTO {
+	return LoginChallengeDTO{
+		MFARequired:    true,
+		ChallengeToken: challengeToken,
+		ExpiresAt:      expiresAt.UTC().Format(time.RFC3339),
+	}
+}
+
+type TOTPEnrollmentDTO struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauthUri"`
+}
+
+type RecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type MFAStatusDTO struct {
+	Enabled                bool  `json:"enabled"`
+	RecoveryCodesRemaining int64 `json:"recoveryCodesRemaining"`
+}
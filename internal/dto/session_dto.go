@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+type SessionDTO struct {
+	ID         int64  `json:"id"`
+	DeviceName string `json:"deviceName"`
+	UserAgent  string `json:"userAgent"`
+	IPAddress  string `json:"ipAddress"`
+	CreatedAt  string `json:"createdAt"`
+	LastSeenAt string `json:"lastSeenAt"`
+	ExpiresAt  string `json:"expiresAt"`
+	Current    bool   `json:"current"`
+}
+
+func NewSessionDTO(session repository.Session, currentSessionID int64) SessionDTO {
+	lastSeenAt := session.CreatedAt
+	if session.LastSeenAt.Valid {
+		lastSeenAt = session.LastSeenAt.Time
+	}
+
+	return SessionDTO{
+		ID:         session.ID,
+		DeviceName: session.DeviceName.String,
+		UserAgent:  session.UserAgent.String,
+		IPAddress:  session.IpAddress.String,
+		CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+		LastSeenAt: lastSeenAt.Format(time.RFC3339),
+		ExpiresAt:  session.ExpiresAt.Format(time.RFC3339),
+		Current:    session.ID == currentSessionID,
+	}
+}
+
+type RevokeSessionsResponseDTO struct {
+	Revoked int `json:"revoked"`
+}
@@ -1,5 +1,12 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/profile"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
 type UserDTO struct {
 	ID       int64  `json:"id"`
 	Username string `json:"username"`
@@ -11,3 +18,65 @@ func NewUserDTO(id int64, username string) UserDTO {
 		Username: username,
 	}
 }
+
+type LoginResponseDTO struct {
+	AccessToken  string  `json:"accessToken"`
+	TokenType    string  `json:"tokenType"`
+	ExpiresAt    string  `json:"expiresAt"`
+	RefreshToken string  `json:"refreshToken"`
+	User         UserDTO `json:"user"`
+}
+
+func NewLoginResponse(accessToken string, expiresAt time.Time, refreshToken string, user UserDTO) LoginResponseDTO {
+	return LoginResponseDTO{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresAt:    expiresAt.UTC().Format(time.RFC3339),
+		RefreshToken: refreshToken,
+		User:         user,
+	}
+}
+
+// ProfileDTO is the public profile of a user. Color is always set, to the
+// chosen color or the default one.
+type ProfileDTO struct {
+	ID          int64  `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"displayName"`
+	Bio         string `json:"bio"`
+	AvatarURL   string `json:"avatarUrl"`
+	Color       string `json:"color"`
+}
+
+func NewProfileDTO(user repository.User) ProfileDTO {
+	p := profile.Of(user)
+	return ProfileDTO{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: p.DisplayName,
+		Bio:         p.Bio,
+		AvatarURL:   p.AvatarURL,
+		Color:       p.Color,
+	}
+}
+
+// OIDCLinkDTO is where the frontend sends the browser to link an external
+// account.
+type OIDCLinkDTO struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+}
+
+// LinkedIdentityDTO is an external account linked to the current user.
+type LinkedIdentityDTO struct {
+	Provider string `json:"provider"`
+	Email    string `json:"email"`
+	LinkedAt string `json:"linkedAt"`
+}
+
+func NewLinkedIdentityDTO(identity repository.UserIdentity) LinkedIdentityDTO {
+	return LinkedIdentityDTO{
+		Provider: identity.Provider,
+		Email:    identity.Email.String,
+		LinkedAt: identity.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -1,25 +1,43 @@
 import { API_BASE } from '$lib/config';
-import type { Channel, CreateChannelRequest } from '$lib/types/channel';
+import { authFetch } from '$lib/session';
+import type {
+	Channel,
+	ChannelCategory,
+	ChannelInvite,
+	ChannelInviteLink,
+	ChannelListQuery,
+	ChannelMember,
+	ChannelPage,
+	ChannelRole,
+	ChannelSidebar,
+	CreateChannelRequest,
+	CreateInviteLinkRequest,
+	UpdateChannelRequest
+} from '$lib/types/channel';
 
 export class ChannelService {
 	private readonly _fullUrl: string = `${API_BASE}/channels`;
-	private readonly _headers: HeadersInit = {
-		'Content-Type': 'application/json'
-	};
 
-	public async getAllChannels(): Promise<Channel[]> {
+	public async getChannels(query: ChannelListQuery = {}): Promise<ChannelPage> {
 		try {
-			const response = await fetch(this._fullUrl, {
-				method: 'GET',
-				headers: this._headers
+			const params = new URLSearchParams();
+			if (query.q) params.set('q', query.q);
+			if (query.sort) params.set('sort', query.sort);
+			if (query.includeArchived) params.set('include', 'archived');
+			if (query.cursor) params.set('cursor', query.cursor);
+			if (query.limit) params.set('limit', String(query.limit));
+
+			const search = params.toString();
+			const response = await authFetch(search ? `${this._fullUrl}?${search}` : this._fullUrl, {
+				method: 'GET'
 			});
 
 			if (!response.ok) {
 				throw new Error(await response.text());
 			}
 
-			const channels: Channel[] = await response.json();
-			return channels;
+			const page: ChannelPage = await response.json();
+			return page;
 		} catch (error: unknown) {
 			throw new Error(
 				`An error occurred while fetching channels: ${error instanceof Error ? error.message : String(error)}`
@@ -29,9 +47,8 @@ export class ChannelService {
 
 	public async createChannel(req: CreateChannelRequest): Promise<Channel> {
 		try {
-			const response = await fetch(this._fullUrl, {
+			const response = await authFetch(this._fullUrl, {
 				method: 'POST',
-				headers: this._headers,
 				body: JSON.stringify(req)
 			});
 
@@ -48,21 +65,338 @@ export class ChannelService {
 		}
 	}
 
-	public async deleteChannel(channelId: number, userId: number): Promise<void> {
+	public async updateChannel(channelId: number, request: UpdateChannelRequest): Promise<Channel> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/${channelId}`, {
+				method: 'PATCH',
+				body: JSON.stringify(request)
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const channel: Channel = await response.json();
+			return channel;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while updating the channel: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async archiveChannel(channelId: number): Promise<Channel> {
+		return this.setArchived(channelId, 'archive');
+	}
+
+	public async unarchiveChannel(channelId: number): Promise<Channel> {
+		return this.setArchived(channelId, 'unarchive');
+	}
+
+	private async setArchived(channelId: number, action: 'archive' | 'unarchive'): Promise<Channel> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/${channelId}/${action}`, {
+				method: 'POST'
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const channel: Channel = await response.json();
+			return channel;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while trying to ${action} the channel: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async joinChannel(channelId: number): Promise<Channel> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/${channelId}/join`, {
+				method: 'POST'
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const channel: Channel = await response.json();
+			return channel;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while joining the channel: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async leaveChannel(channelId: number): Promise<void> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/${channelId}/leave`, {
+				method: 'POST'
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while leaving the channel: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async inviteToChannel(channelId: number, username: string): Promise<ChannelInvite> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/${channelId}/invites`, {
+				method: 'POST',
+				body: JSON.stringify({ username })
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const invite: ChannelInvite = await response.json();
+			return invite;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while inviting to the channel: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async createInviteLink(
+		channelId: number,
+		request: CreateInviteLinkRequest = {}
+	): Promise<ChannelInviteLink> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/${channelId}/invite-links`, {
+				method: 'POST',
+				body: JSON.stringify(request)
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const link: ChannelInviteLink = await response.json();
+			return link;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while creating the invite link: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async getInviteLinks(channelId: number): Promise<ChannelInviteLink[]> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/${channelId}/invite-links`, {
+				method: 'GET'
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const links: ChannelInviteLink[] = await response.json();
+			return links;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while fetching invite links: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async revokeInviteLink(channelId: number, linkId: number): Promise<void> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/${channelId}/invite-links/${linkId}`, {
+				method: 'DELETE'
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while revoking the invite link: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async redeemInviteLink(token: string): Promise<Channel> {
+		try {
+			const response = await authFetch(`${API_BASE}/invite-links/${encodeURIComponent(token)}/redeem`, {
+				method: 'POST'
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const channel: Channel = await response.json();
+			return channel;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while redeeming the invite link: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async getSidebar(): Promise<ChannelSidebar> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/sidebar`, {
+				method: 'GET'
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const sidebar: ChannelSidebar = await response.json();
+			return sidebar;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while fetching the channel sidebar: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async getCategories(): Promise<ChannelCategory[]> {
+		try {
+			const response = await authFetch(`${API_BASE}/channel-categories`, {
+				method: 'GET'
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const categories: ChannelCategory[] = await response.json();
+			return categories;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while fetching channel categories: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async setFavorite(channelId: number, favorite: boolean): Promise<void> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/${channelId}/favorite`, {
+				method: favorite ? 'PUT' : 'DELETE'
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while changing the favorite: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async setChannelOrder(channelIds: number[]): Promise<void> {
+		try {
+			const response = await authFetch(`${API_BASE}/users/me/channel-order`, {
+				method: 'PUT',
+				body: JSON.stringify({ channelIds })
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while changing the channel order: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async getMembers(channelId: number): Promise<ChannelMember[]> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/${channelId}/members`, {
+				method: 'GET'
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const members: ChannelMember[] = await response.json();
+			return members;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while fetching channel members: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async updateMemberRole(
+		channelId: number,
+		userId: number,
+		role: Exclude<ChannelRole, 'owner'>
+	): Promise<ChannelMember> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/${channelId}/members/${userId}/role`, {
+				method: 'PUT',
+				body: JSON.stringify({ role })
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const member: ChannelMember = await response.json();
+			return member;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while updating the channel role: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async transferOwnership(
+		channelId: number,
+		userId: number,
+		confirmName: string
+	): Promise<ChannelMember> {
+		try {
+			const response = await authFetch(`${this._fullUrl}/${channelId}/transfer`, {
+				method: 'POST',
+				body: JSON.stringify({ userId, confirmName })
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const member: ChannelMember = await response.json();
+			return member;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while transferring the channel: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async getMyInvites(): Promise<ChannelInvite[]> {
 		try {
-			const response = await fetch(`${this._fullUrl}/${channelId}/users/${userId}`, {
-				method: 'DELETE',
-				headers: this._headers
+			const response = await authFetch(`${API_BASE}/users/me/invites`, {
+				method: 'GET'
 			});
 
 			if (!response.ok) {
 				throw new Error(await response.text());
 			}
 
-			return;
+			const invites: ChannelInvite[] = await response.json();
+			return invites;
 		} catch (error: unknown) {
 			throw new Error(
-				`An error occurred while deleting the channel: ${error instanceof Error ? error.message : String(error)}`
+				`An error occurred while fetching invites: ${error instanceof Error ? error.message : String(error)}`
 			);
 		}
 	}
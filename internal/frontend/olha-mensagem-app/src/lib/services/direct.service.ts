@@ -0,0 +1,46 @@
+import { API_BASE } from '$lib/config';
+import { authFetch } from '$lib/session';
+import type { DirectConversation } from '$lib/types/channel';
+
+export class DirectService {
+	private readonly _fullUrl: string = `${API_BASE}/dms`;
+
+	public async getConversations(): Promise<DirectConversation[]> {
+		try {
+			const response = await authFetch(this._fullUrl, {
+				method: 'GET'
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const conversations: DirectConversation[] = await response.json();
+			return conversations;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while fetching direct messages: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async openConversation(usernames: string[]): Promise<DirectConversation> {
+		try {
+			const response = await authFetch(this._fullUrl, {
+				method: 'POST',
+				body: JSON.stringify({ usernames })
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const conversation: DirectConversation = await response.json();
+			return conversation;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while opening the conversation: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+}
@@ -1,29 +1,78 @@
 import { API_BASE } from '$lib/config';
-import type { MessageDto } from '$lib/types/message';
+import { authFetch } from '$lib/session';
+import type { MessageDto, MessagePage, MessageRevision } from '$lib/types/message';
 
 export class MessageService {
 	private readonly _fullUrl: string = `${API_BASE}/messages`;
-	private readonly _headers: HeadersInit = {
-		'Content-Type': 'application/json'
-	};
 
-	public async getHistoryMessagesByChannel(channelId: number): Promise<MessageDto[]> {
+	public async getHistoryMessagesByChannel(
+		channelId: number,
+		cursor: { before?: number; after?: number } = {}
+	): Promise<MessagePage> {
 		try {
-			const response = await fetch(`${this._fullUrl}/history/${channelId}`, {
-				method: 'GET',
-				headers: this._headers
+			const params = new URLSearchParams();
+			if (cursor.before) params.set('before', String(cursor.before));
+			if (cursor.after) params.set('after', String(cursor.after));
+			const query = params.toString() ? `?${params}` : '';
+			const response = await authFetch(`${this._fullUrl}/history/${channelId}${query}`, {
+				method: 'GET'
 			});
 
 			if (!response.ok) {
 				throw new Error(await response.text());
 			}
 
-			const messages: MessageDto[] = await response.json();
-			return messages;
+			const page: MessagePage = await response.json();
+			return page;
 		} catch (error: unknown) {
 			throw new Error(
 				`An error occurred while fetching history messages: ${error instanceof Error ? error.message : String(error)}`
 			);
 		}
 	}
+
+	public async editMessage(
+		channelId: number,
+		messageId: number,
+		content: string
+	): Promise<MessageDto> {
+		try {
+			const response = await authFetch(`${API_BASE}/channels/${channelId}/messages/${messageId}`, {
+				method: 'PATCH',
+				headers: { 'Content-Type': 'application/json' },
+				body: JSON.stringify({ content })
+			});
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const message: MessageDto = await response.json();
+			return message;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while editing the message: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
+
+	public async getRevisions(channelId: number, messageId: number): Promise<MessageRevision[]> {
+		try {
+			const response = await authFetch(
+				`${API_BASE}/channels/${channelId}/messages/${messageId}/revisions`,
+				{ method: 'GET' }
+			);
+
+			if (!response.ok) {
+				throw new Error(await response.text());
+			}
+
+			const revisions: MessageRevision[] = await response.json();
+			return revisions;
+		} catch (error: unknown) {
+			throw new Error(
+				`An error occurred while fetching message revisions: ${error instanceof Error ? error.message : String(error)}`
+			);
+		}
+	}
 }
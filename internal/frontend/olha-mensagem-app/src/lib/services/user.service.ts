@@ -1,5 +1,26 @@
 import { API_BASE } from '$lib/config';
-import type { AuthCredentials, UserDto } from '$lib/types/user.types';
+import { authFetch } from '$lib/session';
+import type {
+	AuthCredentials,
+	LoginChallenge,
+	LoginResponse,
+	Profile,
+	ProfileUpdate,
+	RegisterRequest,
+	UserDto,
+	ValidationError
+} from '$lib/types/user.types';
+
+// Policy failures come back as JSON field errors, everything else as text.
+async function errorMessage(response: Response): Promise<string> {
+	const body = await response.text();
+	try {
+		const validation: ValidationError = JSON.parse(body);
+		return validation.fields.map((field) => field.message).join('. ');
+	} catch {
+		return body;
+	}
+}
 
 export class UserService {
 	private readonly _fullUrl: string = `${API_BASE}/users`;
@@ -7,7 +28,10 @@ export class UserService {
 		'Content-Type': 'application/json'
 	};
 
-	public async login(username: string, password: string): Promise<UserDto> {
+	public async login(
+		username: string,
+		password: string
+	): Promise<LoginResponse | LoginChallenge> {
 		const loginData: AuthCredentials = { username, password };
 
 		const response = await fetch(`${this._fullUrl}/login`, {
@@ -20,12 +44,152 @@ export class UserService {
 			throw new Error(`Login failed: ${await response.text()}`);
 		}
 
-		const user: UserDto = await response.json();
-		return user;
+		const loginResponse: LoginResponse | LoginChallenge = await response.json();
+		return loginResponse;
+	}
+
+	public async verifyLoginMfa(challengeToken: string, code: string): Promise<LoginResponse> {
+		const response = await fetch(`${this._fullUrl}/login/mfa`, {
+			method: 'POST',
+			headers: this._headers,
+			body: JSON.stringify({ challengeToken, code })
+		});
+
+		if (!response.ok) {
+			throw new Error(`Verification failed: ${await response.text()}`);
+		}
+
+		const loginResponse: LoginResponse = await response.json();
+		return loginResponse;
+	}
+
+	public oidcLoginUrl(): string {
+		return `${this._fullUrl}/oidc/login`;
+	}
+
+	public async completeOidcLogin(
+		code: string,
+		state: string
+	): Promise<LoginResponse | LoginChallenge> {
+		const response = await fetch(`${this._fullUrl}/oidc/callback`, {
+			method: 'POST',
+			headers: this._headers,
+			credentials: 'include',
+			body: JSON.stringify({ code, state })
+		});
+
+		if (!response.ok) {
+			throw new Error(`Single sign-on failed: ${await response.text()}`);
+		}
+
+		const loginResponse: LoginResponse | LoginChallenge = await response.json();
+		return loginResponse;
+	}
+
+	// startOidcLink starts linking an external account to the current user and
+	// returns the provider URL to send the browser to. The provider redirects to
+	// the same page as a login, so the flag tells that page to link instead.
+	public async startOidcLink(): Promise<string> {
+		const response = await authFetch(`${this._fullUrl}/oidc/link`, {
+			method: 'POST',
+			credentials: 'include'
+		});
+
+		if (!response.ok) {
+			throw new Error(`Linking failed: ${await response.text()}`);
+		}
+
+		const link: { authorizationUrl: string } = await response.json();
+		sessionStorage.setItem('oidcLink', '1');
+		return link.authorizationUrl;
+	}
+
+	public async completeOidcLink(code: string, state: string): Promise<void> {
+		const response = await authFetch(`${this._fullUrl}/oidc/link/callback`, {
+			method: 'POST',
+			credentials: 'include',
+			body: JSON.stringify({ code, state })
+		});
+
+		if (!response.ok) {
+			throw new Error(`Linking failed: ${await response.text()}`);
+		}
+	}
+
+	public async logout(): Promise<void> {
+		await authFetch(`${this._fullUrl}/logout`, { method: 'POST' });
+	}
+
+	public async changePassword(currentPassword: string, newPassword: string): Promise<void> {
+		const response = await authFetch(`${this._fullUrl}/me/password`, {
+			method: 'PUT',
+			body: JSON.stringify({ currentPassword, newPassword })
+		});
+
+		if (!response.ok) {
+			throw new Error(`Password change failed: ${await errorMessage(response)}`);
+		}
+	}
+
+	public async getMyProfile(): Promise<Profile> {
+		const response = await authFetch(`${this._fullUrl}/me`);
+
+		if (!response.ok) {
+			throw new Error(`Loading profile failed: ${await response.text()}`);
+		}
+
+		return response.json();
+	}
+
+	public async updateMyProfile(update: ProfileUpdate): Promise<Profile> {
+		const response = await authFetch(`${this._fullUrl}/me`, {
+			method: 'PATCH',
+			body: JSON.stringify(update)
+		});
+
+		if (!response.ok) {
+			throw new Error(`Profile update failed: ${await errorMessage(response)}`);
+		}
+
+		return response.json();
+	}
+
+	public async getProfile(userId: number): Promise<Profile> {
+		const response = await authFetch(`${this._fullUrl}/${userId}`);
+
+		if (!response.ok) {
+			throw new Error(`Loading profile failed: ${await response.text()}`);
+		}
+
+		return response.json();
+	}
+
+	public async requestPasswordReset(username: string): Promise<void> {
+		await fetch(`${this._fullUrl}/password-reset`, {
+			method: 'POST',
+			headers: this._headers,
+			body: JSON.stringify({ username })
+		});
+	}
+
+	public async confirmPasswordReset(
+		username: string,
+		code: string,
+		newPassword: string
+	): Promise<void> {
+		const response = await fetch(`${this._fullUrl}/password-reset/confirm`, {
+			method: 'POST',
+			headers: this._headers,
+			body: JSON.stringify({ username, code, newPassword })
+		});
+
+		if (!response.ok) {
+			throw new Error(`Password reset failed: ${await errorMessage(response)}`);
+		}
 	}
 
-	public async register(username: string, password: string): Promise<UserDto> {
-		const registerData: AuthCredentials = { username, password };
+	public async register(username: string, password: string, email?: string): Promise<UserDto> {
+		const registerData: RegisterRequest = { username, password, email: email || undefined };
 
 		const response = await fetch(this._fullUrl, {
 			method: 'POST',
@@ -34,7 +198,7 @@ export class UserService {
 		});
 
 		if (!response.ok) {
-			throw new Error(`Registration failed: ${await response.text()}`);
+			throw new Error(`Registration failed: ${await errorMessage(response)}`);
 		}
 
 		const user: UserDto = await response.json();
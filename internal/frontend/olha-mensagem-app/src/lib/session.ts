@@ -0,0 +1,58 @@
+import { API_BASE } from '$lib/config';
+import type { LoginResponse } from '$lib/types/user.types';
+
+const TOKEN_KEY = 'accessToken';
+const REFRESH_TOKEN_KEY = 'refreshToken';
+
+export function getAccessToken(): string | null {
+	return typeof window !== 'undefined' ? sessionStorage.getItem(TOKEN_KEY) : null;
+}
+
+export function storeSession(session: LoginResponse): void {
+	sessionStorage.setItem(TOKEN_KEY, session.accessToken);
+	sessionStorage.setItem(REFRESH_TOKEN_KEY, session.refreshToken);
+	sessionStorage.setItem('user', JSON.stringify(session.user));
+}
+
+export function clearSession(): void {
+	sessionStorage.removeItem(TOKEN_KEY);
+	sessionStorage.removeItem(REFRESH_TOKEN_KEY);
+	sessionStorage.removeItem('user');
+	sessionStorage.removeItem('selectedChannel');
+}
+
+export function authHeaders(): HeadersInit {
+	const token = getAccessToken();
+	return {
+		'Content-Type': 'application/json',
+		...(token ? { Authorization: `Bearer ${token}` } : {})
+	};
+}
+
+export async function refreshSession(): Promise<boolean> {
+	const refreshToken = sessionStorage.getItem(REFRESH_TOKEN_KEY);
+	if (!refreshToken) return false;
+
+	const response = await fetch(`${API_BASE}/users/refresh`, {
+		method: 'POST',
+		headers: { 'Content-Type': 'application/json' },
+		body: JSON.stringify({ refreshToken })
+	});
+	if (!response.ok) {
+		clearSession();
+		return false;
+	}
+
+	storeSession(await response.json());
+	return true;
+}
+
+// authFetch sends the access token and retries once with a refreshed token when
+// the server answers 401.
+export async function authFetch(url: string, init: RequestInit = {}): Promise<Response> {
+	const response = await fetch(url, { ...init, headers: authHeaders() });
+	if (response.status !== 401 || !(await refreshSession())) {
+		return response;
+	}
+	return fetch(url, { ...init, headers: authHeaders() });
+}
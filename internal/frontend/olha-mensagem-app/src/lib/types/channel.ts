@@ -2,13 +2,110 @@ export type Channel = {
 	id: number;
 	name: string;
 	description: string | null;
+	topic: string;
 	createdBy: number;
 	createdByUsername: string;
 	createdAt: string;
+	isPrivate: boolean;
+	isMember: boolean;
+	role?: ChannelRole;
+	archivedAt?: string;
+	memberCount: number;
+	categoryId?: number;
+	position: number;
+	favorite: boolean;
+	personalPosition?: number;
+};
+
+export type ChannelRole = 'owner' | 'moderator' | 'member';
+
+export type ChannelMember = {
+	userId: number;
+	username: string;
+	role: ChannelRole;
+	joinedAt: string;
 };
 
 export type CreateChannelRequest = {
 	name: string;
 	description?: string;
+	topic?: string;
+	isPrivate?: boolean;
+};
+
+export type UpdateChannelRequest = {
+	name?: string;
+	description?: string;
+	topic?: string;
+};
+
+export type ChannelInvite = {
+	id: number;
+	channelId: number;
+	channelName: string;
+	invitedBy: number;
+	invitedByUsername: string;
+	createdAt: string;
+};
+
+export type ChannelInviteLink = {
+	id: number;
+	channelId: number;
+	token?: string;
+	createdBy: number;
+	createdByUsername: string;
+	expiresAt?: string;
+	maxUses?: number;
+	uses: number;
+	revokedAt?: string;
+	createdAt: string;
+};
+
+export type CreateInviteLinkRequest = {
+	expiresAt?: string;
+	maxUses?: number;
+};
+
+export type ChannelSort = 'created' | 'name' | 'activity';
+
+export type ChannelListQuery = {
+	q?: string;
+	sort?: ChannelSort;
+	includeArchived?: boolean;
+	cursor?: string;
+	limit?: number;
+};
+
+export type ChannelPage = {
+	channels: Channel[];
+	nextCursor?: string;
+	hasMore: boolean;
+};
+
+export type DirectParticipant = {
 	userId: number;
+	username: string;
+	displayName?: string;
+};
+
+export type DirectConversation = {
+	id: number;
+	participants: DirectParticipant[];
+	createdAt: string;
+};
+
+export type ChannelCategory = {
+	id: number;
+	name: string;
+	position: number;
+};
+
+export type ChannelSidebarCategory = ChannelCategory & {
+	channels: Channel[];
+};
+
+export type ChannelSidebar = {
+	favorites: Channel[];
+	categories: ChannelSidebarCategory[];
+	uncategorized: Channel[];
 };
@@ -5,18 +5,43 @@ export type MessageDto = {
 	channelId: number;
 	userId: number;
 	userUsername: string;
+	userDisplayName?: string;
+	userAvatarUrl?: string;
 	userColor: string;
 	content: string;
+	sequence: number;
 	timestamp: string;
+	edited: boolean;
+	editedAt?: string;
+};
+
+export type MessagePage = {
+	messages: MessageDto[];
+	nextCursor?: number;
+	hasMore: boolean;
+};
+
+export type MessageRevision = {
+	id: number;
+	messageId: number;
+	content: string;
+	editedBy: number;
+	editedByUsername: string;
+	editedAt: string;
 };
 
 export function messageDtoToChatMessage(message: MessageDto): ChatMessage {
 	return {
 		type: 'Chat',
+		id: message.id,
+		sequence: message.sequence,
 		userId: message.userId,
 		username: message.userUsername,
+		displayName: message.userDisplayName,
+		avatarUrl: message.userAvatarUrl,
 		content: message.content,
 		timestamp: message.timestamp,
+		editedAt: message.editedAt,
 		color: message.userColor
 	};
 }
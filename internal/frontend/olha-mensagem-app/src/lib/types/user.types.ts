@@ -3,11 +3,52 @@ export type UserDto = {
 	username: string;
 };
 
+export type Profile = {
+	id: number;
+	username: string;
+	displayName: string;
+	bio: string;
+	avatarUrl: string;
+	color: string;
+};
+
+export type ProfileUpdate = Partial<Pick<Profile, 'displayName' | 'bio' | 'avatarUrl' | 'color'>>;
+
+export type LoginResponse = {
+	accessToken: string;
+	tokenType: string;
+	expiresAt: string;
+	refreshToken: string;
+	user: UserDto;
+};
+
+export type LoginChallenge = {
+	mfaRequired: true;
+	challengeToken: string;
+	expiresAt: string;
+};
+
 export type AuthCredentials = {
 	username: string;
 	password: string;
 };
 
 export type RegisterForm = AuthCredentials & {
+	email: string;
 	confirmPassword: string;
 };
+
+export type RegisterRequest = AuthCredentials & {
+	email?: string;
+};
+
+export type FieldError = {
+	field: string;
+	code: string;
+	message: string;
+};
+
+export type ValidationError = {
+	error: string;
+	fields: FieldError[];
+};
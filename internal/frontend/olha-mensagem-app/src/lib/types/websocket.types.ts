@@ -1,10 +1,31 @@
-export type MessageType = 'Chat' | 'Notification';
+export type MessageType =
+	| 'Chat'
+	| 'Notification'
+	| 'ChannelUpdated'
+	| 'ChannelDeleted'
+	| 'OwnerChanged'
+	| 'MessageEdited'
+	| 'Error';
+
+export type ChannelInfo = {
+	name: string;
+	description: string;
+	topic: string;
+};
 
 export type ChatMessage = {
 	type: MessageType;
+	// id and sequence are only set on stored Chat messages.
+	id?: number;
+	sequence?: number;
 	userId: number;
 	username: string;
+	displayName?: string;
+	avatarUrl?: string;
 	content: string;
 	timestamp: string;
+	// editedAt is set once a stored message has been edited.
+	editedAt?: string;
 	color: string;
+	channel?: ChannelInfo;
 };
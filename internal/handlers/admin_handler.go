@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/permission"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+
+	failedEncodeAdminUserErrMsg = "Failed to encode user data"
+	disabledByModeratorReason   = "Account disabled"
+	disconnectedByModerator     = "Disconnected by a moderator"
+)
+
+type updateRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type channelOwnerRequest struct {
+	UserID int64 `json:"userId"`
+}
+
+// ListUsers returns users ordered by ID, paginated with the limit and offset
+// query params.
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	limit, ok := queryInt(r, "limit", defaultUserPageSize)
+	if !ok || limit < 1 || limit > maxUserPageSize {
+		http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxUserPageSize), http.StatusBadRequest)
+		return
+	}
+	offset, ok := queryInt(r, "offset", 0)
+	if !ok || offset < 0 {
+		http.Error(w, "offset must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	users, err := h.queries.ListUsers(ctx, repository.ListUsersParams{Limit: int64(limit), Offset: int64(offset)})
+	if err != nil {
+		h.logger.Error("Failed to list users", "error", err)
+		http.Error(w, "Failed to list users", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.AdminUserDTO, len(users))
+	for i, user := range users {
+		response[i] = dto.NewAdminUserDTO(user)
+	}
+
+	respondWithJSON(w, http.StatusOK, response, failedEncodeAdminUserErrMsg)
+}
+
+// UpdateUserRole changes the server-wide role of a user. Nobody can change
+// their own role, so the last administrator cannot demote themselves.
+func (h *Handler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	var req updateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	role, err := auth.ParseRole(req.Role)
+	if err != nil {
+		http.Error(w, "Role must be admin, moderator or user", http.StatusBadRequest)
+		return
+	}
+
+	actor, target, ok := h.manageableUser(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.queries.UpdateUserRole(ctx, repository.UpdateUserRoleParams{Role: string(role), ID: target.ID})
+	if err != nil {
+		h.logger.Error("Failed to update role", "error", err, "userID", target.ID)
+		http.Error(w, "Failed to update role", http.StatusInternalServerError)
+		return
+	}
+
+	h.recordAudit(ctx, auditActionUserRoleChanged, actor.UserID, userAuditSubject(user.ID), clientIP(r),
+		fmt.Sprintf("from=%s to=%s", target.Role, user.Role))
+
+	respondWithJSON(w, http.StatusOK, dto.NewAdminUserDTO(user), failedEncodeAdminUserErrMsg)
+
+	h.logger.Info("User role changed", "userID", user.ID, "role", user.Role, "actorID", actor.UserID)
+}
+
+// DisableUser blocks a user from logging in, revokes all of their sessions
+// and closes their WebSocket connections.
+func (h *Handler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	actor, target, ok := h.manageableUser(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.queries.DisableUser(ctx, target.ID)
+	if err != nil {
+		h.logger.Error("Failed to disable user", "error", err, "userID", target.ID)
+		http.Error(w, "Failed to disable user", http.StatusInternalServerError)
+		return
+	}
+
+	revoked, err := h.revokeOtherSessions(ctx, user.ID, 0)
+	if err != nil {
+		h.logger.Error("Failed to revoke sessions of disabled user", "error", err, "userID", user.ID)
+	}
+	h.hub.DisconnectUser(user.ID, disabledByModeratorReason)
+
+	h.recordAudit(ctx, auditActionUserDisabled, actor.UserID, userAuditSubject(user.ID), clientIP(r),
+		fmt.Sprintf("revoked_sessions=%d", revoked))
+
+	respondWithJSON(w, http.StatusOK, dto.NewAdminUserDTO(user), failedEncodeAdminUserErrMsg)
+
+	h.logger.Info("User disabled", "userID", user.ID, "actorID", actor.UserID, "revokedSessions", revoked)
+}
+
+func (h *Handler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	actor, target, ok := h.manageableUser(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.queries.EnableUser(ctx, target.ID)
+	if err != nil {
+		h.logger.Error("Failed to enable user", "error", err, "userID", target.ID)
+		http.Error(w, "Failed to enable user", http.StatusInternalServerError)
+		return
+	}
+
+	h.recordAudit(ctx, auditActionUserEnabled, actor.UserID, userAuditSubject(user.ID), clientIP(r), "")
+
+	respondWithJSON(w, http.StatusOK, dto.NewAdminUserDTO(user), failedEncodeAdminUserErrMsg)
+
+	h.logger.Info("User enabled", "userID", user.ID, "actorID", actor.UserID)
+}
+
+// DisconnectUser closes the WebSocket connections of a user without touching
+// their sessions, so they can reconnect.
+func (h *Handler) DisconnectUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	actor, target, ok := h.manageableUser(w, r)
+	if !ok {
+		return
+	}
+
+	h.hub.DisconnectUser(target.ID, disconnectedByModerator)
+	h.recordAudit(ctx, auditActionUserDisconnected, actor.UserID, userAuditSubject(target.ID), clientIP(r), "")
+
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("User disconnected by moderator", "userID", target.ID, "actorID", actor.UserID)
+}
+
+// DeleteAnyChannel deletes a channel regardless of who created it. It is
+// routed for administrators only.
+func (h *Handler) DeleteAnyChannel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Channel deletion without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	channelID, err := strconv.ParseInt(chi.URLParam(r, "channelId"), 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid channel ID", "error", err)
+		http.Error(w, "Invalid channel ID", http.StatusBadRequest)
+		return
+	}
+
+	channel, err := h.queries.GetChannelByID(ctx, channelID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Channel not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Failed to retrieve channel", "error", err, "channelID", channelID)
+		http.Error(w, "Failed to delete channel", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := h.queries.DeleteChannelByID(ctx, channelID); err != nil {
+		h.logger.Error("Failed to delete channel", "error", err, "channelID", channelID)
+		http.Error(w, "Failed to delete channel", http.StatusInternalServerError)
+		return
+	}
+
+	h.recordAudit(ctx, auditActionChannelDeleted, identity.UserID, channelAuditSubject(channelID), clientIP(r),
+		fmt.Sprintf("name=%s created_by=%d", channel.Name, channel.CreatedBy))
+	h.hub.DeleteChannel(channelID)
+
+	response := dto.DeleteChannelResponseDTO{
+		Message:   fmt.Sprintf("Channel '%s' deleted successfully", channel.Name),
+		ChannelID: channelID,
+	}
+	respondWithJSON(w, http.StatusOK, response, failedEncodeDeleteChannelRspErrMsg)
+
+	h.logger.Info("Channel deleted by administrator", "channelID", channelID, "channelName", channel.Name, "actorID", identity.UserID)
+}
+
+// SetChannelOwner makes a user the owner of any channel, without the
+// confirmation of the current owner, for example when they left the team.
+func (h *Handler) SetChannelOwner(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Channel ownership change without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	var req channelOwnerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	channelID, err := strconv.ParseInt(chi.URLParam(r, "channelId"), 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid channel ID", "error", err)
+		http.Error(w, "Invalid channel ID", http.StatusBadRequest)
+		return
+	}
+
+	channel, err := h.queries.GetChannelByID(ctx, channelID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Channel not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Failed to retrieve channel", "error", err, "channelID", channelID)
+		http.Error(w, failedTransferOwnerErrMsg, http.StatusInternalServerError)
+		return
+	}
+	if channel.Kind == permission.KindDirect {
+		http.Error(w, "Direct conversations have no owner", http.StatusConflict)
+		return
+	}
+
+	member, ok := h.transferOwnership(w, r, channelID, req.UserID, identity.UserID)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, member, failedEncodeMemberErrMsg)
+
+	h.logger.Info("Channel owner set by administrator", "channelID", channelID, "userID", req.UserID, "actorID", identity.UserID)
+}
+
+// manageableUser loads the user named by the userId URL param and checks that
+// the current user may manage them. Moderators manage users, administrators
+// manage everyone, and nobody manages themselves.
+func (h *Handler) manageableUser(w http.ResponseWriter, r *http.Request) (auth.Identity, repository.User, bool) {
+	actor, ok := auth.IdentityFromContext(r.Context())
+	if !ok {
+		h.logger.Error("User management without an authenticated session", "path", r.URL.Path)
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return auth.Identity{}, repository.User{}, false
+	}
+
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid user ID", "error", err)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return auth.Identity{}, repository.User{}, false
+	}
+
+	if userID == actor.UserID {
+		http.Error(w, "You cannot manage your own account", http.StatusForbidden)
+		return auth.Identity{}, repository.User{}, false
+	}
+
+	target, err := h.queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return auth.Identity{}, repository.User{}, false
+		}
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", userID)
+		http.Error(w, "Failed to retrieve user", http.StatusInternalServerError)
+		return auth.Identity{}, repository.User{}, false
+	}
+
+	if actor.Role != auth.RoleAdmin && !actor.Role.Outranks(auth.Role(target.Role)) {
+		h.logger.Info("Moderator tried to manage a peer or superior", "actorID", actor.UserID, "userID", target.ID, "role", target.Role)
+		http.Error(w, forbiddenErrMsg, http.StatusForbidden)
+		return auth.Identity{}, repository.User{}, false
+	}
+
+	return actor, target, true
+}
+
+// queryInt parses an optional integer query param, returning def when it is
+// absent.
+func queryInt(r *http.Request, name string, def int) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, true
+	}
+
+	n, err := strconv.Atoi(value)
+	return n, err == nil
+}
+
+func channelAuditSubject(channelID int64) string {
+	return "channel:" + strconv.FormatInt(channelID, 10)
+}
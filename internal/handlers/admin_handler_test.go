@@ -0,0 +1,366 @@
+package handlers_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/handlers"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequireRole(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+	h := handlers.NewHandler(getMockLogger(), queries, db)
+
+	admin := createTestUserWithRole(t, queries, "admin1", auth.RoleAdmin)
+	moderator := createTestUserWithRole(t, queries, "moderator1", auth.RoleModerator)
+	disabled := createTestUserWithRole(t, queries, "moderator2", auth.RoleModerator)
+	if _, err := queries.DisableUser(context.Background(), disabled.ID); err != nil {
+		t.Fatalf("failed to disable user: %v", err)
+	}
+
+	testCases := []struct {
+		name           string
+		userID         int64
+		min            auth.Role
+		expectedStatus int
+	}{
+		{"Admin Passes Moderator Check", admin.ID, auth.RoleModerator, http.StatusOK},
+		{"Moderator Passes Moderator Check", moderator.ID, auth.RoleModerator, http.StatusOK},
+		{"Moderator Fails Admin Check", moderator.ID, auth.RoleAdmin, http.StatusForbidden},
+		{"User Fails Moderator Check", 1, auth.RoleModerator, http.StatusForbidden},
+		{"Disabled Moderator", disabled.ID, auth.RoleModerator, http.StatusForbidden},
+		{"Unauthenticated", 0, auth.RoleModerator, http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotRole auth.Role
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				identity, _ := auth.IdentityFromContext(r.Context())
+				gotRole = identity.Role
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+			if tc.userID != 0 {
+				req = withIdentity(req, tc.userID)
+			}
+			w := httptest.NewRecorder()
+
+			h.RequireRole(tc.min)(next).ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus == http.StatusOK && !gotRole.AtLeast(tc.min) {
+				t.Errorf("expected the role to be added to the identity, got %q", gotRole)
+			}
+		})
+	}
+}
+
+func TestDisableUser(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+	moderator := createTestUserWithRole(t, queries, "moderator1", auth.RoleModerator)
+
+	login := loginTestUser(t, h)
+	session := sessionFromLogin(t, queries, login)
+
+	w := manageTestUser(t, h.DisableUser, moderator.ID, auth.RoleModerator, session.UserID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	var resp dto.AdminUserDTO
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if !resp.Disabled || resp.DisabledAt == "" {
+		t.Errorf("expected the user to be disabled, got %+v", resp)
+	}
+
+	if len(hub.disconnectedUsers) != 1 || hub.disconnectedUsers[0] != session.UserID {
+		t.Errorf("expected the WebSocket connections of the user to be closed, got %v", hub.disconnectedUsers)
+	}
+	if got, _ := queries.GetSessionByID(context.Background(), session.ID); !got.RevokedAt.Valid {
+		t.Error("expected the sessions of the user to be revoked")
+	}
+
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusForbidden)
+	refreshTestSession(t, h, login.RefreshToken, http.StatusUnauthorized)
+
+	w = manageTestUser(t, h.EnableUser, moderator.ID, auth.RoleModerator, session.UserID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusOK)
+}
+
+func TestManageUserPermissions(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	admin := createTestUserWithRole(t, queries, "admin1", auth.RoleAdmin)
+	moderator := createTestUserWithRole(t, queries, "moderator1", auth.RoleModerator)
+	otherModerator := createTestUserWithRole(t, queries, "moderator2", auth.RoleModerator)
+
+	testCases := []struct {
+		name           string
+		actor          repository.User
+		userID         int64
+		expectedStatus int
+	}{
+		{"Moderator Disconnects User", moderator, 1, http.StatusNoContent},
+		{"Moderator Disconnects Moderator", moderator, otherModerator.ID, http.StatusForbidden},
+		{"Moderator Disconnects Admin", moderator, admin.ID, http.StatusForbidden},
+		{"Admin Disconnects Moderator", admin, moderator.ID, http.StatusNoContent},
+		{"Disconnect Self", moderator, moderator.ID, http.StatusForbidden},
+		{"Unknown User", admin, 999, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := manageTestUser(t, h.DisconnectUser, tc.actor.ID, auth.Role(tc.actor.Role), tc.userID, "")
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	admin := createTestUserWithRole(t, queries, "admin1", auth.RoleAdmin)
+
+	w := manageTestUser(t, h.UpdateUserRole, admin.ID, auth.RoleAdmin, 1, `{"role": "moderator"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	if user, _ := queries.GetUserByID(context.Background(), 1); user.Role != string(auth.RoleModerator) {
+		t.Errorf("expected the user to be a moderator, got %q", user.Role)
+	}
+
+	w = manageTestUser(t, h.UpdateUserRole, admin.ID, auth.RoleAdmin, 1, `{"role": "owner"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf(expectedStatusErrMsg, http.StatusBadRequest, w.Code)
+	}
+
+	w = manageTestUser(t, h.UpdateUserRole, admin.ID, auth.RoleAdmin, admin.ID, `{"role": "user"}`)
+	if w.Code != http.StatusForbidden {
+		t.Errorf(expectedStatusErrMsg, http.StatusForbidden, w.Code)
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		createTestUserWithRole(t, queries, name, auth.RoleUser)
+	}
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedNames  []string
+	}{
+		{"Default Page", "", http.StatusOK, []string{"testuser", "alice", "bob", "carol"}},
+		{"Limit And Offset", "?limit=2&offset=1", http.StatusOK, []string{"alice", "bob"}},
+		{"Limit Too Large", "?limit=1000", http.StatusBadRequest, nil},
+		{"Negative Offset", "?offset=-1", http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users"+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			h.ListUsers(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp []dto.AdminUserDTO
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf(failedToDecodeResponseBody, err)
+			}
+			var names []string
+			for _, user := range resp {
+				names = append(names, user.Username)
+			}
+			if strings.Join(names, ",") != strings.Join(tc.expectedNames, ",") {
+				t.Errorf("expected users %v, got %v", tc.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestDeleteAnyChannel(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	admin := createTestUserWithRole(t, queries, "admin1", auth.RoleAdmin)
+	moderator := createTestUserWithRole(t, queries, "moderator1", auth.RoleModerator)
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+	channelID, err := queries.CreateChannel(context.Background(), repository.CreateChannelParams{
+		Name:      "general",
+		CreatedBy: owner.ID,
+	})
+	if err != nil {
+		t.Fatalf("failed to create channel: %v", err)
+	}
+
+	testCases := []struct {
+		name           string
+		userID         int64
+		channelID      string
+		expectedStatus int
+	}{
+		{"Moderator", moderator.ID, strconv.FormatInt(channelID, 10), http.StatusForbidden},
+		{"Channel Of Another User", admin.ID, strconv.FormatInt(channelID, 10), http.StatusOK},
+		{"Already Deleted", admin.ID, strconv.FormatInt(channelID, 10), http.StatusNotFound},
+		{"Invalid Channel ID", admin.ID, "abc", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/channels/"+tc.channelID, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("channelId", tc.channelID)
+			req = withIdentity(req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)), tc.userID)
+			w := httptest.NewRecorder()
+
+			// Hard deletes are routed behind the admin check.
+			h.RequireRole(auth.RoleAdmin)(http.HandlerFunc(h.DeleteAnyChannel)).ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	if len(hub.deletedChannels) != 1 || hub.deletedChannels[0] != channelID {
+		t.Errorf("expected the hub to be told once about the deleted channel, got %v", hub.deletedChannels)
+	}
+}
+
+func TestSetChannelOwner(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+
+	admin := createTestUserWithRole(t, queries, "admin1", auth.RoleAdmin)
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	successor := createTestUserWithRole(t, queries, "successor", auth.RoleUser)
+	disabled := createTestUserWithRole(t, queries, "disabled", auth.RoleUser)
+	if _, err := db.Exec("UPDATE users SET disabled_at = CURRENT_TIMESTAMP WHERE id = ?", disabled.ID); err != nil {
+		t.Fatalf("failed to disable user: %v", err)
+	}
+	channel := createTestMemberChannel(t, h, owner.ID, "general", true)
+
+	testCases := []struct {
+		name           string
+		channelID      string
+		userID         int64
+		expectedStatus int
+	}{
+		{"Unknown Channel", "999", successor.ID, http.StatusNotFound},
+		{"Unknown User", strconv.FormatInt(channel.ID, 10), 999, http.StatusNotFound},
+		{"Disabled User", strconv.FormatInt(channel.ID, 10), disabled.ID, http.StatusConflict},
+		{"Non-Member", strconv.FormatInt(channel.ID, 10), successor.ID, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := `{"userId": ` + strconv.FormatInt(tc.userID, 10) + `}`
+			req := httptest.NewRequest(http.MethodPut, "/channels/"+tc.channelID+"/owner", strings.NewReader(body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("channelId", tc.channelID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: admin.ID, Role: auth.RoleAdmin}))
+			w := httptest.NewRecorder()
+
+			h.SetChannelOwner(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	roles := testChannelRoles(t, h, channel.ID, successor.ID)
+	if roles["successor"] != "owner" || roles["owner"] != "moderator" {
+		t.Errorf("expected the successor to own the private channel, got %+v", roles)
+	}
+	if hub.channelOwners[channel.ID] != successor.ID {
+		t.Errorf("expected the hub to announce the new owner, got %+v", hub.channelOwners)
+	}
+	if got, err := queries.GetChannelByID(context.Background(), channel.ID); err != nil || got.CreatedByUsername != "successor" {
+		t.Errorf("expected the channel to name the new owner, got %q (%v)", got.CreatedByUsername, err)
+	}
+}
+
+func createTestUserWithRole(t *testing.T, queries *repository.Queries, username string, role auth.Role) repository.User {
+	t.Helper()
+	user, err := queries.CreateUser(context.Background(), repository.CreateUserParams{
+		Username:    username,
+		Password:    "unused",
+		UsernameKey: sql.NullString{String: auth.UsernameKey(username), Valid: true},
+	})
+	if err != nil {
+		t.Fatalf(failedCreateTestUser, err)
+	}
+
+	user, err = queries.UpdateUserRole(context.Background(), repository.UpdateUserRoleParams{Role: string(role), ID: user.ID})
+	if err != nil {
+		t.Fatalf("failed to set role: %v", err)
+	}
+	return user
+}
+
+// manageTestUser calls an admin handler as actor, the way it runs behind
+// RequireRole, on the user with userID.
+func manageTestUser(t *testing.T, handler http.HandlerFunc, actorID int64, role auth.Role, userID int64, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	target := strconv.FormatInt(userID, 10)
+	req := httptest.NewRequest(http.MethodPost, "/users/"+target, strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("userId", target)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: actorID, SessionID: 1, Role: role}))
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+	return w
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+const (
+	auditActionLoginLockout           = "login.lockout"
+	auditActionPasswordChanged        = "password.changed"
+	auditActionPasswordResetRequested = "password.reset_requested"
+	auditActionPasswordReset          = "password.reset"
+	auditActionMFAEnabled             = "mfa.enabled"
+	auditActionMFADisabled            = "mfa.disabled"
+	auditActionRecoveryCodeUsed       = "mfa.recovery_code_used"
+	auditActionRecoveryCodesRenewed   = "mfa.recovery_codes_renewed"
+	auditActionIdentityLinked         = "identity.linked"
+	auditActionUserRoleChanged        = "user.role_changed"
+	auditActionUserDisabled           = "user.disabled"
+	auditActionUserEnabled            = "user.enabled"
+	auditActionUserDisconnected       = "user.disconnected"
+	auditActionChannelDeleted         = "channel.deleted"
+	auditActionChannelRoleChanged     = "channel.role_changed"
+	auditActionChannelUpdated         = "channel.updated"
+	auditActionChannelArchived        = "channel.archived"
+	auditActionChannelUnarchived      = "channel.unarchived"
+	auditActionChannelOwnerChanged    = "channel.owner_changed"
+	auditActionInviteLinkCreated      = "channel.invite_link_created"
+	auditActionInviteLinkRevoked      = "channel.invite_link_revoked"
+	auditActionInviteLinkRedeemed     = "channel.invite_link_redeemed"
+	auditActionChannelCategoryChanged = "channel.category_changed"
+	auditActionCategoryCreated        = "category.created"
+	auditActionCategoryUpdated        = "category.updated"
+	auditActionCategoryDeleted        = "category.deleted"
+)
+
+// recordAudit appends an entry to the audit log. actorID is 0 when no
+// authenticated user caused the event. The audit log must never break the
+// request it describes, so failures are only logged.
+func (h *Handler) recordAudit(ctx context.Context, action string, actorID int64, subject, ipAddress, details string) {
+	err := h.queries.CreateAuditEvent(ctx, repository.CreateAuditEventParams{
+		Action:    action,
+		ActorID:   nullInt64(actorID),
+		Subject:   subject,
+		IpAddress: nullString(ipAddress),
+		Details:   nullString(details),
+	})
+	if err != nil {
+		h.logger.Error("Failed to record audit event", "error", err, "action", action, "subject", subject)
+	}
+}
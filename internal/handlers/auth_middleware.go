@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+)
+
+const bearerPrefix = "Bearer "
+
+// RequireAuth resolves the current user from the access token and rejects the
+// request when it is missing, invalid or bound to a revoked session. Browsers
+// cannot set headers on a WebSocket handshake, so the token is also accepted as
+// a "token" query param.
+func (h *Handler) RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := tokenFromRequest(r)
+		if token == "" {
+			h.logger.Debug("Missing access token", "path", r.URL.Path)
+			http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := h.tokens.Parse(token)
+		if err != nil {
+			h.logger.Debug("Invalid access token", "path", r.URL.Path, "error", err)
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+
+		session, err := h.queries.GetSessionByID(r.Context(), claims.SessionID)
+		if err != nil || session.UserID != claims.UserID || !isSessionActive(session) {
+			h.logger.Debug("Access token bound to an inactive session", "path", r.URL.Path, "sessionID", claims.SessionID)
+			http.Error(w, "Session is no longer active", http.StatusUnauthorized)
+			return
+		}
+
+		h.touchSession(r.Context(), session)
+
+		ctx := auth.WithIdentity(r.Context(), auth.Identity{UserID: claims.UserID, SessionID: claims.SessionID})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireRole rejects requests from users below min. It must run after
+// RequireAuth, and adds the role of the user to the identity.
+func (h *Handler) RequireRole(min auth.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := auth.IdentityFromContext(r.Context())
+			if !ok {
+				h.logger.Error("Role check without an authenticated session", "path", r.URL.Path)
+				http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+				return
+			}
+
+			user, err := h.queries.GetUserByID(r.Context(), identity.UserID)
+			if err != nil {
+				h.logger.Error("Failed to retrieve user for role check", "error", err, "userID", identity.UserID)
+				http.Error(w, "Failed to check permissions", http.StatusInternalServerError)
+				return
+			}
+
+			identity.Role = auth.Role(user.Role)
+			if user.DisabledAt.Valid || !identity.Role.AtLeast(min) {
+				h.logger.Info("Request denied by role check", "path", r.URL.Path, "userID", user.ID, "role", user.Role, "required", min)
+				http.Error(w, forbiddenErrMsg, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+		})
+	}
+}
+
+func tokenFromRequest(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, bearerPrefix) {
+		return strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix))
+	}
+
+	return r.URL.Query().Get("token")
+}
+
+func (h *Handler) currentUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	identity, ok := auth.IdentityFromContext(r.Context())
+	if !ok {
+		h.logger.Error("Request reached an authenticated handler without identity", "path", r.URL.Path)
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return 0, false
+	}
+	return identity.UserID, true
+}
@@ -0,0 +1,105 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/handlers"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+func TestRequireAuth(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	tokens := auth.NewTokenManager(auth.RandomSecret(), time.Hour)
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithTokenManager(tokens))
+
+	activeSession := createTestSession(t, queries, 1)
+	revokedSession := createTestSession(t, queries, 1)
+	if err := queries.RevokeSession(context.Background(), revokedSession.ID); err != nil {
+		t.Fatalf("failed to revoke session: %v", err)
+	}
+
+	validToken := issueTestToken(t, tokens, 1, activeSession.ID)
+	revokedToken := issueTestToken(t, tokens, 1, revokedSession.ID)
+	unknownSessionToken := issueTestToken(t, tokens, 1, 999)
+	foreignToken := issueTestToken(t, auth.NewTokenManager(auth.RandomSecret(), time.Hour), 1, activeSession.ID)
+
+	testCases := []struct {
+		name           string
+		header         string
+		query          string
+		expectedStatus int
+	}{
+		{"Bearer Header", "Bearer " + validToken, "", http.StatusOK},
+		{"Query Param", "", "?token=" + validToken, http.StatusOK},
+		{"Missing Token", "", "", http.StatusUnauthorized},
+		{"Malformed Token", "Bearer not-a-token", "", http.StatusUnauthorized},
+		{"Token Signed With Another Secret", "Bearer " + foreignToken, "", http.StatusUnauthorized},
+		{"Revoked Session", "Bearer " + revokedToken, "", http.StatusUnauthorized},
+		{"Unknown Session", "Bearer " + unknownSessionToken, "", http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotIdentity auth.Identity
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotIdentity, _ = auth.IdentityFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/channels"+tc.query, nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			w := httptest.NewRecorder()
+
+			h.RequireAuth(next).ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus == http.StatusOK {
+				if gotIdentity.UserID != 1 || gotIdentity.SessionID != activeSession.ID {
+					t.Errorf("expected identity {1 %d} in context, got %+v", activeSession.ID, gotIdentity)
+				}
+			}
+		})
+	}
+}
+
+func createTestSession(t *testing.T, queries *repository.Queries, userID int64) repository.Session {
+	t.Helper()
+	_, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		t.Fatalf("failed to generate refresh token: %v", err)
+	}
+	session, err := queries.CreateSession(context.Background(), repository.CreateSessionParams{
+		UserID:           userID,
+		RefreshTokenHash: hash,
+		ExpiresAt:        time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	return session
+}
+
+func issueTestToken(t *testing.T, tokens *auth.TokenManager, userID, sessionID int64) string {
+	t.Helper()
+	token, _, err := tokens.Issue(userID, sessionID)
+	if err != nil {
+		t.Fatalf("failed to issue token: %v", err)
+	}
+	return token
+}
+
+func withIdentity(req *http.Request, userID int64) *http.Request {
+	return req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: userID, SessionID: 1}))
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/channelsettings"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	categoryNotFoundErrMsg     = "Category not found"
+	failedEncodeCategoryErrMsg = "Failed to encode category data"
+)
+
+// ListChannelCategories returns the categories of the server in display
+// order.
+func (h *Handler) ListChannelCategories(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	categories, err := h.queries.ListChannelCategories(ctx)
+	if err != nil {
+		h.logger.Error("Failed to list categories", "error", err)
+		http.Error(w, "Failed to list categories", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.ChannelCategoryDTO, len(categories))
+	for i, category := range categories {
+		response[i] = dto.NewChannelCategoryDTO(category)
+	}
+	respondWithJSON(w, http.StatusOK, response, failedEncodeCategoryErrMsg)
+}
+
+// CreateChannelCategory adds a category that channels can be moved into.
+func (h *Handler) CreateChannelCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Category creation without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	var req dto.CreateChannelCategoryRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if violations := channelsettings.CheckCategoryName(name); len(violations) > 0 {
+		respondWithViolations(w, http.StatusBadRequest, violations)
+		return
+	}
+	if !h.checkCategoryNameFree(w, r, name, 0) {
+		return
+	}
+
+	category, err := h.queries.CreateChannelCategory(ctx, repository.CreateChannelCategoryParams{
+		Name:     name,
+		Position: req.Position,
+	})
+	if err != nil {
+		h.logger.Error("Failed to create category", "error", err, "name", name)
+		http.Error(w, "Failed to create category", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, dto.NewChannelCategoryDTO(category), failedEncodeCategoryErrMsg)
+
+	h.recordAudit(ctx, auditActionCategoryCreated, identity.UserID, categoryAuditSubject(category.ID), clientIP(r),
+		fmt.Sprintf("name=%s", category.Name))
+	h.logger.Info("Category created", "categoryID", category.ID, "actorID", identity.UserID)
+}
+
+// UpdateChannelCategory renames or moves a category. Omitted fields are kept.
+func (h *Handler) UpdateChannelCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Category update without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	category, ok := h.categoryFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateChannelCategoryRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	params := repository.UpdateChannelCategoryParams{
+		Name:     category.Name,
+		Position: category.Position,
+		ID:       category.ID,
+	}
+	if req.Name != nil {
+		params.Name = strings.TrimSpace(*req.Name)
+		if violations := channelsettings.CheckCategoryName(params.Name); len(violations) > 0 {
+			respondWithViolations(w, http.StatusBadRequest, violations)
+			return
+		}
+		if !h.checkCategoryNameFree(w, r, params.Name, category.ID) {
+			return
+		}
+	}
+	if req.Position != nil {
+		params.Position = *req.Position
+	}
+
+	updated, err := h.queries.UpdateChannelCategory(ctx, params)
+	if err != nil {
+		h.logger.Error("Failed to update category", "error", err, "categoryID", category.ID)
+		http.Error(w, "Failed to update category", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.NewChannelCategoryDTO(updated), failedEncodeCategoryErrMsg)
+
+	h.recordAudit(ctx, auditActionCategoryUpdated, identity.UserID, categoryAuditSubject(category.ID), clientIP(r),
+		fmt.Sprintf("name=%s position=%d", updated.Name, updated.Position))
+	h.logger.Info("Category updated", "categoryID", category.ID, "actorID", identity.UserID)
+}
+
+// DeleteChannelCategory removes a category. Its channels are kept and end up
+// without a category.
+func (h *Handler) DeleteChannelCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Category deletion without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	category, ok := h.categoryFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// The channels are cleared explicitly rather than relying on the foreign
+	// key, which SQLite only enforces when the pragma is on.
+	err := h.withTx(ctx, func(q *repository.Queries) error {
+		if err := q.ClearChannelCategory(ctx, sql.NullInt64{Int64: category.ID, Valid: true}); err != nil {
+			return err
+		}
+		_, err := q.DeleteChannelCategory(ctx, category.ID)
+		return err
+	})
+	if err != nil {
+		h.logger.Error("Failed to delete category", "error", err, "categoryID", category.ID)
+		http.Error(w, "Failed to delete category", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	h.recordAudit(ctx, auditActionCategoryDeleted, identity.UserID, categoryAuditSubject(category.ID), clientIP(r),
+		fmt.Sprintf("name=%s", category.Name))
+	h.logger.Info("Category deleted", "categoryID", category.ID, "actorID", identity.UserID)
+}
+
+// SetChannelCategory moves a channel into a category at a position, or out of
+// every category.
+func (h *Handler) SetChannelCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Channel category change without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	channelID, ok := h.channelIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.SetChannelCategoryRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	params := repository.SetChannelCategoryParams{Position: req.Position, ID: channelID}
+	if req.CategoryID != nil {
+		if _, err := h.queries.GetChannelCategory(ctx, *req.CategoryID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, categoryNotFoundErrMsg, http.StatusNotFound)
+				return
+			}
+			h.logger.Error("Failed to retrieve category", "error", err, "categoryID", *req.CategoryID)
+			http.Error(w, "Failed to change channel category", http.StatusInternalServerError)
+			return
+		}
+		params.CategoryID = sql.NullInt64{Int64: *req.CategoryID, Valid: true}
+	}
+
+	// Direct conversations are not listed with channels, so they are left
+	// out like missing channels.
+	rows, err := h.queries.SetChannelCategory(ctx, params)
+	if err != nil {
+		h.logger.Error("Failed to change channel category", "error", err, "channelID", channelID)
+		http.Error(w, "Failed to change channel category", http.StatusInternalServerError)
+		return
+	}
+	if rows == 0 {
+		http.Error(w, channelNotFoundErrMsg, http.StatusNotFound)
+		return
+	}
+
+	channel, err := h.queries.GetChannelByID(ctx, channelID)
+	if err != nil {
+		h.respondChannelLookupError(w, err, channelID)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, dto.NewChannelResponse(channel), failedEncodeChannelDataErrMsg)
+
+	h.recordAudit(ctx, auditActionChannelCategoryChanged, identity.UserID, channelAuditSubject(channelID), clientIP(r),
+		fmt.Sprintf("category=%d position=%d", params.CategoryID.Int64, params.Position))
+	h.logger.Info("Channel category changed", "channelID", channelID, "categoryID", params.CategoryID.Int64, "actorID", identity.UserID)
+}
+
+func (h *Handler) categoryFromRequest(w http.ResponseWriter, r *http.Request) (repository.ChannelCategory, bool) {
+	categoryID, err := strconv.ParseInt(chi.URLParam(r, "categoryId"), 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid category ID", "error", err)
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return repository.ChannelCategory{}, false
+	}
+
+	category, err := h.queries.GetChannelCategory(r.Context(), categoryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, categoryNotFoundErrMsg, http.StatusNotFound)
+			return repository.ChannelCategory{}, false
+		}
+		h.logger.Error("Failed to retrieve category", "error", err, "categoryID", categoryID)
+		http.Error(w, "Failed to retrieve category", http.StatusInternalServerError)
+		return repository.ChannelCategory{}, false
+	}
+	return category, true
+}
+
+// checkCategoryNameFree responds with a conflict when another category than
+// exceptID already has name, ignoring case.
+func (h *Handler) checkCategoryNameFree(w http.ResponseWriter, r *http.Request, name string, exceptID int64) bool {
+	id, err := h.queries.GetChannelCategoryIDByName(r.Context(), name)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && id == exceptID) {
+		return true
+	}
+	if err != nil {
+		h.logger.Error("Failed to check category name", "error", err, "name", name)
+		http.Error(w, "Failed to check category name", http.StatusInternalServerError)
+		return false
+	}
+
+	respondWithViolations(w, http.StatusConflict, []auth.Violation{
+		{Field: "name", Code: auth.ViolationTaken, Message: "Category name is already taken"},
+	})
+	return false
+}
+
+func categoryAuditSubject(categoryID int64) string {
+	return "category:" + strconv.FormatInt(categoryID, 10)
+}
@@ -0,0 +1,247 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/handlers"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestChannelCategories(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	h := handlers.NewHandler(getMockLogger(), queries, db)
+
+	admin := createTestUserWithRole(t, queries, "admin1", auth.RoleAdmin)
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	channel := createTestMemberChannel(t, h, owner.ID, "backend", false)
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Blank Name", `{"name": "  "}`, http.StatusBadRequest},
+		{"Engineering", `{"name": " Engineering ", "position": 2}`, http.StatusCreated},
+		{"Social", `{"name": "Social", "position": 1}`, http.StatusCreated},
+		{"Taken Name", `{"name": "engineering"}`, http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := categoryRequest(t, h.CreateChannelCategory, "", admin.ID, tc.body)
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	categories := listTestCategories(t, h, owner.ID)
+	if len(categories) != 2 || categories[0].Name != "Social" || categories[1].Name != "Engineering" {
+		t.Fatalf("expected the categories by position, got %+v", categories)
+	}
+	engineering, social := categories[1], categories[0]
+
+	if w := categoryRequest(t, h.UpdateChannelCategory, strconv.FormatInt(social.ID, 10), admin.ID, `{"name": "Engineering"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected a rename to a taken name to conflict, got %d", w.Code)
+	}
+	if w := categoryRequest(t, h.UpdateChannelCategory, strconv.FormatInt(social.ID, 10), admin.ID, `{"position": 3}`); w.Code != http.StatusOK {
+		t.Errorf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	if categories := listTestCategories(t, h, owner.ID); categories[0].ID != engineering.ID || categories[1].Name != "Social" {
+		t.Errorf("expected Social to move after Engineering, got %+v", categories)
+	}
+
+	body := `{"categoryId": ` + strconv.FormatInt(engineering.ID, 10) + `, "position": 4}`
+	if w := channelCategoryRequest(t, h, channel.ID, admin.ID, body); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	if w := channelCategoryRequest(t, h, channel.ID, admin.ID, `{"categoryId": 999}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected an unknown category to be refused, got %d", w.Code)
+	}
+	if w := channelCategoryRequest(t, h, 999, admin.ID, body); w.Code != http.StatusNotFound {
+		t.Errorf("expected an unknown channel to be refused, got %d", w.Code)
+	}
+
+	channels := listTestChannels(t, h, owner.ID, "", http.StatusOK)
+	if len(channels) != 1 || channels[0].CategoryID != engineering.ID || channels[0].Position != 4 {
+		t.Errorf("expected the channel in Engineering at position 4, got %+v", channels)
+	}
+
+	if w := categoryRequest(t, h.DeleteChannelCategory, strconv.FormatInt(engineering.ID, 10), admin.ID, ""); w.Code != http.StatusNoContent {
+		t.Fatalf(expectedStatusErrMsg, http.StatusNoContent, w.Code)
+	}
+	if w := categoryRequest(t, h.DeleteChannelCategory, strconv.FormatInt(engineering.ID, 10), admin.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf(expectedStatusErrMsg, http.StatusNotFound, w.Code)
+	}
+	if channels := listTestChannels(t, h, owner.ID, "", http.StatusOK); channels[0].CategoryID != 0 {
+		t.Errorf("expected the channel to lose its deleted category, got %+v", channels[0])
+	}
+}
+
+func TestChannelSidebar(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	h := handlers.NewHandler(getMockLogger(), queries, db)
+
+	user := createTestUserWithRole(t, queries, "user", auth.RoleUser)
+	other := createTestUserWithRole(t, queries, "other", auth.RoleUser)
+	category, err := queries.CreateChannelCategory(context.Background(), repository.CreateChannelCategoryParams{Name: "Engineering"})
+	if err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+	if _, err := queries.CreateChannelCategory(context.Background(), repository.CreateChannelCategoryParams{Name: "Social", Position: 1}); err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+
+	backend := createTestMemberChannel(t, h, user.ID, "backend", false)
+	frontend := createTestMemberChannel(t, h, user.ID, "frontend", false)
+	random := createTestMemberChannel(t, h, user.ID, "random", false)
+	foreign := createTestMemberChannel(t, h, other.ID, "foreign", false)
+	mustExec(t, db, "UPDATE channels SET category_id = ?, position = 1 WHERE id = ?", category.ID, backend.ID)
+	mustExec(t, db, "UPDATE channels SET category_id = ?, position = 2 WHERE id = ?", category.ID, frontend.ID)
+
+	sidebar := testChannelSidebar(t, h, user.ID)
+	if len(sidebar.Favorites) != 0 || len(sidebar.Categories) != 2 || len(sidebar.Uncategorized) != 1 {
+		t.Fatalf("expected two categories and one uncategorized channel, got %+v", sidebar)
+	}
+	if names := sidebarNames(sidebar.Categories[0].Channels); names != "backend,frontend" {
+		t.Errorf("expected the channels by position, got %s", names)
+	}
+	if len(sidebar.Categories[1].Channels) != 0 {
+		t.Errorf("expected the empty category to be listed, got %+v", sidebar.Categories[1])
+	}
+
+	if w := channelRequest(t, h.FavoriteChannel, random.ID, user.ID, ""); w.Code != http.StatusNoContent {
+		t.Fatalf(expectedStatusErrMsg, http.StatusNoContent, w.Code)
+	}
+	if w := channelRequest(t, h.FavoriteChannel, foreign.ID, user.ID, ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected non-members not to favorite a channel, got %d", w.Code)
+	}
+
+	orderTestCases := []struct {
+		name           string
+		channelIDs     []int64
+		expectedStatus int
+	}{
+		{"Not A Member", []int64{foreign.ID}, http.StatusBadRequest},
+		{"Listed Twice", []int64{backend.ID, backend.ID}, http.StatusBadRequest},
+		{"Frontend First", []int64{frontend.ID}, http.StatusNoContent},
+	}
+
+	for _, tc := range orderTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(dto.ChannelOrderRequestDTO{ChannelIDs: tc.channelIDs})
+			req := withIdentity(httptest.NewRequest(http.MethodPut, "/users/me/channel-order", strings.NewReader(string(body))), user.ID)
+			w := httptest.NewRecorder()
+
+			h.SetChannelOrder(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	sidebar = testChannelSidebar(t, h, user.ID)
+	if names := sidebarNames(sidebar.Favorites); names != "random" {
+		t.Errorf("expected random among the favorites, got %s", names)
+	}
+	if names := sidebarNames(sidebar.Categories[0].Channels); names != "frontend,backend" {
+		t.Errorf("expected the personal order to come first, got %s", names)
+	}
+	if len(sidebar.Uncategorized) != 0 {
+		t.Errorf("expected favorites to leave their section, got %+v", sidebar.Uncategorized)
+	}
+
+	// Other users keep the shared order.
+	if w := channelRequest(t, h.JoinChannel, frontend.ID, other.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	if w := channelRequest(t, h.JoinChannel, backend.ID, other.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	if names := sidebarNames(testChannelSidebar(t, h, other.ID).Categories[0].Channels); names != "backend,frontend" {
+		t.Errorf("expected the shared order for other users, got %s", names)
+	}
+}
+
+func categoryRequest(t *testing.T, handler http.HandlerFunc, categoryID string, userID int64, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/admin/channel-categories/"+categoryID, strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("categoryId", categoryID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: userID, Role: auth.RoleAdmin}))
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+	return w
+}
+
+func channelCategoryRequest(t *testing.T, h *handlers.Handler, channelID, userID int64, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	id := strconv.FormatInt(channelID, 10)
+	req := httptest.NewRequest(http.MethodPut, "/admin/channels/"+id+"/category", strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("channelId", id)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: userID, Role: auth.RoleAdmin}))
+	w := httptest.NewRecorder()
+
+	h.SetChannelCategory(w, req)
+	return w
+}
+
+func listTestCategories(t *testing.T, h *handlers.Handler, userID int64) []dto.ChannelCategoryDTO {
+	t.Helper()
+	req := withIdentity(httptest.NewRequest(http.MethodGet, "/channel-categories", nil), userID)
+	w := httptest.NewRecorder()
+
+	h.ListChannelCategories(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	var categories []dto.ChannelCategoryDTO
+	if err := json.NewDecoder(w.Body).Decode(&categories); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	return categories
+}
+
+func testChannelSidebar(t *testing.T, h *handlers.Handler, userID int64) dto.ChannelSidebarDTO {
+	t.Helper()
+	req := withIdentity(httptest.NewRequest(http.MethodGet, pathChannels+"/sidebar", nil), userID)
+	w := httptest.NewRecorder()
+
+	h.GetChannelSidebar(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	var sidebar dto.ChannelSidebarDTO
+	if err := json.NewDecoder(w.Body).Decode(&sidebar); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	return sidebar
+}
+
+func sidebarNames(channels []dto.ChannelResponseDTO) string {
+	names := make([]string, len(channels))
+	for i, c := range channels {
+		names[i] = c.Name
+	}
+	return strings.Join(names, ",")
+}
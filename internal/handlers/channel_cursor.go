@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+)
+
+const (
+	defaultChannelPageSize = 50
+	maxChannelPageSize     = 200
+
+	channelSortCreated  = "created"
+	channelSortName     = "name"
+	channelSortActivity = "activity"
+)
+
+var errInvalidChannelCursor = errors.New("invalid channel cursor")
+
+// channelCursor is the position after the last channel of a page. Only the
+// fields of its sort order are set. Clients get it as an opaque string.
+type channelCursor struct {
+	Sort          string `json:"s"`
+	ID            int64  `json:"i"`
+	Name          string `json:"n,omitempty"`
+	LastMessageID int64  `json:"m,omitempty"`
+}
+
+// firstChannelCursor is the position before the first channel in sort order.
+func firstChannelCursor(sort string) channelCursor {
+	switch sort {
+	case channelSortName:
+		return channelCursor{Sort: sort}
+	case channelSortActivity:
+		return channelCursor{Sort: sort, ID: math.MaxInt64, LastMessageID: math.MaxInt64}
+	default:
+		return channelCursor{Sort: sort, ID: math.MaxInt64}
+	}
+}
+
+func (c channelCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeChannelCursor reads a cursor from a previous page. A cursor of another
+// sort order is rejected, since its position means nothing in this one.
+func decodeChannelCursor(s, sort string) (channelCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return channelCursor{}, errInvalidChannelCursor
+	}
+	var c channelCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != sort {
+		return channelCursor{}, errInvalidChannelCursor
+	}
+	return c, nil
+}
+
+// likePattern matches s anywhere in a column compared with ESCAPE '\'.
+func likePattern(s string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + escaped + "%"
+}
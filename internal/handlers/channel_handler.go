@@ -1,15 +1,19 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
+	"strings"
 
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/channelsettings"
 	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/permission"
 	"github.com/fortega2/real-time-chat/internal/repository"
-	"github.com/go-chi/chi/v5"
 )
 
 func (h *Handler) GetAllChannels(w http.ResponseWriter, r *http.Request) {
@@ -21,23 +25,115 @@ func (h *Handler) GetAllChannels(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.logger.Debug("Get all channels attempt")
+	userId, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	h.logger.Debug("Get all channels attempt", "userID", userId)
+
+	query := r.URL.Query()
+	include := query.Get("include")
+	if include != "" && include != "archived" {
+		http.Error(w, "include must be archived", http.StatusBadRequest)
+		return
+	}
+
+	sort := query.Get("sort")
+	switch sort {
+	case "":
+		sort = channelSortCreated
+	case channelSortCreated, channelSortName, channelSortActivity:
+	default:
+		http.Error(w, "sort must be created, name or activity", http.StatusBadRequest)
+		return
+	}
+
+	limit, ok := queryInt(r, "limit", defaultChannelPageSize)
+	if !ok || limit < 1 || limit > maxChannelPageSize {
+		http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxChannelPageSize), http.StatusBadRequest)
+		return
+	}
 
-	channelsRepoRsp, err := h.queries.GetAllChannels(ctx)
+	cursor := firstChannelCursor(sort)
+	if raw := query.Get("cursor"); raw != "" {
+		var err error
+		if cursor, err = decodeChannelCursor(raw, sort); err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Private channels are only listed for their members, and archived ones
+	// only when asked for. One extra row tells whether there is another page.
+	pattern := likePattern(strings.TrimSpace(query.Get("q")))
+	channelsRepoRsp, err := h.listChannels(ctx, userId, include == "archived", pattern, cursor, int64(limit)+1)
 	if err != nil {
 		h.logger.Error("Failed to get channels", "error", err)
 		http.Error(w, "Failed to get channels", http.StatusInternalServerError)
 		return
 	}
 
-	channels := make([]dto.ChannelResponseDTO, len(channelsRepoRsp))
-	for i, channel := range channelsRepoRsp {
-		channels[i] = dto.NewChannelResponse(channel)
+	response := dto.ChannelListResponseDTO{Channels: []dto.ChannelResponseDTO{}}
+	if len(channelsRepoRsp) > limit {
+		channelsRepoRsp = channelsRepoRsp[:limit]
+		last := channelsRepoRsp[limit-1]
+		response.HasMore = true
+		response.NextCursor = channelCursor{Sort: sort, ID: last.ID, Name: last.Name, LastMessageID: last.LastMessageID}.encode()
+	}
+	for _, channel := range channelsRepoRsp {
+		response.Channels = append(response.Channels, dto.NewChannelResponse(channel))
 	}
 
-	respondWithJSON(w, http.StatusOK, channels, failedEncodeChannelDataErrMsg)
+	respondWithJSON(w, http.StatusOK, response, failedEncodeChannelDataErrMsg)
 
-	h.logger.Info("Channels retrieved successfully", "count", len(channels))
+	h.logger.Info("Channels retrieved successfully", "count", len(response.Channels))
+}
+
+// listChannels runs the list query of the sort order of cursor. The queries
+// return the same columns, so their rows convert to ListChannelsForUserRow.
+func (h *Handler) listChannels(ctx context.Context, userID int64, includeArchived bool, pattern string, cursor channelCursor, limit int64) ([]repository.ListChannelsForUserRow, error) {
+	switch cursor.Sort {
+	case channelSortName:
+		rows, err := h.queries.ListChannelsForUserByName(ctx, repository.ListChannelsForUserByNameParams{
+			UserID:             userID,
+			IncludeArchived:    includeArchived,
+			NamePattern:        pattern,
+			DescriptionPattern: pattern,
+			AfterName:          cursor.Name,
+			AfterID:            cursor.ID,
+			Limit:              limit,
+		})
+		channels := make([]repository.ListChannelsForUserRow, len(rows))
+		for i, row := range rows {
+			channels[i] = repository.ListChannelsForUserRow(row)
+		}
+		return channels, err
+	case channelSortActivity:
+		rows, err := h.queries.ListChannelsForUserByActivity(ctx, repository.ListChannelsForUserByActivityParams{
+			UserID:             userID,
+			IncludeArchived:    includeArchived,
+			NamePattern:        pattern,
+			DescriptionPattern: pattern,
+			BeforeMessageID:    cursor.LastMessageID,
+			BeforeID:           cursor.ID,
+			Limit:              limit,
+		})
+		channels := make([]repository.ListChannelsForUserRow, len(rows))
+		for i, row := range rows {
+			channels[i] = repository.ListChannelsForUserRow(row)
+		}
+		return channels, err
+	default:
+		return h.queries.ListChannelsForUser(ctx, repository.ListChannelsForUserParams{
+			UserID:             userID,
+			IncludeArchived:    includeArchived,
+			NamePattern:        pattern,
+			DescriptionPattern: pattern,
+			BeforeID:           cursor.ID,
+			Limit:              limit,
+		})
+	}
 }
 
 func (h *Handler) CreateChannel(w http.ResponseWriter, r *http.Request) {
@@ -49,6 +145,11 @@ func (h *Handler) CreateChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userId, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
 	var req dto.CreateChannelRequestDTO
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error("Failed to decode request body", "error", err)
@@ -56,31 +157,46 @@ func (h *Handler) CreateChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !req.IsValid() {
-		h.logger.Error("Invalid channel data", "name", req.Name, "userID", req.UserID)
-		http.Error(w, "Channel name and user ID are required", http.StatusBadRequest)
+	h.logger.Debug("Create channel attempt", "name", req.Name, "description", req.Description, "userID", userId)
+
+	_, err := h.queries.GetUserByID(ctx, userId)
+	if err != nil {
+		h.logger.Error("User not found", "userID", userId, "error", err)
+		http.Error(w, "User not found", http.StatusBadRequest)
 		return
 	}
 
-	h.logger.Debug("Create channel attempt", "name", req.Name, "description", req.Description, "userID", req.UserID)
+	settings := channelsettings.Normalize(channelsettings.Settings{Name: req.Name, Description: req.Description, Topic: req.Topic})
+	if violations := channelsettings.Check(settings); len(violations) > 0 {
+		h.logger.Info("Channel creation rejected", "userID", userId, "violations", len(violations))
+		respondWithViolations(w, http.StatusBadRequest, violations)
+		return
+	}
 
-	_, err := h.queries.GetUserByID(ctx, req.UserID)
-	if err != nil {
-		h.logger.Error("User not found", "userID", req.UserID, "error", err)
-		http.Error(w, "User not found", http.StatusBadRequest)
+	if !h.checkChannelNameFree(w, r, settings.Name, 0) {
 		return
 	}
 
 	createChannelParams := repository.CreateChannelParams{
-		Name: req.Name,
-		Description: sql.NullString{
-			String: req.Description,
-			Valid:  req.Description != "",
-		},
-		CreatedBy: req.UserID,
+		Name:        settings.Name,
+		Description: nullString(settings.Description),
+		Topic:       nullString(settings.Topic),
+		CreatedBy:   userId,
+		IsPrivate:   req.IsPrivate,
 	}
 
-	chId, err := h.queries.CreateChannel(ctx, createChannelParams)
+	var chId int64
+	err = h.withTx(ctx, func(q *repository.Queries) error {
+		var err error
+		if chId, err = q.CreateChannel(ctx, createChannelParams); err != nil {
+			return err
+		}
+		return q.AddChannelMember(ctx, repository.AddChannelMemberParams{
+			ChannelID: chId,
+			UserID:    userId,
+			Role:      string(permission.RoleOwner),
+		})
+	})
 	if err != nil {
 		h.logger.Error("Failed to create channel", "error", err)
 		http.Error(w, "Failed to create channel", http.StatusInternalServerError)
@@ -95,12 +211,16 @@ func (h *Handler) CreateChannel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := dto.NewChannelResponse(channel)
+	response.IsMember = true
+	response.Role = string(permission.RoleOwner)
 	respondWithJSON(w, http.StatusCreated, response, failedEncodeChannelDataErrMsg)
 
 	h.logger.Info("Channel created successfully", "channel", channel)
 }
 
-func (h *Handler) DeleteChannel(w http.ResponseWriter, r *http.Request) {
+// UpdateChannel changes the name, description or topic of a channel and
+// pushes the change to the clients connected to it.
+func (h *Handler) UpdateChannel(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	if ctx.Err() != nil {
@@ -109,70 +229,160 @@ func (h *Handler) DeleteChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	channelIdStr := chi.URLParam(r, "channelId")
-	if channelIdStr == "" {
-		h.logger.Error("Channel ID is required")
-		http.Error(w, "Channel ID is required", http.StatusBadRequest)
+	userId, ok := h.currentUserID(w, r)
+	if !ok {
 		return
 	}
 
-	userIdStr := chi.URLParam(r, "userId")
-	if userIdStr == "" {
-		h.logger.Error("User ID is required")
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+	var req dto.UpdateChannelRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
 		return
 	}
 
-	h.logger.Debug("Delete channel attempt", "channelID", channelIdStr, "userID", userIdStr)
+	channel, member, ok := h.authorizeChannel(w, r, userId, permission.EditChannel)
+	if !ok {
+		return
+	}
 
-	channelId, err := strconv.ParseInt(channelIdStr, 10, 64)
-	if err != nil {
-		h.logger.Error("Invalid channel ID", "error", err)
-		http.Error(w, "Invalid channel ID", http.StatusBadRequest)
+	settings := channelsettings.Normalize(applyChannelUpdate(channelsettings.Of(channel), req))
+	if violations := channelsettings.Check(settings); len(violations) > 0 {
+		h.logger.Info("Channel update rejected", "channelID", channel.ID, "violations", len(violations))
+		respondWithViolations(w, http.StatusBadRequest, violations)
 		return
 	}
 
-	userId, err := strconv.ParseInt(userIdStr, 10, 64)
-	if err != nil {
-		h.logger.Error("Invalid user ID", "error", err)
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+	if settings.Name != channel.Name && !h.checkChannelNameFree(w, r, settings.Name, channel.ID) {
 		return
 	}
 
-	channel, err := h.queries.GetChannelByID(ctx, channelId)
+	err := h.queries.UpdateChannel(ctx, repository.UpdateChannelParams{
+		Name:        settings.Name,
+		Description: nullString(settings.Description),
+		Topic:       nullString(settings.Topic),
+		ID:          channel.ID,
+	})
 	if err != nil {
-		h.logger.Error("Channel not found", "channelID", channelId, "error", err)
-		http.Error(w, "Channel not found", http.StatusNotFound)
+		h.logger.Error("Failed to update channel", "error", err, "channelID", channel.ID)
+		http.Error(w, "Failed to update channel", http.StatusInternalServerError)
 		return
 	}
 
-	if channel.CreatedBy != userId {
-		h.logger.Error("User is not the creator of the channel",
-			"channelID", channelId,
-			"userID", userId,
-			"createdBy", channel.CreatedBy)
-		http.Error(w, "Only the channel creator can delete this channel", http.StatusForbidden)
+	h.hub.UpdateChannel(channel.ID, settings)
+	h.recordAudit(ctx, auditActionChannelUpdated, userId, channelAuditSubject(channel.ID), clientIP(r),
+		fmt.Sprintf("name=%s", settings.Name))
+
+	channel.Name = settings.Name
+	channel.Description = nullString(settings.Description)
+	channel.Topic = nullString(settings.Topic)
+
+	response := dto.NewChannelResponse(channel)
+	response.IsMember = true
+	response.Role = member.Role
+	respondWithJSON(w, http.StatusOK, response, failedEncodeChannelDataErrMsg)
+
+	h.logger.Info("Channel updated", "channelID", channel.ID, "userID", userId)
+}
+
+// ArchiveChannel makes a channel read-only and hides it from the channel list.
+// Its history is kept, unlike when an administrator deletes it.
+func (h *Handler) ArchiveChannel(w http.ResponseWriter, r *http.Request) {
+	h.setChannelArchived(w, r, true)
+}
+
+func (h *Handler) UnarchiveChannel(w http.ResponseWriter, r *http.Request) {
+	h.setChannelArchived(w, r, false)
+}
+
+func (h *Handler) setChannelArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
 		return
 	}
 
-	err = h.queries.DeleteChannel(ctx, repository.DeleteChannelParams{
-		ID:        channelId,
-		CreatedBy: userId,
-	})
+	userId, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	channel, member, ok := h.authorizeChannel(w, r, userId, permission.ArchiveChannel)
+	if !ok {
+		return
+	}
+
+	if channel.ArchivedAt.Valid == archived {
+		if archived {
+			http.Error(w, "Channel is already archived", http.StatusConflict)
+		} else {
+			http.Error(w, "Channel is not archived", http.StatusConflict)
+		}
+		return
+	}
+
+	var err error
+	action := auditActionChannelArchived
+	if archived {
+		err = h.queries.ArchiveChannel(ctx, channel.ID)
+	} else {
+		err = h.queries.UnarchiveChannel(ctx, channel.ID)
+		action = auditActionChannelUnarchived
+	}
 	if err != nil {
-		h.logger.Error("Failed to delete channel", "error", err)
-		http.Error(w, "Failed to delete channel", http.StatusInternalServerError)
+		h.logger.Error("Failed to change channel archive state", "error", err, "channelID", channel.ID, "archived", archived)
+		http.Error(w, "Failed to update channel", http.StatusInternalServerError)
 		return
 	}
 
-	response := dto.DeleteChannelResponseDTO{
-		Message:   fmt.Sprintf("Channel '%s' deleted successfully", channel.Name),
-		ChannelID: channelId,
+	h.recordAudit(ctx, action, userId, channelAuditSubject(channel.ID), clientIP(r), "")
+
+	channel, err = h.queries.GetChannelByID(ctx, channel.ID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve channel", "error", err, "channelID", channel.ID)
+		http.Error(w, "Failed to retrieve channel", http.StatusInternalServerError)
+		return
 	}
-	respondWithJSON(w, http.StatusOK, response, failedEncodeDeleteChannelRspErrMsg)
 
-	h.logger.Info("Channel deleted successfully",
-		"channelID", channelId,
-		"channelName", channel.Name,
-		"userID", userId)
+	response := dto.NewChannelResponse(channel)
+	response.IsMember = true
+	response.Role = member.Role
+	respondWithJSON(w, http.StatusOK, response, failedEncodeChannelDataErrMsg)
+
+	h.logger.Info("Channel archive state changed", "channelID", channel.ID, "archived", archived, "userID", userId)
+}
+
+// checkChannelNameFree responds with a conflict when another channel than
+// exceptID already has name.
+func (h *Handler) checkChannelNameFree(w http.ResponseWriter, r *http.Request, name string, exceptID int64) bool {
+	id, err := h.queries.GetChannelIDByName(r.Context(), name)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && id == exceptID) {
+		return true
+	}
+	if err != nil {
+		h.logger.Error("Failed to check channel name", "error", err, "name", name)
+		http.Error(w, "Failed to check channel name", http.StatusInternalServerError)
+		return false
+	}
+
+	respondWithViolations(w, http.StatusConflict, []auth.Violation{
+		{Field: "name", Code: auth.ViolationTaken, Message: "Channel name is already taken"},
+	})
+	return false
+}
+
+// applyChannelUpdate returns s with the fields of req that are present.
+func applyChannelUpdate(s channelsettings.Settings, req dto.UpdateChannelRequestDTO) channelsettings.Settings {
+	if req.Name != nil {
+		s.Name = *req.Name
+	}
+	if req.Description != nil {
+		s.Description = *req.Description
+	}
+	if req.Topic != nil {
+		s.Topic = *req.Topic
+	}
+	return s
 }
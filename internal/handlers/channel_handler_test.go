@@ -8,8 +8,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
 	"github.com/fortega2/real-time-chat/internal/handlers"
 	"github.com/fortega2/real-time-chat/internal/repository"
 	"github.com/go-chi/chi/v5"
@@ -52,6 +56,18 @@ func TestGetAllChannels(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedCount:  2,
 		},
+		{
+			name: "Success - Private Channels Only For Members",
+			setup: func(t *testing.T) (*handlers.Handler, func()) {
+				db, h := setupChannelTest(t)
+				mustExec(t, db, "INSERT INTO users (id, username, password) VALUES (2, 'other', 'hash')")
+				mustExec(t, db, "INSERT INTO channels (id, name, created_by, is_private) VALUES (10, 'secret', 2, TRUE), (11, 'team', 2, TRUE)")
+				mustExec(t, db, "INSERT INTO channel_members (channel_id, user_id) VALUES (10, 2), (11, 2), (11, 1)")
+				return h, func() { db.Close() }
+			},
+			expectedStatus: http.StatusOK,
+			expectedCount:  3,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -59,7 +75,7 @@ func TestGetAllChannels(t *testing.T) {
 			h, teardown := tc.setup(t)
 			defer teardown()
 
-			req := httptest.NewRequest(http.MethodGet, pathChannels, nil)
+			req := withIdentity(httptest.NewRequest(http.MethodGet, pathChannels, nil), 1)
 			w := httptest.NewRecorder()
 
 			h.GetAllChannels(w, req)
@@ -85,6 +101,7 @@ func TestCreateChannel(t *testing.T) {
 	testCases := []struct {
 		name           string
 		setup          func(t *testing.T) (*handlers.Handler, func())
+		userID         int64
 		payload        interface{}
 		expectedStatus int
 	}{
@@ -105,7 +122,8 @@ func TestCreateChannel(t *testing.T) {
 				h := handlers.NewHandler(getMockLogger(), queries, db)
 				return h, func() { db.Close() }
 			},
-			payload:        map[string]interface{}{"name": "general", "description": "General discussion", "userId": int64(1)},
+			userID:         1,
+			payload:        map[string]interface{}{"name": "general", "description": "General discussion"},
 			expectedStatus: http.StatusCreated,
 		},
 		{
@@ -115,28 +133,20 @@ func TestCreateChannel(t *testing.T) {
 				h := handlers.NewHandler(getMockLogger(), repository.New(db), db)
 				return h, func() { db.Close() }
 			},
-			payload:        map[string]interface{}{"name": "", "description": "Empty name test", "userId": int64(1)},
-			expectedStatus: http.StatusBadRequest,
-		},
-		{
-			name: "Invalid User ID - Zero",
-			setup: func(t *testing.T) (*handlers.Handler, func()) {
-				db := initializeTestDBWithChannels(t)
-				h := handlers.NewHandler(getMockLogger(), repository.New(db), db)
-				return h, func() { db.Close() }
-			},
-			payload:        map[string]interface{}{"name": "test", "description": testChannelDesc, "userId": int64(0)},
+			userID:         1,
+			payload:        map[string]interface{}{"name": "", "description": "Empty name test"},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name: "Missing User ID",
+			name: "Unauthenticated",
 			setup: func(t *testing.T) (*handlers.Handler, func()) {
 				db := initializeTestDBWithChannels(t)
 				h := handlers.NewHandler(getMockLogger(), repository.New(db), db)
 				return h, func() { db.Close() }
 			},
+			userID:         0,
 			payload:        map[string]interface{}{"name": "test", "description": testChannelDesc},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnauthorized,
 		},
 		{
 			name: "Invalid JSON",
@@ -145,6 +155,7 @@ func TestCreateChannel(t *testing.T) {
 				h := handlers.NewHandler(getMockLogger(), repository.New(db), db)
 				return h, func() { db.Close() }
 			},
+			userID:         1,
 			payload:        "invalid-json",
 			expectedStatus: http.StatusBadRequest,
 		},
@@ -155,7 +166,8 @@ func TestCreateChannel(t *testing.T) {
 				h := handlers.NewHandler(getMockLogger(), repository.New(db), db)
 				return h, func() { db.Close() }
 			},
-			payload:        map[string]interface{}{"name": "test", "description": testChannelDesc, "userId": int64(999)},
+			userID:         999,
+			payload:        map[string]interface{}{"name": "test", "description": testChannelDesc},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
@@ -175,7 +187,8 @@ func TestCreateChannel(t *testing.T) {
 				h := handlers.NewHandler(getMockLogger(), queries, db)
 				return h, func() { db.Close() }
 			},
-			payload:        map[string]interface{}{"name": "random", "userId": int64(1)},
+			userID:         1,
+			payload:        map[string]interface{}{"name": "random"},
 			expectedStatus: http.StatusCreated,
 		},
 		{
@@ -185,7 +198,8 @@ func TestCreateChannel(t *testing.T) {
 				h := handlers.NewHandler(getMockLogger(), repository.New(db), db)
 				return h, func() { db.Close() }
 			},
-			payload:        map[string]any{"name": "test", "description": testChannelDesc, "userId": int64(999)},
+			userID:         999,
+			payload:        map[string]any{"name": "test", "description": testChannelDesc},
 			expectedStatus: http.StatusBadRequest,
 		},
 	}
@@ -198,6 +212,9 @@ func TestCreateChannel(t *testing.T) {
 			body, _ := json.Marshal(tc.payload)
 			req := httptest.NewRequest(http.MethodPost, pathChannels, bytes.NewBuffer(body))
 			req.Header.Set(headerContentType, mimeApplicationJSON)
+			if tc.userID != 0 {
+				req = withIdentity(req, tc.userID)
+			}
 
 			w := httptest.NewRecorder()
 
@@ -227,6 +244,7 @@ func TestCreateChannelInvalidJSONSyntax(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, pathChannels, bytes.NewBufferString("{"))
 	req.Header.Set(headerContentType, mimeApplicationJSON)
+	req = withIdentity(req, 1)
 
 	w := httptest.NewRecorder()
 
@@ -240,7 +258,7 @@ func TestCreateChannelInvalidJSONSyntax(t *testing.T) {
 	}
 }
 
-func TestCreateChannelMissingUserID(t *testing.T) {
+func TestCreateChannelUnauthenticated(t *testing.T) {
 	db := initializeTestDBWithChannels(t)
 	defer db.Close()
 	h := handlers.NewHandler(getMockLogger(), repository.New(db), db)
@@ -257,12 +275,12 @@ func TestCreateChannelMissingUserID(t *testing.T) {
 	resp := w.Result()
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusBadRequest {
-		t.Errorf(expectedStatusErrMsg, http.StatusBadRequest, resp.StatusCode)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf(expectedStatusErrMsg, http.StatusUnauthorized, resp.StatusCode)
 	}
 }
 
-func TestDeleteChannel(t *testing.T) {
+func TestArchiveChannel(t *testing.T) {
 	tests := []struct {
 		name           string
 		setup          func(t *testing.T) (*handlers.Handler, int64, int64, func())
@@ -271,8 +289,8 @@ func TestDeleteChannel(t *testing.T) {
 		expectedStatus int
 	}{
 		{
-			name:           "Successful Deletion",
-			setup:          setupSuccessfulChannelDeletion,
+			name:           "Successful Archive",
+			setup:          setupChannelOwner,
 			channelID:      "1",
 			userID:         "1",
 			expectedStatus: http.StatusOK,
@@ -298,13 +316,6 @@ func TestDeleteChannel(t *testing.T) {
 			userID:         "1",
 			expectedStatus: http.StatusBadRequest,
 		},
-		{
-			name:           "Invalid User ID",
-			setup:          setupBasicHandler,
-			channelID:      "1",
-			userID:         "invalid",
-			expectedStatus: http.StatusBadRequest,
-		},
 		{
 			name:           "Missing Channel ID",
 			setup:          setupBasicHandler,
@@ -313,22 +324,22 @@ func TestDeleteChannel(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name:           "Missing User ID",
+			name:           "Unauthenticated",
 			setup:          setupBasicHandler,
 			channelID:      "1",
 			userID:         "",
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnauthorized,
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			runDeleteChannelTest(t, tc.setup, tc.channelID, tc.userID, tc.expectedStatus, tc.name)
+			runArchiveChannelTest(t, tc.setup, tc.channelID, tc.userID, tc.expectedStatus, tc.name)
 		})
 	}
 }
 
-func setupSuccessfulChannelDeletion(t *testing.T) (*handlers.Handler, int64, int64, func()) {
+func setupChannelOwner(t *testing.T) (*handlers.Handler, int64, int64, func()) {
 	db := initializeTestDBWithChannels(t)
 	queries := repository.New(db)
 
@@ -341,13 +352,14 @@ func setupSuccessfulChannelDeletion(t *testing.T) (*handlers.Handler, int64, int
 	}
 
 	chId, err := queries.CreateChannel(context.Background(), repository.CreateChannelParams{
-		Name:        "todelete",
-		Description: sql.NullString{String: "Channel to delete", Valid: true},
+		Name:        "toarchive",
+		Description: sql.NullString{String: "Channel to archive", Valid: true},
 		CreatedBy:   user.ID,
 	})
 	if err != nil {
 		t.Fatalf("Failed to create test channel: %v", err)
 	}
+	mustExec(t, db, "INSERT INTO channel_members (channel_id, user_id, role) VALUES (?, ?, 'owner')", chId, user.ID)
 
 	h := handlers.NewHandler(getMockLogger(), queries, db)
 	return h, chId, user.ID, func() { db.Close() }
@@ -408,26 +420,32 @@ func setupBasicHandler(t *testing.T) (*handlers.Handler, int64, int64, func()) {
 	return h, 0, 0, func() { db.Close() }
 }
 
-func runDeleteChannelTest(t *testing.T, setup func(t *testing.T) (*handlers.Handler, int64, int64, func()), channelID, userID string, expectedStatus int, testName string) {
+func runArchiveChannelTest(t *testing.T, setup func(t *testing.T) (*handlers.Handler, int64, int64, func()), channelID, userID string, expectedStatus int, testName string) {
 	h, actualChannelID, actualUserID, teardown := setup(t)
 	defer teardown()
 
 	finalChannelID := channelID
 	finalUserID := userID
-	if actualChannelID != 0 && testName == "Successful Deletion" {
+	if actualChannelID != 0 && testName == "Successful Archive" {
 		finalChannelID = fmt.Sprintf("%d", actualChannelID)
 		finalUserID = fmt.Sprintf("%d", actualUserID)
 	}
 
-	req := httptest.NewRequest(http.MethodDelete, "/channels/"+finalChannelID+"/users/"+finalUserID, nil)
+	req := httptest.NewRequest(http.MethodPost, "/channels/"+finalChannelID+"/archive", nil)
 
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("channelId", finalChannelID)
-	rctx.URLParams.Add("userId", finalUserID)
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	if finalUserID != "" {
+		userIDValue, err := strconv.ParseInt(finalUserID, 10, 64)
+		if err != nil {
+			t.Fatalf("invalid user ID in test case: %v", err)
+		}
+		req = withIdentity(req, userIDValue)
+	}
 
 	w := httptest.NewRecorder()
-	h.DeleteChannel(w, req)
+	h.ArchiveChannel(w, req)
 
 	resp := w.Result()
 	defer resp.Body.Close()
@@ -437,30 +455,21 @@ func runDeleteChannelTest(t *testing.T, setup func(t *testing.T) (*handlers.Hand
 	}
 
 	if expectedStatus == http.StatusOK {
-		validateSuccessfulDeletion(t, w, resp)
+		validateSuccessfulArchive(t, w, resp)
 	}
 }
 
-func validateSuccessfulDeletion(t *testing.T, w *httptest.ResponseRecorder, resp *http.Response) {
+func validateSuccessfulArchive(t *testing.T, w *httptest.ResponseRecorder, resp *http.Response) {
 	if resp.Header.Get(headerContentType) != mimeApplicationJSON {
 		t.Errorf(contentTypeErrFmt, resp.Header.Get(headerContentType))
 	}
-	checkSuccessfulDeleteChannelResponse(t, w.Body)
-}
-
-func checkSuccessfulDeleteChannelResponse(t *testing.T, body *bytes.Buffer) {
-	t.Helper()
-	var response map[string]any
-	if err := json.NewDecoder(body).Decode(&response); err != nil {
-		t.Fatalf("failed to decode delete response: %v", err)
-	}
 
-	if message, exists := response["message"]; !exists || message == "" {
-		t.Error("Expected response to have a non-empty 'message' field")
+	var response dto.ChannelResponseDTO
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode archive response: %v", err)
 	}
-
-	if channelID, exists := response["channelId"]; !exists || channelID == nil {
-		t.Error("Expected response to have a non-nil 'channelId' field")
+	if response.ArchivedAt == "" {
+		t.Errorf("expected the channel to be archived, got %+v", response)
 	}
 }
 
@@ -476,23 +485,100 @@ func initializeTestDBWithChannels(t *testing.T) *sql.DB {
         id INTEGER PRIMARY KEY AUTOINCREMENT,
         username TEXT NOT NULL UNIQUE,
         password TEXT NOT NULL,
-        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        email TEXT UNIQUE,
+        username_key TEXT UNIQUE,
+        display_name TEXT,
+        bio TEXT,
+        avatar_url TEXT,
+        color VARCHAR(7),
+        role TEXT NOT NULL DEFAULT 'user',
+        disabled_at TIMESTAMP
     );`
 	if _, err := db.Exec(createUsersTableSQL); err != nil {
 		t.Fatalf("Failed to create users table: %v", err)
 	}
 
 	createChannelsTableSQL := `
+    CREATE TABLE IF NOT EXISTS channel_categories (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+        position INTEGER NOT NULL DEFAULT 0,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
     CREATE TABLE IF NOT EXISTS channels (
         id INTEGER PRIMARY KEY AUTOINCREMENT,
         name TEXT NOT NULL UNIQUE,
         description TEXT,
         created_by INTEGER NOT NULL,
         created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        is_private BOOLEAN NOT NULL DEFAULT FALSE,
+        topic TEXT,
+        archived_at TIMESTAMP,
+        kind TEXT NOT NULL DEFAULT 'channel' CHECK (kind IN ('channel', 'direct')),
+        direct_key TEXT UNIQUE,
+        category_id INTEGER REFERENCES channel_categories(id) ON DELETE SET NULL,
+        position INTEGER NOT NULL DEFAULT 0,
         FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+    );
+    CREATE TABLE IF NOT EXISTS channel_members (
+        channel_id INTEGER NOT NULL,
+        user_id INTEGER NOT NULL,
+        joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member')),
+        favorite BOOLEAN NOT NULL DEFAULT FALSE,
+        personal_position INTEGER,
+        PRIMARY KEY (channel_id, user_id),
+        FOREIGN KEY (channel_id) REFERENCES channels(id) ON DELETE CASCADE,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+    CREATE TABLE IF NOT EXISTS channel_invites (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        channel_id INTEGER NOT NULL,
+        user_id INTEGER NOT NULL,
+        invited_by INTEGER NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (channel_id) REFERENCES channels(id) ON DELETE CASCADE,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE CASCADE,
+        UNIQUE (channel_id, user_id)
+    );
+    CREATE TABLE IF NOT EXISTS channel_invite_links (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        channel_id INTEGER NOT NULL,
+        token_hash TEXT NOT NULL UNIQUE,
+        created_by INTEGER NOT NULL,
+        expires_at TIMESTAMP,
+        max_uses INTEGER,
+        uses INTEGER NOT NULL DEFAULT 0,
+        revoked_at TIMESTAMP,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (channel_id) REFERENCES channels(id) ON DELETE CASCADE,
+        FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+    );
+    CREATE TABLE IF NOT EXISTS messages (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        channel_id INTEGER NOT NULL,
+        user_id INTEGER NOT NULL,
+        user_color VARCHAR(7) NOT NULL,
+        content TEXT NOT NULL,
+        sequence INTEGER NOT NULL DEFAULT 0,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        edited_at TIMESTAMP,
+        FOREIGN KEY (channel_id) REFERENCES channels(id) ON DELETE CASCADE,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+    CREATE TABLE IF NOT EXISTS message_revisions (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        message_id INTEGER NOT NULL,
+        content TEXT NOT NULL,
+        edited_by INTEGER,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE,
+        FOREIGN KEY (edited_by) REFERENCES users(id) ON DELETE SET NULL
     );`
 	if _, err := db.Exec(createChannelsTableSQL); err != nil {
-		t.Fatalf("Failed to create channels table: %v", err)
+		t.Fatalf("Failed to create channel tables: %v", err)
 	}
 
 	return db
@@ -540,10 +626,17 @@ func setupChannelTest(t *testing.T) (*sql.DB, *handlers.Handler) {
 
 func checkChannelsResponse(t *testing.T, body *bytes.Buffer, expectedCount int) {
 	t.Helper()
-	var channels []map[string]any
-	if err := json.NewDecoder(body).Decode(&channels); err != nil {
+	var page struct {
+		Channels []map[string]any `json:"channels"`
+		HasMore  bool             `json:"hasMore"`
+	}
+	if err := json.NewDecoder(body).Decode(&page); err != nil {
 		t.Fatalf("failed to decode channels response: %v", err)
 	}
+	channels := page.Channels
+	if page.HasMore {
+		t.Error("Expected a single page")
+	}
 
 	if len(channels) != expectedCount {
 		t.Errorf(expectedCountErrMsg, expectedCount, len(channels))
@@ -565,6 +658,9 @@ func checkChannelsResponse(t *testing.T, body *bytes.Buffer, expectedCount int)
 		if _, ok := channel["createdAt"]; !ok {
 			t.Error("Expected channel to have 'createdAt' field")
 		}
+		if _, ok := channel["memberCount"]; !ok {
+			t.Error("Expected channel to have 'memberCount' field")
+		}
 	}
 }
 
@@ -600,3 +696,202 @@ func checkSuccessfulCreateChannelResponse(t *testing.T, body *bytes.Buffer, expe
 		t.Error("Expected createdAt to be non-empty")
 	}
 }
+
+func TestUpdateChannel(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	member := createTestUserWithRole(t, queries, "member", auth.RoleUser)
+	channel := createTestMemberChannel(t, h, owner.ID, "general", false)
+	createTestMemberChannel(t, h, owner.ID, "random", false)
+	if w := channelRequest(t, h.JoinChannel, channel.ID, member.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+
+	testCases := []struct {
+		name           string
+		userID         int64
+		body           string
+		expectedStatus int
+	}{
+		{"Member Cannot Edit", member.ID, `{"topic": "Hello"}`, http.StatusForbidden},
+		{"Blank Name", owner.ID, `{"name": "  "}`, http.StatusBadRequest},
+		{"Long Topic", owner.ID, `{"topic": "` + strings.Repeat("t", 251) + `"}`, http.StatusBadRequest},
+		{"Name Taken", owner.ID, `{"name": "random"}`, http.StatusConflict},
+		{"Invalid JSON", owner.ID, `{`, http.StatusBadRequest},
+		{"Same Name", owner.ID, `{"name": "general"}`, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := channelRequest(t, h.UpdateChannel, channel.ID, tc.userID, tc.body)
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	w := channelRequest(t, h.UpdateChannel, channel.ID, owner.ID, `{"name": " lobby ", "topic": "Release on Friday"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	var resp dto.ChannelResponseDTO
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if resp.Name != "lobby" || resp.Topic != "Release on Friday" || resp.Role != "owner" {
+		t.Errorf("unexpected channel %+v", resp)
+	}
+
+	stored, err := queries.GetChannelByID(context.Background(), channel.ID)
+	if err != nil || stored.Name != "lobby" || stored.Topic.String != "Release on Friday" {
+		t.Errorf("expected the update to be stored, got %+v, %v", stored, err)
+	}
+	if got := hub.updatedChannels[channel.ID]; got.Name != "lobby" || got.Topic != "Release on Friday" {
+		t.Errorf("expected the update to be pushed to connected clients, got %+v", got)
+	}
+}
+
+func TestCreateChannelNameTaken(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	h := handlers.NewHandler(getMockLogger(), queries, db)
+
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	createTestMemberChannel(t, h, owner.ID, "general", false)
+
+	body, _ := json.Marshal(dto.CreateChannelRequestDTO{Name: " general "})
+	req := withIdentity(httptest.NewRequest(http.MethodPost, pathChannels, bytes.NewReader(body)), owner.ID)
+	w := httptest.NewRecorder()
+
+	h.CreateChannel(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf(expectedStatusErrMsg, http.StatusConflict, w.Code)
+	}
+	var resp dto.ValidationErrorDTO
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if len(resp.Fields) != 1 || resp.Fields[0].Field != "name" || resp.Fields[0].Code != auth.ViolationTaken {
+		t.Errorf("unexpected errors %+v", resp)
+	}
+}
+
+func TestArchivedChannelIsReadOnly(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	h := handlers.NewHandler(getMockLogger(), queries, db)
+
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	channel := createTestMemberChannel(t, h, owner.ID, "general", false)
+
+	if w := channelRequest(t, h.ArchiveChannel, channel.ID, owner.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	if w := channelRequest(t, h.ArchiveChannel, channel.ID, owner.ID, ""); w.Code != http.StatusConflict {
+		t.Errorf("expected archiving twice to conflict, got %d", w.Code)
+	}
+	if w := channelRequest(t, h.UpdateChannel, channel.ID, owner.ID, `{"topic": "Hello"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected archived channels not to be edited, got %d", w.Code)
+	}
+
+	if got := listTestChannels(t, h, owner.ID, "", http.StatusOK); len(got) != 0 {
+		t.Errorf("expected archived channels to be hidden by default, got %+v", got)
+	}
+	got := listTestChannels(t, h, owner.ID, "?include=archived", http.StatusOK)
+	if len(got) != 1 || got[0].ArchivedAt == "" {
+		t.Errorf("expected the archived channel to be listed when asked for, got %+v", got)
+	}
+	listTestChannels(t, h, owner.ID, "?include=deleted", http.StatusBadRequest)
+
+	if w := channelRequest(t, h.UnarchiveChannel, channel.ID, owner.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	if got := listTestChannels(t, h, owner.ID, "", http.StatusOK); len(got) != 1 || got[0].ArchivedAt != "" {
+		t.Errorf("expected the unarchived channel to be listed again, got %+v", got)
+	}
+}
+
+func listTestChannels(t *testing.T, h *handlers.Handler, userID int64, query string, expectedStatus int) []dto.ChannelResponseDTO {
+	t.Helper()
+	return channelPage(t, h, userID, query, expectedStatus).Channels
+}
+
+func TestGetAllChannelsPagination(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	h := handlers.NewHandler(getMockLogger(), queries, db)
+
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	for _, name := range []string{"delta", "Alpha", "charlie", "bravo", "echo_team"} {
+		createTestMemberChannel(t, h, owner.ID, name, false)
+	}
+	mustExec(t, db, "UPDATE channels SET description = 'Release planning' WHERE name = 'delta'")
+	mustExec(t, db, "INSERT INTO messages (channel_id, user_id, user_color, content) SELECT id, created_by, '#000000', 'hi' FROM channels WHERE name = 'bravo'")
+
+	var names []string
+	cursor := ""
+	for page := 0; ; page++ {
+		query := "?sort=name&limit=2"
+		if cursor != "" {
+			query += "&cursor=" + cursor
+		}
+		body := channelPage(t, h, owner.ID, query, http.StatusOK)
+		for _, channel := range body.Channels {
+			names = append(names, channel.Name)
+			if channel.MemberCount != 1 {
+				t.Errorf("expected one member in %s, got %d", channel.Name, channel.MemberCount)
+			}
+		}
+		if !body.HasMore {
+			break
+		}
+		if page > 5 {
+			t.Fatal("pagination did not end")
+		}
+		cursor = body.NextCursor
+	}
+	if strings.Join(names, ",") != "Alpha,bravo,charlie,delta,echo_team" {
+		t.Errorf("expected every channel once, by name, got %v", names)
+	}
+
+	if got := channelPage(t, h, owner.ID, "?sort=activity&limit=1", http.StatusOK); len(got.Channels) != 1 || got.Channels[0].Name != "bravo" {
+		t.Errorf("expected the channel with the latest message first, got %+v", got.Channels)
+	}
+	if got := channelPage(t, h, owner.ID, "?q=PLANNING", http.StatusOK); len(got.Channels) != 1 || got.Channels[0].Name != "delta" {
+		t.Errorf("expected the description to be searched, got %+v", got.Channels)
+	}
+	if got := channelPage(t, h, owner.ID, "?q=o_t", http.StatusOK); len(got.Channels) != 1 || got.Channels[0].Name != "echo_team" {
+		t.Errorf("expected the underscore to match literally, got %+v", got.Channels)
+	}
+
+	nameCursor := channelPage(t, h, owner.ID, "?sort=name&limit=1", http.StatusOK).NextCursor
+	for _, query := range []string{"?sort=popular", "?limit=0", "?limit=201", "?cursor=not-a-cursor", "?sort=activity&cursor=" + nameCursor} {
+		channelPage(t, h, owner.ID, query, http.StatusBadRequest)
+	}
+}
+
+func channelPage(t *testing.T, h *handlers.Handler, userID int64, query string, expectedStatus int) dto.ChannelListResponseDTO {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.GetAllChannels(w, withIdentity(httptest.NewRequest(http.MethodGet, pathChannels+query, nil), userID))
+
+	if w.Code != expectedStatus {
+		t.Fatalf("%s: "+expectedStatusErrMsg, query, expectedStatus, w.Code)
+	}
+	var page dto.ChannelListResponseDTO
+	if expectedStatus == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+			t.Fatalf(failedToDecodeResponseBody, err)
+		}
+	}
+	return page
+}
@@ -0,0 +1,654 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/permission"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	channelNotFoundErrMsg      = "Channel not found"
+	notChannelMemberErrMsg     = "You are not a member of this channel"
+	channelRoleForbiddenErrMsg = "Your role in this channel does not allow this"
+	channelArchivedErrMsg      = "This channel is archived and read-only"
+	failedEncodeInviteErrMsg   = "Failed to encode invite data"
+	failedEncodeMemberErrMsg   = "Failed to encode member data"
+	failedTransferOwnerErrMsg  = "Failed to transfer channel ownership"
+	disabledNewOwnerErrMsg     = "Ownership cannot be given to a disabled account"
+
+	leftChannelReason = "Left the channel"
+)
+
+// JoinChannel makes the current user a member of a public channel, or of a
+// private channel they were invited to. Joining a channel twice is not an
+// error.
+func (h *Handler) JoinChannel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	channelID, ok := h.channelIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	channel, err := h.queries.GetChannelByID(ctx, channelID)
+	if err != nil {
+		h.respondChannelLookupError(w, err, channelID)
+		return
+	}
+
+	if channel.IsPrivate {
+		isMember, err := h.isChannelMember(ctx, channelID, userID)
+		if err != nil {
+			h.logger.Error("Failed to check channel membership", "error", err, "channelID", channelID, "userID", userID)
+			http.Error(w, "Failed to join channel", http.StatusInternalServerError)
+			return
+		}
+		if !isMember {
+			_, err := h.queries.GetChannelInviteForUser(ctx, repository.GetChannelInviteForUserParams{ChannelID: channelID, UserID: userID})
+			if errors.Is(err, sql.ErrNoRows) {
+				h.logger.Info("Join of a private channel without an invite", "channelID", channelID, "userID", userID)
+				http.Error(w, channelNotFoundErrMsg, http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				h.logger.Error("Failed to retrieve invite", "error", err, "channelID", channelID, "userID", userID)
+				http.Error(w, "Failed to join channel", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	var member repository.ChannelMember
+	err = h.withTx(ctx, func(q *repository.Queries) error {
+		if err := q.AddChannelMember(ctx, repository.AddChannelMemberParams{
+			ChannelID: channelID,
+			UserID:    userID,
+			Role:      string(permission.RoleMember),
+		}); err != nil {
+			return err
+		}
+		if err := q.DeleteChannelInviteForUser(ctx, repository.DeleteChannelInviteForUserParams{ChannelID: channelID, UserID: userID}); err != nil {
+			return err
+		}
+		// Members that join again keep their role.
+		member, err = q.GetChannelMember(ctx, repository.GetChannelMemberParams{ChannelID: channelID, UserID: userID})
+		return err
+	})
+	if err != nil {
+		h.logger.Error("Failed to join channel", "error", err, "channelID", channelID, "userID", userID)
+		http.Error(w, "Failed to join channel", http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.NewChannelResponse(channel)
+	response.IsMember = true
+	response.Role = member.Role
+	respondWithJSON(w, http.StatusOK, response, failedEncodeChannelDataErrMsg)
+
+	h.logger.Info("User joined channel", "channelID", channelID, "userID", userID)
+}
+
+// LeaveChannel removes the current user from a channel and closes their
+// connections to it. The owner cannot leave, since nobody else could
+// delete the channel afterwards.
+func (h *Handler) LeaveChannel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	channel, member, ok := h.authorizeChannel(w, r, userID, permission.Read)
+	if !ok {
+		return
+	}
+
+	if member.Kind == permission.KindDirect {
+		http.Error(w, "Direct conversations cannot be left", http.StatusConflict)
+		return
+	}
+	if permission.Role(member.Role) == permission.RoleOwner {
+		http.Error(w, "The channel owner cannot leave the channel; transfer ownership first", http.StatusConflict)
+		return
+	}
+
+	if _, err := h.queries.RemoveChannelMember(ctx, repository.RemoveChannelMemberParams{ChannelID: channel.ID, UserID: userID}); err != nil {
+		h.logger.Error("Failed to leave channel", "error", err, "channelID", channel.ID, "userID", userID)
+		http.Error(w, "Failed to leave channel", http.StatusInternalServerError)
+		return
+	}
+
+	h.hub.DisconnectFromChannel(userID, channel.ID, leftChannelReason)
+
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("User left channel", "channelID", channel.ID, "userID", userID)
+}
+
+// InviteToChannel lets a member of a private channel whose role allows it
+// invite another user, who joins by accepting it with JoinChannel.
+func (h *Handler) InviteToChannel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateChannelInviteRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" {
+		http.Error(w, "Username is required", http.StatusBadRequest)
+		return
+	}
+
+	channel, _, ok := h.authorizeChannel(w, r, userID, permission.Invite)
+	if !ok {
+		return
+	}
+
+	if !channel.IsPrivate {
+		http.Error(w, "Public channels can be joined without an invite", http.StatusBadRequest)
+		return
+	}
+
+	invitee, err := h.queries.GetUserByUsername(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Failed to retrieve user", "error", err, "username", req.Username)
+		http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+		return
+	}
+
+	isMember, err := h.isChannelMember(ctx, channel.ID, invitee.ID)
+	if err != nil {
+		h.logger.Error("Failed to check channel membership", "error", err, "channelID", channel.ID, "userID", invitee.ID)
+		http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+		return
+	}
+	if isMember {
+		http.Error(w, "User is already a member of this channel", http.StatusConflict)
+		return
+	}
+
+	_, err = h.queries.GetChannelInviteForUser(ctx, repository.GetChannelInviteForUserParams{ChannelID: channel.ID, UserID: invitee.ID})
+	switch {
+	case err == nil:
+		http.Error(w, "User is already invited to this channel", http.StatusConflict)
+		return
+	case !errors.Is(err, sql.ErrNoRows):
+		h.logger.Error("Failed to retrieve invite", "error", err, "channelID", channel.ID, "userID", invitee.ID)
+		http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+		return
+	}
+
+	invite, err := h.queries.CreateChannelInvite(ctx, repository.CreateChannelInviteParams{
+		ChannelID: channel.ID,
+		UserID:    invitee.ID,
+		InvitedBy: userID,
+	})
+	if err != nil {
+		h.logger.Error("Failed to create invite", "error", err, "channelID", channel.ID, "userID", invitee.ID)
+		http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+		return
+	}
+
+	inviter, err := h.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", userID)
+		http.Error(w, "Failed to create invite", http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.ChannelInviteDTO{
+		ID:                invite.ID,
+		ChannelID:         channel.ID,
+		ChannelName:       channel.Name,
+		InvitedBy:         userID,
+		InvitedByUsername: inviter.Username,
+		CreatedAt:         invite.CreatedAt.Format(time.RFC3339),
+	}
+	respondWithJSON(w, http.StatusCreated, response, failedEncodeInviteErrMsg)
+
+	h.logger.Info("Channel invite created", "channelID", channel.ID, "userID", invitee.ID, "invitedBy", userID)
+}
+
+// ListMyInvites returns the pending invites of the current user.
+func (h *Handler) ListMyInvites(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	invites, err := h.queries.ListChannelInvitesForUser(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to list invites", "error", err, "userID", userID)
+		http.Error(w, "Failed to list invites", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.ChannelInviteDTO, len(invites))
+	for i, invite := range invites {
+		response[i] = dto.NewChannelInviteDTO(invite)
+	}
+
+	respondWithJSON(w, http.StatusOK, response, failedEncodeInviteErrMsg)
+}
+
+// DeclineInvite deletes a pending invite of the current user.
+func (h *Handler) DeclineInvite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	inviteID, err := strconv.ParseInt(chi.URLParam(r, "inviteId"), 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid invite ID", "error", err)
+		http.Error(w, "Invalid invite ID", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := h.queries.DeleteChannelInvite(ctx, repository.DeleteChannelInviteParams{ID: inviteID, UserID: userID})
+	if err != nil {
+		h.logger.Error("Failed to decline invite", "error", err, "inviteID", inviteID, "userID", userID)
+		http.Error(w, "Failed to decline invite", http.StatusInternalServerError)
+		return
+	}
+	// Invites of other users look the same as missing ones.
+	if deleted == 0 {
+		http.Error(w, "Invite not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Channel invite declined", "inviteID", inviteID, "userID", userID)
+}
+
+// ListChannelMembers returns the members of a channel with their roles.
+func (h *Handler) ListChannelMembers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	channel, _, ok := h.authorizeChannel(w, r, userID, permission.Read)
+	if !ok {
+		return
+	}
+
+	members, err := h.queries.ListChannelMembers(ctx, channel.ID)
+	if err != nil {
+		h.logger.Error("Failed to list channel members", "error", err, "channelID", channel.ID)
+		http.Error(w, "Failed to list channel members", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.ChannelMemberDTO, len(members))
+	for i, member := range members {
+		response[i] = dto.NewChannelMemberDTO(member)
+	}
+
+	respondWithJSON(w, http.StatusOK, response, failedEncodeMemberErrMsg)
+}
+
+// UpdateChannelMemberRole promotes a member to moderator or demotes them back.
+// Only the owner manages roles, and ownership is not given away here.
+func (h *Handler) UpdateChannelMemberRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateChannelMemberRoleRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	role, err := permission.ParseRole(req.Role)
+	if err != nil || role == permission.RoleOwner {
+		http.Error(w, "Role must be moderator or member", http.StatusBadRequest)
+		return
+	}
+
+	channel, _, ok := h.authorizeChannel(w, r, userID, permission.ManageMembers)
+	if !ok {
+		return
+	}
+
+	targetID, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid user ID", "error", err)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if targetID == userID {
+		http.Error(w, "You cannot change your own channel role", http.StatusForbidden)
+		return
+	}
+
+	target, err := h.queries.GetChannelMember(ctx, repository.GetChannelMemberParams{ChannelID: channel.ID, UserID: targetID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Member not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Failed to retrieve channel member", "error", err, "channelID", channel.ID, "userID", targetID)
+		http.Error(w, "Failed to update channel role", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(ctx, targetID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", targetID)
+		http.Error(w, "Failed to update channel role", http.StatusInternalServerError)
+		return
+	}
+
+	member, err := h.queries.UpdateChannelMemberRole(ctx, repository.UpdateChannelMemberRoleParams{
+		Role:      string(role),
+		ChannelID: channel.ID,
+		UserID:    targetID,
+	})
+	if err != nil {
+		h.logger.Error("Failed to update channel role", "error", err, "channelID", channel.ID, "userID", targetID)
+		http.Error(w, "Failed to update channel role", http.StatusInternalServerError)
+		return
+	}
+
+	h.recordAudit(ctx, auditActionChannelRoleChanged, userID, channelAuditSubject(channel.ID), clientIP(r),
+		fmt.Sprintf("user_id=%d from=%s to=%s", targetID, target.Role, member.Role))
+
+	response := dto.NewChannelMemberDTO(repository.ListChannelMembersRow{
+		UserID:   member.UserID,
+		Username: user.Username,
+		Role:     member.Role,
+		JoinedAt: member.JoinedAt,
+	})
+	respondWithJSON(w, http.StatusOK, response, failedEncodeMemberErrMsg)
+
+	h.logger.Info("Channel role changed", "channelID", channel.ID, "userID", targetID, "role", member.Role, "actorID", userID)
+}
+
+// TransferChannelOwnership hands the channel over to another member. The
+// current owner becomes a moderator.
+func (h *Handler) TransferChannelOwnership(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.TransferChannelOwnershipRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	channel, _, ok := h.authorizeChannel(w, r, userID, permission.TransferOwnership)
+	if !ok {
+		return
+	}
+
+	if req.ConfirmName != channel.Name {
+		http.Error(w, "Type the channel name to confirm the transfer", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == userID {
+		http.Error(w, "You already own this channel", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.queries.GetChannelMember(ctx, repository.GetChannelMemberParams{ChannelID: channel.ID, UserID: req.UserID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Member not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Failed to retrieve channel member", "error", err, "channelID", channel.ID, "userID", req.UserID)
+		http.Error(w, failedTransferOwnerErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	member, ok := h.transferOwnership(w, r, channel.ID, req.UserID, userID)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, member, failedEncodeMemberErrMsg)
+
+	h.logger.Info("Channel ownership transferred", "channelID", channel.ID, "from", userID, "to", req.UserID)
+}
+
+// transferOwnership makes newOwnerID the owner of the channel, adding them as a
+// member if needed, and demotes the previous owner to moderator. The channel's
+// created_by follows the owner. It records the change for actorID and tells
+// the clients connected to the channel.
+func (h *Handler) transferOwnership(w http.ResponseWriter, r *http.Request, channelID, newOwnerID, actorID int64) (dto.ChannelMemberDTO, bool) {
+	ctx := r.Context()
+
+	user, err := h.queries.GetUserByID(ctx, newOwnerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return dto.ChannelMemberDTO{}, false
+		}
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", newOwnerID)
+		http.Error(w, failedTransferOwnerErrMsg, http.StatusInternalServerError)
+		return dto.ChannelMemberDTO{}, false
+	}
+	if user.DisabledAt.Valid {
+		http.Error(w, disabledNewOwnerErrMsg, http.StatusConflict)
+		return dto.ChannelMemberDTO{}, false
+	}
+
+	var previousOwnerID int64
+	var member repository.ChannelMember
+	err = h.withTx(ctx, func(q *repository.Queries) error {
+		owner, err := q.GetChannelOwner(ctx, channelID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// Nobody to demote; the new owner fixes the channel.
+		case err != nil:
+			return err
+		default:
+			previousOwnerID = owner
+			if _, err := q.UpdateChannelMemberRole(ctx, repository.UpdateChannelMemberRoleParams{
+				Role:      string(permission.RoleModerator),
+				ChannelID: channelID,
+				UserID:    owner,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := q.AddChannelMember(ctx, repository.AddChannelMemberParams{
+			ChannelID: channelID,
+			UserID:    newOwnerID,
+			Role:      string(permission.RoleMember),
+		}); err != nil {
+			return err
+		}
+		if err := q.DeleteChannelInviteForUser(ctx, repository.DeleteChannelInviteForUserParams{ChannelID: channelID, UserID: newOwnerID}); err != nil {
+			return err
+		}
+		member, err = q.UpdateChannelMemberRole(ctx, repository.UpdateChannelMemberRoleParams{
+			Role:      string(permission.RoleOwner),
+			ChannelID: channelID,
+			UserID:    newOwnerID,
+		})
+		if err != nil {
+			return err
+		}
+		return q.SetChannelCreatedBy(ctx, repository.SetChannelCreatedByParams{CreatedBy: newOwnerID, ID: channelID})
+	})
+	if err != nil {
+		h.logger.Error("Failed to transfer channel ownership", "error", err, "channelID", channelID, "userID", newOwnerID)
+		http.Error(w, failedTransferOwnerErrMsg, http.StatusInternalServerError)
+		return dto.ChannelMemberDTO{}, false
+	}
+
+	h.recordAudit(ctx, auditActionChannelOwnerChanged, actorID, channelAuditSubject(channelID), clientIP(r),
+		fmt.Sprintf("from=%d to=%d", previousOwnerID, newOwnerID))
+	h.hub.UpdateChannelOwner(channelID, newOwnerID, user.Username)
+
+	return dto.NewChannelMemberDTO(repository.ListChannelMembersRow{
+		UserID:   member.UserID,
+		Username: user.Username,
+		Role:     member.Role,
+		JoinedAt: member.JoinedAt,
+	}), true
+}
+
+// authorizeChannel loads the channel named by the channelId URL param and
+// checks that userID is a member whose channel role allows action, and that
+// the channel is not archived unless action is allowed there. Private channels
+// look missing to non-members, so their names do not leak.
+func (h *Handler) authorizeChannel(w http.ResponseWriter, r *http.Request, userID int64, action permission.Action) (repository.GetChannelByIDRow, repository.GetChannelMembershipRow, bool) {
+	channelID, ok := h.channelIDFromRequest(w, r)
+	if !ok {
+		return repository.GetChannelByIDRow{}, repository.GetChannelMembershipRow{}, false
+	}
+
+	channel, err := h.queries.GetChannelByID(r.Context(), channelID)
+	if err != nil {
+		h.respondChannelLookupError(w, err, channelID)
+		return repository.GetChannelByIDRow{}, repository.GetChannelMembershipRow{}, false
+	}
+
+	member, err := permission.Authorize(r.Context(), h.queries, channelID, userID, action)
+	switch {
+	case errors.Is(err, permission.ErrNotMember):
+		h.logger.Info("Channel access denied to non-member", "channelID", channelID, "userID", userID, "private", channel.IsPrivate)
+		if channel.IsPrivate {
+			http.Error(w, channelNotFoundErrMsg, http.StatusNotFound)
+		} else {
+			http.Error(w, notChannelMemberErrMsg, http.StatusForbidden)
+		}
+		return repository.GetChannelByIDRow{}, repository.GetChannelMembershipRow{}, false
+	case errors.Is(err, permission.ErrForbidden):
+		h.logger.Info("Channel action denied by role", "channelID", channelID, "userID", userID, "role", member.Role, "action", action)
+		http.Error(w, channelRoleForbiddenErrMsg, http.StatusForbidden)
+		return repository.GetChannelByIDRow{}, repository.GetChannelMembershipRow{}, false
+	case errors.Is(err, permission.ErrArchived):
+		http.Error(w, channelArchivedErrMsg, http.StatusConflict)
+		return repository.GetChannelByIDRow{}, repository.GetChannelMembershipRow{}, false
+	case err != nil:
+		h.logger.Error("Failed to check channel membership", "error", err, "channelID", channelID, "userID", userID)
+		http.Error(w, "Failed to check channel membership", http.StatusInternalServerError)
+		return repository.GetChannelByIDRow{}, repository.GetChannelMembershipRow{}, false
+	}
+
+	return channel, member, true
+}
+
+func (h *Handler) isChannelMember(ctx context.Context, channelID, userID int64) (bool, error) {
+	_, err := h.queries.GetChannelMember(ctx, repository.GetChannelMemberParams{ChannelID: channelID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h *Handler) channelIDFromRequest(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	channelID, err := strconv.ParseInt(chi.URLParam(r, "channelId"), 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid channel ID", "error", err)
+		http.Error(w, "Invalid channel ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return channelID, true
+}
+
+func (h *Handler) respondChannelLookupError(w http.ResponseWriter, err error, channelID int64) {
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, channelNotFoundErrMsg, http.StatusNotFound)
+		return
+	}
+	h.logger.Error("Failed to retrieve channel", "error", err, "channelID", channelID)
+	http.Error(w, "Failed to retrieve channel", http.StatusInternalServerError)
+}
@@ -0,0 +1,367 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/handlers"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestInviteAndJoinPrivateChannel(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	h := handlers.NewHandler(getMockLogger(), queries, db)
+
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	guest := createTestUserWithRole(t, queries, "guest", auth.RoleUser)
+	channel := createTestMemberChannel(t, h, owner.ID, "secret", true)
+
+	if w := channelRequest(t, h.JoinChannel, channel.ID, guest.ID, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected joining without an invite to look like a missing channel, got %d", w.Code)
+	}
+	if w := channelRequest(t, h.InviteToChannel, channel.ID, guest.ID, `{"username": "owner"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("expected non-members not to invite, got %d", w.Code)
+	}
+
+	w := channelRequest(t, h.InviteToChannel, channel.ID, owner.ID, `{"username": "guest"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf(expectedStatusErrMsg, http.StatusCreated, w.Code)
+	}
+	if w := channelRequest(t, h.InviteToChannel, channel.ID, owner.ID, `{"username": "guest"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected a second invite to conflict, got %d", w.Code)
+	}
+	if w := channelRequest(t, h.InviteToChannel, channel.ID, owner.ID, `{"username": "nobody"}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected inviting an unknown user to fail, got %d", w.Code)
+	}
+
+	invites := listTestInvites(t, h, guest.ID)
+	if len(invites) != 1 || invites[0].ChannelName != "secret" || invites[0].InvitedByUsername != "owner" {
+		t.Fatalf("unexpected invites %+v", invites)
+	}
+
+	w = channelRequest(t, h.JoinChannel, channel.ID, guest.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	if invites := listTestInvites(t, h, guest.ID); len(invites) != 0 {
+		t.Errorf("expected joining to use the invite up, got %+v", invites)
+	}
+	if w := channelRequest(t, h.InviteToChannel, channel.ID, owner.ID, `{"username": "guest"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected inviting a member to conflict, got %d", w.Code)
+	}
+
+	// Members can invite others too.
+	createTestUserWithRole(t, queries, "third", auth.RoleUser)
+	if w := channelRequest(t, h.InviteToChannel, channel.ID, guest.ID, `{"username": "third"}`); w.Code != http.StatusCreated {
+		t.Errorf(expectedStatusErrMsg, http.StatusCreated, w.Code)
+	}
+}
+
+func TestJoinAndLeavePublicChannel(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	user := createTestUserWithRole(t, queries, "member", auth.RoleUser)
+	channel := createTestMemberChannel(t, h, owner.ID, "general", false)
+
+	if w := channelRequest(t, h.LeaveChannel, channel.ID, user.ID, ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected leaving a channel that was never joined to fail, got %d", w.Code)
+	}
+	if w := channelRequest(t, h.InviteToChannel, channel.ID, owner.ID, `{"username": "member"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected invites to public channels to be refused, got %d", w.Code)
+	}
+
+	for range 2 {
+		w := channelRequest(t, h.JoinChannel, channel.ID, user.ID, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+		}
+		var resp dto.ChannelResponseDTO
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf(failedToDecodeResponseBody, err)
+		}
+		if !resp.IsMember {
+			t.Errorf("expected the response to report membership, got %+v", resp)
+		}
+	}
+
+	if w := channelRequest(t, h.LeaveChannel, channel.ID, user.ID, ""); w.Code != http.StatusNoContent {
+		t.Fatalf(expectedStatusErrMsg, http.StatusNoContent, w.Code)
+	}
+	if got := hub.leftChannels[user.ID]; len(got) != 1 || got[0] != channel.ID {
+		t.Errorf("expected the connections to the channel to be closed, got %v", got)
+	}
+	if w := channelRequest(t, h.LeaveChannel, channel.ID, owner.ID, ""); w.Code != http.StatusConflict {
+		t.Errorf("expected the owner not to leave, got %d", w.Code)
+	}
+	if w := channelRequest(t, h.JoinChannel, 999, user.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected joining a missing channel to fail, got %d", w.Code)
+	}
+}
+
+func TestDeclineInvite(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	h := handlers.NewHandler(getMockLogger(), queries, db)
+
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	guest := createTestUserWithRole(t, queries, "guest", auth.RoleUser)
+	channel := createTestMemberChannel(t, h, owner.ID, "secret", true)
+	if w := channelRequest(t, h.InviteToChannel, channel.ID, owner.ID, `{"username": "guest"}`); w.Code != http.StatusCreated {
+		t.Fatalf(expectedStatusErrMsg, http.StatusCreated, w.Code)
+	}
+	inviteID := listTestInvites(t, h, guest.ID)[0].ID
+
+	if w := declineTestInvite(t, h, inviteID, owner.ID); w.Code != http.StatusNotFound {
+		t.Errorf("expected other users not to decline the invite, got %d", w.Code)
+	}
+	if w := declineTestInvite(t, h, inviteID, guest.ID); w.Code != http.StatusNoContent {
+		t.Fatalf(expectedStatusErrMsg, http.StatusNoContent, w.Code)
+	}
+	if w := channelRequest(t, h.JoinChannel, channel.ID, guest.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected a declined invite not to let the user join, got %d", w.Code)
+	}
+}
+
+func createTestMemberChannel(t *testing.T, h *handlers.Handler, userID int64, name string, private bool) dto.ChannelResponseDTO {
+	t.Helper()
+	body, _ := json.Marshal(dto.CreateChannelRequestDTO{Name: name, IsPrivate: private})
+	req := withIdentity(httptest.NewRequest(http.MethodPost, pathChannels, bytes.NewReader(body)), userID)
+	w := httptest.NewRecorder()
+
+	h.CreateChannel(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf(expectedStatusErrMsg, http.StatusCreated, w.Code)
+	}
+	var channel dto.ChannelResponseDTO
+	if err := json.NewDecoder(w.Body).Decode(&channel); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if !channel.IsMember || channel.IsPrivate != private {
+		t.Fatalf("expected the creator to be a member of the channel, got %+v", channel)
+	}
+	return channel
+}
+
+func channelRequest(t *testing.T, handler http.HandlerFunc, channelID, userID int64, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	id := strconv.FormatInt(channelID, 10)
+	req := httptest.NewRequest(http.MethodPost, pathChannels+"/"+id, strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("channelId", id)
+	req = withIdentity(req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)), userID)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+	return w
+}
+
+func listTestInvites(t *testing.T, h *handlers.Handler, userID int64) []dto.ChannelInviteDTO {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ListMyInvites(w, withIdentity(httptest.NewRequest(http.MethodGet, "/me/invites", nil), userID))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	var invites []dto.ChannelInviteDTO
+	if err := json.NewDecoder(w.Body).Decode(&invites); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	return invites
+}
+
+func declineTestInvite(t *testing.T, h *handlers.Handler, inviteID, userID int64) *httptest.ResponseRecorder {
+	t.Helper()
+	id := strconv.FormatInt(inviteID, 10)
+	req := httptest.NewRequest(http.MethodDelete, "/me/invites/"+id, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("inviteId", id)
+	req = withIdentity(req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)), userID)
+	w := httptest.NewRecorder()
+
+	h.DeclineInvite(w, req)
+	return w
+}
+
+func TestChannelRoles(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	h := handlers.NewHandler(getMockLogger(), queries, db)
+
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	moderator := createTestUserWithRole(t, queries, "moderator", auth.RoleUser)
+	member := createTestUserWithRole(t, queries, "member", auth.RoleUser)
+	outsider := createTestUserWithRole(t, queries, "outsider", auth.RoleUser)
+	channel := createTestMemberChannel(t, h, owner.ID, "general", false)
+	for _, user := range []repository.User{moderator, member} {
+		if w := channelRequest(t, h.JoinChannel, channel.ID, user.ID, ""); w.Code != http.StatusOK {
+			t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		actorID        int64
+		userID         int64
+		body           string
+		expectedStatus int
+	}{
+		{"Owner Promotes Member", owner.ID, moderator.ID, `{"role": "moderator"}`, http.StatusOK},
+		{"Moderator Promotes Member", moderator.ID, member.ID, `{"role": "moderator"}`, http.StatusForbidden},
+		{"Member Demotes Moderator", member.ID, moderator.ID, `{"role": "member"}`, http.StatusForbidden},
+		{"Owner Gives Away Ownership", owner.ID, member.ID, `{"role": "owner"}`, http.StatusBadRequest},
+		{"Unknown Role", owner.ID, member.ID, `{"role": "admin"}`, http.StatusBadRequest},
+		{"Owner Demotes Self", owner.ID, owner.ID, `{"role": "member"}`, http.StatusForbidden},
+		{"Non-Member Target", owner.ID, outsider.ID, `{"role": "moderator"}`, http.StatusNotFound},
+		{"Non-Member Actor", outsider.ID, member.ID, `{"role": "moderator"}`, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := memberRoleRequest(t, h, channel.ID, tc.actorID, tc.userID, tc.body)
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	w := channelRequest(t, h.ListChannelMembers, channel.ID, member.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	var members []dto.ChannelMemberDTO
+	if err := json.NewDecoder(w.Body).Decode(&members); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	roles := make(map[string]string)
+	for _, m := range members {
+		roles[m.Username] = m.Role
+	}
+	if len(roles) != 3 || roles["owner"] != "owner" || roles["moderator"] != "moderator" || roles["member"] != "member" {
+		t.Errorf("unexpected members %+v", members)
+	}
+
+	// Joining again keeps the role.
+	w = channelRequest(t, h.JoinChannel, channel.ID, moderator.ID, "")
+	var joined dto.ChannelResponseDTO
+	if err := json.NewDecoder(w.Body).Decode(&joined); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if joined.Role != "moderator" {
+		t.Errorf("expected the moderator to keep their role, got %q", joined.Role)
+	}
+
+	if w := channelRequest(t, h.ArchiveChannel, channel.ID, moderator.ID, ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected moderators not to archive the channel, got %d", w.Code)
+	}
+	if w := channelRequest(t, h.ArchiveChannel, channel.ID, owner.ID, ""); w.Code != http.StatusOK {
+		t.Errorf("expected the owner to archive the channel, got %d", w.Code)
+	}
+}
+
+func memberRoleRequest(t *testing.T, h *handlers.Handler, channelID, actorID, userID int64, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	id := strconv.FormatInt(channelID, 10)
+	target := strconv.FormatInt(userID, 10)
+	req := httptest.NewRequest(http.MethodPut, pathChannels+"/"+id+"/members/"+target+"/role", strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("channelId", id)
+	rctx.URLParams.Add("userId", target)
+	req = withIdentity(req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)), actorID)
+	w := httptest.NewRecorder()
+
+	h.UpdateChannelMemberRole(w, req)
+	return w
+}
+
+func TestTransferChannelOwnership(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	member := createTestUserWithRole(t, queries, "member", auth.RoleUser)
+	outsider := createTestUserWithRole(t, queries, "outsider", auth.RoleUser)
+	channel := createTestMemberChannel(t, h, owner.ID, "general", false)
+	if w := channelRequest(t, h.JoinChannel, channel.ID, member.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+
+	testCases := []struct {
+		name           string
+		actorID        int64
+		body           string
+		expectedStatus int
+	}{
+		{"Member Takes Over", member.ID, `{"userId": ` + strconv.FormatInt(member.ID, 10) + `, "confirmName": "general"}`, http.StatusForbidden},
+		{"Wrong Confirmation", owner.ID, `{"userId": ` + strconv.FormatInt(member.ID, 10) + `, "confirmName": "genera"}`, http.StatusBadRequest},
+		{"Missing Confirmation", owner.ID, `{"userId": ` + strconv.FormatInt(member.ID, 10) + `}`, http.StatusBadRequest},
+		{"To Self", owner.ID, `{"userId": ` + strconv.FormatInt(owner.ID, 10) + `, "confirmName": "general"}`, http.StatusBadRequest},
+		{"To Non-Member", owner.ID, `{"userId": ` + strconv.FormatInt(outsider.ID, 10) + `, "confirmName": "general"}`, http.StatusNotFound},
+		{"To Member", owner.ID, `{"userId": ` + strconv.FormatInt(member.ID, 10) + `, "confirmName": "general"}`, http.StatusOK},
+		{"Previous Owner Again", owner.ID, `{"userId": ` + strconv.FormatInt(member.ID, 10) + `, "confirmName": "general"}`, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := channelRequest(t, h.TransferChannelOwnership, channel.ID, tc.actorID, tc.body)
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	roles := testChannelRoles(t, h, channel.ID, member.ID)
+	if roles["member"] != "owner" || roles["owner"] != "moderator" {
+		t.Errorf("expected the roles to be swapped, got %+v", roles)
+	}
+	if hub.channelOwners[channel.ID] != member.ID {
+		t.Errorf("expected the hub to announce the new owner, got %+v", hub.channelOwners)
+	}
+	if got, err := queries.GetChannelByID(context.Background(), channel.ID); err != nil || got.CreatedByUsername != "member" {
+		t.Errorf("expected the channel to name the new owner, got %q (%v)", got.CreatedByUsername, err)
+	}
+
+	// The previous owner is a plain moderator now and can leave.
+	if w := channelRequest(t, h.LeaveChannel, channel.ID, owner.ID, ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected the previous owner to be able to leave, got %d", w.Code)
+	}
+}
+
+func testChannelRoles(t *testing.T, h *handlers.Handler, channelID, userID int64) map[string]string {
+	t.Helper()
+	w := channelRequest(t, h.ListChannelMembers, channelID, userID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	var members []dto.ChannelMemberDTO
+	if err := json.NewDecoder(w.Body).Decode(&members); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	roles := make(map[string]string)
+	for _, m := range members {
+		roles[m.Username] = m.Role
+	}
+	return roles
+}
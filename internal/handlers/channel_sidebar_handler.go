@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/permission"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+// errChannelNotInOrder is returned for a channel in a personal order that the
+// user is not a member of.
+type errChannelNotInOrder struct {
+	channelID int64
+}
+
+func (e errChannelNotInOrder) Error() string {
+	return fmt.Sprintf("not a member of channel %d", e.channelID)
+}
+
+// GetChannelSidebar returns the channels the current user is a member of,
+// grouped and ordered the way every client shows them. Archived channels and
+// direct conversations are left out.
+func (h *Handler) GetChannelSidebar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	categories, err := h.queries.ListChannelCategories(ctx)
+	if err != nil {
+		h.logger.Error("Failed to list categories", "error", err)
+		http.Error(w, "Failed to load channel sidebar", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := h.queries.ListSidebarChannelsForUser(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to list sidebar channels", "error", err, "userID", userID)
+		http.Error(w, "Failed to load channel sidebar", http.StatusInternalServerError)
+		return
+	}
+	channels := make([]repository.ListChannelsForUserRow, len(rows))
+	for i, row := range rows {
+		channels[i] = repository.ListChannelsForUserRow(row)
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.NewChannelSidebarDTO(categories, channels), failedEncodeChannelDataErrMsg)
+}
+
+// FavoriteChannel pins a channel to the favorites of the current user.
+func (h *Handler) FavoriteChannel(w http.ResponseWriter, r *http.Request) {
+	h.setChannelFavorite(w, r, true)
+}
+
+// UnfavoriteChannel puts a channel back in its category for the current user.
+func (h *Handler) UnfavoriteChannel(w http.ResponseWriter, r *http.Request) {
+	h.setChannelFavorite(w, r, false)
+}
+
+func (h *Handler) setChannelFavorite(w http.ResponseWriter, r *http.Request, favorite bool) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Favorites only concern the user, so they can be changed in archived
+	// channels too.
+	channel, _, ok := h.authorizeChannel(w, r, userID, permission.Read)
+	if !ok {
+		return
+	}
+
+	if _, err := h.queries.SetChannelFavorite(ctx, repository.SetChannelFavoriteParams{
+		Favorite:  favorite,
+		ChannelID: channel.ID,
+		UserID:    userID,
+	}); err != nil {
+		h.logger.Error("Failed to change favorite", "error", err, "channelID", channel.ID, "userID", userID)
+		http.Error(w, "Failed to change favorite", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetChannelOrder replaces the personal channel order of the current user.
+// Channels left out of the list fall back to the order of their category.
+func (h *Handler) SetChannelOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.ChannelOrderRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	seen := make(map[int64]bool, len(req.ChannelIDs))
+	for _, id := range req.ChannelIDs {
+		if seen[id] {
+			http.Error(w, fmt.Sprintf("Channel %d is listed twice", id), http.StatusBadRequest)
+			return
+		}
+		seen[id] = true
+	}
+
+	err := h.withTx(ctx, func(q *repository.Queries) error {
+		if err := q.ClearPersonalPositions(ctx, userID); err != nil {
+			return err
+		}
+		for i, id := range req.ChannelIDs {
+			rows, err := q.SetPersonalPosition(ctx, repository.SetPersonalPositionParams{
+				PersonalPosition: sql.NullInt64{Int64: int64(i), Valid: true},
+				ChannelID:        id,
+				UserID:           userID,
+			})
+			if err != nil {
+				return err
+			}
+			if rows == 0 {
+				return errChannelNotInOrder{channelID: id}
+			}
+		}
+		return nil
+	})
+	var notMember errChannelNotInOrder
+	if errors.As(err, &notMember) {
+		http.Error(w, fmt.Sprintf("You are not a member of channel %d", notMember.channelID), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to change channel order", "error", err, "userID", userID)
+		http.Error(w, "Failed to change channel order", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fortega2/real-time-chat/internal/channelsettings"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/permission"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+const (
+	// maxDirectParticipants includes the user who opens the conversation.
+	maxDirectParticipants = 8
+
+	failedCreateDirectErrMsg = "Failed to open direct conversation"
+	failedEncodeDirectErrMsg = "Failed to encode conversation data"
+)
+
+// ListDirectConversations returns the direct conversations of the current
+// user, newest first.
+func (h *Handler) ListDirectConversations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := h.queries.ListDirectChannelMembersForUser(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to list direct conversations", "error", err, "userID", userID)
+		http.Error(w, "Failed to list direct conversations", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.NewDirectConversationDTOs(rows), failedEncodeDirectErrMsg)
+}
+
+// CreateDirectConversation opens a conversation between the current user and
+// the named users. Each set of participants has one conversation, so asking
+// again returns the existing one with 200 instead of 201.
+func (h *Handler) CreateDirectConversation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateDirectConversationRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	self, err := h.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", userID)
+		http.Error(w, failedCreateDirectErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	participants := []repository.User{self}
+	for _, username := range req.Usernames {
+		user, err := h.queries.GetUserByUsername(ctx, strings.TrimSpace(username))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, fmt.Sprintf("User %q not found", username), http.StatusNotFound)
+				return
+			}
+			h.logger.Error("Failed to retrieve user", "error", err, "username", username)
+			http.Error(w, failedCreateDirectErrMsg, http.StatusInternalServerError)
+			return
+		}
+		if user.DisabledAt.Valid {
+			http.Error(w, fmt.Sprintf("User %q is disabled", username), http.StatusConflict)
+			return
+		}
+		if !slices.ContainsFunc(participants, func(p repository.User) bool { return p.ID == user.ID }) {
+			participants = append(participants, user)
+		}
+	}
+
+	switch {
+	case len(participants) < 2:
+		http.Error(w, "Name at least one other user", http.StatusBadRequest)
+		return
+	case len(participants) > maxDirectParticipants:
+		http.Error(w, fmt.Sprintf("A direct conversation has at most %d participants", maxDirectParticipants), http.StatusBadRequest)
+		return
+	}
+
+	key := directKey(participants)
+	channelID, err := h.queries.GetDirectChannelIDByKey(ctx, sql.NullString{String: key, Valid: true})
+	status := http.StatusOK
+	if errors.Is(err, sql.ErrNoRows) {
+		status = http.StatusCreated
+		channelID, err = h.createDirectChannel(r, userID, key, participants)
+		if err != nil {
+			// Someone else may have opened the same conversation meanwhile.
+			if existing, lookupErr := h.queries.GetDirectChannelIDByKey(ctx, sql.NullString{String: key, Valid: true}); lookupErr == nil {
+				channelID, err, status = existing, nil, http.StatusOK
+			}
+		}
+	}
+	if err != nil {
+		h.logger.Error("Failed to open direct conversation", "error", err, "key", key)
+		http.Error(w, failedCreateDirectErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	channel, err := h.queries.GetChannelByID(ctx, channelID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve direct conversation", "error", err, "channelID", channelID)
+		http.Error(w, failedCreateDirectErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	rows := make([]repository.ListDirectChannelMembersForUserRow, len(participants))
+	for i, p := range participants {
+		rows[i] = repository.ListDirectChannelMembersForUserRow{
+			ChannelID:   channel.ID,
+			CreatedAt:   channel.CreatedAt,
+			UserID:      p.ID,
+			Username:    p.Username,
+			DisplayName: p.DisplayName,
+		}
+	}
+	respondWithJSON(w, status, dto.NewDirectConversationDTOs(rows)[0], failedEncodeDirectErrMsg)
+
+	h.logger.Info("Direct conversation opened", "channelID", channel.ID, "participants", len(participants), "created", status == http.StatusCreated)
+}
+
+func (h *Handler) createDirectChannel(r *http.Request, userID int64, key string, participants []repository.User) (int64, error) {
+	ctx := r.Context()
+
+	var channelID int64
+	err := h.withTx(ctx, func(q *repository.Queries) error {
+		var err error
+		channelID, err = q.CreateDirectChannel(ctx, repository.CreateDirectChannelParams{
+			Name:      channelsettings.DirectNamePrefix + key,
+			CreatedBy: userID,
+			DirectKey: sql.NullString{String: key, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		for _, p := range participants {
+			if err := q.AddChannelMember(ctx, repository.AddChannelMemberParams{
+				ChannelID: channelID,
+				UserID:    p.ID,
+				Role:      string(permission.RoleMember),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return channelID, err
+}
+
+// directKey identifies a set of participants whatever order they were named
+// in.
+func directKey(participants []repository.User) string {
+	ids := make([]int64, len(participants))
+	for i, p := range participants {
+		ids[i] = p.ID
+	}
+	slices.Sort(ids)
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, "-")
+}
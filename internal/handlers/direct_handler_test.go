@@ -0,0 +1,159 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/handlers"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+const pathDirect = "/dms"
+
+func TestCreateDirectConversation(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	h := handlers.NewHandler(getMockLogger(), queries, db)
+
+	alice := createTestUserWithRole(t, queries, "alice", auth.RoleUser)
+	createTestUserWithRole(t, queries, "bob", auth.RoleUser)
+	createTestUserWithRole(t, queries, "carol", auth.RoleUser)
+	disabled := createTestUserWithRole(t, queries, "dave", auth.RoleUser)
+	if _, err := queries.DisableUser(context.Background(), disabled.ID); err != nil {
+		t.Fatalf("Failed to disable user: %v", err)
+	}
+	for _, name := range []string{"u1", "u2", "u3", "u4", "u5", "u6"} {
+		createTestUserWithRole(t, queries, name, auth.RoleUser)
+	}
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Invalid JSON", `{"usernames": `, http.StatusBadRequest},
+		{"Nobody Else", `{"usernames": ["alice"]}`, http.StatusBadRequest},
+		{"Unknown User", `{"usernames": ["nobody"]}`, http.StatusNotFound},
+		{"Disabled User", `{"usernames": ["dave"]}`, http.StatusConflict},
+		{"Too Many", `{"usernames": ["bob", "carol", "u1", "u2", "u3", "u4", "u5", "u6"]}`, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := directRequest(t, h, alice.ID, tc.body)
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	w := directRequest(t, h, alice.ID, `{"usernames": ["bob", " carol "]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf(expectedStatusErrMsg, http.StatusCreated, w.Code)
+	}
+	var created dto.DirectConversationDTO
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if len(created.Participants) != 3 {
+		t.Errorf("expected 3 participants, got %+v", created.Participants)
+	}
+
+	// The same people in another order, with a duplicate, share the conversation.
+	w = directRequest(t, h, alice.ID, `{"usernames": ["carol", "bob", "carol", "alice"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	var again dto.DirectConversationDTO
+	if err := json.NewDecoder(w.Body).Decode(&again); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if again.ID != created.ID {
+		t.Errorf("expected conversation %d to be reused, got %d", created.ID, again.ID)
+	}
+
+	if w := directRequest(t, h, alice.ID, `{"usernames": ["bob"]}`); w.Code != http.StatusCreated {
+		t.Errorf("expected a separate one-to-one conversation, got %d", w.Code)
+	}
+}
+
+func TestDirectConversationIsRestricted(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	h := handlers.NewHandler(getMockLogger(), queries, db)
+
+	alice := createTestUserWithRole(t, queries, "alice", auth.RoleUser)
+	bob := createTestUserWithRole(t, queries, "bob", auth.RoleUser)
+	outsider := createTestUserWithRole(t, queries, "outsider", auth.RoleUser)
+	admin := createTestUserWithRole(t, queries, "admin", auth.RoleAdmin)
+
+	w := directRequest(t, h, alice.ID, `{"usernames": ["bob"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf(expectedStatusErrMsg, http.StatusCreated, w.Code)
+	}
+	var dm dto.DirectConversationDTO
+	if err := json.NewDecoder(w.Body).Decode(&dm); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+
+	if channels := listTestChannels(t, h, alice.ID, "", http.StatusOK); len(channels) != 0 {
+		t.Errorf("expected direct conversations to stay out of the channel list, got %+v", channels)
+	}
+
+	req := withIdentity(httptest.NewRequest(http.MethodGet, pathDirect, nil), bob.ID)
+	lw := httptest.NewRecorder()
+	h.ListDirectConversations(lw, req)
+	if lw.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, lw.Code)
+	}
+	var conversations []dto.DirectConversationDTO
+	if err := json.NewDecoder(lw.Body).Decode(&conversations); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if len(conversations) != 1 || conversations[0].ID != dm.ID || len(conversations[0].Participants) != 2 {
+		t.Errorf("expected bob to see the conversation, got %+v", conversations)
+	}
+
+	testCases := []struct {
+		name           string
+		handler        http.HandlerFunc
+		userID         int64
+		body           string
+		expectedStatus int
+	}{
+		{"Participant Reads History", h.GetHistoryMessagesByChannel, bob.ID, "", http.StatusOK},
+		{"Outsider Reads History", h.GetHistoryMessagesByChannel, outsider.ID, "", http.StatusNotFound},
+		{"Outsider Joins", h.JoinChannel, outsider.ID, "", http.StatusNotFound},
+		{"Invite", h.InviteToChannel, alice.ID, `{"username": "outsider"}`, http.StatusForbidden},
+		{"Rename", h.UpdateChannel, alice.ID, `{"name": "secret"}`, http.StatusForbidden},
+		{"Archive", h.ArchiveChannel, alice.ID, "", http.StatusForbidden},
+		{"Leave", h.LeaveChannel, bob.ID, "", http.StatusConflict},
+		{"Admin Sets Owner", h.SetChannelOwner, admin.ID, `{"userId": 1}`, http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := channelRequest(t, tc.handler, dm.ID, tc.userID, tc.body)
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func directRequest(t *testing.T, h *handlers.Handler, userID int64, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := withIdentity(httptest.NewRequest(http.MethodPost, pathDirect, strings.NewReader(body)), userID)
+	w := httptest.NewRecorder()
+
+	h.CreateDirectConversation(w, req)
+	return w
+}
@@ -2,8 +2,14 @@ package handlers
 
 import (
 	"database/sql"
+	"time"
 
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/channelsettings"
 	"github.com/fortega2/real-time-chat/internal/logger"
+	"github.com/fortega2/real-time-chat/internal/mailer"
+	"github.com/fortega2/real-time-chat/internal/oidc"
+	"github.com/fortega2/real-time-chat/internal/profile"
 	"github.com/fortega2/real-time-chat/internal/repository"
 )
 
@@ -14,6 +20,9 @@ const (
 	failedEncodeuserDataErrMsg     = "Failed to encode user data"
 	usernameAndPasswordEmptyErrMsg = "Username and password cannot be empty"
 	invalidRequestBodyErrMsg       = "Invalid request body"
+	unauthorizedErrMsg             = "Authentication required"
+	forbiddenErrMsg                = "You do not have permission to do this"
+	accountDisabledErrMsg          = "This account has been disabled"
 
 	failedEncodeChannelDataErrMsg      = "Failed to encode channel data"
 	failedEncodeDeleteChannelRspErrMsg = "Failed to encode delete channel response"
@@ -24,15 +33,151 @@ const (
 )
 
 type Handler struct {
-	logger  logger.Logger
-	queries *repository.Queries
-	db      *sql.DB
+	logger    logger.Logger
+	queries   *repository.Queries
+	db        *sql.DB
+	tokens    *auth.TokenManager
+	hub       ConnectionHub
+	mailer    mailer.Mailer
+	secrets   *auth.SecretBox
+	passwords *auth.PasswordHasher
+	policy    *auth.Policy
+	// authenticator checks passwords at login. It defaults to the users table.
+	authenticator auth.Authenticator
+	// oidcProvider is nil when single sign-on is not configured.
+	oidcProvider *oidc.Provider
 }
 
-func NewHandler(l logger.Logger, q *repository.Queries, db *sql.DB) *Handler {
-	return &Handler{
+// ConnectionHub is the part of the WebSocket hub that REST handlers need to
+// act on live connections.
+type ConnectionHub interface {
+	DisconnectSession(sessionID int64)
+	DisconnectUser(userID int64, reason string)
+	DisconnectFromChannel(userID, channelID int64, reason string)
+	UpdateProfile(userID int64, p profile.Profile)
+	UpdateChannel(channelID int64, s channelsettings.Settings)
+	DeleteChannel(channelID int64)
+	UpdateChannelOwner(channelID, ownerID int64, username string)
+	NotifyChannel(channelID int64, message string)
+	EditMessage(channelID, messageID int64, content string, createdAt, editedAt time.Time)
+}
+
+type noopHub struct{}
+
+func (noopHub) DisconnectSession(int64) {}
+
+func (noopHub) DisconnectUser(int64, string) {}
+
+func (noopHub) DisconnectFromChannel(int64, int64, string) {}
+
+func (noopHub) UpdateProfile(int64, profile.Profile) {}
+
+func (noopHub) UpdateChannel(int64, channelsettings.Settings) {}
+
+func (noopHub) DeleteChannel(int64) {}
+
+func (noopHub) UpdateChannelOwner(int64, int64, string) {}
+
+func (noopHub) NotifyChannel(int64, string) {}
+
+func (noopHub) EditMessage(int64, int64, string, time.Time, time.Time) {}
+
+type Option func(*Handler)
+
+func WithTokenManager(tm *auth.TokenManager) Option {
+	return func(h *Handler) {
+		h.tokens = tm
+	}
+}
+
+func WithHub(hub ConnectionHub) Option {
+	return func(h *Handler) {
+		h.hub = hub
+	}
+}
+
+func WithMailer(m mailer.Mailer) Option {
+	return func(h *Handler) {
+		h.mailer = m
+	}
+}
+
+// WithSecretBox sets the box used to encrypt secrets at rest, such as TOTP
+// secrets.
+func WithSecretBox(box *auth.SecretBox) Option {
+	return func(h *Handler) {
+		h.secrets = box
+	}
+}
+
+// WithPasswordHasher sets the hasher for new passwords. Stored hashes with
+// weaker parameters are upgraded when their owner logs in.
+func WithPasswordHasher(hasher *auth.PasswordHasher) Option {
+	return func(h *Handler) {
+		h.passwords = hasher
+	}
+}
+
+// WithPolicy sets the rules for new usernames and passwords.
+func WithPolicy(p *auth.Policy) Option {
+	return func(h *Handler) {
+		h.policy = p
+	}
+}
+
+// WithAuthenticator replaces the local password check, for example with a
+// chain that tries LDAP first.
+func WithAuthenticator(a auth.Authenticator) Option {
+	return func(h *Handler) {
+		h.authenticator = a
+	}
+}
+
+// WithOIDCProvider enables login through an OpenID Connect provider.
+func WithOIDCProvider(p *oidc.Provider) Option {
+	return func(h *Handler) {
+		h.oidcProvider = p
+	}
+}
+
+func NewHandler(l logger.Logger, q *repository.Queries, db *sql.DB, opts ...Option) *Handler {
+	h := &Handler{
 		logger:  l,
 		queries: q,
 		db:      db,
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if h.tokens == nil {
+		h.tokens = auth.NewTokenManager(auth.RandomSecret(), auth.AccessTokenTTLFromEnv())
+	}
+
+	if h.hub == nil {
+		h.hub = noopHub{}
+	}
+
+	if h.mailer == nil {
+		h.mailer = mailer.NewOutbox("")
+	}
+
+	if h.passwords == nil {
+		h.passwords = auth.NewPasswordHasher(auth.DefaultPasswordParams)
+	}
+
+	if h.policy == nil {
+		h.policy = auth.NewPolicy(auth.DefaultPolicyConfig())
+	}
+
+	if h.authenticator == nil {
+		h.authenticator = auth.NewLocalAuthenticator(q, h.passwords)
+	}
+
+	if h.secrets == nil {
+		h.secrets = auth.NewSecretBox(auth.RandomSecret())
+	}
+
+	return h
 }
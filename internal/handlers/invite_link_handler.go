@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/permission"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	inviteLinkNotFoundErrMsg     = "Invite link not found"
+	inviteLinkUnusableErrMsg     = "This invite link is no longer valid"
+	failedRedeemInviteLinkErrMsg = "Failed to redeem invite link"
+	failedEncodeInviteLinkErrMsg = "Failed to encode invite link data"
+)
+
+var errInviteLinkUsedUp = errors.New("invite link used up or revoked")
+
+// CreateChannelInviteLink creates a link that lets anyone holding its token
+// join the channel, optionally until a deadline or for a number of uses.
+func (h *Handler) CreateChannelInviteLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Both limits are optional, so an empty body is a link without any.
+	var req dto.CreateChannelInviteLinkRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	var violations []auth.Violation
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		violations = append(violations, auth.Violation{Field: "expiresAt", Code: auth.ViolationInvalid, Message: "Expiry must be in the future"})
+	}
+	if req.MaxUses != nil && *req.MaxUses < 1 {
+		violations = append(violations, auth.Violation{Field: "maxUses", Code: auth.ViolationInvalid, Message: "Max uses must be at least 1"})
+	}
+	if len(violations) > 0 {
+		respondWithViolations(w, http.StatusBadRequest, violations)
+		return
+	}
+
+	channel, _, ok := h.authorizeChannel(w, r, userID, permission.ManageInviteLinks)
+	if !ok {
+		return
+	}
+
+	user, err := h.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", userID)
+		http.Error(w, "Failed to create invite link", http.StatusInternalServerError)
+		return
+	}
+
+	// Link tokens are as random as refresh tokens and, like them, only their
+	// hash is stored.
+	token, tokenHash, err := auth.NewRefreshToken()
+	if err != nil {
+		h.logger.Error("Failed to generate invite link token", "error", err)
+		http.Error(w, "Failed to create invite link", http.StatusInternalServerError)
+		return
+	}
+
+	params := repository.CreateChannelInviteLinkParams{
+		ChannelID: channel.ID,
+		TokenHash: tokenHash,
+		CreatedBy: userID,
+	}
+	if req.ExpiresAt != nil {
+		params.ExpiresAt = sql.NullTime{Time: req.ExpiresAt.UTC(), Valid: true}
+	}
+	if req.MaxUses != nil {
+		params.MaxUses = sql.NullInt64{Int64: *req.MaxUses, Valid: true}
+	}
+
+	link, err := h.queries.CreateChannelInviteLink(ctx, params)
+	if err != nil {
+		h.logger.Error("Failed to create invite link", "error", err, "channelID", channel.ID)
+		http.Error(w, "Failed to create invite link", http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.NewChannelInviteLinkDTO(repository.ListChannelInviteLinksRow{
+		ID:                link.ID,
+		ChannelID:         link.ChannelID,
+		CreatedBy:         link.CreatedBy,
+		CreatedByUsername: user.Username,
+		ExpiresAt:         link.ExpiresAt,
+		MaxUses:           link.MaxUses,
+		Uses:              link.Uses,
+		RevokedAt:         link.RevokedAt,
+		CreatedAt:         link.CreatedAt,
+	})
+	response.Token = token
+	respondWithJSON(w, http.StatusCreated, response, failedEncodeInviteLinkErrMsg)
+
+	h.recordAudit(ctx, auditActionInviteLinkCreated, userID, channelAuditSubject(channel.ID), clientIP(r),
+		fmt.Sprintf("link=%d", link.ID))
+	h.logger.Info("Invite link created", "channelID", channel.ID, "linkID", link.ID, "userID", userID)
+}
+
+// ListChannelInviteLinks returns the invite links of a channel, newest first,
+// including revoked and used up ones.
+func (h *Handler) ListChannelInviteLinks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	channel, _, ok := h.authorizeChannel(w, r, userID, permission.ManageInviteLinks)
+	if !ok {
+		return
+	}
+
+	links, err := h.queries.ListChannelInviteLinks(ctx, channel.ID)
+	if err != nil {
+		h.logger.Error("Failed to list invite links", "error", err, "channelID", channel.ID)
+		http.Error(w, "Failed to list invite links", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.ChannelInviteLinkDTO, len(links))
+	for i, link := range links {
+		response[i] = dto.NewChannelInviteLinkDTO(link)
+	}
+	respondWithJSON(w, http.StatusOK, response, failedEncodeInviteLinkErrMsg)
+}
+
+// RevokeChannelInviteLink stops a link from being redeemed. Members who
+// already joined through it stay.
+func (h *Handler) RevokeChannelInviteLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	channel, _, ok := h.authorizeChannel(w, r, userID, permission.ManageInviteLinks)
+	if !ok {
+		return
+	}
+
+	linkID, err := strconv.ParseInt(chi.URLParam(r, "linkId"), 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid invite link ID", "error", err)
+		http.Error(w, "Invalid invite link ID", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := h.queries.RevokeChannelInviteLink(ctx, repository.RevokeChannelInviteLinkParams{ID: linkID, ChannelID: channel.ID})
+	if err != nil {
+		h.logger.Error("Failed to revoke invite link", "error", err, "channelID", channel.ID, "linkID", linkID)
+		http.Error(w, "Failed to revoke invite link", http.StatusInternalServerError)
+		return
+	}
+	if rows == 0 {
+		http.Error(w, inviteLinkNotFoundErrMsg, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	h.recordAudit(ctx, auditActionInviteLinkRevoked, userID, channelAuditSubject(channel.ID), clientIP(r),
+		fmt.Sprintf("link=%d", linkID))
+	h.logger.Info("Invite link revoked", "channelID", channel.ID, "linkID", linkID, "userID", userID)
+}
+
+// RedeemChannelInviteLink makes the current user a member of the channel of
+// an invite link and tells the channel about it. Members redeeming a link
+// again do not use it up.
+func (h *Handler) RedeemChannelInviteLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	link, err := h.queries.GetChannelInviteLinkByTokenHash(ctx, auth.HashRefreshToken(chi.URLParam(r, "token")))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, inviteLinkNotFoundErrMsg, http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Failed to retrieve invite link", "error", err)
+		http.Error(w, failedRedeemInviteLinkErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	channel, err := h.queries.GetChannelByID(ctx, link.ChannelID)
+	if err != nil {
+		h.respondChannelLookupError(w, err, link.ChannelID)
+		return
+	}
+
+	member, err := h.queries.GetChannelMember(ctx, repository.GetChannelMemberParams{ChannelID: channel.ID, UserID: userID})
+	if err == nil {
+		response := dto.NewChannelResponse(channel)
+		response.IsMember = true
+		response.Role = member.Role
+		respondWithJSON(w, http.StatusOK, response, failedEncodeChannelDataErrMsg)
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		h.logger.Error("Failed to check channel membership", "error", err, "channelID", channel.ID, "userID", userID)
+		http.Error(w, failedRedeemInviteLinkErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	if link.RevokedAt.Valid || (link.ExpiresAt.Valid && !time.Now().Before(link.ExpiresAt.Time)) {
+		http.Error(w, inviteLinkUnusableErrMsg, http.StatusGone)
+		return
+	}
+	if channel.ArchivedAt.Valid {
+		http.Error(w, channelArchivedErrMsg, http.StatusConflict)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", userID)
+		http.Error(w, failedRedeemInviteLinkErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	err = h.withTx(ctx, func(q *repository.Queries) error {
+		// Counting the use in the same statement that checks the limit keeps
+		// concurrent redemptions from going over it.
+		used, err := q.UseChannelInviteLink(ctx, link.ID)
+		if err != nil {
+			return err
+		}
+		if used == 0 {
+			return errInviteLinkUsedUp
+		}
+		if err := q.AddChannelMember(ctx, repository.AddChannelMemberParams{
+			ChannelID: channel.ID,
+			UserID:    userID,
+			Role:      string(permission.RoleMember),
+		}); err != nil {
+			return err
+		}
+		if err := q.DeleteChannelInviteForUser(ctx, repository.DeleteChannelInviteForUserParams{ChannelID: channel.ID, UserID: userID}); err != nil {
+			return err
+		}
+		member, err = q.GetChannelMember(ctx, repository.GetChannelMemberParams{ChannelID: channel.ID, UserID: userID})
+		return err
+	})
+	if errors.Is(err, errInviteLinkUsedUp) {
+		http.Error(w, inviteLinkUnusableErrMsg, http.StatusGone)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to redeem invite link", "error", err, "linkID", link.ID, "userID", userID)
+		http.Error(w, failedRedeemInviteLinkErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.NewChannelResponse(channel)
+	response.IsMember = true
+	response.Role = member.Role
+	response.MemberCount++
+	respondWithJSON(w, http.StatusOK, response, failedEncodeChannelDataErrMsg)
+
+	name := user.Username
+	if user.DisplayName.Valid && user.DisplayName.String != "" {
+		name = user.DisplayName.String
+	}
+	h.hub.NotifyChannel(channel.ID, fmt.Sprintf("%s joined through an invite link", name))
+
+	h.recordAudit(ctx, auditActionInviteLinkRedeemed, userID, channelAuditSubject(channel.ID), clientIP(r),
+		fmt.Sprintf("link=%d", link.ID))
+	h.logger.Info("Invite link redeemed", "channelID", channel.ID, "linkID", link.ID, "userID", userID)
+}
@@ -0,0 +1,156 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/handlers"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestChannelInviteLinks(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	member := createTestUserWithRole(t, queries, "member", auth.RoleUser)
+	contractor := createTestUserWithRole(t, queries, "contractor", auth.RoleUser)
+	latecomer := createTestUserWithRole(t, queries, "latecomer", auth.RoleUser)
+	channel := createTestMemberChannel(t, h, owner.ID, "projects", true)
+	if w := channelRequest(t, h.InviteToChannel, channel.ID, owner.ID, `{"username": "member"}`); w.Code != http.StatusCreated {
+		t.Fatalf(expectedStatusErrMsg, http.StatusCreated, w.Code)
+	}
+	if w := channelRequest(t, h.JoinChannel, channel.ID, member.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	testCases := []struct {
+		name           string
+		userID         int64
+		body           string
+		expectedStatus int
+	}{
+		{"Member", member.ID, `{}`, http.StatusForbidden},
+		{"Outsider", contractor.ID, `{}`, http.StatusNotFound},
+		{"No Uses", owner.ID, `{"maxUses": 0}`, http.StatusBadRequest},
+		{"Expired", owner.ID, `{"expiresAt": "` + past + `"}`, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := channelRequest(t, h.CreateChannelInviteLink, channel.ID, tc.userID, tc.body)
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	single := createTestInviteLink(t, h, channel.ID, owner.ID, `{"maxUses": 1}`)
+	if single.Token == "" || single.MaxUses != 1 || single.CreatedByUsername != "owner" {
+		t.Fatalf("expected a single-use link with a token, got %+v", single)
+	}
+
+	if w := redeemTestInviteLink(t, h, single.Token, contractor.ID); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	if notes := hub.notifications[channel.ID]; len(notes) != 1 || notes[0] != "contractor joined through an invite link" {
+		t.Errorf("expected a join notification, got %+v", notes)
+	}
+	// Members redeeming again do not use the link up.
+	if w := redeemTestInviteLink(t, h, single.Token, contractor.ID); w.Code != http.StatusOK {
+		t.Errorf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	if w := redeemTestInviteLink(t, h, single.Token, latecomer.ID); w.Code != http.StatusGone {
+		t.Errorf("expected a used up link to be refused, got %d", w.Code)
+	}
+	if w := redeemTestInviteLink(t, h, "not-a-token", latecomer.ID); w.Code != http.StatusNotFound {
+		t.Errorf(expectedStatusErrMsg, http.StatusNotFound, w.Code)
+	}
+
+	// Moderators manage links too.
+	if w := memberRoleRequest(t, h, channel.ID, owner.ID, member.ID, `{"role": "moderator"}`); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	revoked := createTestInviteLink(t, h, channel.ID, member.ID, "")
+	if w := revokeTestInviteLink(t, h, channel.ID, revoked.ID, member.ID); w.Code != http.StatusNoContent {
+		t.Fatalf(expectedStatusErrMsg, http.StatusNoContent, w.Code)
+	}
+	if w := revokeTestInviteLink(t, h, channel.ID, revoked.ID, member.ID); w.Code != http.StatusNotFound {
+		t.Errorf("expected a revoked link to be revoked once, got %d", w.Code)
+	}
+	if w := redeemTestInviteLink(t, h, revoked.Token, latecomer.ID); w.Code != http.StatusGone {
+		t.Errorf("expected a revoked link to be refused, got %d", w.Code)
+	}
+
+	expiring := createTestInviteLink(t, h, channel.ID, owner.ID, `{"expiresAt": "`+time.Now().Add(time.Hour).Format(time.RFC3339)+`"}`)
+	mustExec(t, db, "UPDATE channel_invite_links SET expires_at = ? WHERE id = ?", time.Now().Add(-time.Minute), expiring.ID)
+	if w := redeemTestInviteLink(t, h, expiring.Token, latecomer.ID); w.Code != http.StatusGone {
+		t.Errorf("expected an expired link to be refused, got %d", w.Code)
+	}
+
+	if _, err := queries.GetChannelMember(context.Background(), repository.GetChannelMemberParams{ChannelID: channel.ID, UserID: latecomer.ID}); err == nil {
+		t.Error("expected the latecomer not to be a member")
+	}
+
+	w := channelRequest(t, h.ListChannelInviteLinks, channel.ID, member.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	var links []dto.ChannelInviteLinkDTO
+	if err := json.NewDecoder(w.Body).Decode(&links); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if len(links) != 3 || links[2].ID != single.ID || links[2].Uses != 1 || links[1].RevokedAt == "" || links[0].Token != "" {
+		t.Errorf("expected the three links without tokens, got %+v", links)
+	}
+}
+
+func createTestInviteLink(t *testing.T, h *handlers.Handler, channelID, userID int64, body string) dto.ChannelInviteLinkDTO {
+	t.Helper()
+	w := channelRequest(t, h.CreateChannelInviteLink, channelID, userID, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf(expectedStatusErrMsg, http.StatusCreated, w.Code)
+	}
+	var link dto.ChannelInviteLinkDTO
+	if err := json.NewDecoder(w.Body).Decode(&link); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	return link
+}
+
+func revokeTestInviteLink(t *testing.T, h *handlers.Handler, channelID, linkID, userID int64) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodDelete, pathChannels+"/invite-links", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("channelId", strconv.FormatInt(channelID, 10))
+	rctx.URLParams.Add("linkId", strconv.FormatInt(linkID, 10))
+	req = withIdentity(req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)), userID)
+	w := httptest.NewRecorder()
+
+	h.RevokeChannelInviteLink(w, req)
+	return w
+}
+
+func redeemTestInviteLink(t *testing.T, h *handlers.Handler, token string, userID int64) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/invite-links/redeem", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("token", token)
+	req = withIdentity(req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)), userID)
+	w := httptest.NewRecorder()
+
+	h.RedeemChannelInviteLink(w, req)
+	return w
+}
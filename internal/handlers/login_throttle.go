@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+const (
+	throttleScopeUsername     = "username"
+	throttleScopeIP           = "ip"
+	throttleScopeResetAccount = "reset_account"
+	throttleScopeResetIP      = "reset_ip"
+
+	loginBaseLockout   = 30 * time.Second
+	loginMaxLockout    = 15 * time.Minute
+	loginFailureWindow = 15 * time.Minute
+)
+
+// Failures allowed per scope before the key is locked. An IP is shared by
+// everyone behind the same NAT, so it gets more room than a single username.
+// Password reset requests are counted in scopes of their own, so mailing codes
+// does not lock anyone out of logging in.
+var loginMaxFailures = map[string]int64{
+	throttleScopeUsername:     5,
+	throttleScopeIP:           20,
+	throttleScopeResetAccount: 3,
+	throttleScopeResetIP:      10,
+}
+
+type throttleKey struct {
+	scope   string
+	subject string
+}
+
+func (k throttleKey) String() string {
+	return k.scope + ":" + k.subject
+}
+
+// loginThrottleKeys returns the keys a login attempt counts against. Usernames
+// are tracked whether or not the account exists, so a lockout does not reveal
+// which usernames are registered.
+func loginThrottleKeys(username, ipAddress string) []throttleKey {
+	keys := []throttleKey{{throttleScopeUsername, strings.ToLower(username)}}
+	if ipAddress != "" {
+		keys = append(keys, throttleKey{throttleScopeIP, ipAddress})
+	}
+	return keys
+}
+
+// passwordResetThrottleKeys returns the keys a password reset request counts
+// against. Like login usernames, the account is tracked as it was asked for,
+// whether or not it exists.
+func passwordResetThrottleKeys(req passwordResetRequest, ipAddress string) []throttleKey {
+	account := strings.ToLower(req.Username)
+	if account == "" {
+		account = strings.ToLower(req.Email)
+		if email, ok := normalizeEmail(req.Email); ok {
+			account = email
+		}
+	}
+
+	keys := []throttleKey{{throttleScopeResetAccount, account}}
+	if ipAddress != "" {
+		keys = append(keys, throttleKey{throttleScopeResetIP, ipAddress})
+	}
+	return keys
+}
+
+// checkLoginThrottle writes a 429 and returns false when any of keys is
+// locked out.
+func (h *Handler) checkLoginThrottle(ctx context.Context, w http.ResponseWriter, keys []throttleKey) bool {
+	return h.checkThrottle(ctx, w, keys, tooManyLoginAttemptsErrMsg)
+}
+
+// checkThrottle is checkLoginThrottle with the message of the 429.
+func (h *Handler) checkThrottle(ctx context.Context, w http.ResponseWriter, keys []throttleKey, errMsg string) bool {
+	lockedUntil, err := h.loginLockedUntil(ctx, keys)
+	if err != nil {
+		h.logger.Error("Failed to check login throttle", "error", err)
+		http.Error(w, "Failed to verify credentials", http.StatusInternalServerError)
+		return false
+	}
+
+	if !lockedUntil.IsZero() {
+		h.logger.Warn("Credential check while locked", "lockedUntil", lockedUntil)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(lockedUntil).Seconds()))))
+		http.Error(w, errMsg, http.StatusTooManyRequests)
+		return false
+	}
+
+	return true
+}
+
+// loginLockedUntil returns the latest lockout among keys, or the zero time when
+// none of them is locked.
+func (h *Handler) loginLockedUntil(ctx context.Context, keys []throttleKey) (time.Time, error) {
+	var lockedUntil time.Time
+	now := time.Now()
+
+	for _, key := range keys {
+		throttle, err := h.queries.GetLoginThrottle(ctx, repository.GetLoginThrottleParams{
+			Scope:   key.scope,
+			Subject: key.subject,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if throttle.LockedUntil.Valid && throttle.LockedUntil.Time.After(now) && throttle.LockedUntil.Time.After(lockedUntil) {
+			lockedUntil = throttle.LockedUntil.Time
+		}
+	}
+
+	return lockedUntil, nil
+}
+
+// recordLoginFailure counts a failed attempt against every key. Once a key
+// reaches its limit it is locked, and each further failure doubles the lockout
+// up to loginMaxLockout. The count is incremented in the database, so
+// concurrent failures are never lost, and the lockout is set in the same
+// transaction.
+func (h *Handler) recordLoginFailure(ctx context.Context, keys []throttleKey, ipAddress string) {
+	now := time.Now().UTC()
+
+	for _, key := range keys {
+		var failures int64
+		var lockout time.Duration
+		err := h.withTx(ctx, func(q *repository.Queries) error {
+			var err error
+			failures, err = q.RecordLoginFailure(ctx, repository.RecordLoginFailureParams{
+				Scope:       key.scope,
+				Subject:     key.subject,
+				FailedAt:    now,
+				WindowStart: now.Add(-loginFailureWindow),
+			})
+			if err != nil {
+				return err
+			}
+
+			lockout = loginLockoutDuration(failures, loginMaxFailures[key.scope])
+			if lockout == 0 {
+				return nil
+			}
+			return q.SetLoginLockout(ctx, repository.SetLoginLockoutParams{
+				LockedUntil: sql.NullTime{Time: now.Add(lockout), Valid: true},
+				Scope:       key.scope,
+				Subject:     key.subject,
+			})
+		})
+		if err != nil {
+			h.logger.Error("Failed to record login failure", "error", err, "key", key.String())
+			continue
+		}
+
+		if lockout > 0 {
+			h.logger.Warn("Login locked after repeated failures", "key", key.String(), "failures", failures, "lockout", lockout)
+			h.recordAudit(ctx, auditActionLoginLockout, 0, key.String(), ipAddress,
+				fmt.Sprintf("failures=%d lockout=%s", failures, lockout))
+		}
+	}
+}
+
+// clearLoginFailures forgets the failures of a username after a successful
+// login. IP counters are left to expire, otherwise an attacker could reset
+// them by logging into an account of their own.
+func (h *Handler) clearLoginFailures(ctx context.Context, username string) {
+	err := h.queries.DeleteLoginThrottle(ctx, repository.DeleteLoginThrottleParams{
+		Scope:   throttleScopeUsername,
+		Subject: strings.ToLower(username),
+	})
+	if err != nil {
+		h.logger.Error("Failed to clear login failures", "error", err, "username", username)
+	}
+}
+
+func loginLockoutDuration(failures, maxFailures int64) time.Duration {
+	if failures < maxFailures {
+		return 0
+	}
+
+	lockout := loginBaseLockout
+	for i := maxFailures; i < failures && lockout < loginMaxLockout; i++ {
+		lockout *= 2
+	}
+
+	return min(lockout, loginMaxLockout)
+}
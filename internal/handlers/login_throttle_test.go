@@ -0,0 +1,157 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/handlers"
+)
+
+func TestLoginUserLockout(t *testing.T) {
+	testCases := []struct {
+		name     string
+		username string
+	}{
+		{"Existing User", "testuser"},
+		{"Unknown User", "nobody"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, h := setupUserTest(t)
+			defer db.Close()
+
+			for range 5 {
+				attemptLogin(t, h, tc.username, "wrongpassword", "192.0.2.1", http.StatusUnauthorized)
+			}
+
+			w := attemptLogin(t, h, tc.username, "password123", "192.0.2.1", http.StatusTooManyRequests)
+			if w.Header().Get("Retry-After") == "" {
+				t.Error("expected Retry-After header on lockout")
+			}
+
+			// The lockout follows the username, not the address.
+			attemptLogin(t, h, tc.username, "password123", "198.51.100.7", http.StatusTooManyRequests)
+
+			var count int
+			if err := db.QueryRow("SELECT COUNT(*) FROM audit_events WHERE action = 'login.lockout' AND subject = ?", "username:"+tc.username).Scan(&count); err != nil {
+				t.Fatalf("failed to query audit log: %v", err)
+			}
+			if count != 1 {
+				t.Errorf("expected 1 lockout audit event, got %d", count)
+			}
+		})
+	}
+}
+
+func TestLoginUserUniformFailure(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	unknown := attemptLogin(t, h, "nobody", "password123", "192.0.2.1", http.StatusUnauthorized)
+	badPassword := attemptLogin(t, h, "testuser", "wrongpassword", "192.0.2.1", http.StatusUnauthorized)
+
+	if unknown.Body.String() != badPassword.Body.String() {
+		t.Errorf("expected identical failure bodies, got %q and %q", unknown.Body.String(), badPassword.Body.String())
+	}
+}
+
+func TestLoginUserSuccessResetsFailures(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	for range 4 {
+		attemptLogin(t, h, "testuser", "wrongpassword", "192.0.2.1", http.StatusUnauthorized)
+	}
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusOK)
+
+	for range 4 {
+		attemptLogin(t, h, "testuser", "wrongpassword", "192.0.2.1", http.StatusUnauthorized)
+	}
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusOK)
+}
+
+func TestLoginUserIPLockout(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	for i := range 20 {
+		attemptLogin(t, h, fmt.Sprintf("user%d", i), "wrongpassword", "203.0.113.9", http.StatusUnauthorized)
+	}
+
+	attemptLogin(t, h, "testuser", "password123", "203.0.113.9", http.StatusTooManyRequests)
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusOK)
+}
+
+func TestLoginUserConcurrentFailures(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	const attempts = 10
+	var wg sync.WaitGroup
+	codes := make([]int, attempts)
+	for i := range attempts {
+		wg.Go(func() {
+			body, _ := json.Marshal(map[string]string{"username": fmt.Sprintf("user%d", i), "password": "wrongpassword"})
+			req := httptest.NewRequest(http.MethodPost, pathLogin, bytes.NewBuffer(body))
+			req.RemoteAddr = "203.0.113.9:1234"
+			w := httptest.NewRecorder()
+
+			h.LoginUser(w, req)
+			codes[i] = w.Code
+		})
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusUnauthorized {
+			t.Errorf("attempt %d: "+expectedStatusErrMsg, i, http.StatusUnauthorized, code)
+		}
+	}
+
+	var failures int64
+	if err := db.QueryRow("SELECT failures FROM login_throttles WHERE scope = 'ip' AND subject = '203.0.113.9'").Scan(&failures); err != nil {
+		t.Fatalf("failed to query login throttle: %v", err)
+	}
+	if failures != attempts {
+		t.Errorf("expected every concurrent failure to be counted, got %d of %d", failures, attempts)
+	}
+}
+
+func TestLoginUserFailureWindow(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	for range 4 {
+		attemptLogin(t, h, "testuser", "wrongpassword", "192.0.2.1", http.StatusUnauthorized)
+	}
+	old := time.Now().Add(-time.Hour).UTC()
+	if _, err := db.Exec("UPDATE login_throttles SET last_failure_at = ?", old); err != nil {
+		t.Fatalf("failed to age login failures: %v", err)
+	}
+
+	// Failures outside the window are forgotten, so this one starts over.
+	attemptLogin(t, h, "testuser", "wrongpassword", "192.0.2.1", http.StatusUnauthorized)
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusOK)
+}
+
+func attemptLogin(t *testing.T, h *handlers.Handler, username, password, ipAddress string, expectedStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"username": username, "password": password})
+	req := httptest.NewRequest(http.MethodPost, pathLogin, bytes.NewBuffer(body))
+	req.RemoteAddr = ipAddress + ":1234"
+	w := httptest.NewRecorder()
+
+	h.LoginUser(w, req)
+
+	if w.Code != expectedStatus {
+		t.Fatalf(expectedStatusErrMsg, expectedStatus, w.Code)
+	}
+	return w
+}
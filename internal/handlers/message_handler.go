@@ -1,19 +1,34 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 
 	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/messageedit"
+	"github.com/fortega2/real-time-chat/internal/permission"
 	"github.com/fortega2/real-time-chat/internal/repository"
 	"github.com/go-chi/chi/v5"
 )
 
 const (
 	messageLimitDefault int64 = 50
+	maxMessagePageSize        = 200
+
+	messageNotFoundErrMsg = "Message not found"
 )
 
+// GetHistoryMessagesByChannel returns a page of the history of a channel,
+// the newest messages by default. The before and after message IDs page back
+// and forward from there; messages are ordered by ID either way.
 func (h *Handler) GetHistoryMessagesByChannel(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -23,26 +38,55 @@ func (h *Handler) GetHistoryMessagesByChannel(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	channelIdStr := chi.URLParam(r, "channelId")
-	if channelIdStr == "" {
-		h.logger.Error("Channel ID is required")
-		http.Error(w, "Channel ID is required", http.StatusBadRequest)
+	userId, ok := h.currentUserID(w, r)
+	if !ok {
 		return
 	}
 
-	h.logger.Debug("Fetching messages for channel ID: ", channelIdStr)
+	channel, _, ok := h.authorizeChannel(w, r, userId, permission.Read)
+	if !ok {
+		return
+	}
+	channelId := channel.ID
 
-	channelId, err := strconv.ParseInt(channelIdStr, 10, 64)
-	if err != nil {
-		h.logger.Error("Invalid channel ID", "error", err)
-		http.Error(w, "Invalid channel ID", http.StatusBadRequest)
+	query := r.URL.Query()
+	if query.Has("before") && query.Has("after") {
+		http.Error(w, "Use either before or after, not both", http.StatusBadRequest)
 		return
 	}
 
-	messages, err := h.queries.GetHistoryMessagesByChannel(ctx, repository.GetHistoryMessagesByChannelParams{
-		ChannelID: channelId,
-		Limit:     getMessageLimit(),
-	})
+	limit, ok := queryInt(r, "limit", int(getMessageLimit()))
+	if !ok || limit < 1 || limit > maxMessagePageSize {
+		http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxMessagePageSize), http.StatusBadRequest)
+		return
+	}
+
+	before, ok := queryInt(r, "before", math.MaxInt64)
+	if !ok || before < 1 {
+		http.Error(w, "before must be a message ID", http.StatusBadRequest)
+		return
+	}
+	after, ok := queryInt(r, "after", 0)
+	if !ok || after < 0 {
+		http.Error(w, "after must be a message ID", http.StatusBadRequest)
+		return
+	}
+
+	h.logger.Debug("Fetching messages for channel", "channelID", channelId, "userID", userId)
+
+	// Without after the page ends at before, or at the newest message. One
+	// extra row tells whether there is another page.
+	var messages []repository.GetHistoryMessagesByChannelRow
+	var err error
+	if query.Has("after") {
+		messages, err = h.messagesAfter(ctx, channelId, int64(after), int64(limit)+1)
+	} else {
+		messages, err = h.queries.GetHistoryMessagesByChannel(ctx, repository.GetHistoryMessagesByChannelParams{
+			ChannelID: channelId,
+			BeforeID:  int64(before),
+			Limit:     int64(limit) + 1,
+		})
+	}
 	if err != nil {
 		h.logger.Error("Failed to fetch messages", "error", err)
 		http.Error(w, "Failed to fetch messages", http.StatusInternalServerError)
@@ -51,14 +95,39 @@ func (h *Handler) GetHistoryMessagesByChannel(w http.ResponseWriter, r *http.Req
 
 	h.logger.Debug("Fetched messages", "chanedId", channelId, "count", len(messages))
 
-	messagesDTO := make([]dto.MessageDTO, len(messages))
-	for i, msg := range messages {
-		messagesDTO[i] = dto.NewMessageDTO(msg)
+	response := dto.MessagePageDTO{Messages: []dto.MessageDTO{}}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		response.HasMore = true
+		response.NextCursor = messages[limit-1].ID
 	}
+	if !query.Has("after") {
+		slices.Reverse(messages)
+	}
+	for _, msg := range messages {
+		response.Messages = append(response.Messages, dto.NewMessageDTO(msg))
+	}
+
+	respondWithJSON(w, http.StatusOK, response, failedEncodeMessageDataErrMsg)
 
-	respondWithJSON(w, http.StatusOK, messagesDTO, failedEncodeMessageDataErrMsg)
+	h.logger.Info("Successfully fetched messages", "channelId", channelId, "count", len(response.Messages))
+}
+
+func (h *Handler) messagesAfter(ctx context.Context, channelID, afterID, limit int64) ([]repository.GetHistoryMessagesByChannelRow, error) {
+	rows, err := h.queries.GetHistoryMessagesAfter(ctx, repository.GetHistoryMessagesAfterParams{
+		ChannelID: channelID,
+		AfterID:   afterID,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	h.logger.Info("Successfully fetched messages", "channelId", channelId, "count", len(messagesDTO))
+	messages := make([]repository.GetHistoryMessagesByChannelRow, len(rows))
+	for i, row := range rows {
+		messages[i] = repository.GetHistoryMessagesByChannelRow(row)
+	}
+	return messages, nil
 }
 
 func getMessageLimit() int64 {
@@ -72,5 +141,133 @@ func getMessageLimit() int64 {
 		return messageLimitDefault
 	}
 
-	return limit
+	return min(limit, maxMessagePageSize)
+}
+
+// EditMessage replaces the content of a message. Authors edit their own
+// messages; the owner and moderators edit everyone's. The previous content is
+// kept as a revision.
+func (h *Handler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	channel, _, ok := h.authorizeChannel(w, r, userID, permission.Read)
+	if !ok {
+		return
+	}
+
+	messageID, ok := h.messageIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req dto.EditMessageRequestDTO
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	content := messageedit.Normalize(req.Content)
+	if violations := messageedit.CheckContent(content); len(violations) > 0 {
+		respondWithViolations(w, http.StatusBadRequest, violations)
+		return
+	}
+
+	edited, changed, err := messageedit.Edit(ctx, h.db, h.queries, channel.ID, messageID, userID, content)
+	switch {
+	case errors.Is(err, messageedit.ErrNotFound):
+		http.Error(w, messageNotFoundErrMsg, http.StatusNotFound)
+		return
+	case errors.Is(err, permission.ErrNotMember), errors.Is(err, permission.ErrForbidden):
+		h.logger.Info("Message edit denied", "channelID", channel.ID, "messageID", messageID, "userID", userID)
+		http.Error(w, channelRoleForbiddenErrMsg, http.StatusForbidden)
+		return
+	case errors.Is(err, permission.ErrArchived):
+		http.Error(w, channelArchivedErrMsg, http.StatusConflict)
+		return
+	case err != nil:
+		h.logger.Error("Failed to edit message", "error", err, "channelID", channel.ID, "messageID", messageID)
+		http.Error(w, "Failed to edit message", http.StatusInternalServerError)
+		return
+	}
+
+	if changed {
+		h.hub.EditMessage(channel.ID, edited.ID, edited.Content, edited.CreatedAt, edited.EditedAt.Time)
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.NewMessageDTO(repository.GetHistoryMessagesByChannelRow(edited)), failedEncodeMessageDataErrMsg)
+
+	h.logger.Info("Message edited", "channelID", channel.ID, "messageID", messageID, "userID", userID, "changed", changed)
+}
+
+// ListMessageRevisions returns the earlier contents of a message, oldest
+// first. Every member who can read the channel can see them.
+func (h *Handler) ListMessageRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	channel, _, ok := h.authorizeChannel(w, r, userID, permission.Read)
+	if !ok {
+		return
+	}
+
+	messageID, ok := h.messageIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	message, err := h.queries.GetMessageByID(ctx, messageID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && message.ChannelID != channel.ID) {
+		http.Error(w, messageNotFoundErrMsg, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to retrieve message", "error", err, "messageID", messageID)
+		http.Error(w, "Failed to retrieve message", http.StatusInternalServerError)
+		return
+	}
+
+	revisions, err := h.queries.ListMessageRevisions(ctx, message.ID)
+	if err != nil {
+		h.logger.Error("Failed to list message revisions", "error", err, "messageID", message.ID)
+		http.Error(w, "Failed to list message revisions", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.MessageRevisionDTO, len(revisions))
+	for i, revision := range revisions {
+		response[i] = dto.NewMessageRevisionDTO(revision)
+	}
+	respondWithJSON(w, http.StatusOK, response, failedEncodeMessageDataErrMsg)
+}
+
+func (h *Handler) messageIDFromRequest(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	messageID, err := strconv.ParseInt(chi.URLParam(r, "messageId"), 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid message ID", "error", err)
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return messageID, true
 }
@@ -3,12 +3,17 @@ package handlers_test
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
 	"github.com/fortega2/real-time-chat/internal/handlers"
 	"github.com/fortega2/real-time-chat/internal/repository"
 	"github.com/go-chi/chi/v5"
@@ -63,7 +68,32 @@ func TestGetHistoryMessagesByChannel(t *testing.T) {
 				h := handlers.NewHandler(getMockLogger(), queries, db)
 				return h, func() { db.Close() }
 			},
-			expectedStatus: http.StatusOK,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:      "Not a Member of a Public Channel",
+			channelID: "1",
+			setup: func(t *testing.T) (*handlers.Handler, func()) {
+				db := initializeTestDBWithMessages(t)
+				mustExec(t, db, "DELETE FROM channel_members")
+				queries := repository.New(db)
+				h := handlers.NewHandler(getMockLogger(), queries, db)
+				return h, func() { db.Close() }
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:      "Not a Member of a Private Channel",
+			channelID: "1",
+			setup: func(t *testing.T) (*handlers.Handler, func()) {
+				db := initializeTestDBWithMessages(t)
+				mustExec(t, db, "DELETE FROM channel_members")
+				mustExec(t, db, "UPDATE channels SET is_private = TRUE")
+				queries := repository.New(db)
+				h := handlers.NewHandler(getMockLogger(), queries, db)
+				return h, func() { db.Close() }
+			},
+			expectedStatus: http.StatusNotFound,
 		},
 	}
 
@@ -78,6 +108,7 @@ func TestGetHistoryMessagesByChannel(t *testing.T) {
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("channelId", tc.channelID)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = withIdentity(req, 1)
 
 			h.GetHistoryMessagesByChannel(w, req)
 
@@ -114,6 +145,7 @@ func TestGetHistoryMessagesByChannelWithCustomLimit(t *testing.T) {
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("channelId", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req = withIdentity(req, 1)
 
 	h.GetHistoryMessagesByChannel(w, req)
 
@@ -142,6 +174,7 @@ func TestGetHistoryMessagesByChannelInvalidLimit(t *testing.T) {
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("channelId", "1")
 	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req = withIdentity(req, 1)
 
 	h.GetHistoryMessagesByChannel(w, req)
 
@@ -153,6 +186,171 @@ func TestGetHistoryMessagesByChannelInvalidLimit(t *testing.T) {
 	}
 }
 
+func TestGetHistoryMessagesByChannelPages(t *testing.T) {
+	db := initializeTestDBWithMessages(t)
+	defer db.Close()
+	queries := repository.New(db)
+	h := handlers.NewHandler(getMockLogger(), queries, db)
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    []int64
+		expectedCursor int64
+	}{
+		{"Newest Page", "", http.StatusOK, []int64{1, 2, 3, 4, 5}, 0},
+		{"Newest Two", "?limit=2", http.StatusOK, []int64{4, 5}, 4},
+		{"Before", "?limit=2&before=4", http.StatusOK, []int64{2, 3}, 2},
+		{"Oldest", "?limit=2&before=2", http.StatusOK, []int64{1}, 0},
+		{"After", "?limit=2&after=1", http.StatusOK, []int64{2, 3}, 3},
+		{"After Newest", "?after=5", http.StatusOK, []int64{}, 0},
+		{"Both Cursors", "?before=4&after=1", http.StatusBadRequest, nil, 0},
+		{"Invalid Cursor", "?before=abc", http.StatusBadRequest, nil, 0},
+		{"Limit Too Large", "?limit=1000", http.StatusBadRequest, nil, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/history/1"+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("channelId", "1")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = withIdentity(req, 1)
+
+			h.GetHistoryMessagesByChannel(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var page dto.MessagePageDTO
+			if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+				t.Fatalf(failedToDecodeResponseBody, err)
+			}
+			ids := make([]int64, len(page.Messages))
+			for i, msg := range page.Messages {
+				ids[i] = msg.ID
+			}
+			if !slices.Equal(ids, tc.expectedIDs) {
+				t.Errorf("expected messages %v, got %v", tc.expectedIDs, ids)
+			}
+			if page.NextCursor != tc.expectedCursor || page.HasMore != (tc.expectedCursor != 0) {
+				t.Errorf("expected cursor %d, got %d (hasMore %t)", tc.expectedCursor, page.NextCursor, page.HasMore)
+			}
+		})
+	}
+}
+
+func TestEditMessage(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	defer db.Close()
+	queries := repository.New(db)
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+
+	owner := createTestUserWithRole(t, queries, "owner", auth.RoleUser)
+	member := createTestUserWithRole(t, queries, "member", auth.RoleUser)
+	outsider := createTestUserWithRole(t, queries, "outsider", auth.RoleUser)
+	channel := createTestMemberChannel(t, h, owner.ID, "typos", false)
+	other := createTestMemberChannel(t, h, owner.ID, "elsewhere", false)
+	if w := channelRequest(t, h.JoinChannel, channel.ID, member.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	memberMessage := createTestMessage(t, db, channel.ID, member.ID, "helo")
+	ownerMessage := createTestMessage(t, db, channel.ID, owner.ID, "welcome")
+	otherMessage := createTestMessage(t, db, other.ID, owner.ID, "hi")
+
+	testCases := []struct {
+		name           string
+		messageID      int64
+		userID         int64
+		body           string
+		expectedStatus int
+	}{
+		{"Author", memberMessage, member.ID, `{"content": " hello "}`, http.StatusOK},
+		{"Unchanged", memberMessage, member.ID, `{"content": "hello"}`, http.StatusOK},
+		{"Moderator Rights", memberMessage, owner.ID, `{"content": "hello all"}`, http.StatusOK},
+		{"Someone Else's", ownerMessage, member.ID, `{"content": "goodbye"}`, http.StatusForbidden},
+		{"Outsider", ownerMessage, outsider.ID, `{"content": "goodbye"}`, http.StatusForbidden},
+		{"Empty", memberMessage, member.ID, `{"content": "  "}`, http.StatusBadRequest},
+		{"Other Channel", otherMessage, owner.ID, `{"content": "moved"}`, http.StatusNotFound},
+		{"Unknown", 999, owner.ID, `{"content": "ghost"}`, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := messageRequest(t, h.EditMessage, channel.ID, tc.messageID, tc.userID, tc.body)
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	// The unchanged content was not broadcast again.
+	if got := hub.editedMessages[memberMessage]; len(got) != 2 || got[0] != "hello" || got[1] != "hello all" {
+		t.Errorf("expected the two edits to be broadcast, got %q", got)
+	}
+
+	w := messageRequest(t, h.ListMessageRevisions, channel.ID, memberMessage, member.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	var revisions []dto.MessageRevisionDTO
+	if err := json.NewDecoder(w.Body).Decode(&revisions); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if len(revisions) != 2 || revisions[0].Content != "helo" || revisions[0].EditedByUsername != "member" ||
+		revisions[1].Content != "hello" || revisions[1].EditedByUsername != "owner" {
+		t.Errorf("expected the two earlier contents, got %+v", revisions)
+	}
+
+	page := channelRequest(t, h.GetHistoryMessagesByChannel, channel.ID, member.ID, "")
+	var history dto.MessagePageDTO
+	if err := json.NewDecoder(page.Body).Decode(&history); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if len(history.Messages) != 2 || !history.Messages[0].Edited || history.Messages[0].Content != "hello all" || history.Messages[1].Edited {
+		t.Errorf("expected only the first message to be edited, got %+v", history.Messages)
+	}
+
+	mustExec(t, db, "UPDATE channels SET archived_at = CURRENT_TIMESTAMP WHERE id = ?", channel.ID)
+	if w := messageRequest(t, h.EditMessage, channel.ID, ownerMessage, owner.ID, `{"content": "frozen"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected edits in an archived channel to be refused, got %d", w.Code)
+	}
+}
+
+func createTestMessage(t *testing.T, db *sql.DB, channelID, userID int64, content string) int64 {
+	t.Helper()
+	result, err := db.Exec("INSERT INTO messages (channel_id, user_id, user_color, content) VALUES (?, ?, '#3498db', ?)", channelID, userID, content)
+	if err != nil {
+		t.Fatalf("Failed to insert test message: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		t.Fatalf("Failed to read message ID: %v", err)
+	}
+	return id
+}
+
+func messageRequest(t *testing.T, handler http.HandlerFunc, channelID, messageID, userID int64, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPatch, pathChannels+"/messages", strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("channelId", strconv.FormatInt(channelID, 10))
+	rctx.URLParams.Add("messageId", strconv.FormatInt(messageID, 10))
+	req = withIdentity(req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx)), userID)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+	return w
+}
+
 func initializeTestDBWithMessages(t *testing.T) *sql.DB {
 	t.Helper()
 	db := initializeTestDB(t)
@@ -161,7 +359,25 @@ func initializeTestDBWithMessages(t *testing.T) *sql.DB {
 	CREATE TABLE IF NOT EXISTS channels (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		name TEXT NOT NULL UNIQUE,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		description TEXT,
+		created_by INTEGER NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		is_private BOOLEAN NOT NULL DEFAULT FALSE,
+		topic TEXT,
+		archived_at TIMESTAMP,
+		kind TEXT NOT NULL DEFAULT 'channel' CHECK (kind IN ('channel', 'direct')),
+		direct_key TEXT UNIQUE,
+		category_id INTEGER,
+		position INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE IF NOT EXISTS channel_members (
+		channel_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member')),
+		favorite BOOLEAN NOT NULL DEFAULT FALSE,
+		personal_position INTEGER,
+		PRIMARY KEY (channel_id, user_id)
 	);`
 	if _, err := db.Exec(createChannelsTableSQL); err != nil {
 		t.Fatalf("Failed to create channels table: %v", err)
@@ -174,7 +390,9 @@ func initializeTestDBWithMessages(t *testing.T) *sql.DB {
 		user_id INTEGER NOT NULL,
 		user_color VARCHAR(7) NOT NULL,
 		content TEXT NOT NULL,
+		sequence INTEGER NOT NULL DEFAULT 0,
 		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		edited_at TIMESTAMP,
 		FOREIGN KEY (channel_id) REFERENCES channels(id) ON DELETE CASCADE,
 		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 	);`
@@ -182,7 +400,7 @@ func initializeTestDBWithMessages(t *testing.T) *sql.DB {
 		t.Fatalf("Failed to create messages table: %v", err)
 	}
 
-	_, err := db.Exec("INSERT INTO channels (id, name) VALUES (1, 'test-channel')")
+	_, err := db.Exec("INSERT INTO channels (id, name, created_by) VALUES (1, 'test-channel', 1)")
 	if err != nil {
 		t.Fatalf("Failed to insert test channel: %v", err)
 	}
@@ -192,8 +410,13 @@ func initializeTestDBWithMessages(t *testing.T) *sql.DB {
 		t.Fatalf("Failed to insert test user: %v", err)
 	}
 
+	_, err = db.Exec("INSERT INTO channel_members (channel_id, user_id) VALUES (1, 1)")
+	if err != nil {
+		t.Fatalf("Failed to insert test channel member: %v", err)
+	}
+
 	for i := 1; i <= 5; i++ {
-		_, err = db.Exec("INSERT INTO messages (channel_id, user_id, user_color, content) VALUES (1, 1, ?, ?)", "#3498db", "Test message "+strconv.Itoa(i))
+		_, err = db.Exec("INSERT INTO messages (channel_id, user_id, user_color, content, sequence) VALUES (1, 1, ?, ?, ?)", "#3498db", "Test message "+strconv.Itoa(i), i)
 		if err != nil {
 			t.Fatalf("Failed to insert test message: %v", err)
 		}
@@ -201,3 +424,10 @@ func initializeTestDBWithMessages(t *testing.T) *sql.DB {
 
 	return db
 }
+
+func mustExec(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("Failed to run %q: %v", query, err)
+	}
+}
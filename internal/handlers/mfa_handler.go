@@ -0,0 +1,520 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+const (
+	mfaChallengeTTL       = 5 * time.Minute
+	recoveryCodeCount     = 10
+	defaultTOTPIssuer     = "Olha Mensagem"
+	invalidMFACodeErrMsg  = "Invalid authentication code"
+	failedEncodeMFAErrMsg = "Failed to encode two-factor data"
+)
+
+type mfaLoginRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code"`
+	DeviceName     string `json:"deviceName"`
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// VerifyLoginMFA completes a login that LoginUser answered with a challenge.
+// The code is either a current TOTP code or an unused recovery code.
+func (h *Handler) VerifyLoginMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	var req mfaLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	if req.ChallengeToken == "" || req.Code == "" {
+		h.logger.Error("Challenge token and code are required")
+		http.Error(w, "Challenge token and code are required", http.StatusBadRequest)
+		return
+	}
+
+	claims, err := h.tokens.ParseChallenge(req.ChallengeToken)
+	if err != nil {
+		h.logger.Debug("Invalid login challenge", "error", err)
+		http.Error(w, "Invalid or expired login challenge", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve challenged user", "error", err, "userID", claims.UserID)
+		http.Error(w, "Invalid or expired login challenge", http.StatusUnauthorized)
+		return
+	}
+
+	// The account may have been disabled after the challenge was issued.
+	if user.DisabledAt.Valid {
+		h.logger.Info("Two-factor login to a disabled account", "userID", user.ID)
+		http.Error(w, accountDisabledErrMsg, http.StatusForbidden)
+		return
+	}
+
+	if !h.checkSecondFactor(ctx, w, r, user, req.Code) {
+		return
+	}
+
+	response, err := h.startSession(r, user, req.DeviceName)
+	if err != nil {
+		h.logger.Error("Failed to start session", "error", err, "userID", user.ID)
+		http.Error(w, "Failed to start session", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response, failedEncodeuserDataErrMsg)
+
+	h.logger.Info("User logged in with two-factor authentication", "username", user.Username, "userID", user.ID)
+}
+
+func (h *Handler) GetMFAStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	enabled, err := h.isMFAEnabled(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to load two-factor status", "error", err, "userID", userID)
+		http.Error(w, "Failed to load two-factor status", http.StatusInternalServerError)
+		return
+	}
+
+	status := dto.MFAStatusDTO{Enabled: enabled}
+	if enabled {
+		if status.RecoveryCodesRemaining, err = h.queries.CountUnusedRecoveryCodes(ctx, userID); err != nil {
+			h.logger.Error("Failed to count recovery codes", "error", err, "userID", userID)
+			http.Error(w, "Failed to load two-factor status", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, status, failedEncodeMFAErrMsg)
+}
+
+// EnrollTOTP starts enrolment with a fresh secret. The secret only protects
+// logins after VerifyTOTPEnrollment proves the device can produce codes.
+func (h *Handler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", userID)
+		http.Error(w, "Failed to start two-factor enrolment", http.StatusInternalServerError)
+		return
+	}
+
+	enabled, err := h.isMFAEnabled(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to load two-factor status", "error", err, "userID", userID)
+		http.Error(w, "Failed to start two-factor enrolment", http.StatusInternalServerError)
+		return
+	}
+	if enabled {
+		h.logger.Debug("Two-factor enrolment while already enabled", "userID", userID)
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		h.logger.Error("Failed to generate TOTP secret", "error", err)
+		http.Error(w, "Failed to start two-factor enrolment", http.StatusInternalServerError)
+		return
+	}
+
+	sealed, err := h.secrets.Seal(secret)
+	if err != nil {
+		h.logger.Error("Failed to encrypt TOTP secret", "error", err)
+		http.Error(w, "Failed to start two-factor enrolment", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.queries.UpsertPendingUserTOTP(ctx, repository.UpsertPendingUserTOTPParams{
+		UserID:           userID,
+		SecretCiphertext: sealed,
+	}); err != nil {
+		h.logger.Error("Failed to store TOTP secret", "error", err, "userID", userID)
+		http.Error(w, "Failed to start two-factor enrolment", http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.TOTPEnrollmentDTO{
+		Secret:     auth.EncodeTOTPSecret(secret),
+		OtpauthURI: auth.TOTPURI(totpIssuer(), user.Username, secret),
+	}
+	respondWithJSON(w, http.StatusCreated, response, failedEncodeMFAErrMsg)
+
+	h.logger.Info("Two-factor enrolment started", "userID", userID)
+}
+
+func (h *Handler) VerifyTOTPEnrollment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	totp, err := h.queries.GetUserTOTP(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && totp.EnabledAt.Valid) {
+		h.logger.Debug("No pending two-factor enrolment", "userID", userID)
+		http.Error(w, "No pending two-factor enrolment", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to load TOTP secret", "error", err, "userID", userID)
+		http.Error(w, "Failed to verify two-factor enrolment", http.StatusInternalServerError)
+		return
+	}
+
+	secret, err := h.secrets.Open(totp.SecretCiphertext)
+	if err != nil {
+		h.logger.Error("Failed to decrypt TOTP secret", "error", err, "userID", userID)
+		http.Error(w, "Failed to verify two-factor enrolment", http.StatusInternalServerError)
+		return
+	}
+
+	step, valid := auth.ValidateTOTP(secret, req.Code, time.Now())
+	if !valid {
+		h.logger.Info("Invalid code during two-factor enrolment", "userID", userID)
+		http.Error(w, invalidMFACodeErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	var codes []string
+	err = h.withTx(ctx, func(q *repository.Queries) error {
+		enabled, err := q.EnableUserTOTP(ctx, repository.EnableUserTOTPParams{
+			LastUsedStep: step,
+			UserID:       userID,
+		})
+		if err != nil {
+			return err
+		}
+		if enabled == 0 {
+			return errMFAAlreadyEnabled
+		}
+
+		codes, err = replaceRecoveryCodes(ctx, q, userID)
+		return err
+	})
+	if errors.Is(err, errMFAAlreadyEnabled) {
+		h.logger.Debug("Two-factor enrolment verified twice", "userID", userID)
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to enable two-factor authentication", "error", err, "userID", userID)
+		http.Error(w, "Failed to verify two-factor enrolment", http.StatusInternalServerError)
+		return
+	}
+
+	h.recordAudit(ctx, auditActionMFAEnabled, userID, userAuditSubject(userID), clientIP(r), "")
+
+	respondWithJSON(w, http.StatusOK, dto.RecoveryCodesDTO{RecoveryCodes: codes}, failedEncodeMFAErrMsg)
+
+	h.logger.Info("Two-factor authentication enabled", "userID", userID)
+}
+
+// DisableTOTP turns two-factor authentication off. It asks for a current code
+// rather than trusting the access token alone.
+func (h *Handler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	user, req, ok := h.mfaCodeRequestUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.checkSecondFactor(ctx, w, r, user, req.Code) {
+		return
+	}
+
+	err := h.withTx(ctx, func(q *repository.Queries) error {
+		if err := q.DeleteRecoveryCodes(ctx, user.ID); err != nil {
+			return err
+		}
+		return q.DeleteUserTOTP(ctx, user.ID)
+	})
+	if err != nil {
+		h.logger.Error("Failed to disable two-factor authentication", "error", err, "userID", user.ID)
+		http.Error(w, "Failed to disable two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	h.recordAudit(ctx, auditActionMFADisabled, user.ID, userAuditSubject(user.ID), clientIP(r), "")
+
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Two-factor authentication disabled", "userID", user.ID)
+}
+
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	user, req, ok := h.mfaCodeRequestUser(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.checkSecondFactor(ctx, w, r, user, req.Code) {
+		return
+	}
+
+	var codes []string
+	err := h.withTx(ctx, func(q *repository.Queries) error {
+		var err error
+		codes, err = replaceRecoveryCodes(ctx, q, user.ID)
+		return err
+	})
+	if err != nil {
+		h.logger.Error("Failed to regenerate recovery codes", "error", err, "userID", user.ID)
+		http.Error(w, "Failed to regenerate recovery codes", http.StatusInternalServerError)
+		return
+	}
+
+	h.recordAudit(ctx, auditActionRecoveryCodesRenewed, user.ID, userAuditSubject(user.ID), clientIP(r), "")
+
+	respondWithJSON(w, http.StatusOK, dto.RecoveryCodesDTO{RecoveryCodes: codes}, failedEncodeMFAErrMsg)
+
+	h.logger.Info("Recovery codes regenerated", "userID", user.ID)
+}
+
+var (
+	errMFAAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	errMFANotEnabled     = errors.New("two-factor authentication not enabled")
+)
+
+// respondWithMFAChallenge answers a login whose password was correct but that
+// still needs a second factor.
+func (h *Handler) respondWithMFAChallenge(w http.ResponseWriter, user repository.User) {
+	challenge, expiresAt, err := h.tokens.IssueChallenge(user.ID, mfaChallengeTTL)
+	if err != nil {
+		h.logger.Error("Failed to issue login challenge", "error", err, "userID", user.ID)
+		http.Error(w, "Failed to start session", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.NewLoginChallenge(challenge, expiresAt), failedEncodeuserDataErrMsg)
+
+	h.logger.Info("Login waiting for second factor", "username", user.Username, "userID", user.ID)
+}
+
+func (h *Handler) isMFAEnabled(ctx context.Context, userID int64) (bool, error) {
+	totp, err := h.queries.GetUserTOTP(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return totp.EnabledAt.Valid, nil
+}
+
+func (h *Handler) mfaCodeRequestUser(w http.ResponseWriter, r *http.Request) (repository.User, mfaCodeRequest, bool) {
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return repository.User{}, mfaCodeRequest{}, false
+	}
+
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		h.logger.Error("Invalid two-factor code request", "error", err, "userID", userID)
+		http.Error(w, "Code is required", http.StatusBadRequest)
+		return repository.User{}, mfaCodeRequest{}, false
+	}
+
+	user, err := h.queries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", userID)
+		http.Error(w, "Failed to load user", http.StatusInternalServerError)
+		return repository.User{}, mfaCodeRequest{}, false
+	}
+
+	return user, req, true
+}
+
+// checkSecondFactor verifies code for user and writes the error response when
+// it fails. Wrong codes count as failed logins, so the throttle also limits
+// guessing of TOTP and recovery codes.
+func (h *Handler) checkSecondFactor(ctx context.Context, w http.ResponseWriter, r *http.Request, user repository.User, code string) bool {
+	ipAddress := clientIP(r)
+	throttleKeys := loginThrottleKeys(user.Username, ipAddress)
+	if !h.checkLoginThrottle(ctx, w, throttleKeys) {
+		return false
+	}
+
+	usedRecoveryCode, err := h.verifySecondFactor(ctx, user.ID, code)
+	if errors.Is(err, errMFANotEnabled) {
+		h.logger.Debug("Second factor checked while two-factor is disabled", "userID", user.ID)
+		http.Error(w, "Two-factor authentication is not enabled", http.StatusConflict)
+		return false
+	}
+	if err != nil {
+		h.logger.Info("Invalid second factor", "error", err, "userID", user.ID, "ip", ipAddress)
+		h.recordLoginFailure(ctx, throttleKeys, ipAddress)
+		http.Error(w, invalidMFACodeErrMsg, http.StatusUnauthorized)
+		return false
+	}
+
+	h.clearLoginFailures(ctx, user.Username)
+
+	if usedRecoveryCode {
+		h.recordAudit(ctx, auditActionRecoveryCodeUsed, user.ID, userAuditSubject(user.ID), ipAddress, "")
+	}
+
+	return true
+}
+
+// verifySecondFactor accepts a TOTP code newer than the last accepted one, or
+// an unused recovery code. It reports whether a recovery code was spent.
+func (h *Handler) verifySecondFactor(ctx context.Context, userID int64, code string) (bool, error) {
+	totp, err := h.queries.GetUserTOTP(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !totp.EnabledAt.Valid) {
+		return false, errMFANotEnabled
+	}
+	if err != nil {
+		return false, err
+	}
+
+	secret, err := h.secrets.Open(totp.SecretCiphertext)
+	if err != nil {
+		return false, err
+	}
+
+	if step, ok := auth.ValidateTOTP(secret, code, time.Now()); ok {
+		if step <= totp.LastUsedStep {
+			return false, errors.New("TOTP code already used")
+		}
+
+		// Compare-and-swap on the last step, so two concurrent logins cannot
+		// both spend the same code.
+		advanced, err := h.queries.AdvanceUserTOTPStep(ctx, repository.AdvanceUserTOTPStepParams{
+			NewStep: step,
+			UserID:  userID,
+			OldStep: totp.LastUsedStep,
+		})
+		if err != nil {
+			return false, err
+		}
+		if advanced == 0 {
+			return false, errors.New("TOTP code already used")
+		}
+		return false, nil
+	}
+
+	used, err := h.queries.UseRecoveryCode(ctx, repository.UseRecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: auth.HashHumanCode(code),
+	})
+	if err != nil {
+		return false, err
+	}
+	if used == 0 {
+		return false, errors.New("code does not match")
+	}
+
+	return true, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, q *repository.Queries, userID int64) ([]string, error) {
+	if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, hash, err := auth.NewRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := q.CreateRecoveryCode(ctx, repository.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: hash,
+		}); err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return defaultTOTPIssuer
+}
@@ -0,0 +1,304 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/handlers"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+func TestTOTPEnrollmentAndLogin(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	session := sessionFromLogin(t, queries, loginTestUser(t, h))
+	secret, recoveryCodes := enableTestTOTP(t, h, session)
+
+	if len(recoveryCodes) != 10 {
+		t.Fatalf("expected 10 recovery codes, got %d", len(recoveryCodes))
+	}
+
+	status := getTestMFAStatus(t, h, session)
+	if !status.Enabled || status.RecoveryCodesRemaining != 10 {
+		t.Errorf("unexpected two-factor status: %+v", status)
+	}
+
+	challenge := loginTestChallenge(t, h)
+
+	// The enrolment already spent the current step, so use the next one.
+	code := auth.TOTPCode(secret, auth.TOTPStep(time.Now())+1)
+	if w := verifyTestLoginMFA(t, h, challenge.ChallengeToken, code); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	} else {
+		checkSuccessfulLoginResponse(t, w.Body, "testuser")
+	}
+
+	// A code cannot be replayed.
+	if w := verifyTestLoginMFA(t, h, challenge.ChallengeToken, code); w.Code != http.StatusUnauthorized {
+		t.Errorf(expectedStatusErrMsg, http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestLoginMFAWithRecoveryCode(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	session := sessionFromLogin(t, queries, loginTestUser(t, h))
+	_, recoveryCodes := enableTestTOTP(t, h, session)
+
+	challenge := loginTestChallenge(t, h)
+	code := strings.ToLower(recoveryCodes[0])
+
+	if w := verifyTestLoginMFA(t, h, challenge.ChallengeToken, code); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	if w := verifyTestLoginMFA(t, h, challenge.ChallengeToken, code); w.Code != http.StatusUnauthorized {
+		t.Errorf(expectedStatusErrMsg, http.StatusUnauthorized, w.Code)
+	}
+
+	if status := getTestMFAStatus(t, h, session); status.RecoveryCodesRemaining != 9 {
+		t.Errorf("expected 9 recovery codes left, got %d", status.RecoveryCodesRemaining)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM audit_events WHERE action = 'mfa.recovery_code_used'").Scan(&count); err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 recovery code audit event, got %d", count)
+	}
+}
+
+func TestVerifyLoginMFAInvalidRequests(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	login := loginTestUser(t, h)
+	session := sessionFromLogin(t, queries, login)
+	enableTestTOTP(t, h, session)
+	challenge := loginTestChallenge(t, h)
+
+	testCases := []struct {
+		name           string
+		challengeToken string
+		code           string
+		expectedStatus int
+	}{
+		{"Wrong Code", challenge.ChallengeToken, "000000", http.StatusUnauthorized},
+		{"Access Token As Challenge", login.AccessToken, "000000", http.StatusUnauthorized},
+		{"Garbage Challenge", "not-a-token", "000000", http.StatusUnauthorized},
+		{"Missing Code", challenge.ChallengeToken, "", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := verifyTestLoginMFA(t, h, tc.challengeToken, tc.code); w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestLoginMFAPasswordDoesNotResetFailures(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	session := sessionFromLogin(t, queries, loginTestUser(t, h))
+	enableTestTOTP(t, h, session)
+
+	// Two wrong codes per challenge stay under the limit of five, so the
+	// count only reaches it if the password logins in between keep it.
+	for range 2 {
+		challenge := loginTestChallenge(t, h)
+		for range 2 {
+			if w := verifyTestLoginMFA(t, h, challenge.ChallengeToken, "000000"); w.Code != http.StatusUnauthorized {
+				t.Fatalf(expectedStatusErrMsg, http.StatusUnauthorized, w.Code)
+			}
+		}
+	}
+
+	challenge := loginTestChallenge(t, h)
+	if w := verifyTestLoginMFA(t, h, challenge.ChallengeToken, "000000"); w.Code != http.StatusUnauthorized {
+		t.Fatalf(expectedStatusErrMsg, http.StatusUnauthorized, w.Code)
+	}
+
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusTooManyRequests)
+	if w := verifyTestLoginMFA(t, h, challenge.ChallengeToken, "000000"); w.Code != http.StatusTooManyRequests {
+		t.Errorf(expectedStatusErrMsg, http.StatusTooManyRequests, w.Code)
+	}
+}
+
+func TestEnrollTOTPTwice(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	session := sessionFromLogin(t, queries, loginTestUser(t, h))
+	enableTestTOTP(t, h, session)
+
+	req := withSession(httptest.NewRequest(http.MethodPost, "/me/mfa/totp", nil), session)
+	w := httptest.NewRecorder()
+
+	h.EnrollTOTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf(expectedStatusErrMsg, http.StatusConflict, w.Code)
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	session := sessionFromLogin(t, queries, loginTestUser(t, h))
+	secret, _ := enableTestTOTP(t, h, session)
+
+	wrong := postTestMFACode(t, h.DisableTOTP, session, "000000")
+	if wrong.Code != http.StatusUnauthorized {
+		t.Fatalf(expectedStatusErrMsg, http.StatusUnauthorized, wrong.Code)
+	}
+
+	code := auth.TOTPCode(secret, auth.TOTPStep(time.Now())+1)
+	if w := postTestMFACode(t, h.DisableTOTP, session, code); w.Code != http.StatusNoContent {
+		t.Fatalf(expectedStatusErrMsg, http.StatusNoContent, w.Code)
+	}
+
+	if status := getTestMFAStatus(t, h, session); status.Enabled {
+		t.Error("expected two-factor authentication to be disabled")
+	}
+
+	// Login goes straight to tokens again.
+	loginTestUser(t, h)
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	session := sessionFromLogin(t, queries, loginTestUser(t, h))
+	_, oldCodes := enableTestTOTP(t, h, session)
+
+	w := postTestMFACode(t, h.RegenerateRecoveryCodes, session, oldCodes[0])
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+
+	var response dto.RecoveryCodesDTO
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if len(response.RecoveryCodes) != 10 || response.RecoveryCodes[0] == oldCodes[0] {
+		t.Fatalf("expected 10 new recovery codes, got %v", response.RecoveryCodes)
+	}
+
+	challenge := loginTestChallenge(t, h)
+	if w := verifyTestLoginMFA(t, h, challenge.ChallengeToken, oldCodes[1]); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected old recovery code to be rejected, got %v", w.Code)
+	}
+	if w := verifyTestLoginMFA(t, h, challenge.ChallengeToken, response.RecoveryCodes[1]); w.Code != http.StatusOK {
+		t.Errorf("expected new recovery code to be accepted, got %v", w.Code)
+	}
+}
+
+func enableTestTOTP(t *testing.T, h *handlers.Handler, session repository.Session) ([]byte, []string) {
+	t.Helper()
+	req := withSession(httptest.NewRequest(http.MethodPost, "/me/mfa/totp", nil), session)
+	w := httptest.NewRecorder()
+
+	h.EnrollTOTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf(expectedStatusErrMsg, http.StatusCreated, w.Code)
+	}
+
+	var enrollment dto.TOTPEnrollmentDTO
+	if err := json.NewDecoder(w.Body).Decode(&enrollment); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if !strings.HasPrefix(enrollment.OtpauthURI, "otpauth://totp/") || !strings.Contains(enrollment.OtpauthURI, "testuser") {
+		t.Errorf("unexpected otpauth URI: %s", enrollment.OtpauthURI)
+	}
+
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(enrollment.Secret)
+	if err != nil {
+		t.Fatalf("failed to decode secret: %v", err)
+	}
+
+	w = postTestMFACode(t, h.VerifyTOTPEnrollment, session, auth.TOTPCode(secret, auth.TOTPStep(time.Now())))
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+
+	var response dto.RecoveryCodesDTO
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	return secret, response.RecoveryCodes
+}
+
+func loginTestChallenge(t *testing.T, h *handlers.Handler) dto.LoginChallengeDTO {
+	t.Helper()
+	w := attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusOK)
+
+	var challenge dto.LoginChallengeDTO
+	if err := json.NewDecoder(w.Body).Decode(&challenge); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if !challenge.MFARequired || challenge.ChallengeToken == "" {
+		t.Fatalf("expected a two-factor challenge, got %+v", challenge)
+	}
+	return challenge
+}
+
+func verifyTestLoginMFA(t *testing.T, h *handlers.Handler, challengeToken, code string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"challengeToken": challengeToken, "code": code})
+	req := httptest.NewRequest(http.MethodPost, "/login/mfa", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	h.VerifyLoginMFA(w, req)
+	return w
+}
+
+func getTestMFAStatus(t *testing.T, h *handlers.Handler, session repository.Session) dto.MFAStatusDTO {
+	t.Helper()
+	req := withSession(httptest.NewRequest(http.MethodGet, "/me/mfa", nil), session)
+	w := httptest.NewRecorder()
+
+	h.GetMFAStatus(w, req)
+
+	var status dto.MFAStatusDTO
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	return status
+}
+
+func postTestMFACode(t *testing.T, handler http.HandlerFunc, session repository.Session, code string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"code": code})
+	req := withSession(httptest.NewRequest(http.MethodPost, "/me/mfa", bytes.NewBuffer(body)), session)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+	return w
+}
+
+func withSession(req *http.Request, session repository.Session) *http.Request {
+	return req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: session.UserID, SessionID: session.ID}))
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/mailer"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+const (
+	passwordResetCodeTTL = 30 * time.Minute
+	// passwordResetMailTimeout bounds the background delivery of a code.
+	passwordResetMailTimeout = time.Minute
+
+	invalidResetCodeErrMsg     = "Invalid or expired reset code"
+	failedResetErrMsg          = "Failed to reset password"
+	tooManyResetRequestsErrMsg = "Too many password reset requests, try again later"
+)
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+type passwordResetRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type passwordResetConfirmRequest struct {
+	Username    string `json:"username"`
+	Code        string `json:"code"`
+	NewPassword string `json:"newPassword"`
+}
+
+// ChangePassword replaces the password of the current user after checking the
+// current one. Every other session is revoked, so a device that knew the old
+// password is logged out.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Password change without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	var req changePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		h.logger.Error("Current and new password are required", "userID", identity.UserID)
+		http.Error(w, "Current and new password are required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(ctx, identity.UserID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", identity.UserID)
+		http.Error(w, "Failed to change password", http.StatusInternalServerError)
+		return
+	}
+
+	if violations := h.policy.CheckPassword("newPassword", req.NewPassword, user.Username); len(violations) > 0 {
+		h.logger.Info("New password rejected by policy", "userID", user.ID)
+		respondWithViolations(w, http.StatusBadRequest, violations)
+		return
+	}
+
+	// A stolen access token must not allow guessing the current password
+	// faster than the login form would.
+	ipAddress := clientIP(r)
+	throttleKeys := loginThrottleKeys(user.Username, ipAddress)
+	if !h.checkLoginThrottle(ctx, w, throttleKeys) {
+		return
+	}
+
+	if match, _ := h.passwords.Verify(user.Password, req.CurrentPassword); !match {
+		h.logger.Info("Password change with wrong current password", "userID", user.ID)
+		h.recordLoginFailure(ctx, throttleKeys, ipAddress)
+		http.Error(w, "Current password is incorrect", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.setPassword(ctx, user.ID, req.NewPassword); err != nil {
+		h.logger.Error("Failed to update password", "error", err, "userID", user.ID)
+		http.Error(w, "Failed to change password", http.StatusInternalServerError)
+		return
+	}
+
+	revoked, err := h.revokeOtherSessions(ctx, user.ID, identity.SessionID)
+	if err != nil {
+		h.logger.Error("Failed to revoke other sessions after password change", "error", err, "userID", user.ID)
+	}
+
+	h.recordAudit(ctx, auditActionPasswordChanged, user.ID, userAuditSubject(user.ID), ipAddress,
+		fmt.Sprintf("revoked_sessions=%d", revoked))
+
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Password changed", "userID", user.ID, "revokedSessions", revoked)
+}
+
+// RequestPasswordReset mails a single-use reset code to the account's email
+// address. It answers 202 whether or not the account exists, so it cannot be
+// used to discover usernames or addresses: the code is created and mailed in
+// the background, so both answers take as long. Requests are throttled per
+// account and per IP, so nobody can flood an inbox or keep replacing a
+// pending code.
+func (h *Handler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	var req passwordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	if req.Username == "" && req.Email == "" {
+		h.logger.Error("Password reset requested without username or email")
+		http.Error(w, "Username or email is required", http.StatusBadRequest)
+		return
+	}
+
+	ipAddress := clientIP(r)
+	throttleKeys := passwordResetThrottleKeys(req, ipAddress)
+	if !h.checkThrottle(ctx, w, throttleKeys, tooManyResetRequestsErrMsg) {
+		return
+	}
+	// Every request counts, whether or not a code goes out.
+	h.recordLoginFailure(ctx, throttleKeys, ipAddress)
+
+	user, err := h.findPasswordResetUser(ctx, req)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			h.logger.Error("Failed to retrieve user for password reset", "error", err)
+		}
+		h.logger.Debug("Password reset requested for unknown account", "username", req.Username)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if !user.Email.Valid {
+		h.logger.Info("Password reset requested for account without email", "userID", user.ID)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	go h.sendPasswordResetCode(context.WithoutCancel(ctx), user, ipAddress)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ConfirmPasswordReset sets a new password with a code from
+// RequestPasswordReset. Wrong codes count as failed logins, and a successful
+// reset revokes every session of the account.
+func (h *Handler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	var req passwordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	if req.Username == "" || req.Code == "" || req.NewPassword == "" {
+		h.logger.Error("Username, code and new password are required")
+		http.Error(w, "Username, code and new password are required", http.StatusBadRequest)
+		return
+	}
+
+	// Checked before the code, so a rejected password does not use it up.
+	if violations := h.policy.CheckPassword("newPassword", req.NewPassword, req.Username); len(violations) > 0 {
+		h.logger.Info("New password rejected by policy", "username", req.Username)
+		respondWithViolations(w, http.StatusBadRequest, violations)
+		return
+	}
+
+	ipAddress := clientIP(r)
+	throttleKeys := loginThrottleKeys(req.Username, ipAddress)
+	if !h.checkLoginThrottle(ctx, w, throttleKeys) {
+		return
+	}
+
+	resetCode, err := h.findPasswordResetCode(ctx, req.Username, req.Code)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			h.logger.Error("Failed to retrieve password reset code", "error", err, "username", req.Username)
+			http.Error(w, failedResetErrMsg, http.StatusInternalServerError)
+			return
+		}
+		h.logger.Info("Invalid password reset code", "username", req.Username, "ip", ipAddress)
+		h.recordLoginFailure(ctx, throttleKeys, ipAddress)
+		http.Error(w, invalidResetCodeErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	if resetCode.UsedAt.Valid || !time.Now().Before(resetCode.ExpiresAt) {
+		h.logger.Info("Used or expired password reset code", "userID", resetCode.UserID)
+		http.Error(w, invalidResetCodeErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	consumed, err := h.queries.ConsumePasswordResetCode(ctx, resetCode.ID)
+	if err != nil {
+		h.logger.Error("Failed to consume password reset code", "error", err, "userID", resetCode.UserID)
+		http.Error(w, failedResetErrMsg, http.StatusInternalServerError)
+		return
+	}
+	if consumed == 0 {
+		h.logger.Info("Password reset code already used", "userID", resetCode.UserID)
+		http.Error(w, invalidResetCodeErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.setPassword(ctx, resetCode.UserID, req.NewPassword); err != nil {
+		h.logger.Error("Failed to update password", "error", err, "userID", resetCode.UserID)
+		http.Error(w, failedResetErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	revoked, err := h.revokeOtherSessions(ctx, resetCode.UserID, 0)
+	if err != nil {
+		h.logger.Error("Failed to revoke sessions after password reset", "error", err, "userID", resetCode.UserID)
+	}
+
+	h.clearLoginFailures(ctx, req.Username)
+	h.recordAudit(ctx, auditActionPasswordReset, 0, userAuditSubject(resetCode.UserID), ipAddress,
+		fmt.Sprintf("revoked_sessions=%d", revoked))
+
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Password reset", "userID", resetCode.UserID, "revokedSessions", revoked)
+}
+
+func (h *Handler) setPassword(ctx context.Context, userID int64, password string) error {
+	hashedPassword, err := h.passwords.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	if err := h.queries.UpdateUserPassword(ctx, repository.UpdateUserPasswordParams{
+		Password: hashedPassword,
+		ID:       userID,
+	}); err != nil {
+		return err
+	}
+
+	// Codes requested before the change must not be able to undo it.
+	return h.queries.InvalidatePasswordResetCodes(ctx, userID)
+}
+
+func (h *Handler) findPasswordResetUser(ctx context.Context, req passwordResetRequest) (repository.User, error) {
+	if req.Username != "" {
+		return h.queries.GetUserByUsername(ctx, req.Username)
+	}
+
+	email, ok := normalizeEmail(req.Email)
+	if !ok {
+		return repository.User{}, sql.ErrNoRows
+	}
+
+	return h.queries.GetUserByEmail(ctx, nullString(email))
+}
+
+func (h *Handler) findPasswordResetCode(ctx context.Context, username, code string) (repository.PasswordResetCode, error) {
+	user, err := h.queries.GetUserByUsername(ctx, username)
+	if err != nil {
+		return repository.PasswordResetCode{}, err
+	}
+
+	return h.queries.GetPasswordResetCode(ctx, repository.GetPasswordResetCodeParams{
+		UserID:   user.ID,
+		CodeHash: auth.HashHumanCode(code),
+	})
+}
+
+// sendPasswordResetCode runs after RequestPasswordReset has answered, so it
+// logs its errors instead of returning them.
+func (h *Handler) sendPasswordResetCode(ctx context.Context, user repository.User, ipAddress string) {
+	ctx, cancel := context.WithTimeout(ctx, passwordResetMailTimeout)
+	defer cancel()
+
+	if err := h.mailPasswordResetCode(ctx, user); err != nil {
+		h.logger.Error("Failed to send password reset code", "error", err, "userID", user.ID)
+		return
+	}
+
+	h.recordAudit(ctx, auditActionPasswordResetRequested, 0, userAuditSubject(user.ID), ipAddress, "")
+	h.logger.Info("Password reset code sent", "userID", user.ID)
+}
+
+func (h *Handler) mailPasswordResetCode(ctx context.Context, user repository.User) error {
+	code, codeHash, err := auth.NewResetCode()
+	if err != nil {
+		return err
+	}
+
+	// Only the latest code is valid.
+	if err := h.queries.InvalidatePasswordResetCodes(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if err := h.queries.CreatePasswordResetCode(ctx, repository.CreatePasswordResetCodeParams{
+		UserID:    user.ID,
+		CodeHash:  codeHash,
+		ExpiresAt: time.Now().Add(passwordResetCodeTTL),
+	}); err != nil {
+		return err
+	}
+
+	return h.mailer.Send(ctx, mailer.Message{
+		To:      user.Email.String,
+		Subject: "Your password reset code",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this code to reset your password: %s\n\n"+
+			"It expires in %d minutes and can only be used once. If you did not ask for it, you can ignore this email.\n",
+			user.Username, code, int(passwordResetCodeTTL.Minutes())),
+	})
+}
+
+func userAuditSubject(userID int64) string {
+	return "user:" + strconv.FormatInt(userID, 10)
+}
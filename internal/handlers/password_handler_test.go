@@ -0,0 +1,299 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/handlers"
+	"github.com/fortega2/real-time-chat/internal/mailer"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+const (
+	pathChangePassword       = "/me/password"
+	pathPasswordReset        = "/password-reset"
+	pathPasswordResetConfirm = "/password-reset/confirm"
+)
+
+var resetCodePattern = regexp.MustCompile(`[0-9A-Z]{5}-[0-9A-Z]{5}`)
+
+func TestChangePassword(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+
+	current := sessionFromLogin(t, queries, loginTestUser(t, h))
+	other := sessionFromLogin(t, queries, loginTestUser(t, h))
+
+	w := changeTestPassword(t, h, current, "password123", "newpassword456")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf(expectedStatusErrMsg, http.StatusNoContent, w.Code)
+	}
+
+	if len(hub.disconnectedSessions) != 1 || hub.disconnectedSessions[0] != other.ID {
+		t.Errorf("expected hub to disconnect session %d, got %v", other.ID, hub.disconnectedSessions)
+	}
+
+	sessions, err := queries.ListUnrevokedSessionsByUser(context.Background(), current.UserID)
+	if err != nil {
+		t.Fatalf("failed to list sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != current.ID {
+		t.Errorf("expected only the current session to remain, got %+v", sessions)
+	}
+
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusUnauthorized)
+	attemptLogin(t, h, "testuser", "newpassword456", "192.0.2.1", http.StatusOK)
+}
+
+func TestChangePasswordInvalidRequests(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	session := sessionFromLogin(t, queries, loginTestUser(t, h))
+
+	testCases := []struct {
+		name            string
+		currentPassword string
+		newPassword     string
+		expectedStatus  int
+	}{
+		{"Wrong Current Password", "wrongpassword", "newpassword456", http.StatusUnauthorized},
+		{"Missing New Password", "password123", "", http.StatusBadRequest},
+		{"Missing Current Password", "", "newpassword456", http.StatusBadRequest},
+		{"Too Short New Password", "password123", "short", http.StatusBadRequest},
+		{"New Password Equals Username", "password123", "testuser", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := changeTestPassword(t, h, session, tc.currentPassword, tc.newPassword)
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusOK)
+}
+
+func TestPasswordResetFlow(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	outbox := mailer.NewOutbox("")
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithMailer(outbox), handlers.WithHub(hub))
+	setTestUserEmail(t, db, "testuser", "testuser@example.com")
+
+	session := sessionFromLogin(t, queries, loginTestUser(t, h))
+
+	requestTestPasswordReset(t, h, map[string]string{"email": "TestUser@example.com"})
+
+	messages := waitForTestMail(t, outbox, 1)
+	if messages[0].To != "testuser@example.com" {
+		t.Fatalf("expected one reset email to testuser@example.com, got %+v", messages)
+	}
+	code := resetCodePattern.FindString(messages[0].Body)
+	if code == "" {
+		t.Fatalf("expected a reset code in the email body, got %q", messages[0].Body)
+	}
+
+	if w := confirmTestPasswordReset(t, h, "testuser", code, "resetpassword789"); w.Code != http.StatusNoContent {
+		t.Fatalf(expectedStatusErrMsg, http.StatusNoContent, w.Code)
+	}
+
+	if len(hub.disconnectedSessions) != 1 || hub.disconnectedSessions[0] != session.ID {
+		t.Errorf("expected hub to disconnect session %d, got %v", session.ID, hub.disconnectedSessions)
+	}
+
+	attemptLogin(t, h, "testuser", "resetpassword789", "192.0.2.1", http.StatusOK)
+
+	// Codes are single use.
+	if w := confirmTestPasswordReset(t, h, "testuser", code, "anotherpassword"); w.Code != http.StatusBadRequest {
+		t.Errorf(expectedStatusErrMsg, http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestPasswordResetOnlyLatestCodeIsValid(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+
+	outbox := mailer.NewOutbox("")
+	h := handlers.NewHandler(getMockLogger(), repository.New(db), db, handlers.WithMailer(outbox))
+	setTestUserEmail(t, db, "testuser", "testuser@example.com")
+
+	requestTestPasswordReset(t, h, map[string]string{"username": "testuser"})
+	waitForTestMail(t, outbox, 1)
+	requestTestPasswordReset(t, h, map[string]string{"username": "testuser"})
+
+	messages := waitForTestMail(t, outbox, 2)
+	first := resetCodePattern.FindString(messages[0].Body)
+	second := resetCodePattern.FindString(messages[1].Body)
+
+	if w := confirmTestPasswordReset(t, h, "testuser", first, "resetpassword789"); w.Code != http.StatusBadRequest {
+		t.Errorf(expectedStatusErrMsg, http.StatusBadRequest, w.Code)
+	}
+	if w := confirmTestPasswordReset(t, h, "testuser", second, "resetpassword789"); w.Code != http.StatusNoContent {
+		t.Errorf(expectedStatusErrMsg, http.StatusNoContent, w.Code)
+	}
+}
+
+func TestRequestPasswordResetDoesNotRevealAccounts(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+
+	outbox := mailer.NewOutbox("")
+	h := handlers.NewHandler(getMockLogger(), repository.New(db), db, handlers.WithMailer(outbox))
+
+	requestTestPasswordReset(t, h, map[string]string{"username": "nobody"})
+	requestTestPasswordReset(t, h, map[string]string{"email": "nobody@example.com"})
+	// testuser exists but has no email address.
+	requestTestPasswordReset(t, h, map[string]string{"username": "testuser"})
+
+	if len(outbox.Messages()) != 0 {
+		t.Errorf("expected no emails, got %+v", outbox.Messages())
+	}
+}
+
+func TestRequestPasswordResetThrottle(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+
+	outbox := mailer.NewOutbox("")
+	h := handlers.NewHandler(getMockLogger(), repository.New(db), db, handlers.WithMailer(outbox))
+	setTestUserEmail(t, db, "testuser", "testuser@example.com")
+
+	for _, username := range []string{"testuser", "nobody"} {
+		for range 3 {
+			requestTestPasswordReset(t, h, map[string]string{"username": username})
+		}
+		// Unknown accounts are throttled the same way.
+		if w := passwordResetRequest(t, h, map[string]string{"username": username}, "192.0.2.1"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("%s: "+expectedStatusErrMsg, username, http.StatusTooManyRequests, w.Code)
+		}
+	}
+	if messages := waitForTestMail(t, outbox, 3); len(messages) != 3 {
+		t.Errorf("expected only the first 3 requests to mail a code, got %d", len(messages))
+	}
+
+	// Reset requests do not lock the account out of logging in.
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusOK)
+
+	// The address runs out after 10 requests, whichever accounts they name.
+	for i := range 4 {
+		if w := passwordResetRequest(t, h, map[string]string{"username": fmt.Sprintf("user%d", i)}, "192.0.2.1"); w.Code != http.StatusAccepted {
+			t.Fatalf(expectedStatusErrMsg, http.StatusAccepted, w.Code)
+		}
+	}
+	if w := passwordResetRequest(t, h, map[string]string{"username": "user9"}, "192.0.2.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf(expectedStatusErrMsg, http.StatusTooManyRequests, w.Code)
+	}
+	if w := passwordResetRequest(t, h, map[string]string{"username": "user9"}, "198.51.100.7"); w.Code != http.StatusAccepted {
+		t.Errorf(expectedStatusErrMsg, http.StatusAccepted, w.Code)
+	}
+}
+
+func TestConfirmPasswordResetInvalidRequests(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	testCases := []struct {
+		name           string
+		username       string
+		code           string
+		newPassword    string
+		expectedStatus int
+	}{
+		{"Wrong Code", "testuser", "AAAAA-AAAAA", "resetpassword789", http.StatusBadRequest},
+		{"Unknown User", "nobody", "AAAAA-AAAAA", "resetpassword789", http.StatusBadRequest},
+		{"Missing Code", "testuser", "", "resetpassword789", http.StatusBadRequest},
+		{"Missing Password", "testuser", "AAAAA-AAAAA", "", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := confirmTestPasswordReset(t, h, tc.username, tc.code, tc.newPassword)
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func changeTestPassword(t *testing.T, h *handlers.Handler, session repository.Session, currentPassword, newPassword string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"currentPassword": currentPassword, "newPassword": newPassword})
+	req := httptest.NewRequest(http.MethodPut, pathChangePassword, bytes.NewBuffer(body))
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: session.UserID, SessionID: session.ID}))
+	w := httptest.NewRecorder()
+
+	h.ChangePassword(w, req)
+	return w
+}
+
+func requestTestPasswordReset(t *testing.T, h *handlers.Handler, payload map[string]string) {
+	t.Helper()
+	if w := passwordResetRequest(t, h, payload, "192.0.2.1"); w.Code != http.StatusAccepted {
+		t.Fatalf(expectedStatusErrMsg, http.StatusAccepted, w.Code)
+	}
+}
+
+func passwordResetRequest(t *testing.T, h *handlers.Handler, payload map[string]string, ipAddress string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(http.MethodPost, pathPasswordReset, bytes.NewBuffer(body))
+	req.RemoteAddr = ipAddress + ":1234"
+	w := httptest.NewRecorder()
+
+	h.RequestPasswordReset(w, req)
+	return w
+}
+
+// waitForTestMail returns the outbox once it holds n messages. Reset codes are
+// mailed after the request has been answered.
+func waitForTestMail(t *testing.T, outbox *mailer.Outbox, n int) []mailer.Message {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		messages := outbox.Messages()
+		if len(messages) >= n {
+			return messages
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d emails, got %+v", n, messages)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func confirmTestPasswordReset(t *testing.T, h *handlers.Handler, username, code, newPassword string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"username": username, "code": code, "newPassword": newPassword})
+	req := httptest.NewRequest(http.MethodPost, pathPasswordResetConfirm, bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	h.ConfirmPasswordReset(w, req)
+	return w
+}
+
+func setTestUserEmail(t *testing.T, db *sql.DB, username, email string) {
+	t.Helper()
+	if _, err := db.Exec("UPDATE users SET email = ? WHERE username = ?", email, username); err != nil {
+		t.Fatalf("failed to set email: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/profile"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+const failedEncodeProfileErrMsg = "Failed to encode profile"
+
+// updateProfileRequest only changes the fields that are present. An empty
+// string clears a field, and clearing the color restores the default one.
+type updateProfileRequest struct {
+	DisplayName *string `json:"displayName"`
+	Bio         *string `json:"bio"`
+	AvatarURL   *string `json:"avatarUrl"`
+	Color       *string `json:"color"`
+}
+
+func (h *Handler) GetMyProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Profile request without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(ctx, identity.UserID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", identity.UserID)
+		http.Error(w, "Failed to retrieve profile", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.NewProfileDTO(user), failedEncodeProfileErrMsg)
+}
+
+// UpdateMyProfile changes the profile of the current user. Open WebSocket
+// connections pick up the change for the messages they send next.
+func (h *Handler) UpdateMyProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Profile update without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	var req updateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(ctx, identity.UserID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", identity.UserID)
+		http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+		return
+	}
+
+	updated := profile.Normalize(applyProfileUpdate(user, req))
+	if violations := profile.Check(updated); len(violations) > 0 {
+		h.logger.Info("Profile update rejected", "userID", user.ID, "violations", len(violations))
+		respondWithViolations(w, http.StatusBadRequest, violations)
+		return
+	}
+
+	user, err = h.queries.UpdateUserProfile(ctx, repository.UpdateUserProfileParams{
+		DisplayName: nullString(updated.DisplayName),
+		Bio:         nullString(updated.Bio),
+		AvatarUrl:   nullString(updated.AvatarURL),
+		Color:       nullString(updated.Color),
+		ID:          user.ID,
+	})
+	if err != nil {
+		h.logger.Error("Failed to update profile", "error", err, "userID", identity.UserID)
+		http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+		return
+	}
+
+	h.hub.UpdateProfile(user.ID, profile.Of(user))
+
+	respondWithJSON(w, http.StatusOK, dto.NewProfileDTO(user), failedEncodeProfileErrMsg)
+
+	h.logger.Info("Profile updated", "userID", user.ID)
+}
+
+func (h *Handler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userId"), 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid user ID", "error", err)
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("Failed to retrieve user", "error", err, "userID", userID)
+		http.Error(w, "Failed to retrieve profile", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.NewProfileDTO(user), failedEncodeProfileErrMsg)
+}
+
+// applyProfileUpdate returns the stored profile of user with the fields of
+// req that are present. Unlike profile.Of it keeps an unset color empty.
+func applyProfileUpdate(user repository.User, req updateProfileRequest) profile.Profile {
+	p := profile.Profile{
+		DisplayName: user.DisplayName.String,
+		Bio:         user.Bio.String,
+		AvatarURL:   user.AvatarUrl.String,
+		Color:       user.Color.String,
+	}
+
+	if req.DisplayName != nil {
+		p.DisplayName = *req.DisplayName
+	}
+	if req.Bio != nil {
+		p.Bio = *req.Bio
+	}
+	if req.AvatarURL != nil {
+		p.AvatarURL = *req.AvatarURL
+	}
+	if req.Color != nil {
+		p.Color = *req.Color
+	}
+
+	return p
+}
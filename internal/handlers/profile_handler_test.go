@@ -0,0 +1,157 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/handlers"
+	"github.com/fortega2/real-time-chat/internal/profile"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+const pathMe = "/me"
+
+func TestUpdateMyProfile(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+	user, err := queries.GetUserByUsername(context.Background(), "testuser")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+
+	before := getTestProfile(t, h, user.ID)
+	if before.DisplayName != "" || before.Color != profile.DefaultColor(user.ID) {
+		t.Fatalf("expected an empty profile with the default color, got %+v", before)
+	}
+
+	updated := patchTestProfile(t, h, user.ID, `{"displayName": " Test User ", "bio": "Hello", "color": "#ff6b6b"}`, http.StatusOK)
+	if updated.DisplayName != "Test User" || updated.Bio != "Hello" || updated.Color != "#FF6B6B" {
+		t.Errorf("unexpected profile %+v", updated)
+	}
+	if got := hub.updatedProfiles[user.ID]; got.DisplayName != "Test User" || got.Color != "#FF6B6B" {
+		t.Errorf("expected open connections to get the new profile, got %+v", got)
+	}
+
+	// Absent fields are kept, and clearing the color restores the default.
+	updated = patchTestProfile(t, h, user.ID, `{"color": ""}`, http.StatusOK)
+	if updated.DisplayName != "Test User" || updated.Bio != "Hello" || updated.Color != profile.DefaultColor(user.ID) {
+		t.Errorf("unexpected profile %+v", updated)
+	}
+
+	if got := getTestProfile(t, h, user.ID); got != updated {
+		t.Errorf("expected GET /me to return %+v, got %+v", updated, got)
+	}
+}
+
+func TestUpdateMyProfileInvalid(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, pathMe, strings.NewReader(`{"avatarUrl": "http://example.com/a.png", "color": "red"}`))
+	h.UpdateMyProfile(w, req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: 1})))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf(expectedStatusErrMsg, http.StatusBadRequest, w.Code)
+	}
+
+	var resp dto.ValidationErrorDTO
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if len(resp.Fields) != 2 || resp.Fields[0].Field != "avatarUrl" || resp.Fields[1].Field != "color" {
+		t.Errorf("expected avatarUrl and color errors, got %+v", resp.Fields)
+	}
+
+	if got := getTestProfile(t, h, 1); got.AvatarURL != "" {
+		t.Errorf("expected a rejected update not to be stored, got %+v", got)
+	}
+}
+
+func TestGetUserProfile(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	testCases := []struct {
+		name           string
+		userID         string
+		expectedStatus int
+	}{
+		{"Existing User", "1", http.StatusOK},
+		{"Unknown User", "999", http.StatusNotFound},
+		{"Invalid ID", "abc", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/"+tc.userID, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("userId", tc.userID)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			h.GetUserProfile(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp dto.ProfileDTO
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf(failedToDecodeResponseBody, err)
+			}
+			if resp.ID != 1 || resp.Username != "testuser" || resp.Color == "" {
+				t.Errorf("unexpected profile %+v", resp)
+			}
+		})
+	}
+}
+
+func getTestProfile(t *testing.T, h *handlers.Handler, userID int64) dto.ProfileDTO {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, pathMe, nil)
+	w := httptest.NewRecorder()
+	h.GetMyProfile(w, req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: userID})))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+
+	var resp dto.ProfileDTO
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	return resp
+}
+
+func patchTestProfile(t *testing.T, h *handlers.Handler, userID int64, body string, expectedStatus int) dto.ProfileDTO {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPatch, pathMe, bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	h.UpdateMyProfile(w, req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: userID})))
+
+	if w.Code != expectedStatus {
+		t.Fatalf(expectedStatusErrMsg, expectedStatus, w.Code)
+	}
+
+	var resp dto.ProfileDTO
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	return resp
+}
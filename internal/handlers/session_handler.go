@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	invalidRefreshTokenErrMsg = "Invalid or expired refresh token"
+	failedEncodeSessionErrMsg = "Failed to encode session data"
+
+	maxDeviceNameLength = 64
+	maxUserAgentLength  = 256
+
+	// sessionTouchInterval bounds how often an authenticated request writes
+	// last_seen_at, so busy clients do not turn every request into an UPDATE.
+	sessionTouchInterval = time.Minute
+)
+
+type refreshSessionRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (h *Handler) RefreshSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	var req refreshSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return
+	}
+
+	if req.RefreshToken == "" {
+		h.logger.Error("Refresh token is required")
+		http.Error(w, "Refresh token is required", http.StatusBadRequest)
+		return
+	}
+
+	oldHash := auth.HashRefreshToken(req.RefreshToken)
+	session, err := h.queries.GetSessionByRefreshTokenHash(ctx, oldHash)
+	if err != nil {
+		h.logger.Debug("Refresh token not found", "error", err)
+		http.Error(w, invalidRefreshTokenErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	if !isSessionActive(session) {
+		h.logger.Debug("Refresh attempted on inactive session", "sessionID", session.ID, "userID", session.UserID)
+		http.Error(w, invalidRefreshTokenErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.queries.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		h.logger.Error("Failed to retrieve session user", "error", err, "sessionID", session.ID)
+		http.Error(w, invalidRefreshTokenErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	if user.DisabledAt.Valid {
+		h.logger.Info("Refresh attempted for a disabled account", "userID", user.ID, "sessionID", session.ID)
+		http.Error(w, accountDisabledErrMsg, http.StatusForbidden)
+		return
+	}
+
+	refreshToken, newHash, err := auth.NewRefreshToken()
+	if err != nil {
+		h.logger.Error("Failed to generate refresh token", "error", err)
+		http.Error(w, "Failed to refresh session", http.StatusInternalServerError)
+		return
+	}
+
+	rotated, err := h.queries.RotateSessionRefreshToken(ctx, repository.RotateSessionRefreshTokenParams{
+		NewRefreshTokenHash: newHash,
+		ExpiresAt:           time.Now().Add(auth.RefreshTokenTTLFromEnv()),
+		ID:                  session.ID,
+		OldRefreshTokenHash: oldHash,
+	})
+	if err != nil {
+		h.logger.Error("Failed to rotate refresh token", "error", err, "sessionID", session.ID)
+		http.Error(w, "Failed to refresh session", http.StatusInternalServerError)
+		return
+	}
+
+	// Another request already used this refresh token.
+	if rotated == 0 {
+		h.logger.Debug("Refresh token already rotated", "sessionID", session.ID)
+		http.Error(w, invalidRefreshTokenErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	response, err := h.newLoginResponse(user, session.ID, refreshToken)
+	if err != nil {
+		h.logger.Error("Failed to issue access token", "error", err, "sessionID", session.ID)
+		http.Error(w, "Failed to refresh session", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response, failedEncodeuserDataErrMsg)
+
+	h.logger.Info("Session refreshed", "userID", user.ID, "sessionID", session.ID)
+}
+
+func (h *Handler) LogoutUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Logout without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.revokeSession(ctx, identity.SessionID); err != nil {
+		h.logger.Error("Failed to revoke session", "error", err, "sessionID", identity.SessionID)
+		http.Error(w, "Failed to logout", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("User logged out", "userID", identity.UserID, "sessionID", identity.SessionID)
+}
+
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Session listing without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.queries.ListUnrevokedSessionsByUser(ctx, identity.UserID)
+	if err != nil {
+		h.logger.Error("Failed to list sessions", "error", err, "userID", identity.UserID)
+		http.Error(w, "Failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.SessionDTO, 0, len(sessions))
+	for _, session := range sessions {
+		if !isSessionActive(session) {
+			continue
+		}
+		response = append(response, dto.NewSessionDTO(session, identity.SessionID))
+	}
+
+	respondWithJSON(w, http.StatusOK, response, failedEncodeSessionErrMsg)
+
+	h.logger.Debug("Sessions listed", "userID", identity.UserID, "count", len(response))
+}
+
+func (h *Handler) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Session revocation without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := strconv.ParseInt(chi.URLParam(r, "sessionId"), 10, 64)
+	if err != nil {
+		h.logger.Error("Invalid session ID", "error", err)
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	revoked, err := h.queries.RevokeUserSession(ctx, repository.RevokeUserSessionParams{
+		ID:     sessionID,
+		UserID: identity.UserID,
+	})
+	if err != nil {
+		h.logger.Error("Failed to revoke session", "error", err, "sessionID", sessionID)
+		http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	// Sessions of other users are reported as missing so their ids do not leak.
+	if revoked == 0 {
+		h.logger.Debug("Session not found or already revoked", "sessionID", sessionID, "userID", identity.UserID)
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	h.hub.DisconnectSession(sessionID)
+
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Session revoked", "userID", identity.UserID, "sessionID", sessionID)
+}
+
+func (h *Handler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return
+	}
+
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok {
+		h.logger.Error("Session revocation without an authenticated session")
+		http.Error(w, unauthorizedErrMsg, http.StatusUnauthorized)
+		return
+	}
+
+	revoked, err := h.revokeOtherSessions(ctx, identity.UserID, identity.SessionID)
+	if err != nil {
+		h.logger.Error("Failed to revoke other sessions", "error", err, "userID", identity.UserID)
+		http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.RevokeSessionsResponseDTO{Revoked: revoked}, failedEncodeSessionErrMsg)
+
+	h.logger.Info("Other sessions revoked", "userID", identity.UserID, "sessionID", identity.SessionID, "count", revoked)
+}
+
+func (h *Handler) startSession(r *http.Request, user repository.User, deviceName string) (dto.LoginResponseDTO, error) {
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return dto.LoginResponseDTO{}, err
+	}
+
+	userAgent := truncate(r.UserAgent(), maxUserAgentLength)
+	if deviceName = truncate(strings.TrimSpace(deviceName), maxDeviceNameLength); deviceName == "" {
+		deviceName = deviceNameFromUserAgent(userAgent)
+	}
+
+	session, err := h.queries.CreateSession(r.Context(), repository.CreateSessionParams{
+		UserID:           user.ID,
+		RefreshTokenHash: refreshHash,
+		ExpiresAt:        time.Now().Add(auth.RefreshTokenTTLFromEnv()),
+		DeviceName:       nullString(deviceName),
+		UserAgent:        nullString(userAgent),
+		IpAddress:        nullString(clientIP(r)),
+	})
+	if err != nil {
+		return dto.LoginResponseDTO{}, err
+	}
+
+	h.logger.Debug("Session created", "userID", user.ID, "sessionID", session.ID)
+
+	return h.newLoginResponse(user, session.ID, refreshToken)
+}
+
+func (h *Handler) newLoginResponse(user repository.User, sessionID int64, refreshToken string) (dto.LoginResponseDTO, error) {
+	accessToken, expiresAt, err := h.tokens.Issue(user.ID, sessionID)
+	if err != nil {
+		return dto.LoginResponseDTO{}, err
+	}
+
+	return dto.NewLoginResponse(accessToken, expiresAt, refreshToken, dto.NewUserDTO(user.ID, user.Username)), nil
+}
+
+// revokeSession marks the session as revoked and drops its live sockets, so a
+// stolen device is cut off without waiting for its access token to expire.
+func (h *Handler) revokeSession(ctx context.Context, sessionID int64) error {
+	if err := h.queries.RevokeSession(ctx, sessionID); err != nil {
+		return err
+	}
+
+	h.hub.DisconnectSession(sessionID)
+	return nil
+}
+
+// revokeOtherSessions revokes every session of the user except keepSessionID
+// and drops their sockets. A keepSessionID of 0 revokes all of them.
+func (h *Handler) revokeOtherSessions(ctx context.Context, userID, keepSessionID int64) (int, error) {
+	revoked, err := h.queries.RevokeOtherUserSessions(ctx, repository.RevokeOtherUserSessionsParams{
+		UserID:        userID,
+		KeepSessionID: keepSessionID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, sessionID := range revoked {
+		h.hub.DisconnectSession(sessionID)
+	}
+
+	return len(revoked), nil
+}
+
+func isSessionActive(session repository.Session) bool {
+	return !session.RevokedAt.Valid && time.Now().Before(session.ExpiresAt)
+}
+
+// touchSession records activity on the session, at most once per
+// sessionTouchInterval. Failures only cost accuracy of "last seen", so they
+// never fail the request.
+func (h *Handler) touchSession(ctx context.Context, session repository.Session) {
+	if session.LastSeenAt.Valid && time.Since(session.LastSeenAt.Time) < sessionTouchInterval {
+		return
+	}
+
+	if err := h.queries.TouchSession(ctx, session.ID); err != nil {
+		h.logger.Error("Failed to update session last seen", "error", err, "sessionID", session.ID)
+	}
+}
+
+var (
+	userAgentBrowsers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	userAgentPlatforms = []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// deviceNameFromUserAgent derives a readable label such as "Firefox on Linux"
+// for clients that do not name themselves on login.
+func deviceNameFromUserAgent(userAgent string) string {
+	var browser, platform string
+	for _, b := range userAgentBrowsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, p := range userAgentPlatforms {
+		if strings.Contains(userAgent, p.token) {
+			platform = p.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	default:
+		return "Unknown device"
+	}
+}
+
+// clientIP expects realip.Middleware to have already replaced RemoteAddr with
+// the forwarded address when the request came through a trusted proxy.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
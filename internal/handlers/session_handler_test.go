@@ -0,0 +1,430 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/channelsettings"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/handlers"
+	"github.com/fortega2/real-time-chat/internal/profile"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	pathRefresh  = "/refresh"
+	pathSessions = "/me/sessions"
+
+	firefoxLinuxUserAgent = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+)
+
+type fakeHub struct {
+	disconnectedSessions []int64
+	disconnectedUsers    []int64
+	leftChannels         map[int64][]int64
+	updatedProfiles      map[int64]profile.Profile
+	updatedChannels      map[int64]channelsettings.Settings
+	deletedChannels      []int64
+	channelOwners        map[int64]int64
+	notifications        map[int64][]string
+	editedMessages       map[int64][]string
+}
+
+func (f *fakeHub) DisconnectSession(sessionID int64) {
+	f.disconnectedSessions = append(f.disconnectedSessions, sessionID)
+}
+
+func (f *fakeHub) DisconnectUser(userID int64, _ string) {
+	f.disconnectedUsers = append(f.disconnectedUsers, userID)
+}
+
+func (f *fakeHub) DisconnectFromChannel(userID, channelID int64, _ string) {
+	if f.leftChannels == nil {
+		f.leftChannels = make(map[int64][]int64)
+	}
+	f.leftChannels[userID] = append(f.leftChannels[userID], channelID)
+}
+
+func (f *fakeHub) UpdateProfile(userID int64, p profile.Profile) {
+	if f.updatedProfiles == nil {
+		f.updatedProfiles = make(map[int64]profile.Profile)
+	}
+	f.updatedProfiles[userID] = p
+}
+
+func (f *fakeHub) UpdateChannel(channelID int64, s channelsettings.Settings) {
+	if f.updatedChannels == nil {
+		f.updatedChannels = make(map[int64]channelsettings.Settings)
+	}
+	f.updatedChannels[channelID] = s
+}
+
+func (f *fakeHub) DeleteChannel(channelID int64) {
+	f.deletedChannels = append(f.deletedChannels, channelID)
+}
+
+func (f *fakeHub) UpdateChannelOwner(channelID, ownerID int64, _ string) {
+	if f.channelOwners == nil {
+		f.channelOwners = make(map[int64]int64)
+	}
+	f.channelOwners[channelID] = ownerID
+}
+
+func (f *fakeHub) NotifyChannel(channelID int64, message string) {
+	if f.notifications == nil {
+		f.notifications = make(map[int64][]string)
+	}
+	f.notifications[channelID] = append(f.notifications[channelID], message)
+}
+
+func (f *fakeHub) EditMessage(_, messageID int64, content string, _, _ time.Time) {
+	if f.editedMessages == nil {
+		f.editedMessages = make(map[int64][]string)
+	}
+	f.editedMessages[messageID] = append(f.editedMessages[messageID], content)
+}
+
+func TestRefreshSession(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	login := loginTestUser(t, h)
+
+	first := refreshTestSession(t, h, login.RefreshToken, http.StatusOK)
+	if first.AccessToken == "" || first.RefreshToken == "" {
+		t.Fatal("expected new access and refresh tokens")
+	}
+	if first.RefreshToken == login.RefreshToken {
+		t.Error("expected the refresh token to be rotated")
+	}
+	if first.User.Username != "testuser" {
+		t.Errorf(expectedUsernameErrMsg, "testuser", first.User.Username)
+	}
+
+	// The rotated token must not be accepted twice.
+	refreshTestSession(t, h, login.RefreshToken, http.StatusUnauthorized)
+	refreshTestSession(t, h, first.RefreshToken, http.StatusOK)
+}
+
+func TestRefreshSessionInvalidRequests(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Invalid JSON", "{", http.StatusBadRequest},
+		{"Missing Token", `{}`, http.StatusBadRequest},
+		{"Unknown Token", `{"refreshToken":"unknown"}`, http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, pathRefresh, bytes.NewBufferString(tc.body))
+			w := httptest.NewRecorder()
+
+			h.RefreshSession(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestLogoutUser(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+
+	login := loginTestUser(t, h)
+	session := sessionFromLogin(t, queries, login)
+
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: session.UserID, SessionID: session.ID}))
+	w := httptest.NewRecorder()
+
+	h.LogoutUser(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf(expectedStatusErrMsg, http.StatusNoContent, w.Code)
+	}
+
+	revoked, err := queries.GetSessionByID(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("failed to reload session: %v", err)
+	}
+	if !revoked.RevokedAt.Valid {
+		t.Error("expected session to be revoked")
+	}
+	if len(hub.disconnectedSessions) != 1 || hub.disconnectedSessions[0] != session.ID {
+		t.Errorf("expected hub to disconnect session %d, got %v", session.ID, hub.disconnectedSessions)
+	}
+
+	refreshTestSession(t, h, login.RefreshToken, http.StatusUnauthorized)
+}
+
+func TestLogoutUserUnauthenticated(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	w := httptest.NewRecorder()
+
+	h.LogoutUser(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf(expectedStatusErrMsg, http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	laptop := loginTestDevice(t, h, "", firefoxLinuxUserAgent)
+	phone := loginTestDevice(t, h, "My phone", "")
+	current := sessionFromLogin(t, queries, laptop)
+	other := sessionFromLogin(t, queries, phone)
+
+	req := httptest.NewRequest(http.MethodGet, pathSessions, nil)
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: current.UserID, SessionID: current.ID}))
+	w := httptest.NewRecorder()
+
+	h.ListSessions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+
+	var sessions []dto.SessionDTO
+	if err := json.NewDecoder(w.Body).Decode(&sessions); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	byID := map[int64]dto.SessionDTO{}
+	for _, session := range sessions {
+		byID[session.ID] = session
+	}
+
+	if got := byID[current.ID]; !got.Current || got.DeviceName != "Firefox on Linux" || got.UserAgent != firefoxLinuxUserAgent {
+		t.Errorf("unexpected current session: %+v", got)
+	}
+	if got := byID[current.ID]; got.IPAddress != "192.0.2.1" || got.LastSeenAt == "" {
+		t.Errorf("expected ip address and last seen to be recorded, got %+v", got)
+	}
+	if got := byID[other.ID]; got.Current || got.DeviceName != "My phone" {
+		t.Errorf("unexpected other session: %+v", got)
+	}
+}
+
+func TestListSessionsHidesRevoked(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	current := sessionFromLogin(t, queries, loginTestUser(t, h))
+	revoked := sessionFromLogin(t, queries, loginTestUser(t, h))
+	if err := queries.RevokeSession(context.Background(), revoked.ID); err != nil {
+		t.Fatalf("failed to revoke session: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, pathSessions, nil)
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: current.UserID, SessionID: current.ID}))
+	w := httptest.NewRecorder()
+
+	h.ListSessions(w, req)
+
+	var sessions []dto.SessionDTO
+	if err := json.NewDecoder(w.Body).Decode(&sessions); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != current.ID {
+		t.Errorf("expected only session %d, got %+v", current.ID, sessions)
+	}
+}
+
+func TestRevokeUserSession(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+
+	current := sessionFromLogin(t, queries, loginTestUser(t, h))
+	other := sessionFromLogin(t, queries, loginTestUser(t, h))
+
+	testCases := []struct {
+		name           string
+		sessionID      string
+		expectedStatus int
+	}{
+		{"Revoke Other Device", strconv.FormatInt(other.ID, 10), http.StatusNoContent},
+		{"Already Revoked", strconv.FormatInt(other.ID, 10), http.StatusNotFound},
+		{"Unknown Session", "999", http.StatusNotFound},
+		{"Invalid Session ID", "abc", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, pathSessions+"/"+tc.sessionID, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("sessionId", tc.sessionID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(auth.WithIdentity(ctx, auth.Identity{UserID: current.UserID, SessionID: current.ID}))
+			w := httptest.NewRecorder()
+
+			h.RevokeUserSession(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	if len(hub.disconnectedSessions) != 1 || hub.disconnectedSessions[0] != other.ID {
+		t.Errorf("expected hub to disconnect session %d, got %v", other.ID, hub.disconnectedSessions)
+	}
+}
+
+func TestRevokeUserSessionOfAnotherUser(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	session := sessionFromLogin(t, queries, loginTestUser(t, h))
+	sessionID := strconv.FormatInt(session.ID, 10)
+
+	req := httptest.NewRequest(http.MethodDelete, pathSessions+"/"+sessionID, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("sessionId", sessionID)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	req = req.WithContext(auth.WithIdentity(ctx, auth.Identity{UserID: session.UserID + 1, SessionID: 99}))
+	w := httptest.NewRecorder()
+
+	h.RevokeUserSession(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf(expectedStatusErrMsg, http.StatusNotFound, w.Code)
+	}
+
+	reloaded, err := queries.GetSessionByID(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("failed to reload session: %v", err)
+	}
+	if reloaded.RevokedAt.Valid {
+		t.Error("expected another user's session to stay active")
+	}
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	hub := &fakeHub{}
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithHub(hub))
+
+	current := sessionFromLogin(t, queries, loginTestUser(t, h))
+	loginTestUser(t, h)
+	loginTestUser(t, h)
+
+	req := httptest.NewRequest(http.MethodDelete, pathSessions, nil)
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{UserID: current.UserID, SessionID: current.ID}))
+	w := httptest.NewRecorder()
+
+	h.RevokeOtherSessions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+
+	var response dto.RevokeSessionsResponseDTO
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if response.Revoked != 2 || len(hub.disconnectedSessions) != 2 {
+		t.Errorf("expected 2 revoked and disconnected sessions, got %d and %v", response.Revoked, hub.disconnectedSessions)
+	}
+
+	sessions, err := queries.ListUnrevokedSessionsByUser(context.Background(), current.UserID)
+	if err != nil {
+		t.Fatalf("failed to list sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != current.ID {
+		t.Errorf("expected only the current session to remain, got %+v", sessions)
+	}
+}
+
+func loginTestUser(t *testing.T, h *handlers.Handler) dto.LoginResponseDTO {
+	t.Helper()
+	return loginTestDevice(t, h, "", "")
+}
+
+func loginTestDevice(t *testing.T, h *handlers.Handler, deviceName, userAgent string) dto.LoginResponseDTO {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"username": "testuser", "password": "password123", "deviceName": deviceName})
+	req := httptest.NewRequest(http.MethodPost, pathLogin, bytes.NewBuffer(body))
+	req.Header.Set("User-Agent", userAgent)
+	w := httptest.NewRecorder()
+
+	h.LoginUser(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+
+	var response dto.LoginResponseDTO
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	return response
+}
+
+func refreshTestSession(t *testing.T, h *handlers.Handler, refreshToken string, expectedStatus int) dto.LoginResponseDTO {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"refreshToken": refreshToken})
+	req := httptest.NewRequest(http.MethodPost, pathRefresh, bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	h.RefreshSession(w, req)
+
+	if w.Code != expectedStatus {
+		t.Fatalf(expectedStatusErrMsg, expectedStatus, w.Code)
+	}
+
+	var response dto.LoginResponseDTO
+	if expectedStatus == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf(failedToDecodeResponseBody, err)
+		}
+	}
+	return response
+}
+
+func sessionFromLogin(t *testing.T, queries *repository.Queries, login dto.LoginResponseDTO) repository.Session {
+	t.Helper()
+	session, err := queries.GetSessionByRefreshTokenHash(context.Background(), auth.HashRefreshToken(login.RefreshToken))
+	if err != nil {
+		t.Fatalf("failed to load session: %v", err)
+	}
+	return session
+}
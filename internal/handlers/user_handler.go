@@ -1,17 +1,49 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/mail"
+	"strings"
+	"time"
 
+	"github.com/fortega2/real-time-chat/internal/auth"
 	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/oidc"
 	"github.com/fortega2/real-time-chat/internal/repository"
-	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	invalidCredentialsErrMsg   = "Invalid username or password"
+	tooManyLoginAttemptsErrMsg = "Too many failed login attempts, try again later"
+
+	oidcFlowTTL             = 10 * time.Minute
+	oidcStateCookie         = "oidc_state"
+	oidcCookiePath          = "/api/users/oidc"
+	externalUsernameMaxLen  = 32
+	oidcNotConfiguredErrMsg = "Single sign-on is not configured"
+	invalidOIDCLoginErrMsg  = "Invalid or expired single sign-on login"
+	failedOIDCLoginErrMsg   = "Failed to sign in with single sign-on"
+)
+
+var errIdentityLinkedElsewhere = errors.New("external account linked to another user")
+
 type userCreateLoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Email    string `json:"email"`
+}
+
+type userLoginRequest struct {
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	DeviceName string `json:"deviceName"`
 }
 
 func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
@@ -24,13 +56,39 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 
 	h.logger.Debug("Create user attempt", "username", req.Username, "password_provided", req.Password != "")
 
-	if req.Username == "" || req.Password == "" {
-		h.logger.Error(usernameAndPasswordEmptyErrMsg)
-		http.Error(w, usernameAndPasswordEmptyErrMsg, http.StatusBadRequest)
+	if !auth.Includes(h.authenticator, auth.ProviderLocal) {
+		h.logger.Info("Registration attempt while local accounts are disabled", "username", req.Username)
+		http.Error(w, "Registration is disabled, sign in with your directory account", http.StatusForbidden)
+		return
+	}
+
+	violations := h.policy.CheckUsername(req.Username)
+	violations = append(violations, h.policy.CheckPassword("password", req.Password, req.Username)...)
+
+	email, ok := normalizeEmail(req.Email)
+	if !ok {
+		violations = append(violations, auth.Violation{Field: "email", Code: auth.ViolationInvalid, Message: "Invalid email address"})
+	}
+
+	if len(violations) > 0 {
+		h.logger.Info("Registration rejected by policy", "username", req.Username, "violations", len(violations))
+		respondWithViolations(w, http.StatusBadRequest, violations)
+		return
+	}
+
+	violations, err := h.registrationConflicts(r.Context(), req.Username, email)
+	if err != nil {
+		h.logger.Error("Failed to check username availability", "error", err, "username", req.Username)
+		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
+	if len(violations) > 0 {
+		h.logger.Info("Registration conflicts with an existing user", "username", req.Username)
+		respondWithViolations(w, http.StatusConflict, violations)
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := h.passwords.Hash(req.Password)
 	if err != nil {
 		h.logger.Error("Failed to hash password", "error", err, "username", req.Username)
 		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
@@ -40,8 +98,10 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	h.logger.Debug("Password hashed successfully", "username", req.Username)
 
 	params := repository.CreateUserParams{
-		Username: req.Username,
-		Password: string(hashedPassword),
+		Username:    req.Username,
+		Password:    hashedPassword,
+		Email:       nullString(email),
+		UsernameKey: nullString(auth.UsernameKey(req.Username)),
 	}
 
 	user, err := h.queries.CreateUser(r.Context(), params)
@@ -58,7 +118,7 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) LoginUser(w http.ResponseWriter, r *http.Request) {
-	var req userCreateLoginRequest
+	var req userLoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Error("Failed to decode request body", "error", err)
 		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
@@ -73,23 +133,601 @@ func (h *Handler) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.queries.GetUserByUsername(r.Context(), req.Username)
+	ctx := r.Context()
+	ipAddress := clientIP(r)
+	throttleKeys := loginThrottleKeys(req.Username, ipAddress)
+
+	if !h.checkLoginThrottle(ctx, w, throttleKeys) {
+		return
+	}
+
+	principal, err := h.authenticator.Authenticate(ctx, req.Username, req.Password)
+	if errors.Is(err, auth.ErrInvalidCredentials) {
+		h.logger.Info("Failed login attempt", "username", req.Username, "ip", ipAddress)
+		h.recordLoginFailure(ctx, throttleKeys, ipAddress)
+		http.Error(w, invalidCredentialsErrMsg, http.StatusUnauthorized)
+		return
+	}
 	if err != nil {
-		h.logger.Error("Failed to retrieve user", "error", err, "username", req.Username)
-		http.Error(w, "User not found", http.StatusNotFound)
+		h.logger.Error("Failed to authenticate user", "error", err, "username", req.Username, "providers", h.authenticator.Name())
+		http.Error(w, "Failed to login", http.StatusInternalServerError)
 		return
 	}
 
-	h.logger.Debug("User retrieved", "userID", user.ID, "username", user.Username)
+	user, err := h.userForPrincipal(ctx, principal, ipAddress)
+	if err != nil {
+		h.logger.Error("Failed to retrieve user", "error", err, "username", req.Username, "provider", principal.Provider)
+		http.Error(w, "Failed to login", http.StatusInternalServerError)
+		return
+	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		h.logger.Error("Invalid password", "username", req.Username)
-		http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+	h.logger.Debug("User authenticated", "userID", user.ID, "username", user.Username)
+
+	if user.DisabledAt.Valid {
+		h.logger.Info("Login to a disabled account", "userID", user.ID)
+		http.Error(w, accountDisabledErrMsg, http.StatusForbidden)
 		return
 	}
 
-	userDto := dto.NewUserDTO(user.ID, user.Username)
-	respondWithJSON(w, http.StatusOK, userDto, failedEncodeuserDataErrMsg)
+	if principal.NeedsRehash {
+		h.rehashPassword(ctx, user, req.Password)
+	}
+
+	mfaEnabled, err := h.isMFAEnabled(ctx, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to load two-factor status", "error", err, "userID", user.ID)
+		http.Error(w, "Failed to login", http.StatusInternalServerError)
+		return
+	}
+	if mfaEnabled {
+		// The failures are only cleared once the second factor passes, so
+		// logging in again with the password cannot reset the count of
+		// wrong codes.
+		h.respondWithMFAChallenge(w, user)
+		return
+	}
+
+	h.clearLoginFailures(ctx, req.Username)
+
+	response, err := h.startSession(r, user, req.DeviceName)
+	if err != nil {
+		h.logger.Error("Failed to start session", "error", err, "userID", user.ID)
+		http.Error(w, "Failed to start session", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response, failedEncodeuserDataErrMsg)
 
 	h.logger.Info("User logged in successfully", "username", user.Username, "userID", user.ID)
 }
+
+type oidcCallbackRequest struct {
+	Code       string `json:"code"`
+	State      string `json:"state"`
+	DeviceName string `json:"deviceName"`
+}
+
+// StartOIDCLogin sends the browser to the OpenID Connect provider. The state
+// is also set as a cookie, so CompleteOIDCLogin only accepts a callback in the
+// browser that started the login.
+func (h *Handler) StartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	authURL, ok := h.startOIDCFlow(w, r, 0)
+	if !ok {
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+
+	h.logger.Debug("OIDC login started", "provider", h.oidcProvider.Name())
+}
+
+// StartOIDCLink starts a flow that links an external account to the current
+// user instead of logging in. The flow is bound to the user, and the frontend
+// sends the browser to the returned URL.
+func (h *Handler) StartOIDCLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	authURL, ok := h.startOIDCFlow(w, r, userID)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.OIDCLinkDTO{AuthorizationURL: authURL}, failedEncodeuserDataErrMsg)
+
+	h.logger.Debug("OIDC account link started", "provider", h.oidcProvider.Name(), "userID", userID)
+}
+
+// startOIDCFlow stores a new login flow and sets its state cookie. A non-zero
+// linkUserID makes it a flow that only CompleteOIDCLink accepts, for that user.
+func (h *Handler) startOIDCFlow(w http.ResponseWriter, r *http.Request, linkUserID int64) (string, bool) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return "", false
+	}
+
+	if h.oidcProvider == nil {
+		http.Error(w, oidcNotConfiguredErrMsg, http.StatusNotFound)
+		return "", false
+	}
+
+	authReq, err := oidc.NewAuthRequest()
+	if err != nil {
+		h.logger.Error("Failed to generate OIDC login request", "error", err)
+		http.Error(w, failedOIDCLoginErrMsg, http.StatusInternalServerError)
+		return "", false
+	}
+
+	if err := h.queries.DeleteExpiredOIDCLoginFlows(ctx, time.Now()); err != nil {
+		h.logger.Error("Failed to delete expired OIDC logins", "error", err)
+	}
+
+	if err := h.queries.CreateOIDCLoginFlow(ctx, repository.CreateOIDCLoginFlowParams{
+		StateHash:    oidc.HashState(authReq.State),
+		Nonce:        authReq.Nonce,
+		CodeVerifier: authReq.CodeVerifier,
+		ExpiresAt:    time.Now().Add(oidcFlowTTL),
+		LinkUserID:   sql.NullInt64{Int64: linkUserID, Valid: linkUserID != 0},
+	}); err != nil {
+		h.logger.Error("Failed to store OIDC login", "error", err)
+		http.Error(w, failedOIDCLoginErrMsg, http.StatusInternalServerError)
+		return "", false
+	}
+
+	authURL, err := h.oidcProvider.AuthCodeURL(ctx, authReq)
+	if err != nil {
+		h.logger.Error("Failed to build OIDC authorization URL", "error", err, "provider", h.oidcProvider.Name())
+		http.Error(w, failedOIDCLoginErrMsg, http.StatusBadGateway)
+		return "", false
+	}
+
+	setOIDCStateCookie(w, r, authReq.State, int(oidcFlowTTL.Seconds()))
+	return authURL, true
+}
+
+// CompleteOIDCLogin redeems the code the provider sent back to the frontend.
+// The first login of an external account creates a local user for it. Users
+// with two-factor authentication get a challenge like a password login.
+func (h *Handler) CompleteOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	principal, req, ok := h.completeOIDCFlow(w, r, 0)
+	if !ok {
+		return
+	}
+
+	user, err := h.userForPrincipal(ctx, principal, clientIP(r))
+	if err != nil {
+		h.logger.Error("Failed to resolve OIDC user", "error", err, "provider", principal.Provider, "subject", principal.Subject)
+		http.Error(w, failedOIDCLoginErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	if user.DisabledAt.Valid {
+		h.logger.Info("Single sign-on to a disabled account", "userID", user.ID)
+		http.Error(w, accountDisabledErrMsg, http.StatusForbidden)
+		return
+	}
+
+	mfaEnabled, err := h.isMFAEnabled(ctx, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to load two-factor status", "error", err, "userID", user.ID)
+		http.Error(w, failedOIDCLoginErrMsg, http.StatusInternalServerError)
+		return
+	}
+	if mfaEnabled {
+		h.respondWithMFAChallenge(w, user)
+		return
+	}
+
+	response, err := h.startSession(r, user, req.DeviceName)
+	if err != nil {
+		h.logger.Error("Failed to start session", "error", err, "userID", user.ID)
+		http.Error(w, "Failed to start session", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response, failedEncodeuserDataErrMsg)
+
+	h.logger.Info("User logged in with OIDC", "username", user.Username, "userID", user.ID, "provider", principal.Provider)
+}
+
+// CompleteOIDCLink redeems the code of a flow started with StartOIDCLink and
+// links the external account to the current user. An external account can
+// only belong to one user, so one that is already linked elsewhere is refused.
+func (h *Handler) CompleteOIDCLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := h.currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	principal, _, ok := h.completeOIDCFlow(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var linked repository.UserIdentity
+	err := h.withTx(ctx, func(q *repository.Queries) error {
+		var err error
+		linked, err = q.GetUserIdentity(ctx, repository.GetUserIdentityParams{
+			Provider: principal.Provider,
+			Subject:  principal.Subject,
+		})
+		if err == nil {
+			if linked.UserID != userID {
+				return errIdentityLinkedElsewhere
+			}
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		linked, err = q.CreateUserIdentity(ctx, repository.CreateUserIdentityParams{
+			UserID:   userID,
+			Provider: principal.Provider,
+			Subject:  principal.Subject,
+			Email:    nullString(principal.Email),
+		})
+		return err
+	})
+	if errors.Is(err, errIdentityLinkedElsewhere) {
+		h.logger.Info("External account already linked to another user", "userID", userID, "provider", principal.Provider)
+		http.Error(w, "This account is already linked to another user", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to link external account", "error", err, "userID", userID, "provider", principal.Provider)
+		http.Error(w, failedOIDCLoginErrMsg, http.StatusInternalServerError)
+		return
+	}
+
+	h.recordAudit(ctx, auditActionIdentityLinked, userID, userAuditSubject(userID), clientIP(r),
+		fmt.Sprintf("provider=%s subject=%s", principal.Provider, principal.Subject))
+
+	respondWithJSON(w, http.StatusOK, dto.NewLinkedIdentityDTO(linked), failedEncodeuserDataErrMsg)
+
+	h.logger.Info("External account linked", "userID", userID, "provider", principal.Provider)
+}
+
+// completeOIDCFlow checks the callback against its state cookie, consumes the
+// flow and redeems the code. It only accepts flows started for linkUserID, or
+// login flows when linkUserID is zero.
+func (h *Handler) completeOIDCFlow(w http.ResponseWriter, r *http.Request, linkUserID int64) (auth.Principal, oidcCallbackRequest, bool) {
+	ctx := r.Context()
+
+	if ctx.Err() != nil {
+		h.logger.Error(reqCtxErrMsg, "error", ctx.Err())
+		http.Error(w, reqCtxCancelledOrTimedOutErrMsg, http.StatusRequestTimeout)
+		return auth.Principal{}, oidcCallbackRequest{}, false
+	}
+
+	if h.oidcProvider == nil {
+		http.Error(w, oidcNotConfiguredErrMsg, http.StatusNotFound)
+		return auth.Principal{}, oidcCallbackRequest{}, false
+	}
+
+	var req oidcCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Failed to decode request body", "error", err)
+		http.Error(w, invalidRequestBodyErrMsg, http.StatusBadRequest)
+		return auth.Principal{}, oidcCallbackRequest{}, false
+	}
+
+	if req.Code == "" || req.State == "" {
+		h.logger.Error("Code and state are required")
+		http.Error(w, "Code and state are required", http.StatusBadRequest)
+		return auth.Principal{}, oidcCallbackRequest{}, false
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || cookie.Value != req.State {
+		h.logger.Info("OIDC callback without matching state cookie", "ip", clientIP(r))
+		http.Error(w, invalidOIDCLoginErrMsg, http.StatusBadRequest)
+		return auth.Principal{}, oidcCallbackRequest{}, false
+	}
+	setOIDCStateCookie(w, r, "", -1)
+
+	flow, err := h.queries.ConsumeOIDCLoginFlow(ctx, oidc.HashState(req.State))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			h.logger.Error("Failed to load OIDC login", "error", err)
+			http.Error(w, failedOIDCLoginErrMsg, http.StatusInternalServerError)
+			return auth.Principal{}, oidcCallbackRequest{}, false
+		}
+		h.logger.Info("Unknown or used OIDC state", "ip", clientIP(r))
+		http.Error(w, invalidOIDCLoginErrMsg, http.StatusBadRequest)
+		return auth.Principal{}, oidcCallbackRequest{}, false
+	}
+
+	if !time.Now().Before(flow.ExpiresAt) {
+		h.logger.Info("Expired OIDC login", "ip", clientIP(r))
+		http.Error(w, invalidOIDCLoginErrMsg, http.StatusBadRequest)
+		return auth.Principal{}, oidcCallbackRequest{}, false
+	}
+
+	// A link flow must not log anyone in, and a login flow must not link an
+	// account to whoever finishes it.
+	if flow.LinkUserID.Int64 != linkUserID {
+		h.logger.Info("OIDC callback for a flow of another kind or user", "ip", clientIP(r))
+		http.Error(w, invalidOIDCLoginErrMsg, http.StatusBadRequest)
+		return auth.Principal{}, oidcCallbackRequest{}, false
+	}
+
+	identity, err := h.oidcProvider.Exchange(ctx, req.Code, oidc.AuthRequest{
+		State:        req.State,
+		Nonce:        flow.Nonce,
+		CodeVerifier: flow.CodeVerifier,
+	})
+	if err != nil {
+		h.logger.Info("OIDC code exchange failed", "error", err, "provider", h.oidcProvider.Name())
+		http.Error(w, invalidOIDCLoginErrMsg, http.StatusUnauthorized)
+		return auth.Principal{}, oidcCallbackRequest{}, false
+	}
+
+	return auth.Principal{
+		Provider:      h.oidcProvider.Name(),
+		Subject:       identity.Subject,
+		Username:      identity.PreferredUsername,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+	}, req, true
+}
+
+// registrationConflicts reports the username and email that already belong to
+// another user.
+func (h *Handler) registrationConflicts(ctx context.Context, username, email string) ([]auth.Violation, error) {
+	var violations []auth.Violation
+
+	taken, err := usernameTaken(ctx, h.queries, username)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		violations = append(violations, auth.Violation{Field: "username", Code: auth.ViolationTaken, Message: "Username is already taken"})
+	}
+
+	if email == "" {
+		return violations, nil
+	}
+
+	_, err = h.queries.GetUserByEmail(ctx, nullString(email))
+	switch {
+	case err == nil:
+		violations = append(violations, auth.Violation{Field: "email", Code: auth.ViolationTaken, Message: "Email address is already in use"})
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	return violations, nil
+}
+
+// userForPrincipal returns the user an authenticator vouched for. External
+// accounts are looked up in user_identities, and their first login creates and
+// links a new user. Existing local accounts are never linked by username or
+// email, since that would let the external provider take them over; their
+// owners link them with StartOIDCLink instead.
+func (h *Handler) userForPrincipal(ctx context.Context, principal auth.Principal, ipAddress string) (repository.User, error) {
+	if principal.UserID != 0 {
+		return h.queries.GetUserByID(ctx, principal.UserID)
+	}
+
+	linked, err := h.queries.GetUserIdentity(ctx, repository.GetUserIdentityParams{
+		Provider: principal.Provider,
+		Subject:  principal.Subject,
+	})
+	if err == nil {
+		if err := h.queries.TouchUserIdentity(ctx, repository.TouchUserIdentityParams{
+			Email: nullString(principal.Email),
+			ID:    linked.ID,
+		}); err != nil {
+			h.logger.Error("Failed to update linked account", "error", err, "userID", linked.UserID)
+		}
+		return h.queries.GetUserByID(ctx, linked.UserID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return repository.User{}, err
+	}
+
+	var user repository.User
+	err = h.withTx(ctx, func(q *repository.Queries) error {
+		base := externalUsername(principal)
+		if len(h.policy.CheckUsername(base)) > 0 {
+			base = "user"
+		}
+
+		username, err := availableUsername(ctx, q, base)
+		if err != nil {
+			return err
+		}
+
+		email, err := unclaimedEmail(ctx, q, principal)
+		if err != nil {
+			return err
+		}
+
+		password, err := unusablePassword()
+		if err != nil {
+			return err
+		}
+
+		if user, err = q.CreateUser(ctx, repository.CreateUserParams{
+			Username:    username,
+			Password:    password,
+			Email:       nullString(email),
+			UsernameKey: nullString(auth.UsernameKey(username)),
+		}); err != nil {
+			return err
+		}
+
+		_, err = q.CreateUserIdentity(ctx, repository.CreateUserIdentityParams{
+			UserID:   user.ID,
+			Provider: principal.Provider,
+			Subject:  principal.Subject,
+			Email:    nullString(principal.Email),
+		})
+		return err
+	})
+	if err != nil {
+		return repository.User{}, err
+	}
+
+	h.recordAudit(ctx, auditActionIdentityLinked, user.ID, userAuditSubject(user.ID), ipAddress,
+		fmt.Sprintf("provider=%s subject=%s", principal.Provider, principal.Subject))
+	h.logger.Info("User provisioned from external account", "userID", user.ID, "username", user.Username, "provider", principal.Provider)
+
+	return user, nil
+}
+
+func setOIDCStateCookie(w http.ResponseWriter, r *http.Request, state string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     oidcCookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// externalUsername suggests a username for an external account, keeping only
+// characters that are safe to show and type.
+func externalUsername(principal auth.Principal) string {
+	candidate := principal.Username
+	if candidate == "" {
+		candidate, _, _ = strings.Cut(principal.Email, "@")
+	}
+
+	var b strings.Builder
+	for _, r := range candidate {
+		if b.Len() >= externalUsernameMaxLen {
+			break
+		}
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			b.WriteRune(r)
+		}
+	}
+
+	if b.Len() == 0 {
+		return "user"
+	}
+	return b.String()
+}
+
+// availableUsername returns base, or base with a random suffix when it or a
+// name with the same key is already taken.
+func availableUsername(ctx context.Context, q *repository.Queries, base string) (string, error) {
+	candidate := base
+	for range 5 {
+		taken, err := usernameTaken(ctx, q, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+
+		suffix := make([]byte, 2)
+		if _, err := rand.Read(suffix); err != nil {
+			return "", err
+		}
+		candidate = truncate(base, externalUsernameMaxLen-len(suffix)*2-1) + "-" + hex.EncodeToString(suffix)
+	}
+
+	return "", fmt.Errorf("no free username for %q", base)
+}
+
+// usernameTaken reports whether a user has username or a name with the same
+// key, so "Alice" is taken by "alice". Names that lost their key to an older
+// name differing only in case are still found by the exact lookup.
+func usernameTaken(ctx context.Context, q *repository.Queries, username string) (bool, error) {
+	_, err := q.GetUserByUsernameKey(ctx, nullString(auth.UsernameKey(username)))
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = q.GetUserByUsername(ctx, username)
+	}
+
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// unclaimedEmail returns the verified email of the principal when no other
+// user has it yet, and "" otherwise.
+func unclaimedEmail(ctx context.Context, q *repository.Queries, principal auth.Principal) (string, error) {
+	email, ok := normalizeEmail(principal.Email)
+	if !ok || email == "" || !principal.EmailVerified {
+		return "", nil
+	}
+
+	_, err := q.GetUserByEmail(ctx, nullString(email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return email, nil
+	}
+	return "", err
+}
+
+// unusablePassword is stored for users created from external accounts. It is
+// not in a known hash format, so no password matches it until the user sets one with a
+// reset code. It is random because passwords are unique in the users table.
+func unusablePassword() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return "!sso:" + hex.EncodeToString(raw), nil
+}
+
+// rehashPassword replaces a password hash that uses an older algorithm or
+// weaker parameters. The password was just verified, so it is the only moment
+// the new hash can be computed. A failure only costs the upgrade, not the login.
+func (h *Handler) rehashPassword(ctx context.Context, user repository.User, password string) {
+	newHash, err := h.passwords.Hash(password)
+	if err != nil {
+		h.logger.Error("Failed to rehash password", "error", err, "userID", user.ID)
+		return
+	}
+
+	// Only replace the hash that was verified, in case the password was
+	// changed concurrently.
+	updated, err := h.queries.RehashUserPassword(ctx, repository.RehashUserPasswordParams{
+		NewPassword: newHash,
+		ID:          user.ID,
+		OldPassword: user.Password,
+	})
+	if err != nil {
+		h.logger.Error("Failed to store rehashed password", "error", err, "userID", user.ID)
+		return
+	}
+
+	h.logger.Info("Password hash upgraded", "userID", user.ID, "updated", updated == 1)
+}
+
+// normalizeEmail lowercases a bare address such as "alice@example.com". An
+// empty input is valid, since the email is optional.
+func normalizeEmail(email string) (string, bool) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", true
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", false
+	}
+
+	return strings.ToLower(email), true
+}
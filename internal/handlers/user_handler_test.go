@@ -7,11 +7,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/fortega2/real-time-chat/internal/auth"
 	"github.com/fortega2/real-time-chat/internal/dto"
 	"github.com/fortega2/real-time-chat/internal/handlers"
+	"github.com/fortega2/real-time-chat/internal/ldap/ldaptest"
 	"github.com/fortega2/real-time-chat/internal/logger"
+	"github.com/fortega2/real-time-chat/internal/oidc"
+	"github.com/fortega2/real-time-chat/internal/oidc/oidctest"
 	"github.com/fortega2/real-time-chat/internal/repository"
 	"golang.org/x/crypto/bcrypt"
 
@@ -24,6 +29,10 @@ const (
 	expectedUsernameErrMsg     = "expected username %s, got %s"
 	pathUsers                  = "/users"
 	pathLogin                  = "/login"
+	pathOIDCLogin              = "/api/users/oidc/login"
+	pathOIDCCallback           = "/api/users/oidc/callback"
+	pathOIDCLink               = "/api/users/oidc/link"
+	pathOIDCLinkCallback       = "/api/users/oidc/link/callback"
 	headerContentType          = "Content-Type"
 	mimeApplicationJSON        = "application/json"
 	contentTypeErrFmt          = "expected Content-Type application/json, got %q"
@@ -63,7 +72,7 @@ func TestCreateUser(t *testing.T) {
 				return h, func() { db.Close() }
 			},
 			payload:        map[string]string{"username": "testuser", "password": "password123"},
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusConflict,
 		},
 		{
 			name: "Empty Username",
@@ -85,6 +94,26 @@ func TestCreateUser(t *testing.T) {
 			payload:        map[string]string{"username": "someone", "password": ""},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "With Email",
+			setup: func(t *testing.T) (*handlers.Handler, func()) {
+				db := initializeTestDB(t)
+				h := handlers.NewHandler(getMockLogger(), repository.New(db), db)
+				return h, func() { db.Close() }
+			},
+			payload:        map[string]string{"username": "mailuser", "password": "password123", "email": "mail@example.com"},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "Invalid Email",
+			setup: func(t *testing.T) (*handlers.Handler, func()) {
+				db := initializeTestDB(t)
+				h := handlers.NewHandler(getMockLogger(), repository.New(db), db)
+				return h, func() { db.Close() }
+			},
+			payload:        map[string]string{"username": "mailuser", "password": "password123", "email": "Mail User <mail@example.com>"},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name: "Invalid JSON",
 			setup: func(t *testing.T) (*handlers.Handler, func()) {
@@ -126,6 +155,49 @@ func TestCreateUser(t *testing.T) {
 	}
 }
 
+func TestCreateUserPolicyViolations(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	testCases := []struct {
+		name           string
+		username       string
+		password       string
+		expectedStatus int
+		expectedField  string
+		expectedCode   string
+	}{
+		{"Short Username", "ab", "password123", http.StatusBadRequest, "username", auth.ViolationTooShort},
+		{"Invalid Characters", "new user", "password123", http.StatusBadRequest, "username", auth.ViolationInvalidCharacters},
+		{"Reserved Username", "Admin", "password123", http.StatusBadRequest, "username", auth.ViolationReserved},
+		{"Mixed Scripts", "nеwuser", "password123", http.StatusBadRequest, "username", auth.ViolationMixedScripts},
+		{"Short Password", "newuser", "short", http.StatusBadRequest, "password", auth.ViolationTooShort},
+		{"Password Equals Username", "newuser1", "NewUser1", http.StatusBadRequest, "password", auth.ViolationMatchesUsername},
+		{"Taken In Another Case", "TestUser", "password123", http.StatusConflict, "username", auth.ViolationTaken},
+		{"Taken By Fullwidth Lookalike", "ｔｅｓｔｕｓｅｒ", "password123", http.StatusConflict, "username", auth.ViolationTaken},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]string{"username": tc.username, "password": tc.password})
+			w := httptest.NewRecorder()
+			h.CreateUser(w, httptest.NewRequest(http.MethodPost, pathUsers, bytes.NewBuffer(body)))
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf(expectedStatusErrMsg, tc.expectedStatus, w.Code)
+			}
+
+			var resp dto.ValidationErrorDTO
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf(failedToDecodeResponseBody, err)
+			}
+			if len(resp.Fields) != 1 || resp.Fields[0].Field != tc.expectedField || resp.Fields[0].Code != tc.expectedCode {
+				t.Errorf("expected %s/%s, got %+v", tc.expectedField, tc.expectedCode, resp.Fields)
+			}
+		})
+	}
+}
+
 func TestCreateUserInvalidJSONSyntax(t *testing.T) {
 	db := initializeTestDB(t)
 	defer db.Close()
@@ -151,7 +223,7 @@ func TestCreateUserPasswordStoredHashed(t *testing.T) {
 	queries := repository.New(db)
 	h := handlers.NewHandler(getMockLogger(), queries, db)
 
-	body, _ := json.Marshal(map[string]string{"username": "hashuser", "password": "secret"})
+	body, _ := json.Marshal(map[string]string{"username": "hashuser", "password": "correct horse"})
 	req := httptest.NewRequest(http.MethodPost, pathUsers, bytes.NewBuffer(body))
 	req.Header.Set(headerContentType, mimeApplicationJSON)
 	w := httptest.NewRecorder()
@@ -168,11 +240,45 @@ func TestCreateUserPasswordStoredHashed(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to get user: %v", err)
 	}
-	if u.Password == "secret" {
+	if u.Password == "correct horse" {
 		t.Fatal("password stored in plain text")
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("secret")); err != nil {
-		t.Fatalf("invalid bcrypt hash: %v", err)
+	if !strings.HasPrefix(u.Password, "$argon2id$") {
+		t.Fatalf("expected an argon2id hash, got %q", u.Password)
+	}
+	if match, needsRehash := auth.NewPasswordHasher(auth.DefaultPasswordParams).Verify(u.Password, "correct horse"); !match || needsRehash {
+		t.Fatalf("invalid argon2id hash: match=%v needsRehash=%v", match, needsRehash)
+	}
+}
+
+func TestLoginUserUpgradesLegacyHash(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	before, err := queries.GetUserByUsername(context.Background(), "testuser")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	if !strings.HasPrefix(before.Password, "$2") {
+		t.Fatalf("expected the test user to start with a bcrypt hash, got %q", before.Password)
+	}
+
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusOK)
+
+	after, err := queries.GetUserByUsername(context.Background(), "testuser")
+	if err != nil {
+		t.Fatalf("failed to get user: %v", err)
+	}
+	if !strings.HasPrefix(after.Password, "$argon2id$") {
+		t.Fatalf("expected the hash to be upgraded to argon2id, got %q", after.Password)
+	}
+
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusOK)
+	attemptLogin(t, h, "testuser", "wrong", "192.0.2.1", http.StatusUnauthorized)
+
+	if final, _ := queries.GetUserByUsername(context.Background(), "testuser"); final.Password != after.Password {
+		t.Error("expected a current hash not to be rehashed again")
 	}
 }
 
@@ -186,7 +292,7 @@ func TestLoginUser(t *testing.T) {
 		expectedStatus int
 	}{
 		{"Successful Login", map[string]string{"username": "testuser", "password": "password123"}, http.StatusOK},
-		{"User Not Found", map[string]string{"username": "nonexistent", "password": "password123"}, http.StatusNotFound},
+		{"User Not Found", map[string]string{"username": "nonexistent", "password": "password123"}, http.StatusUnauthorized},
 		{"Invalid Password", map[string]string{"username": "testuser", "password": "wrongpassword"}, http.StatusUnauthorized},
 		{"Empty Payload", map[string]string{}, http.StatusBadRequest},
 	}
@@ -236,23 +342,422 @@ func TestLoginUserInvalidJSONSyntax(t *testing.T) {
 	}
 }
 
+func TestLoginUserProvisionsLDAPUser(t *testing.T) {
+	db, _ := setupUserTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	dir := ldaptest.NewDirectory(t, ldaptest.Entry{
+		DN:         "uid=carol,ou=people,dc=example,dc=com",
+		Password:   "directory-pass",
+		Attributes: map[string][]string{"uid": {"carol"}, "mail": {"carol@example.com"}},
+	})
+	chain := auth.NewChain(
+		auth.NewLDAPAuthenticator(auth.LDAPConfig{URL: dir.URL(), BaseDN: "dc=example,dc=com"}),
+		auth.NewLocalAuthenticator(queries, auth.NewPasswordHasher(auth.DefaultPasswordParams)),
+	)
+	h := handlers.NewHandler(getMockLogger(), queries, db, handlers.WithAuthenticator(chain))
+
+	first := attemptLogin(t, h, "carol", "directory-pass", "192.0.2.1", http.StatusOK)
+	checkSuccessfulLoginResponse(t, first.Body, "carol")
+
+	carol, err := queries.GetUserByUsername(context.Background(), "carol")
+	if err != nil {
+		t.Fatalf("expected LDAP user to be provisioned: %v", err)
+	}
+	if carol.Email.String != "carol@example.com" {
+		t.Errorf("expected directory email to be stored, got %q", carol.Email.String)
+	}
+
+	var identities int
+	if err := db.QueryRow("SELECT COUNT(*) FROM user_identities WHERE provider = 'ldap' AND user_id = ?", carol.ID).Scan(&identities); err != nil {
+		t.Fatalf("failed to count linked accounts: %v", err)
+	}
+	if identities != 1 {
+		t.Errorf("expected 1 linked account, got %d", identities)
+	}
+
+	// The second login reuses the provisioned user, and local accounts still
+	// work behind the directory.
+	attemptLogin(t, h, "carol", "directory-pass", "192.0.2.1", http.StatusOK)
+	attemptLogin(t, h, "testuser", "password123", "192.0.2.1", http.StatusOK)
+	attemptLogin(t, h, "carol", "wrong", "192.0.2.1", http.StatusUnauthorized)
+
+	var users int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&users); err != nil {
+		t.Fatalf("failed to count users: %v", err)
+	}
+	if users != 2 {
+		t.Errorf("expected 2 users, got %d", users)
+	}
+}
+
+func TestCreateUserDisabledWithoutLocalProvider(t *testing.T) {
+	db := initializeTestDB(t)
+	defer db.Close()
+
+	ldapOnly := auth.NewLDAPAuthenticator(auth.LDAPConfig{URL: "ldap://127.0.0.1:1", BaseDN: "dc=example,dc=com"})
+	h := handlers.NewHandler(getMockLogger(), repository.New(db), db, handlers.WithAuthenticator(ldapOnly))
+
+	body, _ := json.Marshal(map[string]string{"username": "mallory", "password": "password123"})
+	w := httptest.NewRecorder()
+	h.CreateUser(w, httptest.NewRequest(http.MethodPost, pathUsers, bytes.NewBuffer(body)))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf(expectedStatusErrMsg, http.StatusForbidden, w.Code)
+	}
+}
+
+func TestOIDCLogin(t *testing.T) {
+	db, fake, h := setupOIDCTest(t)
+	defer db.Close()
+
+	fake.SetUser(oidctest.User{Subject: "alice-1", Email: "Alice@Example.com", EmailVerified: true, PreferredUsername: "alice"})
+
+	first := completeTestOIDCLogin(t, h, fake)
+	if first.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, first.Code)
+	}
+	var firstLogin dto.LoginResponseDTO
+	if err := json.NewDecoder(first.Body).Decode(&firstLogin); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if firstLogin.User.Username != "alice" {
+		t.Errorf(expectedUsernameErrMsg, "alice", firstLogin.User.Username)
+	}
+
+	user, err := repository.New(db).GetUserByUsername(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("expected user to be created: %v", err)
+	}
+	if user.Email.String != "alice@example.com" {
+		t.Errorf("expected verified email to be stored, got %q", user.Email.String)
+	}
+
+	second := completeTestOIDCLogin(t, h, fake)
+	if second.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, second.Code)
+	}
+	var secondLogin dto.LoginResponseDTO
+	if err := json.NewDecoder(second.Body).Decode(&secondLogin); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if secondLogin.User.ID != firstLogin.User.ID {
+		t.Errorf("expected the linked user %v, got %v", firstLogin.User.ID, secondLogin.User.ID)
+	}
+}
+
+func TestOIDCLoginDoesNotTakeOverLocalAccount(t *testing.T) {
+	db, fake, h := setupOIDCTest(t)
+	defer db.Close()
+
+	fake.SetUser(oidctest.User{Subject: "intruder", PreferredUsername: "testuser"})
+
+	w := completeTestOIDCLogin(t, h, fake)
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+
+	var login dto.LoginResponseDTO
+	if err := json.NewDecoder(w.Body).Decode(&login); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if !strings.HasPrefix(login.User.Username, "testuser-") {
+		t.Errorf("expected a new suffixed username, got %s", login.User.Username)
+	}
+
+	// Accounts created through single sign-on have no usable password.
+	attemptLogin(t, h, login.User.Username, "x", "192.0.2.1", http.StatusUnauthorized)
+}
+
+func TestOIDCCallbackRejectsForeignState(t *testing.T) {
+	db, fake, h := setupOIDCTest(t)
+	defer db.Close()
+
+	start := httptest.NewRecorder()
+	h.StartOIDCLogin(start, httptest.NewRequest(http.MethodGet, pathOIDCLogin, nil))
+	code, state := fake.Authorize(t, start.Header().Get("Location"))
+	cookie := start.Result().Cookies()[0]
+
+	testCases := []struct {
+		name   string
+		cookie *http.Cookie
+		state  string
+	}{
+		{"Missing Cookie", nil, state},
+		{"Cookie From Another Login", &http.Cookie{Name: cookie.Name, Value: "other"}, state},
+		{"Unknown State", &http.Cookie{Name: cookie.Name, Value: "unknown"}, "unknown"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := postTestOIDCCallback(h, tc.cookie, code, tc.state); w.Code != http.StatusBadRequest {
+				t.Errorf(expectedStatusErrMsg, http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+
+	if w := postTestOIDCCallback(h, cookie, code, state); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	if w := postTestOIDCCallback(h, cookie, code, state); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a replayed callback to fail, got %v", w.Code)
+	}
+}
+
+func TestOIDCNotConfigured(t *testing.T) {
+	db, h := setupUserTest(t)
+	defer db.Close()
+
+	w := httptest.NewRecorder()
+	h.StartOIDCLogin(w, httptest.NewRequest(http.MethodGet, pathOIDCLogin, nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf(expectedStatusErrMsg, http.StatusNotFound, w.Code)
+	}
+}
+
+func TestOIDCLinkAccount(t *testing.T) {
+	db, fake, h := setupOIDCTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	login := loginTestUser(t, h)
+	session := sessionFromLogin(t, queries, login)
+	fake.SetUser(oidctest.User{Subject: "corp-1", Email: "test@example.com", EmailVerified: true, PreferredUsername: "testuser"})
+
+	w := completeTestOIDCLink(t, h, fake, session)
+	if w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+	var linked dto.LinkedIdentityDTO
+	if err := json.NewDecoder(w.Body).Decode(&linked); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if linked.Provider == "" || linked.Email != "test@example.com" {
+		t.Errorf("unexpected linked identity: %+v", linked)
+	}
+
+	// Single sign-on now logs into the existing account.
+	sso := completeTestOIDCLogin(t, h, fake)
+	if sso.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, sso.Code)
+	}
+	var ssoLogin dto.LoginResponseDTO
+	if err := json.NewDecoder(sso.Body).Decode(&ssoLogin); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+	if ssoLogin.User.ID != login.User.ID || ssoLogin.User.Username != "testuser" {
+		t.Errorf("expected to log in as %+v, got %+v", login.User, ssoLogin.User)
+	}
+
+	// Linking the same account again changes nothing.
+	if w := completeTestOIDCLink(t, h, fake, session); w.Code != http.StatusOK {
+		t.Errorf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM user_identities WHERE user_id = ?", login.User.ID).Scan(&count); err != nil {
+		t.Fatalf("failed to count identities: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 linked identity, got %d", count)
+	}
+}
+
+func TestOIDCLinkAccountLinkedElsewhere(t *testing.T) {
+	db, fake, h := setupOIDCTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	fake.SetUser(oidctest.User{Subject: "alice-1", PreferredUsername: "alice"})
+	if w := completeTestOIDCLogin(t, h, fake); w.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, w.Code)
+	}
+
+	session := sessionFromLogin(t, queries, loginTestUser(t, h))
+	if w := completeTestOIDCLink(t, h, fake, session); w.Code != http.StatusConflict {
+		t.Errorf(expectedStatusErrMsg, http.StatusConflict, w.Code)
+	}
+}
+
+func TestOIDCFlowKindsAreNotInterchangeable(t *testing.T) {
+	db, fake, h := setupOIDCTest(t)
+	defer db.Close()
+	queries := repository.New(db)
+
+	session := sessionFromLogin(t, queries, loginTestUser(t, h))
+	other, err := queries.CreateUser(context.Background(), repository.CreateUserParams{Username: "other", Password: "hash"})
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	fake.SetUser(oidctest.User{Subject: "corp-1", PreferredUsername: "corp"})
+
+	t.Run("Link Flow At Login Callback", func(t *testing.T) {
+		cookie, code, state := startTestOIDCLink(t, h, fake, session)
+		if w := postTestOIDCCallback(h, cookie, code, state); w.Code != http.StatusBadRequest {
+			t.Errorf(expectedStatusErrMsg, http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Login Flow At Link Callback", func(t *testing.T) {
+		start := httptest.NewRecorder()
+		h.StartOIDCLogin(start, httptest.NewRequest(http.MethodGet, pathOIDCLogin, nil))
+		code, state := fake.Authorize(t, start.Header().Get("Location"))
+		if w := postTestOIDCLinkCallback(h, session, start.Result().Cookies()[0], code, state); w.Code != http.StatusBadRequest {
+			t.Errorf(expectedStatusErrMsg, http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Link Flow Of Another User", func(t *testing.T) {
+		cookie, code, state := startTestOIDCLink(t, h, fake, session)
+		otherSession := repository.Session{ID: session.ID + 1, UserID: other.ID}
+		if w := postTestOIDCLinkCallback(h, otherSession, cookie, code, state); w.Code != http.StatusBadRequest {
+			t.Errorf(expectedStatusErrMsg, http.StatusBadRequest, w.Code)
+		}
+	})
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM user_identities").Scan(&count); err != nil {
+		t.Fatalf("failed to count identities: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected no linked identities, got %d", count)
+	}
+}
+
 func initializeTestDB(t *testing.T) *sql.DB {
 	t.Helper()
 	db, err := sql.Open("sqlite3", ":memory:")
 	if err != nil {
 		t.Fatalf("Failed to open in-memory database: %v", err)
 	}
+	// Every connection to :memory: is a separate database, and reset codes are
+	// mailed from another goroutine.
+	db.SetMaxOpenConns(1)
 
 	createTableSQL := `
     CREATE TABLE IF NOT EXISTS users (
         id INTEGER PRIMARY KEY AUTOINCREMENT,
         username TEXT NOT NULL UNIQUE,
         password TEXT NOT NULL,
-        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        email TEXT UNIQUE,
+        username_key TEXT UNIQUE,
+        display_name TEXT,
+        bio TEXT,
+        avatar_url TEXT,
+        color VARCHAR(7),
+        role TEXT NOT NULL DEFAULT 'user',
+        disabled_at TIMESTAMP
     );`
 	if _, err := db.Exec(createTableSQL); err != nil {
 		t.Fatalf("Failed to create users table: %v", err)
 	}
+
+	createSessionsTableSQL := `
+    CREATE TABLE IF NOT EXISTS sessions (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id INTEGER NOT NULL,
+        refresh_token_hash TEXT NOT NULL UNIQUE,
+        expires_at TIMESTAMP NOT NULL,
+        revoked_at TIMESTAMP,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        device_name TEXT,
+        user_agent TEXT,
+        ip_address TEXT,
+        last_seen_at TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );`
+	if _, err := db.Exec(createSessionsTableSQL); err != nil {
+		t.Fatalf("Failed to create sessions table: %v", err)
+	}
+
+	createLoginThrottlesTableSQL := `
+    CREATE TABLE IF NOT EXISTS login_throttles (
+        scope TEXT NOT NULL,
+        subject TEXT NOT NULL,
+        failures INTEGER NOT NULL DEFAULT 0,
+        locked_until TIMESTAMP,
+        last_failure_at TIMESTAMP NOT NULL,
+        PRIMARY KEY (scope, subject)
+    );`
+	if _, err := db.Exec(createLoginThrottlesTableSQL); err != nil {
+		t.Fatalf("Failed to create login_throttles table: %v", err)
+	}
+
+	createAuditEventsTableSQL := `
+    CREATE TABLE IF NOT EXISTS audit_events (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        action TEXT NOT NULL,
+        actor_id INTEGER,
+        subject TEXT NOT NULL,
+        ip_address TEXT,
+        details TEXT,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );`
+	if _, err := db.Exec(createAuditEventsTableSQL); err != nil {
+		t.Fatalf("Failed to create audit_events table: %v", err)
+	}
+
+	createPasswordResetCodesTableSQL := `
+    CREATE TABLE IF NOT EXISTS password_reset_codes (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id INTEGER NOT NULL,
+        code_hash TEXT NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        used_at TIMESTAMP,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );`
+	if _, err := db.Exec(createPasswordResetCodesTableSQL); err != nil {
+		t.Fatalf("Failed to create password_reset_codes table: %v", err)
+	}
+
+	createTOTPTablesSQL := `
+    CREATE TABLE IF NOT EXISTS user_totp (
+        user_id INTEGER PRIMARY KEY,
+        secret_ciphertext TEXT NOT NULL,
+        enabled_at TIMESTAMP,
+        last_used_step INTEGER NOT NULL DEFAULT 0,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+    CREATE TABLE IF NOT EXISTS user_recovery_codes (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id INTEGER NOT NULL,
+        code_hash TEXT NOT NULL,
+        used_at TIMESTAMP,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );`
+	if _, err := db.Exec(createTOTPTablesSQL); err != nil {
+		t.Fatalf("Failed to create two-factor tables: %v", err)
+	}
+
+	createOIDCTablesSQL := `
+    CREATE TABLE IF NOT EXISTS user_identities (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id INTEGER NOT NULL,
+        provider TEXT NOT NULL,
+        subject TEXT NOT NULL,
+        email TEXT,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        last_login_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+        UNIQUE (provider, subject)
+    );
+    CREATE TABLE IF NOT EXISTS oidc_login_flows (
+        state_hash TEXT PRIMARY KEY,
+        nonce TEXT NOT NULL,
+        code_verifier TEXT NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        link_user_id INTEGER
+    );`
+	if _, err := db.Exec(createOIDCTablesSQL); err != nil {
+		t.Fatalf("Failed to create OIDC tables: %v", err)
+	}
 	return db
 }
 
@@ -273,8 +778,9 @@ func setupUserTest(t *testing.T) (*sql.DB, *handlers.Handler) {
 		t.Fatalf("Failed to hash password: %v", err)
 	}
 	_, err = queries.CreateUser(context.Background(), repository.CreateUserParams{
-		Username: "testuser",
-		Password: string(hashedPassword),
+		Username:    "testuser",
+		Password:    string(hashedPassword),
+		UsernameKey: sql.NullString{String: "testuser", Valid: true},
 	})
 	if err != nil {
 		t.Fatalf("Failed to create user for test: %v", err)
@@ -299,14 +805,90 @@ func checkSuccessfulCreateUserResponse(t *testing.T, body *bytes.Buffer, usernam
 
 func checkSuccessfulLoginResponse(t *testing.T, body *bytes.Buffer, username string) {
 	t.Helper()
-	var userDto dto.UserDTO
-	if err := json.NewDecoder(body).Decode(&userDto); err != nil {
+	var loginDto dto.LoginResponseDTO
+	if err := json.NewDecoder(body).Decode(&loginDto); err != nil {
 		t.Fatalf(failedToDecodeResponseBody, err)
 	}
-	if userDto.Username != username {
-		t.Errorf(expectedUsernameErrMsg, username, userDto.Username)
+	if loginDto.User.Username != username {
+		t.Errorf(expectedUsernameErrMsg, username, loginDto.User.Username)
 	}
-	if userDto.ID == 0 {
+	if loginDto.User.ID == 0 {
 		t.Error("expected user ID to be non-zero")
 	}
+	if loginDto.AccessToken == "" {
+		t.Error("expected access token to be non-empty")
+	}
+	if loginDto.TokenType != "Bearer" {
+		t.Errorf("expected token type Bearer, got %s", loginDto.TokenType)
+	}
+}
+
+func setupOIDCTest(t *testing.T) (*sql.DB, *oidctest.Provider, *handlers.Handler) {
+	t.Helper()
+	db, _ := setupUserTest(t)
+	fake := oidctest.NewProvider(t)
+
+	provider := oidc.NewProvider(fake.Config("http://app.example/login/oidc"), nil)
+	h := handlers.NewHandler(getMockLogger(), repository.New(db), db, handlers.WithOIDCProvider(provider))
+
+	return db, fake, h
+}
+
+func completeTestOIDCLogin(t *testing.T, h *handlers.Handler, fake *oidctest.Provider) *httptest.ResponseRecorder {
+	t.Helper()
+	start := httptest.NewRecorder()
+	h.StartOIDCLogin(start, httptest.NewRequest(http.MethodGet, pathOIDCLogin, nil))
+
+	if start.Code != http.StatusFound {
+		t.Fatalf(expectedStatusErrMsg, http.StatusFound, start.Code)
+	}
+
+	code, state := fake.Authorize(t, start.Header().Get("Location"))
+	return postTestOIDCCallback(h, start.Result().Cookies()[0], code, state)
+}
+
+func postTestOIDCCallback(h *handlers.Handler, cookie *http.Cookie, code, state string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"code": code, "state": state})
+	req := httptest.NewRequest(http.MethodPost, pathOIDCCallback, bytes.NewBuffer(body))
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+
+	h.CompleteOIDCLogin(w, req)
+	return w
+}
+
+func startTestOIDCLink(t *testing.T, h *handlers.Handler, fake *oidctest.Provider, session repository.Session) (*http.Cookie, string, string) {
+	t.Helper()
+	start := httptest.NewRecorder()
+	h.StartOIDCLink(start, withSession(httptest.NewRequest(http.MethodPost, pathOIDCLink, nil), session))
+
+	if start.Code != http.StatusOK {
+		t.Fatalf(expectedStatusErrMsg, http.StatusOK, start.Code)
+	}
+
+	var link dto.OIDCLinkDTO
+	if err := json.NewDecoder(start.Body).Decode(&link); err != nil {
+		t.Fatalf(failedToDecodeResponseBody, err)
+	}
+
+	code, state := fake.Authorize(t, link.AuthorizationURL)
+	return start.Result().Cookies()[0], code, state
+}
+
+func completeTestOIDCLink(t *testing.T, h *handlers.Handler, fake *oidctest.Provider, session repository.Session) *httptest.ResponseRecorder {
+	t.Helper()
+	cookie, code, state := startTestOIDCLink(t, h, fake, session)
+	return postTestOIDCLinkCallback(h, session, cookie, code, state)
+}
+
+func postTestOIDCLinkCallback(h *handlers.Handler, session repository.Session, cookie *http.Cookie, code, state string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"code": code, "state": state})
+	req := withSession(httptest.NewRequest(http.MethodPost, pathOIDCLinkCallback, bytes.NewBuffer(body)), session)
+	req.AddCookie(cookie)
+	w := httptest.NewRecorder()
+
+	h.CompleteOIDCLink(w, req)
+	return w
 }
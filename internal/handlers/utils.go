@@ -1,10 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"net/http"
+	"strings"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/dto"
+	"github.com/fortega2/real-time-chat/internal/repository"
 )
 
+const failedEncodeValidationErrMsg = "Failed to encode validation errors"
+
 func setContentTypeJSON(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
 }
@@ -16,3 +25,45 @@ func respondWithJSON(w http.ResponseWriter, statusCode int, payload any, errEnco
 		http.Error(w, errEncodeMsg, http.StatusInternalServerError)
 	}
 }
+
+// respondWithViolations rejects a request with one entry per broken rule, so
+// clients can show each message next to its field.
+func respondWithViolations(w http.ResponseWriter, statusCode int, violations []auth.Violation) {
+	fields := make([]dto.FieldErrorDTO, len(violations))
+	for i, v := range violations {
+		fields[i] = dto.FieldErrorDTO{Field: v.Field, Code: v.Code, Message: v.Message}
+	}
+
+	respondWithJSON(w, statusCode, dto.NewValidationErrorDTO("Validation failed", fields), failedEncodeValidationErrMsg)
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullInt64(n int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: n, Valid: n != 0}
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.ToValidUTF8(s[:max], "")
+}
+
+// withTx runs fn with queries bound to a transaction, committing when fn
+// returns nil and rolling back otherwise.
+func (h *Handler) withTx(ctx context.Context, fn func(q *repository.Queries) error) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(h.queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,223 @@
+// Package ldaptest provides an in-process LDAP directory for tests. It answers
+// simple binds and equality searches, which is all the LDAP authenticator
+// sends.
+package ldaptest
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+var errMalformedRequest = errors.New("ldaptest: malformed request")
+
+type Entry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+type Directory struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	entries []Entry
+	binds   int
+}
+
+// NewDirectory starts a directory that is shut down when the test ends.
+func NewDirectory(t testing.TB, entries ...Entry) *Directory {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ldaptest: listen: %v", err)
+	}
+
+	d := &Directory{listener: listener, entries: entries}
+	go d.serve()
+	t.Cleanup(func() { listener.Close() })
+
+	return d
+}
+
+func (d *Directory) URL() string {
+	return "ldap://" + d.listener.Addr().String()
+}
+
+// Binds returns the number of bind requests received so far.
+func (d *Directory) Binds() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.binds
+}
+
+func (d *Directory) serve() {
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+		go d.handle(conn)
+	}
+}
+
+func (d *Directory) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		msg, err := ber.ReadPacket(conn)
+		if err != nil || len(msg.Children) < 2 {
+			return
+		}
+
+		id, ok := msg.Children[0].Value.(int64)
+		if !ok {
+			return
+		}
+
+		op := msg.Children[1]
+		if op.ClassType != ber.ClassApplication {
+			return
+		}
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			d.reply(conn, id, result(ldap.ApplicationBindResponse, d.bind(op)))
+		case ldap.ApplicationSearchRequest:
+			code := int64(ldap.LDAPResultSuccess)
+			entries, err := d.search(op)
+			if err != nil {
+				code = ldap.LDAPResultProtocolError
+			}
+			for _, entry := range entries {
+				d.reply(conn, id, entry)
+			}
+			d.reply(conn, id, result(ldap.ApplicationSearchResultDone, code))
+		default:
+			// Unbind, or a request this directory does not implement.
+			return
+		}
+	}
+}
+
+func (d *Directory) bind(op *ber.Packet) int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.binds++
+
+	if len(op.Children) < 3 {
+		return ldap.LDAPResultProtocolError
+	}
+	dn, password := op.Children[1].Data.String(), op.Children[2].Data.String()
+
+	if dn == "" && password == "" {
+		return ldap.LDAPResultSuccess
+	}
+	for _, entry := range d.entries {
+		if strings.EqualFold(entry.DN, dn) && entry.Password != "" && entry.Password == password {
+			return ldap.LDAPResultSuccess
+		}
+	}
+	return ldap.LDAPResultInvalidCredentials
+}
+
+func (d *Directory) search(op *ber.Packet) ([]*ber.Packet, error) {
+	if len(op.Children) < 8 {
+		return nil, errMalformedRequest
+	}
+	base, filter, requested := op.Children[0].Data.String(), op.Children[6], op.Children[7]
+	if filter.ClassType != ber.ClassContext || filter.Tag != ldap.FilterEqualityMatch || len(filter.Children) != 2 {
+		return nil, errMalformedRequest
+	}
+
+	attr, value := filter.Children[0].Data.String(), filter.Children[1].Data.String()
+	suffix := "," + strings.ToLower(base)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var results []*ber.Packet
+	for _, entry := range d.entries {
+		dn := strings.ToLower(entry.DN)
+		if dn != strings.ToLower(base) && !strings.HasSuffix(dn, suffix) {
+			continue
+		}
+		if !hasValue(entry, attr, value) {
+			continue
+		}
+		results = append(results, encodeEntry(entry, requested.Children))
+	}
+	return results, nil
+}
+
+func (d *Directory) reply(conn net.Conn, id int64, op *ber.Packet) {
+	msg := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "Message ID"))
+	msg.AppendChild(op)
+	conn.Write(msg.Bytes())
+}
+
+func result(tag ber.Tag, code int64) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	op.AppendChild(octetString("", "Matched DN"))
+	op.AppendChild(octetString("", "Diagnostic Message"))
+	return op
+}
+
+func hasValue(entry Entry, attr, value string) bool {
+	for name, values := range entry.Attributes {
+		if !strings.EqualFold(name, attr) {
+			continue
+		}
+		for _, v := range values {
+			if strings.EqualFold(v, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func encodeEntry(entry Entry, requested []*ber.Packet) *ber.Packet {
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range entry.Attributes {
+		if !isRequested(name, requested) {
+			continue
+		}
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, v := range values {
+			set.AppendChild(octetString(v, "Value"))
+		}
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attribute.AppendChild(octetString(name, "Type"))
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	op.AppendChild(octetString(entry.DN, "DN"))
+	op.AppendChild(attributes)
+	return op
+}
+
+func isRequested(name string, requested []*ber.Packet) bool {
+	if len(requested) == 0 {
+		return true
+	}
+	for _, r := range requested {
+		if strings.EqualFold(r.Data.String(), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func octetString(s, description string) *ber.Packet {
+	return ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, s, description)
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"strconv"
+)
+
+const defaultSMTPPort = 587
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional mail such as password reset codes.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv returns an SMTP mailer when SMTP_HOST is set. Otherwise mail goes to
+// an outbox in MAIL_OUTBOX_DIR, or stays in memory when that is unset too.
+func FromEnv() Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return NewOutbox(os.Getenv("MAIL_OUTBOX_DIR"))
+	}
+
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil || port <= 0 {
+		port = defaultSMTPPort
+	}
+
+	return NewSMTPMailer(SMTPConfig{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	})
+}
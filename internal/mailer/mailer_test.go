@@ -0,0 +1,64 @@
+package mailer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/mailer"
+)
+
+func TestOutboxSend(t *testing.T) {
+	dir := t.TempDir()
+	outbox := mailer.NewOutbox(dir)
+
+	msg := mailer.Message{To: "alice@example.com", Subject: "Hello", Body: "line one\nline two"}
+	if err := outbox.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	messages := outbox.Messages()
+	if len(messages) != 1 || messages[0] != msg {
+		t.Fatalf("expected the sent message in the outbox, got %+v", messages)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected 1 .eml file, got %v (%v)", files, err)
+	}
+
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("failed to read .eml file: %v", err)
+	}
+	for _, want := range []string{"To: alice@example.com\r\n", "Subject: Hello\r\n", "line one\r\nline two"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected %q in message, got %q", want, content)
+		}
+	}
+}
+
+func TestOutboxRejectsHeaderInjection(t *testing.T) {
+	outbox := mailer.NewOutbox(t.TempDir())
+
+	err := outbox.Send(context.Background(), mailer.Message{To: "alice@example.com\r\nBcc: eve@example.com", Subject: "Hi"})
+	if err != mailer.ErrInvalidHeader {
+		t.Fatalf("expected ErrInvalidHeader, got %v", err)
+	}
+	if len(outbox.Messages()) != 0 {
+		t.Error("expected rejected message not to be stored")
+	}
+}
+
+func TestOutboxInMemory(t *testing.T) {
+	outbox := mailer.NewOutbox("")
+
+	if err := outbox.Send(context.Background(), mailer.Message{To: "bob@example.com", Subject: "Hi"}); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if len(outbox.Messages()) != 1 {
+		t.Errorf("expected 1 message, got %d", len(outbox.Messages()))
+	}
+}
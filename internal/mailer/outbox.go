@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Outbox keeps every sent message in memory and, when dir is set, also writes
+// it as an .eml file there. It stands in for SMTP in local development and
+// tests.
+type Outbox struct {
+	dir string
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewOutbox(dir string) *Outbox {
+	return &Outbox{
+		dir: dir,
+	}
+}
+
+func (o *Outbox) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	body, err := formatMessage("no-reply@localhost", msg, now)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.dir != "" {
+		if err := os.MkdirAll(o.dir, 0o755); err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%s-%03d.eml", now.UTC().Format("20060102T150405"), len(o.messages)+1)
+		if err := os.WriteFile(filepath.Join(o.dir, name), body, 0o600); err != nil {
+			return err
+		}
+	}
+
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of everything sent so far, oldest first.
+func (o *Outbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([]Message(nil), o.messages...)
+}
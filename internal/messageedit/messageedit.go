@@ -0,0 +1,98 @@
+// Package messageedit changes the content of stored messages. The REST
+// handlers and the WebSocket clients both go through Edit, so an edit follows
+// the same rules and leaves the same revision whichever way it comes in.
+package messageedit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/permission"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+// ContentMaxLength is the longest message, in characters.
+const ContentMaxLength = 512
+
+// ErrNotFound is returned for a message that does not exist in the channel.
+var ErrNotFound = errors.New("message not found")
+
+// Normalize puts content on one line and trims it, the way every message is
+// stored.
+func Normalize(content string) string {
+	return strings.TrimSpace(strings.ReplaceAll(content, "\n", " "))
+}
+
+// CheckContent returns the rules that trimmed message content breaks.
+func CheckContent(content string) []auth.Violation {
+	switch {
+	case content == "":
+		return []auth.Violation{{Field: "content", Code: auth.ViolationRequired,
+			Message: "Message content is required"}}
+	case utf8.RuneCountInString(content) > ContentMaxLength:
+		return []auth.Violation{{Field: "content", Code: auth.ViolationTooLong,
+			Message: fmt.Sprintf("Message must be at most %d characters", ContentMaxLength)}}
+	}
+	return nil
+}
+
+// Edit replaces the content of a message in channelID and keeps the previous
+// content as a revision. content is expected to be trimmed and to pass
+// CheckContent. Authors edit their own messages while they may post;
+// other messages need the EditOthersMessages action. Errors from
+// permission.Authorize are returned as they are. Content that does not change
+// leaves the message and its revisions alone. The returned bool reports
+// whether the message changed, so callers do not announce an edit that did
+// not happen.
+func Edit(ctx context.Context, db *sql.DB, q *repository.Queries, channelID, messageID, editorID int64, content string) (repository.GetMessageByIDRow, bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return repository.GetMessageByIDRow{}, false, err
+	}
+	defer tx.Rollback()
+	qtx := q.WithTx(tx)
+
+	message, err := qtx.GetMessageByID(ctx, messageID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && message.ChannelID != channelID) {
+		return repository.GetMessageByIDRow{}, false, ErrNotFound
+	}
+	if err != nil {
+		return repository.GetMessageByIDRow{}, false, err
+	}
+
+	action := permission.Post
+	if message.UserID != editorID {
+		action = permission.EditOthersMessages
+	}
+	if _, err := permission.Authorize(ctx, qtx, channelID, editorID, action); err != nil {
+		return repository.GetMessageByIDRow{}, false, err
+	}
+
+	if content == message.Content {
+		return message, false, nil
+	}
+
+	if err := qtx.CreateMessageRevision(ctx, repository.CreateMessageRevisionParams{
+		MessageID: message.ID,
+		Content:   message.Content,
+		EditedBy:  editorID,
+	}); err != nil {
+		return repository.GetMessageByIDRow{}, false, err
+	}
+	if err := qtx.UpdateMessageContent(ctx, repository.UpdateMessageContentParams{
+		Content: content,
+		ID:      message.ID,
+	}); err != nil {
+		return repository.GetMessageByIDRow{}, false, err
+	}
+
+	if message, err = qtx.GetMessageByID(ctx, messageID); err != nil {
+		return repository.GetMessageByIDRow{}, false, err
+	}
+	return message, true, tx.Commit()
+}
@@ -0,0 +1,208 @@
+package messageedit_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/database"
+	"github.com/fortega2/real-time-chat/internal/logger"
+	"github.com/fortega2/real-time-chat/internal/messageedit"
+	"github.com/fortega2/real-time-chat/internal/permission"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+// Users and messages seeded by initializeTestDB.
+const (
+	owner     = 1
+	member    = 2
+	moderator = 3
+	outsider  = 4
+
+	general  = 1
+	random   = 2
+	archived = 3
+
+	memberMessage   = 1
+	ownerMessage    = 2
+	randomMessage   = 3
+	archivedMessage = 4
+)
+
+func TestCheckContent(t *testing.T) {
+	testCases := []struct {
+		name         string
+		content      string
+		expectedCode string
+	}{
+		{"Valid", "fixed the typo", ""},
+		{"Empty", "", auth.ViolationRequired},
+		{"At Limit", strings.Repeat("é", messageedit.ContentMaxLength), ""},
+		{"Too Long", strings.Repeat("a", messageedit.ContentMaxLength+1), auth.ViolationTooLong},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := messageedit.CheckContent(tc.content)
+			if tc.expectedCode == "" {
+				if len(violations) != 0 {
+					t.Errorf("expected no violations, got %+v", violations)
+				}
+				return
+			}
+			if len(violations) != 1 || violations[0].Code != tc.expectedCode || violations[0].Field != "content" {
+				t.Errorf("expected a %s violation on content, got %+v", tc.expectedCode, violations)
+			}
+		})
+	}
+}
+
+func TestEdit(t *testing.T) {
+	testCases := []struct {
+		name      string
+		channelID int64
+		messageID int64
+		editorID  int64
+		content   string
+		wantErr   error
+		// wantRevision is false when the message must be left alone.
+		wantRevision bool
+	}{
+		{"Author", general, memberMessage, member, "hello", nil, true},
+		{"Unchanged", general, memberMessage, member, "helo", nil, false},
+		{"Someone Else's", general, ownerMessage, member, "goodbye", permission.ErrForbidden, false},
+		{"Moderator", general, memberMessage, moderator, "hello", nil, true},
+		{"Owner", general, memberMessage, owner, "hello", nil, true},
+		{"Outsider", general, memberMessage, outsider, "hello", permission.ErrNotMember, false},
+		{"Other Channel", general, randomMessage, owner, "moved", messageedit.ErrNotFound, false},
+		{"Unknown", general, 999, owner, "ghost", messageedit.ErrNotFound, false},
+		{"Archived Channel", archived, archivedMessage, owner, "frozen", permission.ErrArchived, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := initializeTestDB(t)
+			q := repository.New(db)
+			ctx := context.Background()
+
+			var before string
+			_ = db.QueryRow("SELECT content FROM messages WHERE id = ?", tc.messageID).Scan(&before)
+
+			edited, changed, err := messageedit.Edit(ctx, db, q, tc.channelID, tc.messageID, tc.editorID, tc.content)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if changed != tc.wantRevision {
+				t.Errorf("expected changed %v, got %v", tc.wantRevision, changed)
+			}
+
+			revisions, err := q.ListMessageRevisions(ctx, tc.messageID)
+			if err != nil {
+				t.Fatalf("failed to list revisions: %v", err)
+			}
+			var content string
+			var editedAt sql.NullTime
+			if err := db.QueryRow("SELECT content, edited_at FROM messages WHERE id = ?", tc.messageID).Scan(&content, &editedAt); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				t.Fatalf("failed to load message: %v", err)
+			}
+
+			if !tc.wantRevision {
+				if len(revisions) != 0 || content != before || editedAt.Valid {
+					t.Errorf("expected the message to be left alone, got %q, edited %v and revisions %+v", content, editedAt.Valid, revisions)
+				}
+				return
+			}
+
+			if content != tc.content || !editedAt.Valid || edited.Content != tc.content || !edited.EditedAt.Valid {
+				t.Errorf("expected the new content to be stored and returned, got %q and %+v", content, edited)
+			}
+			if len(revisions) != 1 || revisions[0].Content != before || revisions[0].EditedBy.Int64 != tc.editorID {
+				t.Errorf("expected one revision of %q by %d, got %+v", before, tc.editorID, revisions)
+			}
+		})
+	}
+}
+
+func TestEditUnchangedAfterEdit(t *testing.T) {
+	db := initializeTestDB(t)
+	q := repository.New(db)
+	ctx := context.Background()
+
+	if _, changed, err := messageedit.Edit(ctx, db, q, general, memberMessage, member, "hello"); err != nil || !changed {
+		t.Fatalf("expected the first edit to change the message, got %v and %v", changed, err)
+	}
+
+	// The message is marked as edited now, which must not count as a change.
+	edited, changed, err := messageedit.Edit(ctx, db, q, general, memberMessage, member, "hello")
+	if err != nil {
+		t.Fatalf("failed to edit: %v", err)
+	}
+	if changed || !edited.EditedAt.Valid || edited.Content != "hello" {
+		t.Errorf("expected the stored message back unchanged, got %v and %+v", changed, edited)
+	}
+}
+
+func TestEditKeepsRevisionsOfDeletedEditor(t *testing.T) {
+	db := initializeTestDB(t)
+	q := repository.New(db)
+	ctx := context.Background()
+
+	if _, _, err := messageedit.Edit(ctx, db, q, general, memberMessage, moderator, "hello"); err != nil {
+		t.Fatalf("failed to edit: %v", err)
+	}
+
+	// Foreign keys are enforced per connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("failed to get a connection: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = ON"); err != nil {
+		t.Fatalf("failed to enable foreign keys: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, "DELETE FROM users WHERE id = ?", moderator); err != nil {
+		t.Fatalf("failed to delete the editor: %v", err)
+	}
+
+	revisions, err := q.ListMessageRevisions(ctx, memberMessage)
+	if err != nil {
+		t.Fatalf("failed to list revisions: %v", err)
+	}
+	if len(revisions) != 1 || revisions[0].Content != "helo" || revisions[0].EditedBy.Valid || revisions[0].EditedByUsername.Valid {
+		t.Errorf("expected the revision to stay without its editor, got %+v", revisions)
+	}
+}
+
+// initializeTestDB migrates a fresh database file. general has an owner, a
+// member and a moderator; outsider belongs to no channel.
+func initializeTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	t.Setenv("DB_NAME", filepath.Join(t.TempDir(), "chat.db"))
+	t.Setenv("DB_MIGRATIONS_PATH", "../database/migrations")
+
+	d, err := database.NewDatabase(logger.NewMockLogger())
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	db := d.GetDB()
+
+	seed := `
+	INSERT INTO users (id, username, password) VALUES
+		(1, 'owner', 'hash1'), (2, 'member', 'hash2'), (3, 'moderator', 'hash3'), (4, 'outsider', 'hash4');
+	INSERT INTO channels (id, name, created_by, archived_at) VALUES
+		(1, 'general', 1, NULL), (2, 'random', 1, NULL), (3, 'archived', 1, CURRENT_TIMESTAMP);
+	INSERT INTO channel_members (channel_id, user_id, role) VALUES
+		(1, 1, 'owner'), (1, 2, 'member'), (1, 3, 'moderator'), (2, 1, 'owner'), (3, 1, 'owner');
+	INSERT INTO messages (id, channel_id, user_id, user_color, content, sequence) VALUES
+		(1, 1, 2, '#000000', 'helo', 1), (2, 1, 1, '#000000', 'welcome', 2),
+		(3, 2, 1, '#000000', 'hi', 1), (4, 3, 1, '#000000', 'old news', 1);`
+	if _, err := db.Exec(seed); err != nil {
+		t.Fatalf("Failed to seed test database: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,20 @@
+package oidc
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ClockSkew          = clockSkew
+	KeyRefreshInterval = keyRefreshInterval
+)
+
+// VerifyIDToken checks an ID token the way Exchange does, at the given time.
+func (p *Provider) VerifyIDToken(ctx context.Context, token, nonce string, now time.Time) (Identity, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+	return p.verifyIDToken(ctx, md, token, nonce, now)
+}
@@ -0,0 +1,198 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/oidc"
+	"github.com/fortega2/real-time-chat/internal/oidc/oidctest"
+)
+
+const testNonce = "test-nonce"
+
+func TestVerifyIDTokenAlgorithms(t *testing.T) {
+	fake := oidctest.NewProvider(t)
+	provider := oidc.NewProvider(fake.Config(testRedirectURL), nil)
+	ctx := context.Background()
+	now := time.Unix(time.Now().Unix(), 0)
+	claims := fake.IDTokenClaims(testNonce, now)
+
+	valid := fake.SignIDToken(t, nil, claims)
+	if _, err := provider.VerifyIDToken(ctx, valid, testNonce, now); err != nil {
+		t.Fatalf("expected a valid token to be accepted, got %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(fake.PublicKey())
+	if err != nil {
+		t.Fatalf("failed to encode public key: %v", err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	parts := strings.Split(valid, ".")
+
+	testCases := []struct {
+		name  string
+		token string
+	}{
+		{"None Without Signature", unsignedToken(t, map[string]any{"alg": "none"}, claims) + "."},
+		{"None With RS256 Signature", unsignedToken(t, map[string]any{"alg": "none"}, claims) + "." + parts[2]},
+		{"Lower Case rs256", fake.SignIDToken(t, map[string]any{"alg": "rs256"}, claims)},
+		{"RS384", fake.SignIDToken(t, map[string]any{"alg": "RS384"}, claims)},
+		{"HS256 With DER Public Key", hmacToken(t, der, claims)},
+		{"HS256 With PEM Public Key", hmacToken(t, publicPEM, claims)},
+		{"HS256 With Modulus", hmacToken(t, fake.PublicKey().N.Bytes(), claims)},
+		{"Claims Changed After Signing", parts[0] + "." + encodeSegment(t, map[string]any{"sub": "admin"}) + "." + parts[2]},
+		{"Missing Signature", parts[0] + "." + parts[1]},
+		{"Signature Not Base64", parts[0] + "." + parts[1] + ".!!!"},
+		{"Header Not JSON", base64.RawURLEncoding.EncodeToString([]byte("{")) + "." + parts[1] + "." + parts[2]},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := provider.VerifyIDToken(ctx, tc.token, testNonce, now); !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Errorf("expected %v, got %v", oidc.ErrInvalidIDToken, err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenKeyRotation(t *testing.T) {
+	fake := oidctest.NewProvider(t)
+	provider := oidc.NewProvider(fake.Config(testRedirectURL), nil)
+	ctx := context.Background()
+	now := time.Unix(time.Now().Unix(), 0)
+	claims := fake.IDTokenClaims(testNonce, now)
+
+	old := fake.SignIDToken(t, nil, claims)
+	for range 2 {
+		if _, err := provider.VerifyIDToken(ctx, old, testNonce, now); err != nil {
+			t.Fatalf("expected the token to be accepted, got %v", err)
+		}
+	}
+	if n := fake.JWKSRequests(); n != 1 {
+		t.Errorf("expected the keys to be downloaded once, got %d downloads", n)
+	}
+
+	// A token without kid is fine while the provider has a single key.
+	if _, err := provider.VerifyIDToken(ctx, fake.SignIDToken(t, map[string]any{"kid": ""}, claims), testNonce, now); err != nil {
+		t.Errorf("expected a token without kid to be accepted, got %v", err)
+	}
+
+	fake.RotateKey(t)
+	rotated := fake.SignIDToken(t, nil, claims)
+
+	// Unknown key IDs do not download the keys again right away.
+	if _, err := provider.VerifyIDToken(ctx, rotated, testNonce, now.Add(time.Second)); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("expected %v before the keys may be refreshed, got %v", oidc.ErrInvalidIDToken, err)
+	}
+	if n := fake.JWKSRequests(); n != 1 {
+		t.Errorf("expected no download before the refresh interval, got %d downloads", n)
+	}
+
+	later := now.Add(oidc.KeyRefreshInterval)
+	if _, err := provider.VerifyIDToken(ctx, rotated, testNonce, later); err != nil {
+		t.Fatalf("expected the new key to be fetched, got %v", err)
+	}
+	if n := fake.JWKSRequests(); n != 2 {
+		t.Errorf("expected the keys to be downloaded again, got %d downloads", n)
+	}
+
+	if _, err := provider.VerifyIDToken(ctx, old, testNonce, later); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("expected a token of the retired key to be rejected, got %v", err)
+	}
+	forged := fake.SignIDToken(t, map[string]any{"kid": "made-up"}, claims)
+	if _, err := provider.VerifyIDToken(ctx, forged, testNonce, later.Add(time.Second)); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("expected an unknown kid to be rejected, got %v", err)
+	}
+	if n := fake.JWKSRequests(); n != 2 {
+		t.Errorf("expected unknown kids not to trigger downloads, got %d downloads", n)
+	}
+}
+
+func TestVerifyIDTokenClaims(t *testing.T) {
+	fake := oidctest.NewProvider(t)
+	provider := oidc.NewProvider(fake.Config(testRedirectURL), nil)
+	ctx := context.Background()
+	issuedAt := time.Unix(time.Now().Unix(), 0)
+	ttl := time.Unix(fake.IDTokenClaims(testNonce, issuedAt)["exp"].(int64), 0).Sub(issuedAt)
+
+	testCases := []struct {
+		name    string
+		tamper  func(claims map[string]any)
+		after   time.Duration
+		wantErr bool
+	}{
+		{"Valid", func(map[string]any) {}, 0, false},
+		{"Other Issuer", func(c map[string]any) { c["iss"] = fake.URL + "/" }, 0, true},
+		{"Other Audience", func(c map[string]any) { c["aud"] = "other-client" }, 0, true},
+		{"Audience Not A String", func(c map[string]any) { c["aud"] = 1 }, 0, true},
+		{"Audience List", func(c map[string]any) {
+			c["aud"] = []string{"other-client", oidctest.ClientID}
+			c["azp"] = oidctest.ClientID
+		}, 0, false},
+		{"Audience List Without azp", func(c map[string]any) { c["aud"] = []string{"other-client", oidctest.ClientID} }, 0, true},
+		{"Audience List For Other azp", func(c map[string]any) {
+			c["aud"] = []string{"other-client", oidctest.ClientID}
+			c["azp"] = "other-client"
+		}, 0, true},
+		{"Single Audience With Own azp", func(c map[string]any) { c["azp"] = oidctest.ClientID }, 0, false},
+		{"Single Audience With Other azp", func(c map[string]any) { c["azp"] = "other-client" }, 0, true},
+		{"Other Nonce", func(c map[string]any) { c["nonce"] = "other-login" }, 0, true},
+		{"Missing Nonce", func(c map[string]any) { delete(c, "nonce") }, 0, true},
+		{"Missing Subject", func(c map[string]any) { delete(c, "sub") }, 0, true},
+		{"Expired Within Clock Skew", func(map[string]any) {}, ttl + oidc.ClockSkew - time.Second, false},
+		{"Expired", func(map[string]any) {}, ttl + oidc.ClockSkew, true},
+		{"Missing Expiry", func(c map[string]any) { delete(c, "exp") }, 0, true},
+		{"Issued Within Clock Skew", func(c map[string]any) { c["iat"] = issuedAt.Add(oidc.ClockSkew).Unix() }, 0, false},
+		{"Issued In The Future", func(c map[string]any) { c["iat"] = issuedAt.Add(oidc.ClockSkew + time.Second).Unix() }, 0, true},
+		{"Email Verified As String", func(c map[string]any) { c["email_verified"] = "true" }, 0, false},
+		{"Email Verified Not A Boolean", func(c map[string]any) { c["email_verified"] = "yes" }, 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := fake.IDTokenClaims(testNonce, issuedAt)
+			tc.tamper(claims)
+
+			_, err := provider.VerifyIDToken(ctx, fake.SignIDToken(t, nil, claims), testNonce, issuedAt.Add(tc.after))
+			if tc.wantErr && !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Errorf("expected %v, got %v", oidc.ErrInvalidIDToken, err)
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("expected the token to be accepted, got %v", err)
+			}
+		})
+	}
+}
+
+func unsignedToken(t *testing.T, header, claims map[string]any) string {
+	t.Helper()
+	return encodeSegment(t, header) + "." + encodeSegment(t, claims)
+}
+
+// hmacToken signs claims with HS256, using key as the shared secret. A
+// verifier that trusts the alg header would check it with the RSA public key.
+func hmacToken(t *testing.T, key []byte, claims map[string]any) string {
+	t.Helper()
+	unsigned := unsignedToken(t, map[string]any{"alg": "HS256", "typ": "JWT", "kid": "test-key"}, claims)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to encode token segment: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
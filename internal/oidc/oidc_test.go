@@ -0,0 +1,127 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/oidc"
+	"github.com/fortega2/real-time-chat/internal/oidc/oidctest"
+)
+
+const testRedirectURL = "http://app.example/login/oidc"
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	fake := oidctest.NewProvider(t)
+	fake.SetUser(oidctest.User{
+		Subject:           "abc123",
+		Email:             "alice@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "alice",
+		Name:              "Alice",
+	})
+	provider := oidc.NewProvider(fake.Config(testRedirectURL), nil)
+	ctx := context.Background()
+
+	req, err := oidc.NewAuthRequest()
+	if err != nil {
+		t.Fatalf("NewAuthRequest failed: %v", err)
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, req)
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+
+	parsed, _ := url.Parse(authURL)
+	if q := parsed.Query(); q.Get("code_challenge") == "" || q.Get("code_challenge") == req.CodeVerifier {
+		t.Errorf("expected an S256 code challenge, got %q", q.Get("code_challenge"))
+	}
+
+	code, state := fake.Authorize(t, authURL)
+	if state != req.State {
+		t.Fatalf("expected state %q, got %q", req.State, state)
+	}
+
+	identity, err := provider.Exchange(ctx, code, req)
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+
+	want := oidc.Identity{Subject: "abc123", Email: "alice@example.com", EmailVerified: true, PreferredUsername: "alice", Name: "Alice"}
+	if identity != want {
+		t.Errorf("expected identity %+v, got %+v", want, identity)
+	}
+
+	if _, err := provider.Exchange(ctx, code, req); !errors.Is(err, oidc.ErrTokenExchange) {
+		t.Errorf("expected a used code to be rejected, got %v", err)
+	}
+}
+
+func TestExchangeRejectsWrongVerifierAndNonce(t *testing.T) {
+	fake := oidctest.NewProvider(t)
+	provider := oidc.NewProvider(fake.Config(testRedirectURL), nil)
+	ctx := context.Background()
+
+	testCases := []struct {
+		name    string
+		tamper  func(req *oidc.AuthRequest)
+		wantErr error
+	}{
+		{"Wrong Code Verifier", func(req *oidc.AuthRequest) { req.CodeVerifier = "stolen-code" }, oidc.ErrTokenExchange},
+		{"Wrong Nonce", func(req *oidc.AuthRequest) { req.Nonce = "other-login" }, oidc.ErrInvalidIDToken},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := oidc.NewAuthRequest()
+			if err != nil {
+				t.Fatalf("NewAuthRequest failed: %v", err)
+			}
+
+			authURL, err := provider.AuthCodeURL(ctx, req)
+			if err != nil {
+				t.Fatalf("AuthCodeURL failed: %v", err)
+			}
+			code, _ := fake.Authorize(t, authURL)
+
+			tc.tamper(&req)
+			if _, err := provider.Exchange(ctx, code, req); !errors.Is(err, tc.wantErr) {
+				t.Errorf("expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	fake := oidctest.NewProvider(t)
+	cfg := fake.Config(testRedirectURL)
+	cfg.Issuer = fake.URL + "/"
+
+	req, _ := oidc.NewAuthRequest()
+	if _, err := oidc.NewProvider(cfg, nil).AuthCodeURL(context.Background(), req); err == nil {
+		t.Error("expected discovery to fail for a different issuer")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("OIDC_ISSUER", "https://idp.example")
+	t.Setenv("OIDC_CLIENT_ID", "chat")
+	t.Setenv("OIDC_REDIRECT_URL", "")
+
+	if _, ok := oidc.ConfigFromEnv(); ok {
+		t.Error("expected config without redirect URL to be incomplete")
+	}
+
+	t.Setenv("OIDC_REDIRECT_URL", testRedirectURL)
+	t.Setenv("OIDC_SCOPES", "openid email")
+
+	cfg, ok := oidc.ConfigFromEnv()
+	if !ok {
+		t.Fatal("expected config to be complete")
+	}
+	if len(cfg.Scopes) != 2 || cfg.Scopes[1] != "email" {
+		t.Errorf("unexpected scopes %v", cfg.Scopes)
+	}
+}
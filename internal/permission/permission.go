@@ -0,0 +1,116 @@
+// Package permission decides what members of a channel may do there. The
+// REST handlers and the WebSocket clients both go through Authorize, so a
+// role change applies to both right away.
+package permission
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+// Role is the role of a member in a channel. Server-wide roles are in the
+// auth package.
+type Role string
+
+const (
+	RoleOwner     Role = "owner"
+	RoleModerator Role = "moderator"
+	RoleMember    Role = "member"
+)
+
+// KindDirect is the kind of the channels that hold direct conversations.
+const KindDirect = "direct"
+
+// Action is something a member does in a channel.
+type Action string
+
+const (
+	Read                 Action = "read"
+	Post                 Action = "post"
+	Invite               Action = "invite"
+	Pin                  Action = "pin"
+	DeleteOthersMessages Action = "delete_others_messages"
+	EditOthersMessages   Action = "edit_others_messages"
+	EditChannel          Action = "edit_channel"
+	ManageInviteLinks    Action = "manage_invite_links"
+	ManageMembers        Action = "manage_members"
+	ArchiveChannel       Action = "archive_channel"
+	TransferOwnership    Action = "transfer_ownership"
+)
+
+var (
+	ErrNotMember = errors.New("not a member of the channel")
+	ErrForbidden = errors.New("channel role does not allow the action")
+	ErrArchived  = errors.New("channel is archived")
+)
+
+var matrix = map[Role]map[Action]bool{
+	RoleOwner: {
+		Read: true, Post: true, Invite: true, Pin: true, DeleteOthersMessages: true,
+		EditOthersMessages: true, EditChannel: true, ManageInviteLinks: true, ManageMembers: true,
+		ArchiveChannel: true, TransferOwnership: true,
+	},
+	RoleModerator: {
+		Read: true, Post: true, Invite: true, Pin: true, DeleteOthersMessages: true,
+		EditOthersMessages: true, EditChannel: true, ManageInviteLinks: true,
+	},
+	RoleMember: {
+		Read: true, Post: true, Invite: true,
+	},
+}
+
+// archivedActions are still allowed in an archived channel, which is read-only
+// until the owner unarchives it. Handing it over stays possible, so an archived
+// channel is never left without someone who can unarchive it.
+var archivedActions = map[Action]bool{
+	Read:              true,
+	ArchiveChannel:    true,
+	TransferOwnership: true,
+}
+
+// directActions are the only ones allowed in a direct conversation. Its
+// participants are fixed, so nobody invites, manages or archives there.
+var directActions = map[Action]bool{
+	Read: true,
+	Post: true,
+}
+
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if _, ok := matrix[role]; !ok {
+		return "", fmt.Errorf("unknown channel role %q", s)
+	}
+	return role, nil
+}
+
+// Can reports whether r allows action. Unknown roles allow nothing.
+func (r Role) Can(action Action) bool {
+	return matrix[r][action]
+}
+
+// Authorize loads the membership of userID in channelID and checks that its
+// role allows action. It returns ErrNotMember when the user is not a member,
+// ErrForbidden when the role or a direct conversation does not allow the
+// action and ErrArchived when the channel is archived and the action would
+// change it.
+func Authorize(ctx context.Context, q *repository.Queries, channelID, userID int64, action Action) (repository.GetChannelMembershipRow, error) {
+	member, err := q.GetChannelMembership(ctx, repository.GetChannelMembershipParams{ChannelID: channelID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.GetChannelMembershipRow{}, ErrNotMember
+	}
+	if err != nil {
+		return repository.GetChannelMembershipRow{}, err
+	}
+
+	if !Role(member.Role).Can(action) || (member.Kind == KindDirect && !directActions[action]) {
+		return member, ErrForbidden
+	}
+	if member.ArchivedAt.Valid && !archivedActions[action] {
+		return member, ErrArchived
+	}
+	return member, nil
+}
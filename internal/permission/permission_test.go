@@ -0,0 +1,58 @@
+package permission_test
+
+import (
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/permission"
+)
+
+func TestRoleCan(t *testing.T) {
+	testCases := []struct {
+		role    permission.Role
+		allowed []permission.Action
+		denied  []permission.Action
+	}{
+		{
+			role:    permission.RoleOwner,
+			allowed: []permission.Action{permission.Post, permission.ManageMembers, permission.ArchiveChannel, permission.TransferOwnership},
+		},
+		{
+			role:    permission.RoleModerator,
+			allowed: []permission.Action{permission.Post, permission.Pin, permission.DeleteOthersMessages, permission.EditOthersMessages, permission.EditChannel, permission.ManageInviteLinks},
+			denied:  []permission.Action{permission.ManageMembers, permission.ArchiveChannel, permission.TransferOwnership},
+		},
+		{
+			role:    permission.RoleMember,
+			allowed: []permission.Action{permission.Read, permission.Post, permission.Invite},
+			denied:  []permission.Action{permission.Pin, permission.DeleteOthersMessages, permission.EditOthersMessages, permission.EditChannel, permission.ManageInviteLinks},
+		},
+		{
+			role:   permission.Role("admin"),
+			denied: []permission.Action{permission.Read, permission.Post},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.role), func(t *testing.T) {
+			for _, action := range tc.allowed {
+				if !tc.role.Can(action) {
+					t.Errorf("expected %s to allow %s", tc.role, action)
+				}
+			}
+			for _, action := range tc.denied {
+				if tc.role.Can(action) {
+					t.Errorf("expected %s not to allow %s", tc.role, action)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	if _, err := permission.ParseRole("admin"); err == nil {
+		t.Error("expected a server-wide role to be rejected")
+	}
+	if role, err := permission.ParseRole("moderator"); err != nil || role != permission.RoleModerator {
+		t.Errorf("expected moderator, got %q, %v", role, err)
+	}
+}
@@ -0,0 +1,131 @@
+package profile
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+const (
+	DisplayNameMaxLength = 64
+	BioMaxLength         = 500
+	AvatarURLMaxLength   = 2048
+)
+
+// Colors is the palette that users get a color from until they choose one.
+var Colors = []string{
+	"#FF6B6B", "#4ECDC4", "#45B7D1", "#96CEB4", "#FFEAA7",
+	"#DDA0DD", "#98D8C8", "#F7DC6F", "#BB8FCE", "#85C1E9",
+	"#F8C471", "#82E0AA", "#F1948A", "#D7DBDD",
+}
+
+// Profile is what other users see of a user besides the username. Empty
+// fields are unset.
+type Profile struct {
+	DisplayName string
+	Bio         string
+	AvatarURL   string
+	Color       string
+}
+
+// Of returns the profile stored on u. The color is never empty: users that
+// did not choose one get a color from the palette based on their ID, so it
+// stays the same across connections.
+func Of(u repository.User) Profile {
+	return Profile{
+		DisplayName: u.DisplayName.String,
+		Bio:         u.Bio.String,
+		AvatarURL:   u.AvatarUrl.String,
+		Color:       ColorOr(u.Color.String, u.ID),
+	}
+}
+
+// ColorOr returns color, or the default color of the user when it is empty.
+func ColorOr(color string, userID int64) string {
+	if color != "" {
+		return color
+	}
+	return DefaultColor(userID)
+}
+
+func DefaultColor(userID int64) string {
+	i := userID % int64(len(Colors))
+	if i < 0 {
+		i = -i
+	}
+	return Colors[i]
+}
+
+// Normalize trims the fields and upper cases the color, so "#ff6b6b" and
+// "#FF6B6B" are stored the same way.
+func Normalize(p Profile) Profile {
+	return Profile{
+		DisplayName: strings.TrimSpace(p.DisplayName),
+		Bio:         strings.TrimSpace(p.Bio),
+		AvatarURL:   strings.TrimSpace(p.AvatarURL),
+		Color:       strings.ToUpper(strings.TrimSpace(p.Color)),
+	}
+}
+
+// Check returns the rules that a normalized profile breaks.
+func Check(p Profile) []auth.Violation {
+	var violations []auth.Violation
+
+	switch {
+	case utf8.RuneCountInString(p.DisplayName) > DisplayNameMaxLength:
+		violations = append(violations, auth.Violation{Field: "displayName", Code: auth.ViolationTooLong,
+			Message: fmt.Sprintf("Display name must be at most %d characters", DisplayNameMaxLength)})
+	case strings.ContainsFunc(p.DisplayName, unicode.IsControl):
+		violations = append(violations, auth.Violation{Field: "displayName", Code: auth.ViolationInvalidCharacters,
+			Message: "Display name must not contain control characters"})
+	}
+
+	switch {
+	case utf8.RuneCountInString(p.Bio) > BioMaxLength:
+		violations = append(violations, auth.Violation{Field: "bio", Code: auth.ViolationTooLong,
+			Message: fmt.Sprintf("Bio must be at most %d characters", BioMaxLength)})
+	case strings.ContainsFunc(p.Bio, func(r rune) bool { return unicode.IsControl(r) && r != '\n' }):
+		violations = append(violations, auth.Violation{Field: "bio", Code: auth.ViolationInvalidCharacters,
+			Message: "Bio must not contain control characters"})
+	}
+
+	if p.AvatarURL != "" && !validAvatarURL(p.AvatarURL) {
+		violations = append(violations, auth.Violation{Field: "avatarUrl", Code: auth.ViolationInvalid,
+			Message: fmt.Sprintf("Avatar must be an https URL of at most %d characters", AvatarURLMaxLength)})
+	}
+
+	if p.Color != "" && !validColor(p.Color) {
+		violations = append(violations, auth.Violation{Field: "color", Code: auth.ViolationInvalid,
+			Message: "Color must be a hex color such as #4ECDC4"})
+	}
+
+	return violations
+}
+
+// validAvatarURL accepts absolute https URLs only, so avatars never load over
+// plain HTTP and cannot be javascript: or data: URLs.
+func validAvatarURL(raw string) bool {
+	if len(raw) > AvatarURLMaxLength {
+		return false
+	}
+
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme == "https" && u.Host != "" && u.User == nil
+}
+
+func validColor(color string) bool {
+	if len(color) != 7 || color[0] != '#' {
+		return false
+	}
+	for _, r := range color[1:] {
+		if !strings.ContainsRune("0123456789ABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
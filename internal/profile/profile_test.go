@@ -0,0 +1,74 @@
+package profile_test
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/profile"
+	"github.com/fortega2/real-time-chat/internal/repository"
+)
+
+func TestOf(t *testing.T) {
+	user := repository.User{ID: 3, Username: "alice", DisplayName: sql.NullString{String: "Alice", Valid: true}}
+
+	p := profile.Of(user)
+	if p.DisplayName != "Alice" || p.Color != profile.DefaultColor(3) {
+		t.Errorf("unexpected profile %+v", p)
+	}
+
+	user.Color = sql.NullString{String: "#123456", Valid: true}
+	if p := profile.Of(user); p.Color != "#123456" {
+		t.Errorf("expected the chosen color, got %s", p.Color)
+	}
+}
+
+func TestDefaultColor(t *testing.T) {
+	for _, id := range []int64{0, 1, 42, -5} {
+		if color := profile.DefaultColor(id); color == "" || color != profile.DefaultColor(id) {
+			t.Errorf("expected a stable color for %d, got %q", id, color)
+		}
+	}
+	if profile.DefaultColor(1) == profile.DefaultColor(2) {
+		t.Error("expected neighbouring users to get different colors")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		name      string
+		profile   profile.Profile
+		wantField string
+		wantCode  string
+	}{
+		{"Valid", profile.Profile{DisplayName: "Alice", Bio: "Line one\nLine two", AvatarURL: "https://example.com/a.png", Color: "#4ECDC4"}, "", ""},
+		{"Empty", profile.Profile{}, "", ""},
+		{"Long Display Name", profile.Profile{DisplayName: strings.Repeat("a", profile.DisplayNameMaxLength+1)}, "displayName", auth.ViolationTooLong},
+		{"Control Character", profile.Profile{DisplayName: "Al\x00ice"}, "displayName", auth.ViolationInvalidCharacters},
+		{"Long Bio", profile.Profile{Bio: strings.Repeat("b", profile.BioMaxLength+1)}, "bio", auth.ViolationTooLong},
+		{"HTTP Avatar", profile.Profile{AvatarURL: "http://example.com/a.png"}, "avatarUrl", auth.ViolationInvalid},
+		{"Script Avatar", profile.Profile{AvatarURL: "javascript:alert(1)"}, "avatarUrl", auth.ViolationInvalid},
+		{"Named Color", profile.Profile{Color: "RED"}, "color", auth.ViolationInvalid},
+		{"Short Color", profile.Profile{Color: "#FFF"}, "color", auth.ViolationInvalid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := profile.Check(profile.Normalize(tc.profile))
+			switch {
+			case tc.wantCode == "" && len(violations) > 0:
+				t.Errorf("expected no violations, got %+v", violations)
+			case tc.wantCode != "" && (len(violations) != 1 || violations[0].Field != tc.wantField || violations[0].Code != tc.wantCode):
+				t.Errorf("expected %s/%s, got %+v", tc.wantField, tc.wantCode, violations)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	p := profile.Normalize(profile.Profile{DisplayName: "  Alice ", Color: " #4ecdc4"})
+	if p.DisplayName != "Alice" || p.Color != "#4ECDC4" {
+		t.Errorf("unexpected normalized profile %+v", p)
+	}
+}
@@ -0,0 +1,83 @@
+package realip_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fortega2/real-time-chat/internal/realip"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"Unset", "", 0, false},
+		{"Address And Range", "10.0.0.1, 172.16.0.0/12", 2, false},
+		{"IPv6", "::1,fd00::/8", 2, false},
+		{"Hostname", "proxy.internal", 0, true},
+		{"Bad Range", "10.0.0.0/33", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			proxies, err := realip.ParseTrustedProxies(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if len(proxies) != tc.want {
+				t.Errorf("expected %d proxies, got %v", tc.want, proxies)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	trusted, err := realip.ParseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("failed to parse trusted proxies: %v", err)
+	}
+
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		realIP       string
+		expectedAddr string
+	}{
+		{"Direct Client", "203.0.113.9:4321", nil, "", "203.0.113.9:4321"},
+		{"Spoofed Header From Client", "203.0.113.9:4321", []string{"198.51.100.1"}, "198.51.100.2", "203.0.113.9:4321"},
+		{"Trusted Proxy", "10.0.0.5:80", []string{"198.51.100.1"}, "", "198.51.100.1"},
+		{"Client Prepends Entries", "10.0.0.5:80", []string{"192.0.2.77, 198.51.100.1"}, "", "198.51.100.1"},
+		{"Chain Of Proxies", "10.0.0.5:80", []string{"198.51.100.1, 10.0.0.9", "10.0.0.7"}, "", "198.51.100.1"},
+		{"Real IP Header", "10.0.0.5:80", nil, "198.51.100.2", "198.51.100.2"},
+		{"Garbage Header", "10.0.0.5:80", []string{"not-an-ip"}, "", "10.0.0.5:80"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for _, value := range tc.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tc.realIP != "" {
+				req.Header.Set("X-Real-IP", tc.realIP)
+			}
+
+			realip.Middleware(trusted)(next).ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tc.expectedAddr {
+				t.Errorf("expected remote address %q, got %q", tc.expectedAddr, got)
+			}
+		})
+	}
+}
@@ -11,74 +11,243 @@ import (
 	"time"
 )
 
+const archiveChannel = `-- name: ArchiveChannel :exec
+UPDATE channels
+SET archived_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+func (q *Queries) ArchiveChannel(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.archiveChannelStmt, archiveChannel, id)
+	return err
+}
+
 const createChannel = `-- name: CreateChannel :one
-INSERT INTO channels (name, description, created_by)
-VALUES (?, ?, ?)
+INSERT INTO channels (name, description, topic, created_by, is_private)
+VALUES (?, ?, ?, ?, ?)
 RETURNING id
 `
 
 type CreateChannelParams struct {
 	Name        string         `json:"name"`
 	Description sql.NullString `json:"description"`
+	Topic       sql.NullString `json:"topic"`
 	CreatedBy   int64          `json:"createdBy"`
+	IsPrivate   bool           `json:"isPrivate"`
 }
 
 func (q *Queries) CreateChannel(ctx context.Context, arg CreateChannelParams) (int64, error) {
-	row := q.queryRow(ctx, q.createChannelStmt, createChannel, arg.Name, arg.Description, arg.CreatedBy)
+	row := q.queryRow(ctx, q.createChannelStmt, createChannel,
+		arg.Name,
+		arg.Description,
+		arg.Topic,
+		arg.CreatedBy,
+		arg.IsPrivate,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createDirectChannel = `-- name: CreateDirectChannel :one
+INSERT INTO channels (name, created_by, is_private, kind, direct_key)
+VALUES (?, ?, TRUE, 'direct', ?)
+RETURNING id
+`
+
+type CreateDirectChannelParams struct {
+	Name      string         `json:"name"`
+	CreatedBy int64          `json:"createdBy"`
+	DirectKey sql.NullString `json:"directKey"`
+}
+
+func (q *Queries) CreateDirectChannel(ctx context.Context, arg CreateDirectChannelParams) (int64, error) {
+	row := q.queryRow(ctx, q.createDirectChannelStmt, createDirectChannel, arg.Name, arg.CreatedBy, arg.DirectKey)
 	var id int64
 	err := row.Scan(&id)
 	return id, err
 }
 
-const deleteChannel = `-- name: DeleteChannel :exec
+const deleteChannelByID = `-- name: DeleteChannelByID :execrows
 DELETE FROM channels
-WHERE id = ? AND created_by = ?
+WHERE id = ?
 `
 
-type DeleteChannelParams struct {
-	ID        int64 `json:"id"`
-	CreatedBy int64 `json:"createdBy"`
+func (q *Queries) DeleteChannelByID(ctx context.Context, id int64) (int64, error) {
+	result, err := q.exec(ctx, q.deleteChannelByIDStmt, deleteChannelByID, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-func (q *Queries) DeleteChannel(ctx context.Context, arg DeleteChannelParams) error {
-	_, err := q.exec(ctx, q.deleteChannelStmt, deleteChannel, arg.ID, arg.CreatedBy)
-	return err
+const getChannelByID = `-- name: GetChannelByID :one
+SELECT
+    c.id,
+    c.name,
+    c.description,
+    c.created_by,
+    u.username AS created_by_username,
+    c.created_at,
+    c.is_private,
+    c.topic,
+    c.archived_at,
+    c.kind,
+    c.category_id,
+    c.position,
+    (SELECT COUNT(*) FROM channel_members AS cm WHERE cm.channel_id = c.id) AS member_count
+FROM
+    channels AS c
+INNER JOIN
+    users AS u ON u.id = c.created_by
+WHERE
+    c.id = ?
+`
+
+type GetChannelByIDRow struct {
+	ID                int64          `json:"id"`
+	Name              string         `json:"name"`
+	Description       sql.NullString `json:"description"`
+	CreatedBy         int64          `json:"createdBy"`
+	CreatedByUsername string         `json:"createdByUsername"`
+	CreatedAt         time.Time      `json:"createdAt"`
+	IsPrivate         bool           `json:"isPrivate"`
+	Topic             sql.NullString `json:"topic"`
+	ArchivedAt        sql.NullTime   `json:"archivedAt"`
+	Kind              string         `json:"kind"`
+	CategoryID        sql.NullInt64  `json:"categoryId"`
+	Position          int64          `json:"position"`
+	MemberCount       int64          `json:"memberCount"`
+}
+
+func (q *Queries) GetChannelByID(ctx context.Context, id int64) (GetChannelByIDRow, error) {
+	row := q.queryRow(ctx, q.getChannelByIDStmt, getChannelByID, id)
+	var i GetChannelByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedBy,
+		&i.CreatedByUsername,
+		&i.CreatedAt,
+		&i.IsPrivate,
+		&i.Topic,
+		&i.ArchivedAt,
+		&i.Kind,
+		&i.CategoryID,
+		&i.Position,
+		&i.MemberCount,
+	)
+	return i, err
+}
+
+const getChannelIDByName = `-- name: GetChannelIDByName :one
+SELECT id
+FROM channels
+WHERE name = ?
+`
+
+func (q *Queries) GetChannelIDByName(ctx context.Context, name string) (int64, error) {
+	row := q.queryRow(ctx, q.getChannelIDByNameStmt, getChannelIDByName, name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getDirectChannelIDByKey = `-- name: GetDirectChannelIDByKey :one
+SELECT id
+FROM channels
+WHERE direct_key = ?
+`
+
+func (q *Queries) GetDirectChannelIDByKey(ctx context.Context, directKey sql.NullString) (int64, error) {
+	row := q.queryRow(ctx, q.getDirectChannelIDByKeyStmt, getDirectChannelIDByKey, directKey)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
 }
 
-const getAllChannels = `-- name: GetAllChannels :many
+const listChannelsForUser = `-- name: ListChannelsForUser :many
 SELECT
     c.id,
     c.name,
     c.description,
     c.created_by,
     u.username AS created_by_username,
-    c.created_at
+    c.created_at,
+    c.is_private,
+    c.topic,
+    c.archived_at,
+    m.joined_at,
+    m.role,
+    c.category_id,
+    c.position,
+    m.favorite,
+    m.personal_position,
+    (SELECT COUNT(*) FROM channel_members AS cm WHERE cm.channel_id = c.id) AS member_count,
+    COALESCE((SELECT MAX(msg.id) FROM messages AS msg WHERE msg.channel_id = c.id), 0) AS last_message_id
 FROM
     channels AS c
 INNER JOIN
     users AS u ON u.id = c.created_by
+LEFT JOIN
+    channel_members AS m ON m.channel_id = c.id AND m.user_id = ?
+WHERE
+    c.kind = 'channel'
+    AND (c.is_private = FALSE OR m.user_id IS NOT NULL)
+    AND (c.archived_at IS NULL OR CAST(? AS BOOLEAN))
+    AND (c.name LIKE ? ESCAPE '\' OR c.description LIKE ? ESCAPE '\')
+    AND c.id < ?
 ORDER BY
     c.id DESC
+LIMIT ?
 `
 
-type GetAllChannelsRow struct {
+type ListChannelsForUserParams struct {
+	UserID             int64  `json:"userId"`
+	IncludeArchived    bool   `json:"includeArchived"`
+	NamePattern        string `json:"namePattern"`
+	DescriptionPattern string `json:"descriptionPattern"`
+	BeforeID           int64  `json:"beforeId"`
+	Limit              int64  `json:"limit"`
+}
+
+type ListChannelsForUserRow struct {
 	ID                int64          `json:"id"`
 	Name              string         `json:"name"`
 	Description       sql.NullString `json:"description"`
 	CreatedBy         int64          `json:"createdBy"`
 	CreatedByUsername string         `json:"createdByUsername"`
 	CreatedAt         time.Time      `json:"createdAt"`
+	IsPrivate         bool           `json:"isPrivate"`
+	Topic             sql.NullString `json:"topic"`
+	ArchivedAt        sql.NullTime   `json:"archivedAt"`
+	JoinedAt          sql.NullTime   `json:"joinedAt"`
+	Role              sql.NullString `json:"role"`
+	CategoryID        sql.NullInt64  `json:"categoryId"`
+	Position          int64          `json:"position"`
+	Favorite          sql.NullBool   `json:"favorite"`
+	PersonalPosition  sql.NullInt64  `json:"personalPosition"`
+	MemberCount       int64          `json:"memberCount"`
+	LastMessageID     int64          `json:"lastMessageId"`
 }
 
-func (q *Queries) GetAllChannels(ctx context.Context) ([]GetAllChannelsRow, error) {
-	rows, err := q.query(ctx, q.getAllChannelsStmt, getAllChannels)
+func (q *Queries) ListChannelsForUser(ctx context.Context, arg ListChannelsForUserParams) ([]ListChannelsForUserRow, error) {
+	rows, err := q.query(ctx, q.listChannelsForUserStmt, listChannelsForUser,
+		arg.UserID,
+		arg.IncludeArchived,
+		arg.NamePattern,
+		arg.DescriptionPattern,
+		arg.BeforeID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetAllChannelsRow
+	var items []ListChannelsForUserRow
 	for rows.Next() {
-		var i GetAllChannelsRow
+		var i ListChannelsForUserRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Name,
@@ -86,6 +255,17 @@ func (q *Queries) GetAllChannels(ctx context.Context) ([]GetAllChannelsRow, erro
 			&i.CreatedBy,
 			&i.CreatedByUsername,
 			&i.CreatedAt,
+			&i.IsPrivate,
+			&i.Topic,
+			&i.ArchivedAt,
+			&i.JoinedAt,
+			&i.Role,
+			&i.CategoryID,
+			&i.Position,
+			&i.Favorite,
+			&i.PersonalPosition,
+			&i.MemberCount,
+			&i.LastMessageID,
 		); err != nil {
 			return nil, err
 		}
@@ -100,41 +280,453 @@ func (q *Queries) GetAllChannels(ctx context.Context) ([]GetAllChannelsRow, erro
 	return items, nil
 }
 
-const getChannelByID = `-- name: GetChannelByID :one
+const listChannelsForUserByActivity = `-- name: ListChannelsForUserByActivity :many
 SELECT
     c.id,
     c.name,
     c.description,
     c.created_by,
     u.username AS created_by_username,
-    c.created_at
+    c.created_at,
+    c.is_private,
+    c.topic,
+    c.archived_at,
+    m.joined_at,
+    m.role,
+    c.category_id,
+    c.position,
+    m.favorite,
+    m.personal_position,
+    (SELECT COUNT(*) FROM channel_members AS cm WHERE cm.channel_id = c.id) AS member_count,
+    COALESCE((SELECT MAX(msg.id) FROM messages AS msg WHERE msg.channel_id = c.id), 0) AS last_message_id
 FROM
     channels AS c
 INNER JOIN
     users AS u ON u.id = c.created_by
+LEFT JOIN
+    channel_members AS m ON m.channel_id = c.id AND m.user_id = ?
 WHERE
-    c.id = ?
+    c.kind = 'channel'
+    AND (c.is_private = FALSE OR m.user_id IS NOT NULL)
+    AND (c.archived_at IS NULL OR CAST(? AS BOOLEAN))
+    AND (c.name LIKE ? ESCAPE '\' OR c.description LIKE ? ESCAPE '\')
+    AND (COALESCE((SELECT MAX(msg.id) FROM messages AS msg WHERE msg.channel_id = c.id), 0), c.id) < (?, ?)
+ORDER BY
+    last_message_id DESC, c.id DESC
+LIMIT ?
 `
 
-type GetChannelByIDRow struct {
+type ListChannelsForUserByActivityParams struct {
+	UserID             int64  `json:"userId"`
+	IncludeArchived    bool   `json:"includeArchived"`
+	NamePattern        string `json:"namePattern"`
+	DescriptionPattern string `json:"descriptionPattern"`
+	BeforeMessageID    int64  `json:"beforeMessageId"`
+	BeforeID           int64  `json:"beforeId"`
+	Limit              int64  `json:"limit"`
+}
+
+type ListChannelsForUserByActivityRow struct {
 	ID                int64          `json:"id"`
 	Name              string         `json:"name"`
 	Description       sql.NullString `json:"description"`
 	CreatedBy         int64          `json:"createdBy"`
 	CreatedByUsername string         `json:"createdByUsername"`
 	CreatedAt         time.Time      `json:"createdAt"`
+	IsPrivate         bool           `json:"isPrivate"`
+	Topic             sql.NullString `json:"topic"`
+	ArchivedAt        sql.NullTime   `json:"archivedAt"`
+	JoinedAt          sql.NullTime   `json:"joinedAt"`
+	Role              sql.NullString `json:"role"`
+	CategoryID        sql.NullInt64  `json:"categoryId"`
+	Position          int64          `json:"position"`
+	Favorite          sql.NullBool   `json:"favorite"`
+	PersonalPosition  sql.NullInt64  `json:"personalPosition"`
+	MemberCount       int64          `json:"memberCount"`
+	LastMessageID     int64          `json:"lastMessageId"`
 }
 
-func (q *Queries) GetChannelByID(ctx context.Context, id int64) (GetChannelByIDRow, error) {
-	row := q.queryRow(ctx, q.getChannelByIDStmt, getChannelByID, id)
-	var i GetChannelByIDRow
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Description,
-		&i.CreatedBy,
-		&i.CreatedByUsername,
-		&i.CreatedAt,
+func (q *Queries) ListChannelsForUserByActivity(ctx context.Context, arg ListChannelsForUserByActivityParams) ([]ListChannelsForUserByActivityRow, error) {
+	rows, err := q.query(ctx, q.listChannelsForUserByActivityStmt, listChannelsForUserByActivity,
+		arg.UserID,
+		arg.IncludeArchived,
+		arg.NamePattern,
+		arg.DescriptionPattern,
+		arg.BeforeMessageID,
+		arg.BeforeID,
+		arg.Limit,
 	)
-	return i, err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChannelsForUserByActivityRow
+	for rows.Next() {
+		var i ListChannelsForUserByActivityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedBy,
+			&i.CreatedByUsername,
+			&i.CreatedAt,
+			&i.IsPrivate,
+			&i.Topic,
+			&i.ArchivedAt,
+			&i.JoinedAt,
+			&i.Role,
+			&i.CategoryID,
+			&i.Position,
+			&i.Favorite,
+			&i.PersonalPosition,
+			&i.MemberCount,
+			&i.LastMessageID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChannelsForUserByName = `-- name: ListChannelsForUserByName :many
+SELECT
+    c.id,
+    c.name,
+    c.description,
+    c.created_by,
+    u.username AS created_by_username,
+    c.created_at,
+    c.is_private,
+    c.topic,
+    c.archived_at,
+    m.joined_at,
+    m.role,
+    c.category_id,
+    c.position,
+    m.favorite,
+    m.personal_position,
+    (SELECT COUNT(*) FROM channel_members AS cm WHERE cm.channel_id = c.id) AS member_count,
+    COALESCE((SELECT MAX(msg.id) FROM messages AS msg WHERE msg.channel_id = c.id), 0) AS last_message_id
+FROM
+    channels AS c
+INNER JOIN
+    users AS u ON u.id = c.created_by
+LEFT JOIN
+    channel_members AS m ON m.channel_id = c.id AND m.user_id = ?
+WHERE
+    c.kind = 'channel'
+    AND (c.is_private = FALSE OR m.user_id IS NOT NULL)
+    AND (c.archived_at IS NULL OR CAST(? AS BOOLEAN))
+    AND (c.name LIKE ? ESCAPE '\' OR c.description LIKE ? ESCAPE '\')
+    AND (c.name COLLATE NOCASE, c.id) > (?, ?)
+ORDER BY
+    c.name COLLATE NOCASE, c.id
+LIMIT ?
+`
+
+type ListChannelsForUserByNameParams struct {
+	UserID             int64  `json:"userId"`
+	IncludeArchived    bool   `json:"includeArchived"`
+	NamePattern        string `json:"namePattern"`
+	DescriptionPattern string `json:"descriptionPattern"`
+	AfterName          string `json:"afterName"`
+	AfterID            int64  `json:"afterId"`
+	Limit              int64  `json:"limit"`
+}
+
+type ListChannelsForUserByNameRow struct {
+	ID                int64          `json:"id"`
+	Name              string         `json:"name"`
+	Description       sql.NullString `json:"description"`
+	CreatedBy         int64          `json:"createdBy"`
+	CreatedByUsername string         `json:"createdByUsername"`
+	CreatedAt         time.Time      `json:"createdAt"`
+	IsPrivate         bool           `json:"isPrivate"`
+	Topic             sql.NullString `json:"topic"`
+	ArchivedAt        sql.NullTime   `json:"archivedAt"`
+	JoinedAt          sql.NullTime   `json:"joinedAt"`
+	Role              sql.NullString `json:"role"`
+	CategoryID        sql.NullInt64  `json:"categoryId"`
+	Position          int64          `json:"position"`
+	Favorite          sql.NullBool   `json:"favorite"`
+	PersonalPosition  sql.NullInt64  `json:"personalPosition"`
+	MemberCount       int64          `json:"memberCount"`
+	LastMessageID     int64          `json:"lastMessageId"`
+}
+
+func (q *Queries) ListChannelsForUserByName(ctx context.Context, arg ListChannelsForUserByNameParams) ([]ListChannelsForUserByNameRow, error) {
+	rows, err := q.query(ctx, q.listChannelsForUserByNameStmt, listChannelsForUserByName,
+		arg.UserID,
+		arg.IncludeArchived,
+		arg.NamePattern,
+		arg.DescriptionPattern,
+		arg.AfterName,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChannelsForUserByNameRow
+	for rows.Next() {
+		var i ListChannelsForUserByNameRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedBy,
+			&i.CreatedByUsername,
+			&i.CreatedAt,
+			&i.IsPrivate,
+			&i.Topic,
+			&i.ArchivedAt,
+			&i.JoinedAt,
+			&i.Role,
+			&i.CategoryID,
+			&i.Position,
+			&i.Favorite,
+			&i.PersonalPosition,
+			&i.MemberCount,
+			&i.LastMessageID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDirectChannelMembersForUser = `-- name: ListDirectChannelMembersForUser :many
+SELECT
+    c.id AS channel_id,
+    c.created_at,
+    u.id AS user_id,
+    u.username,
+    u.display_name
+FROM
+    channels AS c
+INNER JOIN
+    channel_members AS m ON m.channel_id = c.id
+INNER JOIN
+    users AS u ON u.id = m.user_id
+WHERE
+    c.kind = 'direct'
+    AND c.id IN (SELECT own.channel_id FROM channel_members AS own WHERE own.user_id = ?)
+ORDER BY
+    c.id DESC, u.username
+`
+
+type ListDirectChannelMembersForUserRow struct {
+	ChannelID   int64          `json:"channelId"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UserID      int64          `json:"userId"`
+	Username    string         `json:"username"`
+	DisplayName sql.NullString `json:"displayName"`
+}
+
+func (q *Queries) ListDirectChannelMembersForUser(ctx context.Context, userID int64) ([]ListDirectChannelMembersForUserRow, error) {
+	rows, err := q.query(ctx, q.listDirectChannelMembersForUserStmt, listDirectChannelMembersForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDirectChannelMembersForUserRow
+	for rows.Next() {
+		var i ListDirectChannelMembersForUserRow
+		if err := rows.Scan(
+			&i.ChannelID,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.Username,
+			&i.DisplayName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSidebarChannelsForUser = `-- name: ListSidebarChannelsForUser :many
+SELECT
+    c.id,
+    c.name,
+    c.description,
+    c.created_by,
+    u.username AS created_by_username,
+    c.created_at,
+    c.is_private,
+    c.topic,
+    c.archived_at,
+    m.joined_at,
+    m.role,
+    c.category_id,
+    c.position,
+    m.favorite,
+    m.personal_position,
+    (SELECT COUNT(*) FROM channel_members AS cm WHERE cm.channel_id = c.id) AS member_count,
+    COALESCE((SELECT MAX(msg.id) FROM messages AS msg WHERE msg.channel_id = c.id), 0) AS last_message_id
+FROM
+    channels AS c
+INNER JOIN
+    users AS u ON u.id = c.created_by
+LEFT JOIN
+    channel_members AS m ON m.channel_id = c.id AND m.user_id = ?
+WHERE
+    c.kind = 'channel'
+    AND m.user_id IS NOT NULL
+    AND c.archived_at IS NULL
+ORDER BY
+    m.personal_position IS NULL, m.personal_position, c.position, c.name COLLATE NOCASE, c.id
+`
+
+type ListSidebarChannelsForUserRow struct {
+	ID                int64          `json:"id"`
+	Name              string         `json:"name"`
+	Description       sql.NullString `json:"description"`
+	CreatedBy         int64          `json:"createdBy"`
+	CreatedByUsername string         `json:"createdByUsername"`
+	CreatedAt         time.Time      `json:"createdAt"`
+	IsPrivate         bool           `json:"isPrivate"`
+	Topic             sql.NullString `json:"topic"`
+	ArchivedAt        sql.NullTime   `json:"archivedAt"`
+	JoinedAt          sql.NullTime   `json:"joinedAt"`
+	Role              sql.NullString `json:"role"`
+	CategoryID        sql.NullInt64  `json:"categoryId"`
+	Position          int64          `json:"position"`
+	Favorite          sql.NullBool   `json:"favorite"`
+	PersonalPosition  sql.NullInt64  `json:"personalPosition"`
+	MemberCount       int64          `json:"memberCount"`
+	LastMessageID     int64          `json:"lastMessageId"`
+}
+
+func (q *Queries) ListSidebarChannelsForUser(ctx context.Context, userID int64) ([]ListSidebarChannelsForUserRow, error) {
+	rows, err := q.query(ctx, q.listSidebarChannelsForUserStmt, listSidebarChannelsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSidebarChannelsForUserRow
+	for rows.Next() {
+		var i ListSidebarChannelsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedBy,
+			&i.CreatedByUsername,
+			&i.CreatedAt,
+			&i.IsPrivate,
+			&i.Topic,
+			&i.ArchivedAt,
+			&i.JoinedAt,
+			&i.Role,
+			&i.CategoryID,
+			&i.Position,
+			&i.Favorite,
+			&i.PersonalPosition,
+			&i.MemberCount,
+			&i.LastMessageID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setChannelCategory = `-- name: SetChannelCategory :execrows
+UPDATE channels
+SET category_id = ?, position = ?
+WHERE id = ? AND kind = 'channel'
+`
+
+type SetChannelCategoryParams struct {
+	CategoryID sql.NullInt64 `json:"categoryId"`
+	Position   int64         `json:"position"`
+	ID         int64         `json:"id"`
+}
+
+func (q *Queries) SetChannelCategory(ctx context.Context, arg SetChannelCategoryParams) (int64, error) {
+	result, err := q.exec(ctx, q.setChannelCategoryStmt, setChannelCategory, arg.CategoryID, arg.Position, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setChannelCreatedBy = `-- name: SetChannelCreatedBy :exec
+UPDATE channels
+SET created_by = ?
+WHERE id = ?
+`
+
+type SetChannelCreatedByParams struct {
+	CreatedBy int64 `json:"createdBy"`
+	ID        int64 `json:"id"`
+}
+
+func (q *Queries) SetChannelCreatedBy(ctx context.Context, arg SetChannelCreatedByParams) error {
+	_, err := q.exec(ctx, q.setChannelCreatedByStmt, setChannelCreatedBy, arg.CreatedBy, arg.ID)
+	return err
+}
+
+const unarchiveChannel = `-- name: UnarchiveChannel :exec
+UPDATE channels
+SET archived_at = NULL
+WHERE id = ?
+`
+
+func (q *Queries) UnarchiveChannel(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.unarchiveChannelStmt, unarchiveChannel, id)
+	return err
+}
+
+const updateChannel = `-- name: UpdateChannel :exec
+UPDATE channels
+SET name = ?, description = ?, topic = ?
+WHERE id = ?
+`
+
+type UpdateChannelParams struct {
+	Name        string         `json:"name"`
+	Description sql.NullString `json:"description"`
+	Topic       sql.NullString `json:"topic"`
+	ID          int64          `json:"id"`
+}
+
+func (q *Queries) UpdateChannel(ctx context.Context, arg UpdateChannelParams) error {
+	_, err := q.exec(ctx, q.updateChannelStmt, updateChannel,
+		arg.Name,
+		arg.Description,
+		arg.Topic,
+		arg.ID,
+	)
+	return err
 }
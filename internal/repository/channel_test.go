@@ -3,6 +3,7 @@ package repository_test
 import (
 	"context"
 	"database/sql"
+	"math"
 	"testing"
 
 	"github.com/fortega2/real-time-chat/internal/repository"
@@ -12,8 +13,7 @@ import (
 const (
 	msgCreateChannelFailed  = "CreateChannel failed: %v"
 	msgGetChannelByIDFailed = "GetChannelByID failed: %v"
-	msgGetAllChannelsFailed = "GetAllChannels failed: %v"
-	msgDeleteChannelFailed  = "DeleteChannel failed: %v"
+	msgDeleteChannelFailed  = "DeleteChannelByID failed: %v"
 )
 
 func initializeTestDBWithChannels(t *testing.T) *sql.DB {
@@ -29,7 +29,15 @@ func initializeTestDBWithChannels(t *testing.T) *sql.DB {
         id INTEGER PRIMARY KEY AUTOINCREMENT,
         username TEXT NOT NULL UNIQUE,
         password TEXT NOT NULL UNIQUE,
-        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        email TEXT UNIQUE,
+        username_key TEXT UNIQUE,
+        display_name TEXT,
+        bio TEXT,
+        avatar_url TEXT,
+        color VARCHAR(7),
+        role TEXT NOT NULL DEFAULT 'user',
+        disabled_at TIMESTAMP
     );
     CREATE INDEX IF NOT EXISTS idx_users_username_password ON users (username, password);
     `
@@ -39,14 +47,48 @@ func initializeTestDBWithChannels(t *testing.T) *sql.DB {
 
 	// Create channels table
 	channelsSchema := `
+    CREATE TABLE IF NOT EXISTS channel_categories (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+        position INTEGER NOT NULL DEFAULT 0,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
     CREATE TABLE IF NOT EXISTS channels (
         id INTEGER PRIMARY KEY AUTOINCREMENT,
         name TEXT NOT NULL UNIQUE,
         description TEXT,
         created_by INTEGER NOT NULL,
         created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        is_private BOOLEAN NOT NULL DEFAULT FALSE,
+        topic TEXT,
+        archived_at TIMESTAMP,
+        kind TEXT NOT NULL DEFAULT 'channel' CHECK (kind IN ('channel', 'direct')),
+        direct_key TEXT UNIQUE,
+        category_id INTEGER REFERENCES channel_categories(id) ON DELETE SET NULL,
+        position INTEGER NOT NULL DEFAULT 0,
         FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
     );
+    CREATE TABLE IF NOT EXISTS channel_members (
+        channel_id INTEGER NOT NULL,
+        user_id INTEGER NOT NULL,
+        joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member')),
+        favorite BOOLEAN NOT NULL DEFAULT FALSE,
+        personal_position INTEGER,
+        PRIMARY KEY (channel_id, user_id),
+        FOREIGN KEY (channel_id) REFERENCES channels(id) ON DELETE CASCADE,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+    CREATE TABLE IF NOT EXISTS messages (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        channel_id INTEGER NOT NULL,
+        user_id INTEGER NOT NULL,
+        user_color VARCHAR(7) NOT NULL,
+        content TEXT NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (channel_id) REFERENCES channels(id) ON DELETE CASCADE,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
     `
 	if _, err := db.Exec(channelsSchema); err != nil {
 		t.Fatalf("failed to create channels table: %v", err)
@@ -155,119 +197,139 @@ func runCreateChannelCase(t *testing.T, tc struct {
 	assertChannelCreated(t, ch, tc.params.Name, tc.params.Description, userID)
 }
 
-func TestGetAllChannels(t *testing.T) {
-	tests := []struct {
-		name      string
-		setup     func(t *testing.T) (*repository.Queries, int, func())
-		wantCount int
-	}{
-		{
-			name:      "Empty Result",
-			setup:     setupEmptyChannels,
-			wantCount: 0,
-		},
-		{
-			name:      "Multiple Channels",
-			setup:     setupMultipleChannels,
-			wantCount: 3,
-		},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
-			runGetAllChannelsTest(t, tc.setup, tc.wantCount)
-		})
-	}
-}
-
-func setupEmptyChannels(t *testing.T) (*repository.Queries, int, func()) {
-	db := initializeTestDBWithChannels(t)
-	return repository.New(db), 0, func() { _ = db.Close() }
-}
-
-func setupMultipleChannels(t *testing.T) (*repository.Queries, int, func()) {
+func TestListChannelsForUser(t *testing.T) {
 	db := initializeTestDBWithChannels(t)
 	q := repository.New(db)
-	user := createTestUser(t, q)
-
-	channels := []string{"general", "random", "dev"}
-	createTestChannels(t, q, user.ID, channels)
+	ctx := context.Background()
 
-	return q, len(channels), func() { _ = db.Close() }
-}
+	owner := createTestUser(t, q)
+	other, err := q.CreateUser(ctx, repository.CreateUserParams{Username: "other", Password: "otherpass"})
+	if err != nil {
+		t.Fatalf("failed to create second user: %v", err)
+	}
 
-func createTestChannels(t *testing.T, q *repository.Queries, userID int64, channelNames []string) {
-	for _, name := range channelNames {
-		if _, err := q.CreateChannel(context.Background(), repository.CreateChannelParams{
-			Name:        name,
-			Description: sql.NullString{String: name + " channel", Valid: true},
-			CreatedBy:   userID,
-		}); err != nil {
-			t.Fatalf("failed to create channel %s: %v", name, err)
+	publicID := createChannelForUser(t, q, owner.ID, "general", "")
+	privateID, err := q.CreateChannel(ctx, repository.CreateChannelParams{Name: "secret", CreatedBy: owner.ID, IsPrivate: true})
+	if err != nil {
+		t.Fatalf(msgCreateChannelFailed, err)
+	}
+	for _, id := range []int64{publicID, privateID} {
+		if err := q.AddChannelMember(ctx, repository.AddChannelMemberParams{ChannelID: id, UserID: owner.ID, Role: "owner"}); err != nil {
+			t.Fatalf("AddChannelMember failed: %v", err)
 		}
 	}
-}
 
-func runGetAllChannelsTest(t *testing.T, setup func(t *testing.T) (*repository.Queries, int, func()), expectedCount int) {
-	q, _, cleanup := setup(t)
-	defer cleanup()
-
-	channels, err := q.GetAllChannels(context.Background())
+	channels, err := q.ListChannelsForUser(ctx, channelsFor(owner.ID, false))
 	if err != nil {
-		t.Fatalf(msgGetAllChannelsFailed, err)
+		t.Fatalf("ListChannelsForUser failed: %v", err)
+	}
+	if len(channels) != 2 || !channels[0].JoinedAt.Valid || !channels[1].JoinedAt.Valid {
+		t.Fatalf("expected the owner to see both channels as a member, got %+v", channels)
+	}
+	if channels[0].Role.String != "owner" {
+		t.Errorf("expected the role of the owner to be listed, got %+v", channels[0].Role)
 	}
 
-	if len(channels) != expectedCount {
-		t.Fatalf("expected %d channels, got %d", expectedCount, len(channels))
+	channels, err = q.ListChannelsForUser(ctx, channelsFor(other.ID, false))
+	if err != nil {
+		t.Fatalf("ListChannelsForUser failed: %v", err)
+	}
+	if len(channels) != 1 || channels[0].ID != publicID || channels[0].JoinedAt.Valid {
+		t.Fatalf("expected other users to see only the public channel, got %+v", channels)
 	}
 
-	validateChannelOrdering(t, channels)
+	if err := q.ArchiveChannel(ctx, publicID); err != nil {
+		t.Fatalf("ArchiveChannel failed: %v", err)
+	}
+	if channels, _ := q.ListChannelsForUser(ctx, channelsFor(other.ID, false)); len(channels) != 0 {
+		t.Errorf("expected archived channels to be left out, got %+v", channels)
+	}
+	channels, err = q.ListChannelsForUser(ctx, channelsFor(other.ID, true))
+	if err != nil || len(channels) != 1 || !channels[0].ArchivedAt.Valid {
+		t.Errorf("expected the archived channel to be listed when included, got %+v, %v", channels, err)
+	}
 }
 
-func validateChannelOrdering(t *testing.T, channels []repository.GetAllChannelsRow) {
-	if len(channels) <= 1 {
-		return
+// channelsFor lists the first page of every channel visible to userID.
+func channelsFor(userID int64, includeArchived bool) repository.ListChannelsForUserParams {
+	return repository.ListChannelsForUserParams{
+		UserID:             userID,
+		IncludeArchived:    includeArchived,
+		NamePattern:        "%",
+		DescriptionPattern: "%",
+		BeforeID:           math.MaxInt64,
+		Limit:              50,
 	}
+}
 
-	for i := 0; i < len(channels)-1; i++ {
-		if channels[i].CreatedAt.Before(channels[i+1].CreatedAt) {
-			t.Error("channels should be ordered by created_at DESC")
-			break
+func TestListChannelsForUserSortOrders(t *testing.T) {
+	db := initializeTestDBWithChannels(t)
+	q := repository.New(db)
+	ctx := context.Background()
+
+	owner := createTestUser(t, q)
+	alpha := createChannelForUser(t, q, owner.ID, "alpha", "")
+	bravo := createChannelForUser(t, q, owner.ID, "Bravo", "")
+	charlie := createChannelForUser(t, q, owner.ID, "charlie", "")
+	if err := q.AddChannelMember(ctx, repository.AddChannelMemberParams{ChannelID: bravo, UserID: owner.ID, Role: "owner"}); err != nil {
+		t.Fatalf("AddChannelMember failed: %v", err)
+	}
+	for _, channelID := range []int64{charlie, alpha} {
+		if _, err := db.Exec("INSERT INTO messages (channel_id, user_id, user_color, content) VALUES (?, ?, '#000000', 'hi')", channelID, owner.ID); err != nil {
+			t.Fatalf("failed to insert message: %v", err)
 		}
 	}
+
+	byName, err := q.ListChannelsForUserByName(ctx, repository.ListChannelsForUserByNameParams{
+		UserID: owner.ID, NamePattern: "%", DescriptionPattern: "%", AfterName: "alpha", AfterID: alpha, Limit: 50,
+	})
+	if err != nil {
+		t.Fatalf("ListChannelsForUserByName failed: %v", err)
+	}
+	if len(byName) != 2 || byName[0].ID != bravo || byName[1].ID != charlie {
+		t.Errorf("expected the channels after alpha, ignoring case, got %+v", byName)
+	}
+	if byName[0].MemberCount != 1 || byName[1].MemberCount != 0 {
+		t.Errorf("expected member counts 1 and 0, got %d and %d", byName[0].MemberCount, byName[1].MemberCount)
+	}
+
+	byActivity, err := q.ListChannelsForUserByActivity(ctx, repository.ListChannelsForUserByActivityParams{
+		UserID: owner.ID, NamePattern: "%", DescriptionPattern: "%", BeforeMessageID: math.MaxInt64, BeforeID: math.MaxInt64, Limit: 50,
+	})
+	if err != nil {
+		t.Fatalf("ListChannelsForUserByActivity failed: %v", err)
+	}
+	if len(byActivity) != 3 || byActivity[0].ID != alpha || byActivity[1].ID != charlie || byActivity[2].ID != bravo {
+		t.Errorf("expected the channel with the latest message first and quiet ones last, got %+v", byActivity)
+	}
 }
 
-func TestDeleteChannel(t *testing.T) {
+func TestDeleteChannelByID(t *testing.T) {
 	tests := []struct {
-		name    string
-		setup   func(t *testing.T) (*repository.Queries, repository.DeleteChannelParams, func())
-		wantErr bool
+		name     string
+		setup    func(t *testing.T) (*repository.Queries, int64, func())
+		wantRows int64
 	}{
 		{
-			name:    "Success",
-			setup:   setupSuccessfulDelete,
-			wantErr: false,
+			name:     "Success",
+			setup:    setupSuccessfulDelete,
+			wantRows: 1,
 		},
 		{
-			name:    "Wrong Creator",
-			setup:   setupWrongCreatorDelete,
-			wantErr: false,
-		},
-		{
-			name:    "Non-existent Channel",
-			setup:   setupNonExistentChannelDelete,
-			wantErr: false,
+			name:     "Non-existent Channel",
+			setup:    setupNonExistentChannelDelete,
+			wantRows: 0,
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			runDeleteChannelTest(t, tc.setup, tc.wantErr)
+			runDeleteChannelTest(t, tc.setup, tc.wantRows)
 		})
 	}
 }
 
-func setupSuccessfulDelete(t *testing.T) (*repository.Queries, repository.DeleteChannelParams, func()) {
+func setupSuccessfulDelete(t *testing.T) (*repository.Queries, int64, func()) {
 	db := initializeTestDBWithChannels(t)
 	q := repository.New(db)
 	user := createTestUser(t, q)
@@ -281,48 +343,14 @@ func setupSuccessfulDelete(t *testing.T) (*repository.Queries, repository.Delete
 		t.Fatalf(msgCreateChannelFailed, err)
 	}
 
-	params := repository.DeleteChannelParams{
-		ID:        chId,
-		CreatedBy: user.ID,
-	}
-
-	return q, params, func() { _ = db.Close() }
-}
-
-func setupWrongCreatorDelete(t *testing.T) (*repository.Queries, repository.DeleteChannelParams, func()) {
-	db := initializeTestDBWithChannels(t)
-	q := repository.New(db)
-	user1 := createTestUser(t, q)
-
-	user2, err := q.CreateUser(context.Background(), repository.CreateUserParams{
-		Username: "user2",
-		Password: "pass2",
-	})
-	if err != nil {
-		t.Fatalf("failed to create second user: %v", err)
-	}
-
-	chId := createChannelForUser(t, q, user1.ID, "protected", "Protected channel")
-
-	params := repository.DeleteChannelParams{
-		ID:        chId,
-		CreatedBy: user2.ID,
-	}
-
-	return q, params, func() { _ = db.Close() }
+	return q, chId, func() { _ = db.Close() }
 }
 
-func setupNonExistentChannelDelete(t *testing.T) (*repository.Queries, repository.DeleteChannelParams, func()) {
+func setupNonExistentChannelDelete(t *testing.T) (*repository.Queries, int64, func()) {
 	db := initializeTestDBWithChannels(t)
 	q := repository.New(db)
-	user := createTestUser(t, q)
-
-	params := repository.DeleteChannelParams{
-		ID:        9999,
-		CreatedBy: user.ID,
-	}
 
-	return q, params, func() { _ = db.Close() }
+	return q, 9999, func() { _ = db.Close() }
 }
 
 func createChannelForUser(t *testing.T, q *repository.Queries, userID int64, name, description string) int64 {
@@ -337,19 +365,17 @@ func createChannelForUser(t *testing.T, q *repository.Queries, userID int64, nam
 	return chId
 }
 
-func runDeleteChannelTest(t *testing.T, setup func(t *testing.T) (*repository.Queries, repository.DeleteChannelParams, func()), wantErr bool) {
-	q, params, cleanup := setup(t)
+func runDeleteChannelTest(t *testing.T, setup func(t *testing.T) (*repository.Queries, int64, func()), wantRows int64) {
+	q, channelID, cleanup := setup(t)
 	defer cleanup()
 
-	err := q.DeleteChannel(context.Background(), params)
-
-	if wantErr && err == nil {
-		t.Fatal("expected error, got nil")
-	}
-
-	if !wantErr && err != nil {
+	rows, err := q.DeleteChannelByID(context.Background(), channelID)
+	if err != nil {
 		t.Fatalf(msgDeleteChannelFailed, err)
 	}
+	if rows != wantRows {
+		t.Errorf("expected %d deleted rows, got %d", wantRows, rows)
+	}
 }
 
 func assertChannelCreated(t *testing.T, chId int64, wantName string, wantDescription sql.NullString, wantCreatedBy int64) {
@@ -24,61 +24,468 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.addChannelMemberStmt, err = db.PrepareContext(ctx, addChannelMember); err != nil {
+		return nil, fmt.Errorf("error preparing query AddChannelMember: %w", err)
+	}
+	if q.advanceUserTOTPStepStmt, err = db.PrepareContext(ctx, advanceUserTOTPStep); err != nil {
+		return nil, fmt.Errorf("error preparing query AdvanceUserTOTPStep: %w", err)
+	}
+	if q.archiveChannelStmt, err = db.PrepareContext(ctx, archiveChannel); err != nil {
+		return nil, fmt.Errorf("error preparing query ArchiveChannel: %w", err)
+	}
+	if q.clearChannelCategoryStmt, err = db.PrepareContext(ctx, clearChannelCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearChannelCategory: %w", err)
+	}
+	if q.clearPersonalPositionsStmt, err = db.PrepareContext(ctx, clearPersonalPositions); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearPersonalPositions: %w", err)
+	}
+	if q.consumeOIDCLoginFlowStmt, err = db.PrepareContext(ctx, consumeOIDCLoginFlow); err != nil {
+		return nil, fmt.Errorf("error preparing query ConsumeOIDCLoginFlow: %w", err)
+	}
+	if q.consumePasswordResetCodeStmt, err = db.PrepareContext(ctx, consumePasswordResetCode); err != nil {
+		return nil, fmt.Errorf("error preparing query ConsumePasswordResetCode: %w", err)
+	}
+	if q.countUnusedRecoveryCodesStmt, err = db.PrepareContext(ctx, countUnusedRecoveryCodes); err != nil {
+		return nil, fmt.Errorf("error preparing query CountUnusedRecoveryCodes: %w", err)
+	}
+	if q.createAuditEventStmt, err = db.PrepareContext(ctx, createAuditEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAuditEvent: %w", err)
+	}
 	if q.createChannelStmt, err = db.PrepareContext(ctx, createChannel); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateChannel: %w", err)
 	}
+	if q.createChannelCategoryStmt, err = db.PrepareContext(ctx, createChannelCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateChannelCategory: %w", err)
+	}
+	if q.createChannelInviteStmt, err = db.PrepareContext(ctx, createChannelInvite); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateChannelInvite: %w", err)
+	}
+	if q.createChannelInviteLinkStmt, err = db.PrepareContext(ctx, createChannelInviteLink); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateChannelInviteLink: %w", err)
+	}
+	if q.createDirectChannelStmt, err = db.PrepareContext(ctx, createDirectChannel); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateDirectChannel: %w", err)
+	}
 	if q.createMessageStmt, err = db.PrepareContext(ctx, createMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateMessage: %w", err)
 	}
+	if q.createMessageRevisionStmt, err = db.PrepareContext(ctx, createMessageRevision); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateMessageRevision: %w", err)
+	}
+	if q.createOIDCLoginFlowStmt, err = db.PrepareContext(ctx, createOIDCLoginFlow); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOIDCLoginFlow: %w", err)
+	}
+	if q.createPasswordResetCodeStmt, err = db.PrepareContext(ctx, createPasswordResetCode); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePasswordResetCode: %w", err)
+	}
+	if q.createRecoveryCodeStmt, err = db.PrepareContext(ctx, createRecoveryCode); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateRecoveryCode: %w", err)
+	}
+	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
+	}
 	if q.createUserStmt, err = db.PrepareContext(ctx, createUser); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateUser: %w", err)
 	}
-	if q.deleteChannelStmt, err = db.PrepareContext(ctx, deleteChannel); err != nil {
-		return nil, fmt.Errorf("error preparing query DeleteChannel: %w", err)
+	if q.createUserIdentityStmt, err = db.PrepareContext(ctx, createUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateUserIdentity: %w", err)
+	}
+	if q.deleteChannelByIDStmt, err = db.PrepareContext(ctx, deleteChannelByID); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteChannelByID: %w", err)
+	}
+	if q.deleteChannelCategoryStmt, err = db.PrepareContext(ctx, deleteChannelCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteChannelCategory: %w", err)
+	}
+	if q.deleteChannelInviteStmt, err = db.PrepareContext(ctx, deleteChannelInvite); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteChannelInvite: %w", err)
+	}
+	if q.deleteChannelInviteForUserStmt, err = db.PrepareContext(ctx, deleteChannelInviteForUser); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteChannelInviteForUser: %w", err)
+	}
+	if q.deleteExpiredOIDCLoginFlowsStmt, err = db.PrepareContext(ctx, deleteExpiredOIDCLoginFlows); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredOIDCLoginFlows: %w", err)
+	}
+	if q.deleteLoginThrottleStmt, err = db.PrepareContext(ctx, deleteLoginThrottle); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLoginThrottle: %w", err)
+	}
+	if q.deleteRecoveryCodesStmt, err = db.PrepareContext(ctx, deleteRecoveryCodes); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteRecoveryCodes: %w", err)
+	}
+	if q.deleteUserTOTPStmt, err = db.PrepareContext(ctx, deleteUserTOTP); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserTOTP: %w", err)
 	}
-	if q.getAllChannelsStmt, err = db.PrepareContext(ctx, getAllChannels); err != nil {
-		return nil, fmt.Errorf("error preparing query GetAllChannels: %w", err)
+	if q.disableUserStmt, err = db.PrepareContext(ctx, disableUser); err != nil {
+		return nil, fmt.Errorf("error preparing query DisableUser: %w", err)
+	}
+	if q.enableUserStmt, err = db.PrepareContext(ctx, enableUser); err != nil {
+		return nil, fmt.Errorf("error preparing query EnableUser: %w", err)
+	}
+	if q.enableUserTOTPStmt, err = db.PrepareContext(ctx, enableUserTOTP); err != nil {
+		return nil, fmt.Errorf("error preparing query EnableUserTOTP: %w", err)
 	}
 	if q.getChannelByIDStmt, err = db.PrepareContext(ctx, getChannelByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetChannelByID: %w", err)
 	}
+	if q.getChannelCategoryStmt, err = db.PrepareContext(ctx, getChannelCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query GetChannelCategory: %w", err)
+	}
+	if q.getChannelCategoryIDByNameStmt, err = db.PrepareContext(ctx, getChannelCategoryIDByName); err != nil {
+		return nil, fmt.Errorf("error preparing query GetChannelCategoryIDByName: %w", err)
+	}
+	if q.getChannelIDByNameStmt, err = db.PrepareContext(ctx, getChannelIDByName); err != nil {
+		return nil, fmt.Errorf("error preparing query GetChannelIDByName: %w", err)
+	}
+	if q.getChannelInviteForUserStmt, err = db.PrepareContext(ctx, getChannelInviteForUser); err != nil {
+		return nil, fmt.Errorf("error preparing query GetChannelInviteForUser: %w", err)
+	}
+	if q.getChannelInviteLinkByTokenHashStmt, err = db.PrepareContext(ctx, getChannelInviteLinkByTokenHash); err != nil {
+		return nil, fmt.Errorf("error preparing query GetChannelInviteLinkByTokenHash: %w", err)
+	}
+	if q.getChannelMemberStmt, err = db.PrepareContext(ctx, getChannelMember); err != nil {
+		return nil, fmt.Errorf("error preparing query GetChannelMember: %w", err)
+	}
+	if q.getChannelMembershipStmt, err = db.PrepareContext(ctx, getChannelMembership); err != nil {
+		return nil, fmt.Errorf("error preparing query GetChannelMembership: %w", err)
+	}
+	if q.getChannelOwnerStmt, err = db.PrepareContext(ctx, getChannelOwner); err != nil {
+		return nil, fmt.Errorf("error preparing query GetChannelOwner: %w", err)
+	}
+	if q.getDirectChannelIDByKeyStmt, err = db.PrepareContext(ctx, getDirectChannelIDByKey); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDirectChannelIDByKey: %w", err)
+	}
+	if q.getHistoryMessagesAfterStmt, err = db.PrepareContext(ctx, getHistoryMessagesAfter); err != nil {
+		return nil, fmt.Errorf("error preparing query GetHistoryMessagesAfter: %w", err)
+	}
 	if q.getHistoryMessagesByChannelStmt, err = db.PrepareContext(ctx, getHistoryMessagesByChannel); err != nil {
 		return nil, fmt.Errorf("error preparing query GetHistoryMessagesByChannel: %w", err)
 	}
+	if q.getLoginThrottleStmt, err = db.PrepareContext(ctx, getLoginThrottle); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLoginThrottle: %w", err)
+	}
+	if q.getMessageByIDStmt, err = db.PrepareContext(ctx, getMessageByID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetMessageByID: %w", err)
+	}
+	if q.getPasswordResetCodeStmt, err = db.PrepareContext(ctx, getPasswordResetCode); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPasswordResetCode: %w", err)
+	}
+	if q.getSessionByIDStmt, err = db.PrepareContext(ctx, getSessionByID); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSessionByID: %w", err)
+	}
+	if q.getSessionByRefreshTokenHashStmt, err = db.PrepareContext(ctx, getSessionByRefreshTokenHash); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSessionByRefreshTokenHash: %w", err)
+	}
+	if q.getUserByEmailStmt, err = db.PrepareContext(ctx, getUserByEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserByEmail: %w", err)
+	}
 	if q.getUserByIDStmt, err = db.PrepareContext(ctx, getUserByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserByID: %w", err)
 	}
 	if q.getUserByUsernameStmt, err = db.PrepareContext(ctx, getUserByUsername); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserByUsername: %w", err)
 	}
+	if q.getUserByUsernameKeyStmt, err = db.PrepareContext(ctx, getUserByUsernameKey); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserByUsernameKey: %w", err)
+	}
+	if q.getUserIdentityStmt, err = db.PrepareContext(ctx, getUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserIdentity: %w", err)
+	}
+	if q.getUserTOTPStmt, err = db.PrepareContext(ctx, getUserTOTP); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserTOTP: %w", err)
+	}
+	if q.invalidatePasswordResetCodesStmt, err = db.PrepareContext(ctx, invalidatePasswordResetCodes); err != nil {
+		return nil, fmt.Errorf("error preparing query InvalidatePasswordResetCodes: %w", err)
+	}
+	if q.listChannelCategoriesStmt, err = db.PrepareContext(ctx, listChannelCategories); err != nil {
+		return nil, fmt.Errorf("error preparing query ListChannelCategories: %w", err)
+	}
+	if q.listChannelInviteLinksStmt, err = db.PrepareContext(ctx, listChannelInviteLinks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListChannelInviteLinks: %w", err)
+	}
+	if q.listChannelInvitesForUserStmt, err = db.PrepareContext(ctx, listChannelInvitesForUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListChannelInvitesForUser: %w", err)
+	}
+	if q.listChannelMembersStmt, err = db.PrepareContext(ctx, listChannelMembers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListChannelMembers: %w", err)
+	}
+	if q.listChannelsForUserStmt, err = db.PrepareContext(ctx, listChannelsForUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListChannelsForUser: %w", err)
+	}
+	if q.listChannelsForUserByActivityStmt, err = db.PrepareContext(ctx, listChannelsForUserByActivity); err != nil {
+		return nil, fmt.Errorf("error preparing query ListChannelsForUserByActivity: %w", err)
+	}
+	if q.listChannelsForUserByNameStmt, err = db.PrepareContext(ctx, listChannelsForUserByName); err != nil {
+		return nil, fmt.Errorf("error preparing query ListChannelsForUserByName: %w", err)
+	}
+	if q.listDirectChannelMembersForUserStmt, err = db.PrepareContext(ctx, listDirectChannelMembersForUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListDirectChannelMembersForUser: %w", err)
+	}
+	if q.listMessageRevisionsStmt, err = db.PrepareContext(ctx, listMessageRevisions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListMessageRevisions: %w", err)
+	}
+	if q.listSidebarChannelsForUserStmt, err = db.PrepareContext(ctx, listSidebarChannelsForUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSidebarChannelsForUser: %w", err)
+	}
+	if q.listUnrevokedSessionsByUserStmt, err = db.PrepareContext(ctx, listUnrevokedSessionsByUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUnrevokedSessionsByUser: %w", err)
+	}
+	if q.listUsernameKeysStmt, err = db.PrepareContext(ctx, listUsernameKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUsernameKeys: %w", err)
+	}
+	if q.listUsersStmt, err = db.PrepareContext(ctx, listUsers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUsers: %w", err)
+	}
+	if q.promoteUserToAdminStmt, err = db.PrepareContext(ctx, promoteUserToAdmin); err != nil {
+		return nil, fmt.Errorf("error preparing query PromoteUserToAdmin: %w", err)
+	}
+	if q.recordLoginFailureStmt, err = db.PrepareContext(ctx, recordLoginFailure); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordLoginFailure: %w", err)
+	}
+	if q.rehashUserPasswordStmt, err = db.PrepareContext(ctx, rehashUserPassword); err != nil {
+		return nil, fmt.Errorf("error preparing query RehashUserPassword: %w", err)
+	}
+	if q.removeChannelMemberStmt, err = db.PrepareContext(ctx, removeChannelMember); err != nil {
+		return nil, fmt.Errorf("error preparing query RemoveChannelMember: %w", err)
+	}
+	if q.revokeChannelInviteLinkStmt, err = db.PrepareContext(ctx, revokeChannelInviteLink); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeChannelInviteLink: %w", err)
+	}
+	if q.revokeOtherUserSessionsStmt, err = db.PrepareContext(ctx, revokeOtherUserSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeOtherUserSessions: %w", err)
+	}
+	if q.revokeSessionStmt, err = db.PrepareContext(ctx, revokeSession); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeSession: %w", err)
+	}
+	if q.revokeUserSessionStmt, err = db.PrepareContext(ctx, revokeUserSession); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeUserSession: %w", err)
+	}
+	if q.rotateSessionRefreshTokenStmt, err = db.PrepareContext(ctx, rotateSessionRefreshToken); err != nil {
+		return nil, fmt.Errorf("error preparing query RotateSessionRefreshToken: %w", err)
+	}
+	if q.setChannelCategoryStmt, err = db.PrepareContext(ctx, setChannelCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query SetChannelCategory: %w", err)
+	}
+	if q.setChannelCreatedByStmt, err = db.PrepareContext(ctx, setChannelCreatedBy); err != nil {
+		return nil, fmt.Errorf("error preparing query SetChannelCreatedBy: %w", err)
+	}
+	if q.setChannelFavoriteStmt, err = db.PrepareContext(ctx, setChannelFavorite); err != nil {
+		return nil, fmt.Errorf("error preparing query SetChannelFavorite: %w", err)
+	}
+	if q.setLoginLockoutStmt, err = db.PrepareContext(ctx, setLoginLockout); err != nil {
+		return nil, fmt.Errorf("error preparing query SetLoginLockout: %w", err)
+	}
+	if q.setPersonalPositionStmt, err = db.PrepareContext(ctx, setPersonalPosition); err != nil {
+		return nil, fmt.Errorf("error preparing query SetPersonalPosition: %w", err)
+	}
+	if q.setUsernameKeyStmt, err = db.PrepareContext(ctx, setUsernameKey); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUsernameKey: %w", err)
+	}
+	if q.touchSessionStmt, err = db.PrepareContext(ctx, touchSession); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchSession: %w", err)
+	}
+	if q.touchUserIdentityStmt, err = db.PrepareContext(ctx, touchUserIdentity); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchUserIdentity: %w", err)
+	}
+	if q.unarchiveChannelStmt, err = db.PrepareContext(ctx, unarchiveChannel); err != nil {
+		return nil, fmt.Errorf("error preparing query UnarchiveChannel: %w", err)
+	}
+	if q.updateChannelStmt, err = db.PrepareContext(ctx, updateChannel); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateChannel: %w", err)
+	}
+	if q.updateChannelCategoryStmt, err = db.PrepareContext(ctx, updateChannelCategory); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateChannelCategory: %w", err)
+	}
+	if q.updateChannelMemberRoleStmt, err = db.PrepareContext(ctx, updateChannelMemberRole); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateChannelMemberRole: %w", err)
+	}
+	if q.updateMessageContentStmt, err = db.PrepareContext(ctx, updateMessageContent); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateMessageContent: %w", err)
+	}
+	if q.updateUserPasswordStmt, err = db.PrepareContext(ctx, updateUserPassword); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserPassword: %w", err)
+	}
+	if q.updateUserProfileStmt, err = db.PrepareContext(ctx, updateUserProfile); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserProfile: %w", err)
+	}
+	if q.updateUserRoleStmt, err = db.PrepareContext(ctx, updateUserRole); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserRole: %w", err)
+	}
+	if q.upsertPendingUserTOTPStmt, err = db.PrepareContext(ctx, upsertPendingUserTOTP); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertPendingUserTOTP: %w", err)
+	}
+	if q.useChannelInviteLinkStmt, err = db.PrepareContext(ctx, useChannelInviteLink); err != nil {
+		return nil, fmt.Errorf("error preparing query UseChannelInviteLink: %w", err)
+	}
+	if q.useRecoveryCodeStmt, err = db.PrepareContext(ctx, useRecoveryCode); err != nil {
+		return nil, fmt.Errorf("error preparing query UseRecoveryCode: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.addChannelMemberStmt != nil {
+		if cerr := q.addChannelMemberStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addChannelMemberStmt: %w", cerr)
+		}
+	}
+	if q.advanceUserTOTPStepStmt != nil {
+		if cerr := q.advanceUserTOTPStepStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing advanceUserTOTPStepStmt: %w", cerr)
+		}
+	}
+	if q.archiveChannelStmt != nil {
+		if cerr := q.archiveChannelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing archiveChannelStmt: %w", cerr)
+		}
+	}
+	if q.clearChannelCategoryStmt != nil {
+		if cerr := q.clearChannelCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearChannelCategoryStmt: %w", cerr)
+		}
+	}
+	if q.clearPersonalPositionsStmt != nil {
+		if cerr := q.clearPersonalPositionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearPersonalPositionsStmt: %w", cerr)
+		}
+	}
+	if q.consumeOIDCLoginFlowStmt != nil {
+		if cerr := q.consumeOIDCLoginFlowStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing consumeOIDCLoginFlowStmt: %w", cerr)
+		}
+	}
+	if q.consumePasswordResetCodeStmt != nil {
+		if cerr := q.consumePasswordResetCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing consumePasswordResetCodeStmt: %w", cerr)
+		}
+	}
+	if q.countUnusedRecoveryCodesStmt != nil {
+		if cerr := q.countUnusedRecoveryCodesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countUnusedRecoveryCodesStmt: %w", cerr)
+		}
+	}
+	if q.createAuditEventStmt != nil {
+		if cerr := q.createAuditEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAuditEventStmt: %w", cerr)
+		}
+	}
 	if q.createChannelStmt != nil {
 		if cerr := q.createChannelStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createChannelStmt: %w", cerr)
 		}
 	}
+	if q.createChannelCategoryStmt != nil {
+		if cerr := q.createChannelCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createChannelCategoryStmt: %w", cerr)
+		}
+	}
+	if q.createChannelInviteStmt != nil {
+		if cerr := q.createChannelInviteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createChannelInviteStmt: %w", cerr)
+		}
+	}
+	if q.createChannelInviteLinkStmt != nil {
+		if cerr := q.createChannelInviteLinkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createChannelInviteLinkStmt: %w", cerr)
+		}
+	}
+	if q.createDirectChannelStmt != nil {
+		if cerr := q.createDirectChannelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createDirectChannelStmt: %w", cerr)
+		}
+	}
 	if q.createMessageStmt != nil {
 		if cerr := q.createMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createMessageStmt: %w", cerr)
 		}
 	}
+	if q.createMessageRevisionStmt != nil {
+		if cerr := q.createMessageRevisionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createMessageRevisionStmt: %w", cerr)
+		}
+	}
+	if q.createOIDCLoginFlowStmt != nil {
+		if cerr := q.createOIDCLoginFlowStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOIDCLoginFlowStmt: %w", cerr)
+		}
+	}
+	if q.createPasswordResetCodeStmt != nil {
+		if cerr := q.createPasswordResetCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPasswordResetCodeStmt: %w", cerr)
+		}
+	}
+	if q.createRecoveryCodeStmt != nil {
+		if cerr := q.createRecoveryCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createRecoveryCodeStmt: %w", cerr)
+		}
+	}
+	if q.createSessionStmt != nil {
+		if cerr := q.createSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
+		}
+	}
 	if q.createUserStmt != nil {
 		if cerr := q.createUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createUserStmt: %w", cerr)
 		}
 	}
-	if q.deleteChannelStmt != nil {
-		if cerr := q.deleteChannelStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing deleteChannelStmt: %w", cerr)
+	if q.createUserIdentityStmt != nil {
+		if cerr := q.createUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.deleteChannelByIDStmt != nil {
+		if cerr := q.deleteChannelByIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteChannelByIDStmt: %w", cerr)
+		}
+	}
+	if q.deleteChannelCategoryStmt != nil {
+		if cerr := q.deleteChannelCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteChannelCategoryStmt: %w", cerr)
+		}
+	}
+	if q.deleteChannelInviteStmt != nil {
+		if cerr := q.deleteChannelInviteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteChannelInviteStmt: %w", cerr)
+		}
+	}
+	if q.deleteChannelInviteForUserStmt != nil {
+		if cerr := q.deleteChannelInviteForUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteChannelInviteForUserStmt: %w", cerr)
 		}
 	}
-	if q.getAllChannelsStmt != nil {
-		if cerr := q.getAllChannelsStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getAllChannelsStmt: %w", cerr)
+	if q.deleteExpiredOIDCLoginFlowsStmt != nil {
+		if cerr := q.deleteExpiredOIDCLoginFlowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredOIDCLoginFlowsStmt: %w", cerr)
+		}
+	}
+	if q.deleteLoginThrottleStmt != nil {
+		if cerr := q.deleteLoginThrottleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLoginThrottleStmt: %w", cerr)
+		}
+	}
+	if q.deleteRecoveryCodesStmt != nil {
+		if cerr := q.deleteRecoveryCodesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteRecoveryCodesStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserTOTPStmt != nil {
+		if cerr := q.deleteUserTOTPStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserTOTPStmt: %w", cerr)
+		}
+	}
+	if q.disableUserStmt != nil {
+		if cerr := q.disableUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing disableUserStmt: %w", cerr)
+		}
+	}
+	if q.enableUserStmt != nil {
+		if cerr := q.enableUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing enableUserStmt: %w", cerr)
+		}
+	}
+	if q.enableUserTOTPStmt != nil {
+		if cerr := q.enableUserTOTPStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing enableUserTOTPStmt: %w", cerr)
 		}
 	}
 	if q.getChannelByIDStmt != nil {
@@ -86,11 +493,91 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getChannelByIDStmt: %w", cerr)
 		}
 	}
+	if q.getChannelCategoryStmt != nil {
+		if cerr := q.getChannelCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getChannelCategoryStmt: %w", cerr)
+		}
+	}
+	if q.getChannelCategoryIDByNameStmt != nil {
+		if cerr := q.getChannelCategoryIDByNameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getChannelCategoryIDByNameStmt: %w", cerr)
+		}
+	}
+	if q.getChannelIDByNameStmt != nil {
+		if cerr := q.getChannelIDByNameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getChannelIDByNameStmt: %w", cerr)
+		}
+	}
+	if q.getChannelInviteForUserStmt != nil {
+		if cerr := q.getChannelInviteForUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getChannelInviteForUserStmt: %w", cerr)
+		}
+	}
+	if q.getChannelInviteLinkByTokenHashStmt != nil {
+		if cerr := q.getChannelInviteLinkByTokenHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getChannelInviteLinkByTokenHashStmt: %w", cerr)
+		}
+	}
+	if q.getChannelMemberStmt != nil {
+		if cerr := q.getChannelMemberStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getChannelMemberStmt: %w", cerr)
+		}
+	}
+	if q.getChannelMembershipStmt != nil {
+		if cerr := q.getChannelMembershipStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getChannelMembershipStmt: %w", cerr)
+		}
+	}
+	if q.getChannelOwnerStmt != nil {
+		if cerr := q.getChannelOwnerStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getChannelOwnerStmt: %w", cerr)
+		}
+	}
+	if q.getDirectChannelIDByKeyStmt != nil {
+		if cerr := q.getDirectChannelIDByKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDirectChannelIDByKeyStmt: %w", cerr)
+		}
+	}
+	if q.getHistoryMessagesAfterStmt != nil {
+		if cerr := q.getHistoryMessagesAfterStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getHistoryMessagesAfterStmt: %w", cerr)
+		}
+	}
 	if q.getHistoryMessagesByChannelStmt != nil {
 		if cerr := q.getHistoryMessagesByChannelStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getHistoryMessagesByChannelStmt: %w", cerr)
 		}
 	}
+	if q.getLoginThrottleStmt != nil {
+		if cerr := q.getLoginThrottleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLoginThrottleStmt: %w", cerr)
+		}
+	}
+	if q.getMessageByIDStmt != nil {
+		if cerr := q.getMessageByIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getMessageByIDStmt: %w", cerr)
+		}
+	}
+	if q.getPasswordResetCodeStmt != nil {
+		if cerr := q.getPasswordResetCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPasswordResetCodeStmt: %w", cerr)
+		}
+	}
+	if q.getSessionByIDStmt != nil {
+		if cerr := q.getSessionByIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSessionByIDStmt: %w", cerr)
+		}
+	}
+	if q.getSessionByRefreshTokenHashStmt != nil {
+		if cerr := q.getSessionByRefreshTokenHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSessionByRefreshTokenHashStmt: %w", cerr)
+		}
+	}
+	if q.getUserByEmailStmt != nil {
+		if cerr := q.getUserByEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserByEmailStmt: %w", cerr)
+		}
+	}
 	if q.getUserByIDStmt != nil {
 		if cerr := q.getUserByIDStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUserByIDStmt: %w", cerr)
@@ -101,6 +588,231 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getUserByUsernameStmt: %w", cerr)
 		}
 	}
+	if q.getUserByUsernameKeyStmt != nil {
+		if cerr := q.getUserByUsernameKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserByUsernameKeyStmt: %w", cerr)
+		}
+	}
+	if q.getUserIdentityStmt != nil {
+		if cerr := q.getUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.getUserTOTPStmt != nil {
+		if cerr := q.getUserTOTPStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserTOTPStmt: %w", cerr)
+		}
+	}
+	if q.invalidatePasswordResetCodesStmt != nil {
+		if cerr := q.invalidatePasswordResetCodesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing invalidatePasswordResetCodesStmt: %w", cerr)
+		}
+	}
+	if q.listChannelCategoriesStmt != nil {
+		if cerr := q.listChannelCategoriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listChannelCategoriesStmt: %w", cerr)
+		}
+	}
+	if q.listChannelInviteLinksStmt != nil {
+		if cerr := q.listChannelInviteLinksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listChannelInviteLinksStmt: %w", cerr)
+		}
+	}
+	if q.listChannelInvitesForUserStmt != nil {
+		if cerr := q.listChannelInvitesForUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listChannelInvitesForUserStmt: %w", cerr)
+		}
+	}
+	if q.listChannelMembersStmt != nil {
+		if cerr := q.listChannelMembersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listChannelMembersStmt: %w", cerr)
+		}
+	}
+	if q.listChannelsForUserStmt != nil {
+		if cerr := q.listChannelsForUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listChannelsForUserStmt: %w", cerr)
+		}
+	}
+	if q.listChannelsForUserByActivityStmt != nil {
+		if cerr := q.listChannelsForUserByActivityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listChannelsForUserByActivityStmt: %w", cerr)
+		}
+	}
+	if q.listChannelsForUserByNameStmt != nil {
+		if cerr := q.listChannelsForUserByNameStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listChannelsForUserByNameStmt: %w", cerr)
+		}
+	}
+	if q.listDirectChannelMembersForUserStmt != nil {
+		if cerr := q.listDirectChannelMembersForUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listDirectChannelMembersForUserStmt: %w", cerr)
+		}
+	}
+	if q.listMessageRevisionsStmt != nil {
+		if cerr := q.listMessageRevisionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listMessageRevisionsStmt: %w", cerr)
+		}
+	}
+	if q.listSidebarChannelsForUserStmt != nil {
+		if cerr := q.listSidebarChannelsForUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSidebarChannelsForUserStmt: %w", cerr)
+		}
+	}
+	if q.listUnrevokedSessionsByUserStmt != nil {
+		if cerr := q.listUnrevokedSessionsByUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUnrevokedSessionsByUserStmt: %w", cerr)
+		}
+	}
+	if q.listUsernameKeysStmt != nil {
+		if cerr := q.listUsernameKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUsernameKeysStmt: %w", cerr)
+		}
+	}
+	if q.listUsersStmt != nil {
+		if cerr := q.listUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUsersStmt: %w", cerr)
+		}
+	}
+	if q.promoteUserToAdminStmt != nil {
+		if cerr := q.promoteUserToAdminStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing promoteUserToAdminStmt: %w", cerr)
+		}
+	}
+	if q.recordLoginFailureStmt != nil {
+		if cerr := q.recordLoginFailureStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordLoginFailureStmt: %w", cerr)
+		}
+	}
+	if q.rehashUserPasswordStmt != nil {
+		if cerr := q.rehashUserPasswordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing rehashUserPasswordStmt: %w", cerr)
+		}
+	}
+	if q.removeChannelMemberStmt != nil {
+		if cerr := q.removeChannelMemberStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing removeChannelMemberStmt: %w", cerr)
+		}
+	}
+	if q.revokeChannelInviteLinkStmt != nil {
+		if cerr := q.revokeChannelInviteLinkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeChannelInviteLinkStmt: %w", cerr)
+		}
+	}
+	if q.revokeOtherUserSessionsStmt != nil {
+		if cerr := q.revokeOtherUserSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeOtherUserSessionsStmt: %w", cerr)
+		}
+	}
+	if q.revokeSessionStmt != nil {
+		if cerr := q.revokeSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeSessionStmt: %w", cerr)
+		}
+	}
+	if q.revokeUserSessionStmt != nil {
+		if cerr := q.revokeUserSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeUserSessionStmt: %w", cerr)
+		}
+	}
+	if q.rotateSessionRefreshTokenStmt != nil {
+		if cerr := q.rotateSessionRefreshTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing rotateSessionRefreshTokenStmt: %w", cerr)
+		}
+	}
+	if q.setChannelCategoryStmt != nil {
+		if cerr := q.setChannelCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setChannelCategoryStmt: %w", cerr)
+		}
+	}
+	if q.setChannelCreatedByStmt != nil {
+		if cerr := q.setChannelCreatedByStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setChannelCreatedByStmt: %w", cerr)
+		}
+	}
+	if q.setChannelFavoriteStmt != nil {
+		if cerr := q.setChannelFavoriteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setChannelFavoriteStmt: %w", cerr)
+		}
+	}
+	if q.setLoginLockoutStmt != nil {
+		if cerr := q.setLoginLockoutStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setLoginLockoutStmt: %w", cerr)
+		}
+	}
+	if q.setPersonalPositionStmt != nil {
+		if cerr := q.setPersonalPositionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setPersonalPositionStmt: %w", cerr)
+		}
+	}
+	if q.setUsernameKeyStmt != nil {
+		if cerr := q.setUsernameKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUsernameKeyStmt: %w", cerr)
+		}
+	}
+	if q.touchSessionStmt != nil {
+		if cerr := q.touchSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchSessionStmt: %w", cerr)
+		}
+	}
+	if q.touchUserIdentityStmt != nil {
+		if cerr := q.touchUserIdentityStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchUserIdentityStmt: %w", cerr)
+		}
+	}
+	if q.unarchiveChannelStmt != nil {
+		if cerr := q.unarchiveChannelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing unarchiveChannelStmt: %w", cerr)
+		}
+	}
+	if q.updateChannelStmt != nil {
+		if cerr := q.updateChannelStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateChannelStmt: %w", cerr)
+		}
+	}
+	if q.updateChannelCategoryStmt != nil {
+		if cerr := q.updateChannelCategoryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateChannelCategoryStmt: %w", cerr)
+		}
+	}
+	if q.updateChannelMemberRoleStmt != nil {
+		if cerr := q.updateChannelMemberRoleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateChannelMemberRoleStmt: %w", cerr)
+		}
+	}
+	if q.updateMessageContentStmt != nil {
+		if cerr := q.updateMessageContentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateMessageContentStmt: %w", cerr)
+		}
+	}
+	if q.updateUserPasswordStmt != nil {
+		if cerr := q.updateUserPasswordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserPasswordStmt: %w", cerr)
+		}
+	}
+	if q.updateUserProfileStmt != nil {
+		if cerr := q.updateUserProfileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserProfileStmt: %w", cerr)
+		}
+	}
+	if q.updateUserRoleStmt != nil {
+		if cerr := q.updateUserRoleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserRoleStmt: %w", cerr)
+		}
+	}
+	if q.upsertPendingUserTOTPStmt != nil {
+		if cerr := q.upsertPendingUserTOTPStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertPendingUserTOTPStmt: %w", cerr)
+		}
+	}
+	if q.useChannelInviteLinkStmt != nil {
+		if cerr := q.useChannelInviteLinkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useChannelInviteLinkStmt: %w", cerr)
+		}
+	}
+	if q.useRecoveryCodeStmt != nil {
+		if cerr := q.useRecoveryCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useRecoveryCodeStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -138,31 +850,209 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                              DBTX
-	tx                              *sql.Tx
-	createChannelStmt               *sql.Stmt
-	createMessageStmt               *sql.Stmt
-	createUserStmt                  *sql.Stmt
-	deleteChannelStmt               *sql.Stmt
-	getAllChannelsStmt              *sql.Stmt
-	getChannelByIDStmt              *sql.Stmt
-	getHistoryMessagesByChannelStmt *sql.Stmt
-	getUserByIDStmt                 *sql.Stmt
-	getUserByUsernameStmt           *sql.Stmt
+	db                                  DBTX
+	tx                                  *sql.Tx
+	addChannelMemberStmt                *sql.Stmt
+	advanceUserTOTPStepStmt             *sql.Stmt
+	archiveChannelStmt                  *sql.Stmt
+	clearChannelCategoryStmt            *sql.Stmt
+	clearPersonalPositionsStmt          *sql.Stmt
+	consumeOIDCLoginFlowStmt            *sql.Stmt
+	consumePasswordResetCodeStmt        *sql.Stmt
+	countUnusedRecoveryCodesStmt        *sql.Stmt
+	createAuditEventStmt                *sql.Stmt
+	createChannelStmt                   *sql.Stmt
+	createChannelCategoryStmt           *sql.Stmt
+	createChannelInviteStmt             *sql.Stmt
+	createChannelInviteLinkStmt         *sql.Stmt
+	createDirectChannelStmt             *sql.Stmt
+	createMessageStmt                   *sql.Stmt
+	createMessageRevisionStmt           *sql.Stmt
+	createOIDCLoginFlowStmt             *sql.Stmt
+	createPasswordResetCodeStmt         *sql.Stmt
+	createRecoveryCodeStmt              *sql.Stmt
+	createSessionStmt                   *sql.Stmt
+	createUserStmt                      *sql.Stmt
+	createUserIdentityStmt              *sql.Stmt
+	deleteChannelByIDStmt               *sql.Stmt
+	deleteChannelCategoryStmt           *sql.Stmt
+	deleteChannelInviteStmt             *sql.Stmt
+	deleteChannelInviteForUserStmt      *sql.Stmt
+	deleteExpiredOIDCLoginFlowsStmt     *sql.Stmt
+	deleteLoginThrottleStmt             *sql.Stmt
+	deleteRecoveryCodesStmt             *sql.Stmt
+	deleteUserTOTPStmt                  *sql.Stmt
+	disableUserStmt                     *sql.Stmt
+	enableUserStmt                      *sql.Stmt
+	enableUserTOTPStmt                  *sql.Stmt
+	getChannelByIDStmt                  *sql.Stmt
+	getChannelCategoryStmt              *sql.Stmt
+	getChannelCategoryIDByNameStmt      *sql.Stmt
+	getChannelIDByNameStmt              *sql.Stmt
+	getChannelInviteForUserStmt         *sql.Stmt
+	getChannelInviteLinkByTokenHashStmt *sql.Stmt
+	getChannelMemberStmt                *sql.Stmt
+	getChannelMembershipStmt            *sql.Stmt
+	getChannelOwnerStmt                 *sql.Stmt
+	getDirectChannelIDByKeyStmt         *sql.Stmt
+	getHistoryMessagesAfterStmt         *sql.Stmt
+	getHistoryMessagesByChannelStmt     *sql.Stmt
+	getLoginThrottleStmt                *sql.Stmt
+	getMessageByIDStmt                  *sql.Stmt
+	getPasswordResetCodeStmt            *sql.Stmt
+	getSessionByIDStmt                  *sql.Stmt
+	getSessionByRefreshTokenHashStmt    *sql.Stmt
+	getUserByEmailStmt                  *sql.Stmt
+	getUserByIDStmt                     *sql.Stmt
+	getUserByUsernameStmt               *sql.Stmt
+	getUserByUsernameKeyStmt            *sql.Stmt
+	getUserIdentityStmt                 *sql.Stmt
+	getUserTOTPStmt                     *sql.Stmt
+	invalidatePasswordResetCodesStmt    *sql.Stmt
+	listChannelCategoriesStmt           *sql.Stmt
+	listChannelInviteLinksStmt          *sql.Stmt
+	listChannelInvitesForUserStmt       *sql.Stmt
+	listChannelMembersStmt              *sql.Stmt
+	listChannelsForUserStmt             *sql.Stmt
+	listChannelsForUserByActivityStmt   *sql.Stmt
+	listChannelsForUserByNameStmt       *sql.Stmt
+	listDirectChannelMembersForUserStmt *sql.Stmt
+	listMessageRevisionsStmt            *sql.Stmt
+	listSidebarChannelsForUserStmt      *sql.Stmt
+	listUnrevokedSessionsByUserStmt     *sql.Stmt
+	listUsernameKeysStmt                *sql.Stmt
+	listUsersStmt                       *sql.Stmt
+	promoteUserToAdminStmt              *sql.Stmt
+	recordLoginFailureStmt              *sql.Stmt
+	rehashUserPasswordStmt              *sql.Stmt
+	removeChannelMemberStmt             *sql.Stmt
+	revokeChannelInviteLinkStmt         *sql.Stmt
+	revokeOtherUserSessionsStmt         *sql.Stmt
+	revokeSessionStmt                   *sql.Stmt
+	revokeUserSessionStmt               *sql.Stmt
+	rotateSessionRefreshTokenStmt       *sql.Stmt
+	setChannelCategoryStmt              *sql.Stmt
+	setChannelCreatedByStmt             *sql.Stmt
+	setChannelFavoriteStmt              *sql.Stmt
+	setLoginLockoutStmt                 *sql.Stmt
+	setPersonalPositionStmt             *sql.Stmt
+	setUsernameKeyStmt                  *sql.Stmt
+	touchSessionStmt                    *sql.Stmt
+	touchUserIdentityStmt               *sql.Stmt
+	unarchiveChannelStmt                *sql.Stmt
+	updateChannelStmt                   *sql.Stmt
+	updateChannelCategoryStmt           *sql.Stmt
+	updateChannelMemberRoleStmt         *sql.Stmt
+	updateMessageContentStmt            *sql.Stmt
+	updateUserPasswordStmt              *sql.Stmt
+	updateUserProfileStmt               *sql.Stmt
+	updateUserRoleStmt                  *sql.Stmt
+	upsertPendingUserTOTPStmt           *sql.Stmt
+	useChannelInviteLinkStmt            *sql.Stmt
+	useRecoveryCodeStmt                 *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                              tx,
-		tx:                              tx,
-		createChannelStmt:               q.createChannelStmt,
-		createMessageStmt:               q.createMessageStmt,
-		createUserStmt:                  q.createUserStmt,
-		deleteChannelStmt:               q.deleteChannelStmt,
-		getAllChannelsStmt:              q.getAllChannelsStmt,
-		getChannelByIDStmt:              q.getChannelByIDStmt,
-		getHistoryMessagesByChannelStmt: q.getHistoryMessagesByChannelStmt,
-		getUserByIDStmt:                 q.getUserByIDStmt,
-		getUserByUsernameStmt:           q.getUserByUsernameStmt,
+		db:                                  tx,
+		tx:                                  tx,
+		addChannelMemberStmt:                q.addChannelMemberStmt,
+		advanceUserTOTPStepStmt:             q.advanceUserTOTPStepStmt,
+		archiveChannelStmt:                  q.archiveChannelStmt,
+		clearChannelCategoryStmt:            q.clearChannelCategoryStmt,
+		clearPersonalPositionsStmt:          q.clearPersonalPositionsStmt,
+		consumeOIDCLoginFlowStmt:            q.consumeOIDCLoginFlowStmt,
+		consumePasswordResetCodeStmt:        q.consumePasswordResetCodeStmt,
+		countUnusedRecoveryCodesStmt:        q.countUnusedRecoveryCodesStmt,
+		createAuditEventStmt:                q.createAuditEventStmt,
+		createChannelStmt:                   q.createChannelStmt,
+		createChannelCategoryStmt:           q.createChannelCategoryStmt,
+		createChannelInviteStmt:             q.createChannelInviteStmt,
+		createChannelInviteLinkStmt:         q.createChannelInviteLinkStmt,
+		createDirectChannelStmt:             q.createDirectChannelStmt,
+		createMessageStmt:                   q.createMessageStmt,
+		createMessageRevisionStmt:           q.createMessageRevisionStmt,
+		createOIDCLoginFlowStmt:             q.createOIDCLoginFlowStmt,
+		createPasswordResetCodeStmt:         q.createPasswordResetCodeStmt,
+		createRecoveryCodeStmt:              q.createRecoveryCodeStmt,
+		createSessionStmt:                   q.createSessionStmt,
+		createUserStmt:                      q.createUserStmt,
+		createUserIdentityStmt:              q.createUserIdentityStmt,
+		deleteChannelByIDStmt:               q.deleteChannelByIDStmt,
+		deleteChannelCategoryStmt:           q.deleteChannelCategoryStmt,
+		deleteChannelInviteStmt:             q.deleteChannelInviteStmt,
+		deleteChannelInviteForUserStmt:      q.deleteChannelInviteForUserStmt,
+		deleteExpiredOIDCLoginFlowsStmt:     q.deleteExpiredOIDCLoginFlowsStmt,
+		deleteLoginThrottleStmt:             q.deleteLoginThrottleStmt,
+		deleteRecoveryCodesStmt:             q.deleteRecoveryCodesStmt,
+		deleteUserTOTPStmt:                  q.deleteUserTOTPStmt,
+		disableUserStmt:                     q.disableUserStmt,
+		enableUserStmt:                      q.enableUserStmt,
+		enableUserTOTPStmt:                  q.enableUserTOTPStmt,
+		getChannelByIDStmt:                  q.getChannelByIDStmt,
+		getChannelCategoryStmt:              q.getChannelCategoryStmt,
+		getChannelCategoryIDByNameStmt:      q.getChannelCategoryIDByNameStmt,
+		getChannelIDByNameStmt:              q.getChannelIDByNameStmt,
+		getChannelInviteForUserStmt:         q.getChannelInviteForUserStmt,
+		getChannelInviteLinkByTokenHashStmt: q.getChannelInviteLinkByTokenHashStmt,
+		getChannelMemberStmt:                q.getChannelMemberStmt,
+		getChannelMembershipStmt:            q.getChannelMembershipStmt,
+		getChannelOwnerStmt:                 q.getChannelOwnerStmt,
+		getDirectChannelIDByKeyStmt:         q.getDirectChannelIDByKeyStmt,
+		getHistoryMessagesAfterStmt:         q.getHistoryMessagesAfterStmt,
+		getHistoryMessagesByChannelStmt:     q.getHistoryMessagesByChannelStmt,
+		getLoginThrottleStmt:                q.getLoginThrottleStmt,
+		getMessageByIDStmt:                  q.getMessageByIDStmt,
+		getPasswordResetCodeStmt:            q.getPasswordResetCodeStmt,
+		getSessionByIDStmt:                  q.getSessionByIDStmt,
+		getSessionByRefreshTokenHashStmt:    q.getSessionByRefreshTokenHashStmt,
+		getUserByEmailStmt:                  q.getUserByEmailStmt,
+		getUserByIDStmt:                     q.getUserByIDStmt,
+		getUserByUsernameStmt:               q.getUserByUsernameStmt,
+		getUserByUsernameKeyStmt:            q.getUserByUsernameKeyStmt,
+		getUserIdentityStmt:                 q.getUserIdentityStmt,
+		getUserTOTPStmt:                     q.getUserTOTPStmt,
+		invalidatePasswordResetCodesStmt:    q.invalidatePasswordResetCodesStmt,
+		listChannelCategoriesStmt:           q.listChannelCategoriesStmt,
+		listChannelInviteLinksStmt:          q.listChannelInviteLinksStmt,
+		listChannelInvitesForUserStmt:       q.listChannelInvitesForUserStmt,
+		listChannelMembersStmt:              q.listChannelMembersStmt,
+		listChannelsForUserStmt:             q.listChannelsForUserStmt,
+		listChannelsForUserByActivityStmt:   q.listChannelsForUserByActivityStmt,
+		listChannelsForUserByNameStmt:       q.listChannelsForUserByNameStmt,
+		listDirectChannelMembersForUserStmt: q.listDirectChannelMembersForUserStmt,
+		listMessageRevisionsStmt:            q.listMessageRevisionsStmt,
+		listSidebarChannelsForUserStmt:      q.listSidebarChannelsForUserStmt,
+		listUnrevokedSessionsByUserStmt:     q.listUnrevokedSessionsByUserStmt,
+		listUsernameKeysStmt:                q.listUsernameKeysStmt,
+		listUsersStmt:                       q.listUsersStmt,
+		promoteUserToAdminStmt:              q.promoteUserToAdminStmt,
+		recordLoginFailureStmt:              q.recordLoginFailureStmt,
+		rehashUserPasswordStmt:              q.rehashUserPasswordStmt,
+		removeChannelMemberStmt:             q.removeChannelMemberStmt,
+		revokeChannelInviteLinkStmt:         q.revokeChannelInviteLinkStmt,
+		revokeOtherUserSessionsStmt:         q.revokeOtherUserSessionsStmt,
+		revokeSessionStmt:                   q.revokeSessionStmt,
+		revokeUserSessionStmt:               q.revokeUserSessionStmt,
+		rotateSessionRefreshTokenStmt:       q.rotateSessionRefreshTokenStmt,
+		setChannelCategoryStmt:              q.setChannelCategoryStmt,
+		setChannelCreatedByStmt:             q.setChannelCreatedByStmt,
+		setChannelFavoriteStmt:              q.setChannelFavoriteStmt,
+		setLoginLockoutStmt:                 q.setLoginLockoutStmt,
+		setPersonalPositionStmt:             q.setPersonalPositionStmt,
+		setUsernameKeyStmt:                  q.setUsernameKeyStmt,
+		touchSessionStmt:                    q.touchSessionStmt,
+		touchUserIdentityStmt:               q.touchUserIdentityStmt,
+		unarchiveChannelStmt:                q.unarchiveChannelStmt,
+		updateChannelStmt:                   q.updateChannelStmt,
+		updateChannelCategoryStmt:           q.updateChannelCategoryStmt,
+		updateChannelMemberRoleStmt:         q.updateChannelMemberRoleStmt,
+		updateMessageContentStmt:            q.updateMessageContentStmt,
+		updateUserPasswordStmt:              q.updateUserPasswordStmt,
+		updateUserProfileStmt:               q.updateUserProfileStmt,
+		updateUserRoleStmt:                  q.updateUserRoleStmt,
+		upsertPendingUserTOTPStmt:           q.upsertPendingUserTOTPStmt,
+		useChannelInviteLinkStmt:            q.useChannelInviteLinkStmt,
+		useRecoveryCodeStmt:                 q.useRecoveryCodeStmt,
 	}
 }
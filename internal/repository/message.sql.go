@@ -7,31 +7,153 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
-const createMessage = `-- name: CreateMessage :exec
-INSERT INTO messages (channel_id, user_id, user_color, content)
-VALUES (?, ?, ?, ?)
+const createMessage = `-- name: CreateMessage :one
+INSERT INTO messages (channel_id, user_id, user_color, content, sequence)
+VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    (SELECT COALESCE(MAX(sequence), 0) + 1 FROM messages WHERE channel_id = ?)
+)
+RETURNING id, sequence, created_at
 `
 
 type CreateMessageParams struct {
-	ChannelID int64  `json:"channelId"`
-	UserID    int64  `json:"userId"`
-	UserColor string `json:"userColor"`
-	Content   string `json:"content"`
+	ChannelID         int64  `json:"channelId"`
+	UserID            int64  `json:"userId"`
+	UserColor         string `json:"userColor"`
+	Content           string `json:"content"`
+	SequenceChannelID int64  `json:"sequenceChannelId"`
+}
+
+type CreateMessageRow struct {
+	ID        int64     `json:"id"`
+	Sequence  int64     `json:"sequence"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
-func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) error {
-	_, err := q.exec(ctx, q.createMessageStmt, createMessage,
+func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (CreateMessageRow, error) {
+	row := q.queryRow(ctx, q.createMessageStmt, createMessage,
 		arg.ChannelID,
 		arg.UserID,
 		arg.UserColor,
 		arg.Content,
+		arg.SequenceChannelID,
 	)
+	var i CreateMessageRow
+	err := row.Scan(
+		&i.ID,
+		&i.Sequence,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createMessageRevision = `-- name: CreateMessageRevision :exec
+INSERT INTO message_revisions (message_id, content, edited_by)
+VALUES (?, ?, ?)
+`
+
+type CreateMessageRevisionParams struct {
+	MessageID int64  `json:"messageId"`
+	Content   string `json:"content"`
+	EditedBy  int64  `json:"editedBy"`
+}
+
+func (q *Queries) CreateMessageRevision(ctx context.Context, arg CreateMessageRevisionParams) error {
+	_, err := q.exec(ctx, q.createMessageRevisionStmt, createMessageRevision, arg.MessageID, arg.Content, arg.EditedBy)
 	return err
 }
 
+const getHistoryMessagesAfter = `-- name: GetHistoryMessagesAfter :many
+SELECT
+    m.id,
+    m.channel_id,
+    m.user_id,
+    m.user_color,
+    u.username AS user_username,
+    u.display_name AS user_display_name,
+    u.avatar_url AS user_avatar_url,
+    u.color AS user_profile_color,
+    m.content,
+    m.sequence,
+    m.created_at,
+    m.edited_at
+FROM
+    messages AS m
+INNER JOIN
+    users AS u ON u.id = m.user_id
+WHERE
+    m.channel_id = ?
+    AND m.id > ?
+ORDER BY
+    m.id ASC
+LIMIT
+    ?
+`
+
+type GetHistoryMessagesAfterParams struct {
+	ChannelID int64 `json:"channelId"`
+	AfterID   int64 `json:"afterId"`
+	Limit     int64 `json:"limit"`
+}
+
+type GetHistoryMessagesAfterRow struct {
+	ID               int64          `json:"id"`
+	ChannelID        int64          `json:"channelId"`
+	UserID           int64          `json:"userId"`
+	UserColor        string         `json:"userColor"`
+	UserUsername     string         `json:"userUsername"`
+	UserDisplayName  sql.NullString `json:"userDisplayName"`
+	UserAvatarUrl    sql.NullString `json:"userAvatarUrl"`
+	UserProfileColor sql.NullString `json:"userProfileColor"`
+	Content          string         `json:"content"`
+	Sequence         int64          `json:"sequence"`
+	CreatedAt        time.Time      `json:"createdAt"`
+	EditedAt         sql.NullTime   `json:"editedAt"`
+}
+
+func (q *Queries) GetHistoryMessagesAfter(ctx context.Context, arg GetHistoryMessagesAfterParams) ([]GetHistoryMessagesAfterRow, error) {
+	rows, err := q.query(ctx, q.getHistoryMessagesAfterStmt, getHistoryMessagesAfter, arg.ChannelID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetHistoryMessagesAfterRow
+	for rows.Next() {
+		var i GetHistoryMessagesAfterRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChannelID,
+			&i.UserID,
+			&i.UserColor,
+			&i.UserUsername,
+			&i.UserDisplayName,
+			&i.UserAvatarUrl,
+			&i.UserProfileColor,
+			&i.Content,
+			&i.Sequence,
+			&i.CreatedAt,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getHistoryMessagesByChannel = `-- name: GetHistoryMessagesByChannel :many
 SELECT
     m.id,
@@ -39,37 +161,49 @@ SELECT
     m.user_id,
     m.user_color,
     u.username AS user_username,
+    u.display_name AS user_display_name,
+    u.avatar_url AS user_avatar_url,
+    u.color AS user_profile_color,
     m.content,
-    m.created_at
+    m.sequence,
+    m.created_at,
+    m.edited_at
 FROM
     messages AS m
 INNER JOIN
     users AS u ON u.id = m.user_id
 WHERE
     m.channel_id = ?
+    AND m.id < ?
 ORDER BY
-    m.created_at ASC
+    m.id DESC
 LIMIT
     ?
 `
 
 type GetHistoryMessagesByChannelParams struct {
 	ChannelID int64 `json:"channelId"`
+	BeforeID  int64 `json:"beforeId"`
 	Limit     int64 `json:"limit"`
 }
 
 type GetHistoryMessagesByChannelRow struct {
-	ID           int64     `json:"id"`
-	ChannelID    int64     `json:"channelId"`
-	UserID       int64     `json:"userId"`
-	UserColor    string    `json:"userColor"`
-	UserUsername string    `json:"userUsername"`
-	Content      string    `json:"content"`
-	CreatedAt    time.Time `json:"createdAt"`
+	ID               int64          `json:"id"`
+	ChannelID        int64          `json:"channelId"`
+	UserID           int64          `json:"userId"`
+	UserColor        string         `json:"userColor"`
+	UserUsername     string         `json:"userUsername"`
+	UserDisplayName  sql.NullString `json:"userDisplayName"`
+	UserAvatarUrl    sql.NullString `json:"userAvatarUrl"`
+	UserProfileColor sql.NullString `json:"userProfileColor"`
+	Content          string         `json:"content"`
+	Sequence         int64          `json:"sequence"`
+	CreatedAt        time.Time      `json:"createdAt"`
+	EditedAt         sql.NullTime   `json:"editedAt"`
 }
 
 func (q *Queries) GetHistoryMessagesByChannel(ctx context.Context, arg GetHistoryMessagesByChannelParams) ([]GetHistoryMessagesByChannelRow, error) {
-	rows, err := q.query(ctx, q.getHistoryMessagesByChannelStmt, getHistoryMessagesByChannel, arg.ChannelID, arg.Limit)
+	rows, err := q.query(ctx, q.getHistoryMessagesByChannelStmt, getHistoryMessagesByChannel, arg.ChannelID, arg.BeforeID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -83,7 +217,126 @@ func (q *Queries) GetHistoryMessagesByChannel(ctx context.Context, arg GetHistor
 			&i.UserID,
 			&i.UserColor,
 			&i.UserUsername,
+			&i.UserDisplayName,
+			&i.UserAvatarUrl,
+			&i.UserProfileColor,
+			&i.Content,
+			&i.Sequence,
+			&i.CreatedAt,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMessageByID = `-- name: GetMessageByID :one
+SELECT
+    m.id,
+    m.channel_id,
+    m.user_id,
+    m.user_color,
+    u.username AS user_username,
+    u.display_name AS user_display_name,
+    u.avatar_url AS user_avatar_url,
+    u.color AS user_profile_color,
+    m.content,
+    m.sequence,
+    m.created_at,
+    m.edited_at
+FROM
+    messages AS m
+INNER JOIN
+    users AS u ON u.id = m.user_id
+WHERE
+    m.id = ?
+`
+
+type GetMessageByIDRow struct {
+	ID               int64          `json:"id"`
+	ChannelID        int64          `json:"channelId"`
+	UserID           int64          `json:"userId"`
+	UserColor        string         `json:"userColor"`
+	UserUsername     string         `json:"userUsername"`
+	UserDisplayName  sql.NullString `json:"userDisplayName"`
+	UserAvatarUrl    sql.NullString `json:"userAvatarUrl"`
+	UserProfileColor sql.NullString `json:"userProfileColor"`
+	Content          string         `json:"content"`
+	Sequence         int64          `json:"sequence"`
+	CreatedAt        time.Time      `json:"createdAt"`
+	EditedAt         sql.NullTime   `json:"editedAt"`
+}
+
+func (q *Queries) GetMessageByID(ctx context.Context, id int64) (GetMessageByIDRow, error) {
+	row := q.queryRow(ctx, q.getMessageByIDStmt, getMessageByID, id)
+	var i GetMessageByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.ChannelID,
+		&i.UserID,
+		&i.UserColor,
+		&i.UserUsername,
+		&i.UserDisplayName,
+		&i.UserAvatarUrl,
+		&i.UserProfileColor,
+		&i.Content,
+		&i.Sequence,
+		&i.CreatedAt,
+		&i.EditedAt,
+	)
+	return i, err
+}
+
+const listMessageRevisions = `-- name: ListMessageRevisions :many
+SELECT
+    r.id,
+    r.message_id,
+    r.content,
+    r.edited_by,
+    u.username AS edited_by_username,
+    r.created_at
+FROM
+    message_revisions AS r
+LEFT JOIN
+    users AS u ON u.id = r.edited_by
+WHERE
+    r.message_id = ?
+ORDER BY
+    r.id ASC
+`
+
+type ListMessageRevisionsRow struct {
+	ID               int64          `json:"id"`
+	MessageID        int64          `json:"messageId"`
+	Content          string         `json:"content"`
+	EditedBy         sql.NullInt64  `json:"editedBy"`
+	EditedByUsername sql.NullString `json:"editedByUsername"`
+	CreatedAt        time.Time      `json:"createdAt"`
+}
+
+func (q *Queries) ListMessageRevisions(ctx context.Context, messageID int64) ([]ListMessageRevisionsRow, error) {
+	rows, err := q.query(ctx, q.listMessageRevisionsStmt, listMessageRevisions, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMessageRevisionsRow
+	for rows.Next() {
+		var i ListMessageRevisionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
 			&i.Content,
+			&i.EditedBy,
+			&i.EditedByUsername,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -98,3 +351,19 @@ func (q *Queries) GetHistoryMessagesByChannel(ctx context.Context, arg GetHistor
 	}
 	return items, nil
 }
+
+const updateMessageContent = `-- name: UpdateMessageContent :exec
+UPDATE messages
+SET content = ?, edited_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateMessageContentParams struct {
+	Content string `json:"content"`
+	ID      int64  `json:"id"`
+}
+
+func (q *Queries) UpdateMessageContent(ctx context.Context, arg UpdateMessageContentParams) error {
+	_, err := q.exec(ctx, q.updateMessageContentStmt, updateMessageContent, arg.Content, arg.ID)
+	return err
+}
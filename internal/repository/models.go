@@ -9,26 +9,162 @@ import (
 	"time"
 )
 
+type AuditEvent struct {
+	ID        int64          `json:"id"`
+	Action    string         `json:"action"`
+	ActorID   sql.NullInt64  `json:"actorId"`
+	Subject   string         `json:"subject"`
+	IpAddress sql.NullString `json:"ipAddress"`
+	Details   sql.NullString `json:"details"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
 type Channel struct {
 	ID          int64          `json:"id"`
 	Name        string         `json:"name"`
 	Description sql.NullString `json:"description"`
 	CreatedBy   int64          `json:"createdBy"`
 	CreatedAt   time.Time      `json:"createdAt"`
+	IsPrivate   bool           `json:"isPrivate"`
+	Topic       sql.NullString `json:"topic"`
+	ArchivedAt  sql.NullTime   `json:"archivedAt"`
+	Kind        string         `json:"kind"`
+	DirectKey   sql.NullString `json:"directKey"`
+	CategoryID  sql.NullInt64  `json:"categoryId"`
+	Position    int64          `json:"position"`
 }
 
-type Message struct {
+type ChannelCategory struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Position  int64     `json:"position"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ChannelInvite struct {
 	ID        int64     `json:"id"`
 	ChannelID int64     `json:"channelId"`
 	UserID    int64     `json:"userId"`
-	UserColor string    `json:"userColor"`
-	Content   string    `json:"content"`
+	InvitedBy int64     `json:"invitedBy"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
+type ChannelInviteLink struct {
+	ID        int64         `json:"id"`
+	ChannelID int64         `json:"channelId"`
+	TokenHash string        `json:"tokenHash"`
+	CreatedBy int64         `json:"createdBy"`
+	ExpiresAt sql.NullTime  `json:"expiresAt"`
+	MaxUses   sql.NullInt64 `json:"maxUses"`
+	Uses      int64         `json:"uses"`
+	RevokedAt sql.NullTime  `json:"revokedAt"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+type ChannelMember struct {
+	ChannelID        int64         `json:"channelId"`
+	UserID           int64         `json:"userId"`
+	JoinedAt         time.Time     `json:"joinedAt"`
+	Role             string        `json:"role"`
+	Favorite         bool          `json:"favorite"`
+	PersonalPosition sql.NullInt64 `json:"personalPosition"`
+}
+
+type LoginThrottle struct {
+	Scope         string       `json:"scope"`
+	Subject       string       `json:"subject"`
+	Failures      int64        `json:"failures"`
+	LockedUntil   sql.NullTime `json:"lockedUntil"`
+	LastFailureAt time.Time    `json:"lastFailureAt"`
+}
+
+type Message struct {
+	ID        int64        `json:"id"`
+	ChannelID int64        `json:"channelId"`
+	UserID    int64        `json:"userId"`
+	UserColor string       `json:"userColor"`
+	Content   string       `json:"content"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Sequence  int64        `json:"sequence"`
+	EditedAt  sql.NullTime `json:"editedAt"`
+}
+
+type MessageRevision struct {
+	ID        int64         `json:"id"`
+	MessageID int64         `json:"messageId"`
+	Content   string        `json:"content"`
+	EditedBy  sql.NullInt64 `json:"editedBy"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+type OidcLoginFlow struct {
+	StateHash    string        `json:"stateHash"`
+	Nonce        string        `json:"nonce"`
+	CodeVerifier string        `json:"codeVerifier"`
+	ExpiresAt    time.Time     `json:"expiresAt"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	LinkUserID   sql.NullInt64 `json:"linkUserId"`
+}
+
+type PasswordResetCode struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"userId"`
+	CodeHash  string       `json:"codeHash"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+	UsedAt    sql.NullTime `json:"usedAt"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+type Session struct {
+	ID               int64          `json:"id"`
+	UserID           int64          `json:"userId"`
+	RefreshTokenHash string         `json:"refreshTokenHash"`
+	ExpiresAt        time.Time      `json:"expiresAt"`
+	RevokedAt        sql.NullTime   `json:"revokedAt"`
+	CreatedAt        time.Time      `json:"createdAt"`
+	DeviceName       sql.NullString `json:"deviceName"`
+	UserAgent        sql.NullString `json:"userAgent"`
+	IpAddress        sql.NullString `json:"ipAddress"`
+	LastSeenAt       sql.NullTime   `json:"lastSeenAt"`
+}
+
 type User struct {
-	ID        int64     `json:"id"`
-	Username  string    `json:"username"`
-	Password  string    `json:"password"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID          int64          `json:"id"`
+	Username    string         `json:"username"`
+	Password    string         `json:"password"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	Email       sql.NullString `json:"email"`
+	UsernameKey sql.NullString `json:"usernameKey"`
+	DisplayName sql.NullString `json:"displayName"`
+	Bio         sql.NullString `json:"bio"`
+	AvatarUrl   sql.NullString `json:"avatarUrl"`
+	Color       sql.NullString `json:"color"`
+	Role        string         `json:"role"`
+	DisabledAt  sql.NullTime   `json:"disabledAt"`
+}
+
+type UserIdentity struct {
+	ID          int64          `json:"id"`
+	UserID      int64          `json:"userId"`
+	Provider    string         `json:"provider"`
+	Subject     string         `json:"subject"`
+	Email       sql.NullString `json:"email"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	LastLoginAt time.Time      `json:"lastLoginAt"`
+}
+
+type UserRecoveryCode struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"userId"`
+	CodeHash  string       `json:"codeHash"`
+	UsedAt    sql.NullTime `json:"usedAt"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+type UserTotp struct {
+	UserID           int64        `json:"userId"`
+	SecretCiphertext string       `json:"secretCiphertext"`
+	EnabledAt        sql.NullTime `json:"enabledAt"`
+	LastUsedStep     int64        `json:"lastUsedStep"`
+	CreatedAt        time.Time    `json:"createdAt"`
 }
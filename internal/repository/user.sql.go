@@ -7,33 +7,129 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (username, password)
-VALUES (?, ?)
-RETURNING id, username, password, created_at
+INSERT INTO users (username, password, email, username_key)
+VALUES (?, ?, ?, ?)
+RETURNING id, username, password, created_at, email, username_key, display_name, bio, avatar_url, color, role, disabled_at
 `
 
 type CreateUserParams struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username    string         `json:"username"`
+	Password    string         `json:"password"`
+	Email       sql.NullString `json:"email"`
+	UsernameKey sql.NullString `json:"usernameKey"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.queryRow(ctx, q.createUserStmt, createUser, arg.Username, arg.Password)
+	row := q.queryRow(ctx, q.createUserStmt, createUser,
+		arg.Username,
+		arg.Password,
+		arg.Email,
+		arg.UsernameKey,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UsernameKey,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Color,
+		&i.Role,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const disableUser = `-- name: DisableUser :one
+UPDATE users
+SET disabled_at = COALESCE(disabled_at, CURRENT_TIMESTAMP)
+WHERE id = ?
+RETURNING id, username, password, created_at, email, username_key, display_name, bio, avatar_url, color, role, disabled_at
+`
+
+func (q *Queries) DisableUser(ctx context.Context, id int64) (User, error) {
+	row := q.queryRow(ctx, q.disableUserStmt, disableUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UsernameKey,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Color,
+		&i.Role,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const enableUser = `-- name: EnableUser :one
+UPDATE users
+SET disabled_at = NULL
+WHERE id = ?
+RETURNING id, username, password, created_at, email, username_key, display_name, bio, avatar_url, color, role, disabled_at
+`
+
+func (q *Queries) EnableUser(ctx context.Context, id int64) (User, error) {
+	row := q.queryRow(ctx, q.enableUserStmt, enableUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UsernameKey,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Color,
+		&i.Role,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, username, password, created_at, email, username_key, display_name, bio, avatar_url, color, role, disabled_at
+FROM users
+WHERE email = ?
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email sql.NullString) (User, error) {
+	row := q.queryRow(ctx, q.getUserByEmailStmt, getUserByEmail, email)
 	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.Username,
 		&i.Password,
 		&i.CreatedAt,
+		&i.Email,
+		&i.UsernameKey,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Color,
+		&i.Role,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, username, password, created_at
+SELECT id, username, password, created_at, email, username_key, display_name, bio, avatar_url, color, role, disabled_at
 FROM users
 WHERE id = ?
 `
@@ -46,12 +142,20 @@ func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
 		&i.Username,
 		&i.Password,
 		&i.CreatedAt,
+		&i.Email,
+		&i.UsernameKey,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Color,
+		&i.Role,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password, created_at
+SELECT id, username, password, created_at, email, username_key, display_name, bio, avatar_url, color, role, disabled_at
 FROM users
 WHERE username = ?
 `
@@ -64,6 +168,266 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.Username,
 		&i.Password,
 		&i.CreatedAt,
+		&i.Email,
+		&i.UsernameKey,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Color,
+		&i.Role,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const getUserByUsernameKey = `-- name: GetUserByUsernameKey :one
+SELECT id, username, password, created_at, email, username_key, display_name, bio, avatar_url, color, role, disabled_at
+FROM users
+WHERE username_key = ?
+`
+
+func (q *Queries) GetUserByUsernameKey(ctx context.Context, usernameKey sql.NullString) (User, error) {
+	row := q.queryRow(ctx, q.getUserByUsernameKeyStmt, getUserByUsernameKey, usernameKey)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UsernameKey,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Color,
+		&i.Role,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const listUsernameKeys = `-- name: ListUsernameKeys :many
+SELECT id, username, username_key
+FROM users
+ORDER BY id
+`
+
+type ListUsernameKeysRow struct {
+	ID          int64          `json:"id"`
+	Username    string         `json:"username"`
+	UsernameKey sql.NullString `json:"usernameKey"`
+}
+
+func (q *Queries) ListUsernameKeys(ctx context.Context) ([]ListUsernameKeysRow, error) {
+	rows, err := q.query(ctx, q.listUsernameKeysStmt, listUsernameKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsernameKeysRow
+	for rows.Next() {
+		var i ListUsernameKeysRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.UsernameKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, username, password, created_at, email, username_key, display_name, bio, avatar_url, color, role, disabled_at
+FROM users
+ORDER BY id
+LIMIT ? OFFSET ?
+`
+
+type ListUsersParams struct {
+	Limit  int64 `json:"limit"`
+	Offset int64 `json:"offset"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.query(ctx, q.listUsersStmt, listUsers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Password,
+			&i.CreatedAt,
+			&i.Email,
+			&i.UsernameKey,
+			&i.DisplayName,
+			&i.Bio,
+			&i.AvatarUrl,
+			&i.Color,
+			&i.Role,
+			&i.DisabledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const promoteUserToAdmin = `-- name: PromoteUserToAdmin :execrows
+UPDATE users
+SET role = 'admin'
+WHERE username = ? AND role != 'admin'
+`
+
+func (q *Queries) PromoteUserToAdmin(ctx context.Context, username string) (int64, error) {
+	result, err := q.exec(ctx, q.promoteUserToAdminStmt, promoteUserToAdmin, username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rehashUserPassword = `-- name: RehashUserPassword :execrows
+UPDATE users
+SET password = ?
+WHERE id = ? AND password = ?
+`
+
+type RehashUserPasswordParams struct {
+	NewPassword string `json:"newPassword"`
+	ID          int64  `json:"id"`
+	OldPassword string `json:"oldPassword"`
+}
+
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error) {
+	result, err := q.exec(ctx, q.rehashUserPasswordStmt, rehashUserPassword, arg.NewPassword, arg.ID, arg.OldPassword)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUsernameKey = `-- name: SetUsernameKey :exec
+UPDATE users
+SET username_key = ?
+WHERE id = ?
+`
+
+type SetUsernameKeyParams struct {
+	UsernameKey sql.NullString `json:"usernameKey"`
+	ID          int64          `json:"id"`
+}
+
+func (q *Queries) SetUsernameKey(ctx context.Context, arg SetUsernameKeyParams) error {
+	_, err := q.exec(ctx, q.setUsernameKeyStmt, setUsernameKey, arg.UsernameKey, arg.ID)
+	return err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET password = ?
+WHERE id = ?
+`
+
+type UpdateUserPasswordParams struct {
+	Password string `json:"password"`
+	ID       int64  `json:"id"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.exec(ctx, q.updateUserPasswordStmt, updateUserPassword, arg.Password, arg.ID)
+	return err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET display_name = ?, bio = ?, avatar_url = ?, color = ?
+WHERE id = ?
+RETURNING id, username, password, created_at, email, username_key, display_name, bio, avatar_url, color, role, disabled_at
+`
+
+type UpdateUserProfileParams struct {
+	DisplayName sql.NullString `json:"displayName"`
+	Bio         sql.NullString `json:"bio"`
+	AvatarUrl   sql.NullString `json:"avatarUrl"`
+	Color       sql.NullString `json:"color"`
+	ID          int64          `json:"id"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.queryRow(ctx, q.updateUserProfileStmt, updateUserProfile,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarUrl,
+		arg.Color,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UsernameKey,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Color,
+		&i.Role,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = ?
+WHERE id = ?
+RETURNING id, username, password, created_at, email, username_key, display_name, bio, avatar_url, color, role, disabled_at
+`
+
+type UpdateUserRoleParams struct {
+	Role string `json:"role"`
+	ID   int64  `json:"id"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.queryRow(ctx, q.updateUserRoleStmt, updateUserRole, arg.Role, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.CreatedAt,
+		&i.Email,
+		&i.UsernameKey,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarUrl,
+		&i.Color,
+		&i.Role,
+		&i.DisabledAt,
 	)
 	return i, err
 }
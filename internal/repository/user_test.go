@@ -28,7 +28,15 @@ func initializeTestDB(t *testing.T) *sql.DB {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		username TEXT NOT NULL UNIQUE,
 		password TEXT NOT NULL UNIQUE,
-		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		email TEXT UNIQUE,
+		username_key TEXT UNIQUE,
+		display_name TEXT,
+		bio TEXT,
+		avatar_url TEXT,
+		color VARCHAR(7),
+		role TEXT NOT NULL DEFAULT 'user',
+		disabled_at TIMESTAMP
 	);
 	CREATE INDEX IF NOT EXISTS idx_users_username_password ON users (username, password);
 	`
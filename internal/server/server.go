@@ -7,8 +7,12 @@ import (
 	"os"
 	"time"
 
+	"github.com/fortega2/real-time-chat/internal/auth"
 	"github.com/fortega2/real-time-chat/internal/handlers"
 	"github.com/fortega2/real-time-chat/internal/logger"
+	"github.com/fortega2/real-time-chat/internal/mailer"
+	"github.com/fortega2/real-time-chat/internal/oidc"
+	"github.com/fortega2/real-time-chat/internal/realip"
 	"github.com/fortega2/real-time-chat/internal/repository"
 	"github.com/fortega2/real-time-chat/internal/websocket"
 	"github.com/go-chi/chi/v5"
@@ -26,8 +30,12 @@ func NewServer(l logger.Logger, q *repository.Queries, db *sql.DB) *Server {
 func (s *Server) Start() error {
 	r := chi.NewRouter()
 
-	s.configMiddlewares(r)
-	s.setRoutes(r)
+	if err := s.configMiddlewares(r); err != nil {
+		return err
+	}
+	if err := s.setRoutes(r); err != nil {
+		return err
+	}
 
 	port := ":" + os.Getenv("PORT")
 	if port == ":" {
@@ -69,34 +77,202 @@ func (s *Server) Shutdown() error {
 	return nil
 }
 
-func (s *Server) configMiddlewares(r *chi.Mux) {
+func (s *Server) configMiddlewares(r *chi.Mux) error {
+	trustedProxies, err := realip.TrustedProxiesFromEnv()
+	if err != nil {
+		return err
+	}
+
+	r.Use(realip.Middleware(trustedProxies))
 	r.Use(middleware.Recoverer)
+	return nil
 }
 
-func (s *Server) setRoutes(r *chi.Mux) {
-	handlers := handlers.NewHandler(s.logger, s.queries, s.db)
-	wsHandler := websocket.NewWebsocketHandler(s.logger, s.queries)
+func (s *Server) setRoutes(r *chi.Mux) error {
+	passwords := auth.NewPasswordHasher(auth.PasswordParamsFromEnv())
+	authenticator, err := auth.AuthenticatorFromEnv(s.queries, passwords)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Password authentication providers: " + authenticator.Name())
+
+	policyConfig, err := auth.PolicyConfigFromEnv()
+	if err != nil {
+		return err
+	}
+
+	s.promoteAdmins()
+
+	wsHandler := websocket.NewWebsocketHandler(s.logger, s.queries, s.db)
+	handlers := handlers.NewHandler(s.logger, s.queries, s.db,
+		handlers.WithTokenManager(s.newTokenManager()),
+		handlers.WithPasswordHasher(passwords),
+		handlers.WithPolicy(auth.NewPolicy(policyConfig)),
+		handlers.WithAuthenticator(authenticator),
+		handlers.WithHub(wsHandler.Hub()),
+		handlers.WithMailer(s.newMailer()),
+		handlers.WithSecretBox(s.newSecretBox()),
+		handlers.WithOIDCProvider(s.newOIDCProvider()),
+	)
 
 	r.Get("/health", handlers.HealthCheck)
 
 	r.Route("/api", func(r chi.Router) {
 		r.Route("/users", func(r chi.Router) {
 			r.Post("/login", handlers.LoginUser)
+			r.Post("/login/mfa", handlers.VerifyLoginMFA)
+			r.Post("/refresh", handlers.RefreshSession)
 			r.Post("/", handlers.CreateUser)
-		})
+			r.Post("/password-reset", handlers.RequestPasswordReset)
+			r.Post("/password-reset/confirm", handlers.ConfirmPasswordReset)
+			r.Get("/oidc/login", handlers.StartOIDCLogin)
+			r.Post("/oidc/callback", handlers.CompleteOIDCLogin)
+			r.With(handlers.RequireAuth).Post("/oidc/link", handlers.StartOIDCLink)
+			r.With(handlers.RequireAuth).Post("/oidc/link/callback", handlers.CompleteOIDCLink)
+			r.With(handlers.RequireAuth).Post("/logout", handlers.LogoutUser)
+
+			r.With(handlers.RequireAuth).Put("/me/password", handlers.ChangePassword)
+			r.With(handlers.RequireAuth).Get("/me", handlers.GetMyProfile)
+			r.With(handlers.RequireAuth).Patch("/me", handlers.UpdateMyProfile)
+			r.With(handlers.RequireAuth).Put("/me/channel-order", handlers.SetChannelOrder)
+			r.With(handlers.RequireAuth).Get("/{userId}", handlers.GetUserProfile)
+
+			r.Route("/me/mfa", func(r chi.Router) {
+				r.Use(handlers.RequireAuth)
+				r.Get("/", handlers.GetMFAStatus)
+				r.Post("/totp", handlers.EnrollTOTP)
+				r.Post("/totp/verify", handlers.VerifyTOTPEnrollment)
+				r.Post("/totp/disable", handlers.DisableTOTP)
+				r.Post("/recovery-codes", handlers.RegenerateRecoveryCodes)
+			})
+
+			r.Route("/me/invites", func(r chi.Router) {
+				r.Use(handlers.RequireAuth)
+				r.Get("/", handlers.ListMyInvites)
+				r.Delete("/{inviteId}", handlers.DeclineInvite)
+			})
 
-		r.Route("/channels", func(r chi.Router) {
-			r.Get("/", handlers.GetAllChannels)
-			r.Post("/", handlers.CreateChannel)
-			r.Delete("/{channelId}/users/{userId}", handlers.DeleteChannel)
+			r.Route("/me/sessions", func(r chi.Router) {
+				r.Use(handlers.RequireAuth)
+				r.Get("/", handlers.ListSessions)
+				r.Delete("/", handlers.RevokeOtherSessions)
+				r.Delete("/{sessionId}", handlers.RevokeUserSession)
+			})
 		})
 
-		r.Route("/messages", func(r chi.Router) {
-			r.Get("/history/{channelId}", handlers.GetHistoryMessagesByChannel)
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.RequireAuth)
+
+			r.Route("/channels", func(r chi.Router) {
+				r.Get("/", handlers.GetAllChannels)
+				r.Get("/sidebar", handlers.GetChannelSidebar)
+				r.Post("/", handlers.CreateChannel)
+				r.Patch("/{channelId}", handlers.UpdateChannel)
+				r.Post("/{channelId}/archive", handlers.ArchiveChannel)
+				r.Post("/{channelId}/unarchive", handlers.UnarchiveChannel)
+				r.Post("/{channelId}/join", handlers.JoinChannel)
+				r.Post("/{channelId}/leave", handlers.LeaveChannel)
+				r.Post("/{channelId}/invites", handlers.InviteToChannel)
+				r.Get("/{channelId}/invite-links", handlers.ListChannelInviteLinks)
+				r.Post("/{channelId}/invite-links", handlers.CreateChannelInviteLink)
+				r.Delete("/{channelId}/invite-links/{linkId}", handlers.RevokeChannelInviteLink)
+				r.Get("/{channelId}/members", handlers.ListChannelMembers)
+				r.Put("/{channelId}/members/{userId}/role", handlers.UpdateChannelMemberRole)
+				r.Post("/{channelId}/transfer", handlers.TransferChannelOwnership)
+				r.Put("/{channelId}/favorite", handlers.FavoriteChannel)
+				r.Delete("/{channelId}/favorite", handlers.UnfavoriteChannel)
+				r.Patch("/{channelId}/messages/{messageId}", handlers.EditMessage)
+				r.Get("/{channelId}/messages/{messageId}/revisions", handlers.ListMessageRevisions)
+			})
+
+			r.Post("/invite-links/{token}/redeem", handlers.RedeemChannelInviteLink)
+			r.Get("/channel-categories", handlers.ListChannelCategories)
+
+			r.Route("/dms", func(r chi.Router) {
+				r.Get("/", handlers.ListDirectConversations)
+				r.Post("/", handlers.CreateDirectConversation)
+			})
+
+			r.Route("/messages", func(r chi.Router) {
+				r.Get("/history/{channelId}", handlers.GetHistoryMessagesByChannel)
+			})
+
+			r.Get("/ws/{channelId}", wsHandler.HandleWebSocket)
 		})
 
-		r.Get("/ws/{channelId}/{userId}", wsHandler.HandleWebSocket)
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(handlers.RequireAuth)
+			r.Use(handlers.RequireRole(auth.RoleModerator))
+
+			r.Get("/users", handlers.ListUsers)
+			r.With(handlers.RequireRole(auth.RoleAdmin)).Put("/users/{userId}/role", handlers.UpdateUserRole)
+			r.Post("/users/{userId}/disable", handlers.DisableUser)
+			r.Post("/users/{userId}/enable", handlers.EnableUser)
+			r.Post("/users/{userId}/disconnect", handlers.DisconnectUser)
+			r.With(handlers.RequireRole(auth.RoleAdmin)).Delete("/channels/{channelId}", handlers.DeleteAnyChannel)
+			r.With(handlers.RequireRole(auth.RoleAdmin)).Put("/channels/{channelId}/owner", handlers.SetChannelOwner)
+			r.With(handlers.RequireRole(auth.RoleAdmin)).Put("/channels/{channelId}/category", handlers.SetChannelCategory)
+			r.With(handlers.RequireRole(auth.RoleAdmin)).Post("/channel-categories", handlers.CreateChannelCategory)
+			r.With(handlers.RequireRole(auth.RoleAdmin)).Patch("/channel-categories/{categoryId}", handlers.UpdateChannelCategory)
+			r.With(handlers.RequireRole(auth.RoleAdmin)).Delete("/channel-categories/{categoryId}", handlers.DeleteChannelCategory)
+		})
 	})
 
 	r.Mount("/", s.serveStaticFiles())
+
+	return nil
+}
+
+// promoteAdmins makes the users in ADMIN_USERNAMES administrators, which is
+// how the first administrator of a server is created. Usernames that do not
+// exist yet are skipped and picked up on the next start.
+func (s *Server) promoteAdmins() {
+	for _, username := range auth.AdminUsernamesFromEnv() {
+		promoted, err := s.queries.PromoteUserToAdmin(context.Background(), username)
+		switch {
+		case err != nil:
+			s.logger.Error("Failed to promote administrator", "error", err, "username", username)
+		case promoted > 0:
+			s.logger.Info("User promoted to administrator", "username", username)
+		}
+	}
+}
+
+func (s *Server) newTokenManager() *auth.TokenManager {
+	secret, generated := auth.SecretFromEnv()
+	if generated {
+		s.logger.Warn("AUTH_SECRET is not set or shorter than 32 bytes, using a random secret. Tokens will not survive a restart")
+	}
+
+	return auth.NewTokenManager(secret, auth.AccessTokenTTLFromEnv())
+}
+
+func (s *Server) newSecretBox() *auth.SecretBox {
+	key, generated := auth.EncryptionKeyFromEnv()
+	if generated {
+		s.logger.Warn("ENCRYPTION_KEY and AUTH_SECRET are not set, using a random key. Two-factor secrets will not survive a restart")
+	}
+
+	return auth.NewSecretBox(key)
+}
+
+// newOIDCProvider returns nil when single sign-on is not configured, which
+// disables the OIDC routes.
+func (s *Server) newOIDCProvider() *oidc.Provider {
+	cfg, ok := oidc.ConfigFromEnv()
+	if !ok {
+		return nil
+	}
+
+	s.logger.Info("OIDC login enabled", "issuer", cfg.Issuer)
+	return oidc.NewProvider(cfg, nil)
+}
+
+func (s *Server) newMailer() mailer.Mailer {
+	m := mailer.FromEnv()
+	if _, ok := m.(*mailer.Outbox); ok {
+		s.logger.Warn("SMTP_HOST is not set, emails are written to the outbox instead of being sent", "dir", os.Getenv("MAIL_OUTBOX_DIR"))
+	}
+
+	return m
 }
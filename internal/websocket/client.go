@@ -1,40 +1,75 @@
 package websocket
 
 import (
-	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
+	"github.com/fortega2/real-time-chat/internal/messageedit"
+	"github.com/fortega2/real-time-chat/internal/permission"
 	"github.com/fortega2/real-time-chat/internal/repository"
 	"github.com/gorilla/websocket"
 )
 
 type Client struct {
-	hub       *Hub
-	conn      *websocket.Conn
-	queries   *repository.Queries
-	send      chan []byte
-	user      *User
+	hub     *Hub
+	conn    *websocket.Conn
+	queries *repository.Queries
+	db      *sql.DB
+	send    chan []byte
+	// user is replaced by the hub when the profile changes.
+	user      atomic.Pointer[User]
+	sessionID int64
 	ChannelID int
+	// closeMessage is set by the hub before it closes send, so the writer can
+	// tell the peer why it is being disconnected.
+	closeMessage []byte
 }
 
+// inboundFrame is the JSON envelope of every frame a client sends. A Message
+// frame posts content to the channel, and an Edit frame replaces the content
+// of the message with ID. Text is only ever read from content, so a message
+// that looks like a frame is posted as it is.
+type inboundFrame struct {
+	Type    string `json:"type"`
+	ID      int64  `json:"id,omitempty"`
+	Content string `json:"content"`
+}
+
+const (
+	messageFrameType = "Message"
+	editFrameType    = "Edit"
+)
+
 const (
-	maxMessageSize = 512
+	// maxMessageSize leaves room around the longest content for the JSON
+	// envelope and escapes.
+	maxMessageSize = 2 * messageedit.ContentMaxLength * utf8.UTFMax
 	writeWait      = 10 * time.Second
 	pongWait       = 60 * time.Second
 	pingPeriod     = (writeWait * 9) / 10
 )
 
-func newClient(hub *Hub, conn *websocket.Conn, queries *repository.Queries, user *User, channelID int) *Client {
-	return &Client{
+func newClient(hub *Hub, conn *websocket.Conn, queries *repository.Queries, db *sql.DB, user *User, sessionID int64, channelID int) *Client {
+	c := &Client{
 		hub:       hub,
 		conn:      conn,
 		queries:   queries,
+		db:        db,
 		send:      make(chan []byte, 256),
-		user:      user,
+		sessionID: sessionID,
 		ChannelID: channelID,
 	}
+	c.user.Store(user)
+	return c
+}
+
+func (c *Client) currentUser() *User {
+	return c.user.Load()
 }
 
 func (c *Client) processClientMessages() {
@@ -54,41 +89,147 @@ func (c *Client) processClientMessages() {
 		_, msgBytes, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
-				c.hub.logger.Debug("Client disconnected", "reason", err.Error(), "user", c.user)
+				c.hub.logger.Debug("Client disconnected", "reason", err.Error(), "user", c.currentUser())
 			} else {
-				c.hub.logger.Error("Error reading message", "error", err, "user", c.user)
+				c.hub.logger.Error("Error reading message", "error", err, "user", c.currentUser())
 			}
 			break
 		}
 
-		content := string(bytes.TrimSpace(bytes.ReplaceAll(msgBytes, []byte{'\n'}, []byte{' '})))
-		if content == "" {
-			continue
+		if !c.handleFrame(msgBytes) {
+			return
 		}
+	}
+}
 
-		message := NewChatMessage(c.user, chatType, content, c.ChannelID)
-		jsonMsg, err := json.Marshal(message)
-		if err != nil {
-			c.hub.logger.Error("Failed to marshal message", "error", err, "user", c.user)
-			continue
-		}
+// handleFrame acts on one frame from the client. It returns false when the
+// user is no longer a member and the connection must close.
+func (c *Client) handleFrame(data []byte) bool {
+	var frame inboundFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		c.sendError("Invalid frame")
+		return true
+	}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		err = c.queries.CreateMessage(ctx, repository.CreateMessageParams{
-			ChannelID: int64(c.ChannelID),
-			UserID:    int64(c.user.ID),
-			UserColor: c.user.Color,
-			Content:   content,
-		})
-		if err != nil {
-			c.hub.logger.Error("Failed to persist message", "error", err, "userId", c.user.ID, "channelId", c.ChannelID)
-			continue
-		}
-		c.hub.logger.Debug("Message create and broadcast", "user", c.user.Username, "channelId", c.ChannelID, "message", content)
+	user := c.currentUser()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	switch frame.Type {
+	case messageFrameType:
+		return c.postMessage(ctx, user, frame.Content)
+	case editFrameType:
+		return c.editMessage(ctx, user, frame)
+	default:
+		c.sendError("Unknown frame type")
+		return true
+	}
+}
+
+// postMessage stores content as a new message of the channel and broadcasts
+// it. It returns false when the user is no longer a member.
+func (c *Client) postMessage(ctx context.Context, user *User, content string) bool {
+	content = messageedit.Normalize(content)
+	if content == "" {
+		return true
+	}
+	if violations := messageedit.CheckContent(content); len(violations) > 0 {
+		c.sendError(violations[0].Message)
+		return true
+	}
+
+	// The role is checked for every message, so members that are removed
+	// or lose the right to post stop right away.
+	_, err := permission.Authorize(ctx, c.queries, int64(c.ChannelID), int64(user.ID), permission.Post)
+	switch {
+	case errors.Is(err, permission.ErrNotMember):
+		c.hub.logger.Info("Closing connection of a removed member", "userId", user.ID, "channelId", c.ChannelID)
+		return false
+	case errors.Is(err, permission.ErrArchived):
+		c.hub.logger.Info("Message to an archived channel rejected", "userId", user.ID, "channelId", c.ChannelID)
+		c.sendError("This channel is archived and read-only")
+		return true
+	case errors.Is(err, permission.ErrForbidden):
+		c.hub.logger.Info("Message rejected by channel role", "userId", user.ID, "channelId", c.ChannelID)
+		c.sendError("Your role in this channel does not allow posting")
+		return true
+	case err != nil:
+		c.hub.logger.Error("Failed to authorize message", "error", err, "userId", user.ID, "channelId", c.ChannelID)
+		return true
+	}
+
+	stored, err := c.queries.CreateMessage(ctx, repository.CreateMessageParams{
+		ChannelID:         int64(c.ChannelID),
+		UserID:            int64(user.ID),
+		UserColor:         user.Color,
+		Content:           content,
+		SequenceChannelID: int64(c.ChannelID),
+	})
+	if err != nil {
+		c.hub.logger.Error("Failed to persist message", "error", err, "userId", user.ID, "channelId", c.ChannelID)
+		return true
+	}
+
+	// The stored row is the canonical copy, so live and history clients
+	// see the same ID, sequence and timestamp.
+	message := NewChatMessage(user, chatType, content, c.ChannelID)
+	message.ID = stored.ID
+	message.Sequence = stored.Sequence
+	message.Timestamp = stored.CreatedAt.UTC().Format(time.RFC3339)
+	jsonMsg, err := json.Marshal(message)
+	if err != nil {
+		c.hub.logger.Error("Failed to marshal message", "error", err, "user", user)
+		return true
+	}
+	c.hub.logger.Debug("Message create and broadcast", "user", user.Username, "channelId", c.ChannelID, "message", content)
+
+	c.hub.broadcast <- jsonMsg
+	return true
+}
+
+// editMessage applies an edit frame and tells the channel about it. It returns
+// false when the user is no longer a member and the connection must close.
+func (c *Client) editMessage(ctx context.Context, user *User, frame inboundFrame) bool {
+	content := messageedit.Normalize(frame.Content)
+	if violations := messageedit.CheckContent(content); len(violations) > 0 {
+		c.sendError(violations[0].Message)
+		return true
+	}
+
+	edited, changed, err := messageedit.Edit(ctx, c.db, c.queries, int64(c.ChannelID), frame.ID, int64(user.ID), content)
+	switch {
+	case errors.Is(err, permission.ErrNotMember):
+		c.hub.logger.Info("Closing connection of a removed member", "userId", user.ID, "channelId", c.ChannelID)
+		return false
+	case errors.Is(err, messageedit.ErrNotFound):
+		c.sendError("Message not found")
+		return true
+	case errors.Is(err, permission.ErrArchived):
+		c.sendError("This channel is archived and read-only")
+		return true
+	case errors.Is(err, permission.ErrForbidden):
+		c.sendError("Your role in this channel does not allow editing this message")
+		return true
+	case err != nil:
+		c.hub.logger.Error("Failed to edit message", "error", err, "userId", user.ID, "channelId", c.ChannelID, "messageId", frame.ID)
+		return true
+	}
+
+	if changed {
+		c.hub.EditMessage(edited.ChannelID, edited.ID, edited.Content, edited.CreatedAt, edited.EditedAt.Time)
+	}
+	return true
+}
 
-		c.hub.broadcast <- jsonMsg
+// sendError tells this client alone that its message was rejected.
+func (c *Client) sendError(content string) {
+	jsonMsg, err := json.Marshal(NewErrorMessage(content, c.ChannelID))
+	if err != nil {
+		c.hub.logger.Error("Failed to marshal error message", "error", err)
+		return
 	}
+	c.hub.sendTo(c, jsonMsg)
 }
 
 func (c *Client) handleBroadcastMessages() {
@@ -104,22 +245,26 @@ func (c *Client) handleBroadcastMessages() {
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 
 			if !ok {
-				c.hub.logger.Debug("Send channel closed, closing WS", "user", c.user.Username)
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.hub.logger.Debug("Send channel closed, closing WS", "user", c.currentUser().Username)
+				closeMessage := c.closeMessage
+				if closeMessage == nil {
+					closeMessage = []byte{}
+				}
+				c.conn.WriteMessage(websocket.CloseMessage, closeMessage)
 				return
 			}
 
-			c.hub.logger.Debug("Delivering message to client", "user", c.user.Username, "message", string(message))
+			c.hub.logger.Debug("Delivering message to client", "user", c.currentUser().Username, "message", string(message))
 
 			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
-				c.hub.logger.Error("Failed to write message", "user", c.user.Username, "error", err)
+				c.hub.logger.Error("Failed to write message", "user", c.currentUser().Username, "error", err)
 				return
 			}
 
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-				c.hub.logger.Error("Ping failed", "user", c.user.Username, "error", err)
+				c.hub.logger.Error("Ping failed", "user", c.currentUser().Username, "error", err)
 				return
 			}
 		}
@@ -1,11 +1,16 @@
 package websocket
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 	"sync"
 
+	"github.com/fortega2/real-time-chat/internal/auth"
 	"github.com/fortega2/real-time-chat/internal/logger"
+	"github.com/fortega2/real-time-chat/internal/permission"
+	"github.com/fortega2/real-time-chat/internal/profile"
 	"github.com/fortega2/real-time-chat/internal/repository"
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
@@ -14,6 +19,7 @@ import (
 type WebsocketHandler struct {
 	logger  logger.Logger
 	queries *repository.Queries
+	db      *sql.DB
 }
 
 var (
@@ -26,7 +32,7 @@ var (
 	once sync.Once
 )
 
-func NewWebsocketHandler(l logger.Logger, q *repository.Queries) *WebsocketHandler {
+func NewWebsocketHandler(l logger.Logger, q *repository.Queries, db *sql.DB) *WebsocketHandler {
 	once.Do(func() {
 		hub = NewHub(l)
 		go hub.Run()
@@ -35,14 +41,16 @@ func NewWebsocketHandler(l logger.Logger, q *repository.Queries) *WebsocketHandl
 	return &WebsocketHandler{
 		logger:  l,
 		queries: q,
+		db:      db,
 	}
 }
 
 func (wh *WebsocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
-	userId, ok := wh.getUserIDFromRequest(r, w)
+	identity, ok := wh.getIdentityFromRequest(r, w)
 	if !ok {
 		return
 	}
+	userId := identity.UserID
 
 	channelId, ok := wh.getChannelIDFromRequest(r, w)
 	if !ok {
@@ -51,13 +59,30 @@ func (wh *WebsocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reque
 
 	wh.logger.Debug("WebSocket connection attempt", "channelID", channelId, "userID", userId)
 
-	dbUser, err := wh.queries.GetUserByID(r.Context(), int64(userId))
+	dbUser, err := wh.queries.GetUserByID(r.Context(), userId)
 	if err != nil {
 		wh.logger.Error("Failed to get user by ID", "error", err)
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
 
+	_, err = permission.Authorize(r.Context(), wh.queries, int64(channelId), userId, permission.Read)
+	if err != nil {
+		if !errors.Is(err, permission.ErrNotMember) {
+			wh.logger.Error("Failed to check channel membership", "error", err, "channelID", channelId, "userID", userId)
+			http.Error(w, "Failed to check channel membership", http.StatusInternalServerError)
+			return
+		}
+		if _, err := wh.queries.GetChannelByID(r.Context(), int64(channelId)); errors.Is(err, sql.ErrNoRows) {
+			wh.logger.Info("WebSocket connection refused to a missing channel", "channelID", channelId, "userID", userId)
+			http.Error(w, "Channel not found", http.StatusNotFound)
+			return
+		}
+		wh.logger.Info("WebSocket connection refused to non-member", "channelID", channelId, "userID", userId)
+		http.Error(w, "You are not a member of this channel", http.StatusForbidden)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		wh.logger.Error("Failed to upgrade connection", "error", err)
@@ -67,8 +92,8 @@ func (wh *WebsocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reque
 
 	wh.logger.Info("WebSocket connection established", "userID", userId, "username", dbUser.Username)
 
-	user := NewUser(int(dbUser.ID), dbUser.Username)
-	client := newClient(hub, conn, wh.queries, user, channelId)
+	user := NewUserWithProfile(int(dbUser.ID), dbUser.Username, profile.Of(dbUser))
+	client := newClient(hub, conn, wh.queries, wh.db, user, identity.SessionID, channelId)
 
 	client.hub.register <- client
 
@@ -76,15 +101,15 @@ func (wh *WebsocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reque
 	go client.processClientMessages()
 }
 
-func (wh *WebsocketHandler) getUserIDFromRequest(r *http.Request, w http.ResponseWriter) (int, bool) {
-	userIdStr := chi.URLParam(r, "userId")
-	userId, err := strconv.Atoi(userIdStr)
-	if err != nil {
-		wh.logger.Error("Invalid user ID", "error", err)
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
-		return 0, false
+func (wh *WebsocketHandler) getIdentityFromRequest(r *http.Request, w http.ResponseWriter) (auth.Identity, bool) {
+	identity, ok := auth.IdentityFromContext(r.Context())
+	if !ok {
+		wh.logger.Error("WebSocket handshake without an authenticated user")
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return auth.Identity{}, false
 	}
-	return userId, true
+
+	return identity, true
 }
 
 func (wh *WebsocketHandler) getChannelIDFromRequest(r *http.Request, w http.ResponseWriter) (int, bool) {
@@ -98,6 +123,10 @@ func (wh *WebsocketHandler) getChannelIDFromRequest(r *http.Request, w http.Resp
 	return channelId, true
 }
 
+func (wh *WebsocketHandler) Hub() *Hub {
+	return hub
+}
+
 func Shutdown() {
 	hub.Shutdown()
 }
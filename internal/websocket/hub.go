@@ -3,12 +3,21 @@ package websocket
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/fortega2/real-time-chat/internal/channelsettings"
 	"github.com/fortega2/real-time-chat/internal/logger"
+	"github.com/fortega2/real-time-chat/internal/profile"
+	"github.com/gorilla/websocket"
 )
 
 const notificationBuffer = 10
 
+// CloseChannelDeleted closes the connections to a channel that was deleted.
+// Codes from 4000 are left to applications, so clients can tell it apart from
+// a revoked session and stop reconnecting.
+const CloseChannelDeleted = 4004
+
 type Hub struct {
 	logger       logger.Logger
 	clients      map[*Client]struct{}
@@ -16,9 +25,38 @@ type Hub struct {
 	notification chan Notification
 	register     chan *Client
 	unregister   chan *Client
+	disconnect   chan disconnectRequest
+	profiles     chan profileUpdate
+	channels     chan channelUpdate
+	direct       chan directMessage
 	shutdown     chan struct{}
 }
 
+type profileUpdate struct {
+	userID  int64
+	profile profile.Profile
+}
+
+type channelUpdate struct {
+	channelID int64
+	settings  channelsettings.Settings
+}
+
+// directMessage is sent to one client only. It goes through the hub so it is
+// never sent after the hub closed the send channel of the client.
+type directMessage struct {
+	client  *Client
+	message []byte
+}
+
+type disconnectRequest struct {
+	match     func(*Client) bool
+	closeCode int
+	reason    string
+	// message is sent to each matching client before the close frame.
+	message []byte
+}
+
 type Notification struct {
 	message   string
 	channelID int
@@ -39,6 +77,10 @@ func NewHub(l logger.Logger) *Hub {
 		notification: make(chan Notification, notificationBuffer),
 		register:     make(chan *Client),
 		unregister:   make(chan *Client),
+		disconnect:   make(chan disconnectRequest),
+		profiles:     make(chan profileUpdate),
+		channels:     make(chan channelUpdate),
+		direct:       make(chan directMessage),
 		shutdown:     make(chan struct{}),
 	}
 }
@@ -48,15 +90,25 @@ func (h *Hub) Run() {
 		select {
 		case client := <-h.register:
 			h.clients[client] = struct{}{}
-			h.logger.Debug("Client registered", "user", client.user, "total_clients", len(h.clients))
-			h.notification <- NewNotification(fmt.Sprintf("%s has joined the chat", client.user.Username), client.ChannelID)
+			h.logger.Debug("Client registered", "user", client.currentUser(), "total_clients", len(h.clients))
+			// NotifyChannel fills the notification buffer from other
+			// goroutines, so the loop that drains it must not wait on it.
+			go h.sendNotificationMessage(NewNotification(fmt.Sprintf("%s has joined the chat", client.currentUser().Name()), client.ChannelID))
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
 				close(client.send)
-				h.logger.Debug("Client unregistered", "user", client.user, "total_clients", len(h.clients))
-				h.notification <- NewNotification(fmt.Sprintf("%s has left the chat", client.user.Username), client.ChannelID)
+				h.logger.Debug("Client unregistered", "user", client.currentUser(), "total_clients", len(h.clients))
+				go h.sendNotificationMessage(NewNotification(fmt.Sprintf("%s has left the chat", client.currentUser().Name()), client.ChannelID))
 			}
+		case req := <-h.disconnect:
+			h.disconnectClients(req)
+		case update := <-h.profiles:
+			h.updateProfiles(update)
+		case update := <-h.channels:
+			h.sendChannelUpdate(update)
+		case direct := <-h.direct:
+			h.sendDirect(direct)
 		case message := <-h.broadcast:
 			h.broadcastToChannel(message)
 		case note := <-h.notification:
@@ -81,6 +133,172 @@ func (h *Hub) Shutdown() {
 	close(h.unregister)
 }
 
+// DisconnectSession closes every connection opened with the given session.
+func (h *Hub) DisconnectSession(sessionID int64) {
+	h.requestDisconnect(disconnectRequest{
+		match:     func(c *Client) bool { return c.sessionID == sessionID },
+		closeCode: websocket.ClosePolicyViolation,
+		reason:    "Session revoked",
+	})
+}
+
+// DisconnectUser closes every connection of a user, for example when a
+// moderator disables the account.
+func (h *Hub) DisconnectUser(userID int64, reason string) {
+	h.requestDisconnect(disconnectRequest{
+		match:     func(c *Client) bool { return int64(c.currentUser().ID) == userID },
+		closeCode: websocket.ClosePolicyViolation,
+		reason:    reason,
+	})
+}
+
+// DisconnectFromChannel closes the connections of a user to one channel, for
+// example when they leave it.
+func (h *Hub) DisconnectFromChannel(userID, channelID int64, reason string) {
+	h.requestDisconnect(disconnectRequest{
+		match: func(c *Client) bool {
+			return int64(c.currentUser().ID) == userID && int64(c.ChannelID) == channelID
+		},
+		closeCode: websocket.ClosePolicyViolation,
+		reason:    reason,
+	})
+}
+
+// DeleteChannel tells the clients connected to a deleted channel and closes
+// their connections.
+func (h *Hub) DeleteChannel(channelID int64) {
+	jsonMsg, err := json.Marshal(NewChannelDeletedMessage(int(channelID)))
+	if err != nil {
+		h.logger.Error("Failed to marshal channel deletion", "error", err, "channelID", channelID)
+	}
+	h.requestDisconnect(disconnectRequest{
+		match:     func(c *Client) bool { return int64(c.ChannelID) == channelID },
+		closeCode: CloseChannelDeleted,
+		reason:    "Channel deleted",
+		message:   jsonMsg,
+	})
+}
+
+// UpdateProfile applies a changed profile to the open connections of a user,
+// so their next messages use it.
+func (h *Hub) UpdateProfile(userID int64, p profile.Profile) {
+	select {
+	case h.profiles <- profileUpdate{userID: userID, profile: p}:
+	case <-h.shutdown:
+	}
+}
+
+func (h *Hub) updateProfiles(update profileUpdate) {
+	for client := range h.clients {
+		if user := client.currentUser(); int64(user.ID) == update.userID {
+			client.user.Store(user.withProfile(update.profile))
+		}
+	}
+}
+
+// UpdateChannel tells the clients connected to a channel that its settings
+// changed, so they can update the header.
+func (h *Hub) UpdateChannel(channelID int64, s channelsettings.Settings) {
+	select {
+	case h.channels <- channelUpdate{channelID: channelID, settings: s}:
+	case <-h.shutdown:
+	}
+}
+
+// UpdateChannelOwner tells the clients connected to a channel who owns it now.
+// Roles are checked on every message, so nothing else changes in the hub.
+func (h *Hub) UpdateChannelOwner(channelID, ownerID int64, username string) {
+	jsonMsg, err := json.Marshal(NewOwnerChangedMessage(int(channelID), int(ownerID), username))
+	if err != nil {
+		h.logger.Error("Failed to marshal owner change", "error", err, "channelID", channelID)
+		return
+	}
+	select {
+	case h.broadcast <- jsonMsg:
+	case <-h.shutdown:
+	}
+}
+
+// EditMessage tells the clients connected to a channel that a message was
+// edited.
+func (h *Hub) EditMessage(channelID, messageID int64, content string, createdAt, editedAt time.Time) {
+	jsonMsg, err := json.Marshal(NewMessageEditedMessage(int(channelID), messageID, content, createdAt, editedAt))
+	if err != nil {
+		h.logger.Error("Failed to marshal message edit", "error", err, "channelID", channelID)
+		return
+	}
+	select {
+	case h.broadcast <- jsonMsg:
+	case <-h.shutdown:
+	}
+}
+
+// NotifyChannel posts a system notification to the clients connected to a
+// channel, the same way joins and leaves are announced.
+func (h *Hub) NotifyChannel(channelID int64, message string) {
+	select {
+	case h.notification <- NewNotification(message, int(channelID)):
+	case <-h.shutdown:
+	}
+}
+
+func (h *Hub) sendChannelUpdate(update channelUpdate) {
+	jsonMsg, err := json.Marshal(NewChannelUpdatedMessage(int(update.channelID), update.settings))
+	if err != nil {
+		h.logger.Error("Failed to marshal channel update", "error", err, "channelID", update.channelID)
+		return
+	}
+	h.broadcastToChannel(jsonMsg)
+}
+
+// sendTo queues message for client alone.
+func (h *Hub) sendTo(client *Client, message []byte) {
+	select {
+	case h.direct <- directMessage{client: client, message: message}:
+	case <-h.shutdown:
+	}
+}
+
+func (h *Hub) sendDirect(direct directMessage) {
+	if _, ok := h.clients[direct.client]; !ok {
+		return
+	}
+	select {
+	case direct.client.send <- direct.message:
+	default:
+		h.logger.Debug("Dropping direct message to a slow client", "user", direct.client.currentUser())
+	}
+}
+
+func (h *Hub) requestDisconnect(req disconnectRequest) {
+	select {
+	case h.disconnect <- req:
+	case <-h.shutdown:
+	}
+}
+
+func (h *Hub) disconnectClients(req disconnectRequest) {
+	for client := range h.clients {
+		if !req.match(client) {
+			continue
+		}
+
+		delete(h.clients, client)
+		if req.message != nil {
+			select {
+			case client.send <- req.message:
+			default:
+			}
+		}
+		client.closeMessage = websocket.FormatCloseMessage(req.closeCode, req.reason)
+		close(client.send)
+		h.logger.Debug("Client disconnected by server", "user", client.currentUser(), "reason", req.reason, "total_clients", len(h.clients))
+		// Several clients can match at once, so skip the buffered notification
+		// channel that only this loop drains.
+		go h.sendNotificationMessage(NewNotification(fmt.Sprintf("%s has left the chat", client.currentUser().Name()), client.ChannelID))
+	}
+}
+
 func (h *Hub) broadcastToChannel(message []byte) {
 	var msg Message
 	if err := json.Unmarshal(message, &msg); err != nil {
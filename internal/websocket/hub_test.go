@@ -0,0 +1,592 @@
+package websocket_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/auth"
+	"github.com/fortega2/real-time-chat/internal/channelsettings"
+	"github.com/fortega2/real-time-chat/internal/logger"
+	"github.com/fortega2/real-time-chat/internal/profile"
+	"github.com/fortega2/real-time-chat/internal/repository"
+	"github.com/fortega2/real-time-chat/internal/websocket"
+	"github.com/go-chi/chi/v5"
+	gorillaws "github.com/gorilla/websocket"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestHubDisconnectSession(t *testing.T) {
+	srv, wsHandler := newTestWebsocketServer(t)
+
+	revoked := dialTestClient(t, srv, 1, 10)
+	kept := dialTestClient(t, srv, 1, 20)
+
+	wsHandler.Hub().DisconnectSession(10)
+
+	err := readUntilError(t, revoked)
+	if !gorillaws.IsCloseError(err, gorillaws.ClosePolicyViolation) {
+		t.Fatalf("Expected policy violation close, got %v", err)
+	}
+
+	if err := kept.WriteMessage(gorillaws.TextMessage, chatFrame("still here")); err != nil {
+		t.Fatalf("Expected remaining session to stay connected, got %v", err)
+	}
+}
+
+func TestHubDisconnectUser(t *testing.T) {
+	srv, wsHandler := newTestWebsocketServer(t)
+
+	first := dialTestClient(t, srv, 1, 10)
+	second := dialTestClient(t, srv, 1, 20)
+	other := dialTestClient(t, srv, 2, 30)
+
+	wsHandler.Hub().DisconnectUser(1, "Account disabled")
+
+	for _, conn := range []*gorillaws.Conn{first, second} {
+		err := readUntilError(t, conn)
+		if !gorillaws.IsCloseError(err, gorillaws.ClosePolicyViolation) {
+			t.Fatalf("Expected policy violation close, got %v", err)
+		}
+		if closeErr, ok := err.(*gorillaws.CloseError); !ok || closeErr.Text != "Account disabled" {
+			t.Errorf("Expected the reason in the close frame, got %v", err)
+		}
+	}
+
+	if err := other.WriteMessage(gorillaws.TextMessage, chatFrame("still here")); err != nil {
+		t.Fatalf("Expected other users to stay connected, got %v", err)
+	}
+}
+
+func TestHubDisconnectFromChannel(t *testing.T) {
+	srv, wsHandler := newTestWebsocketServer(t)
+
+	left := dialTestChannel(t, srv, 1, 1, 10)
+	kept := dialTestChannel(t, srv, 2, 1, 10)
+
+	wsHandler.Hub().DisconnectFromChannel(1, 1, "Left the channel")
+
+	if err := readUntilError(t, left); !gorillaws.IsCloseError(err, gorillaws.ClosePolicyViolation) {
+		t.Fatalf("Expected policy violation close, got %v", err)
+	}
+	if err := kept.WriteMessage(gorillaws.TextMessage, chatFrame("still here")); err != nil {
+		t.Fatalf("Expected the connection to the other channel to stay open, got %v", err)
+	}
+}
+
+func TestHandshakeRequiresMembership(t *testing.T) {
+	srv, _ := newTestWebsocketServer(t)
+
+	_, resp, err := gorillaws.DefaultDialer.Dial(testChannelURL(srv, 2, 2, 30), nil)
+	if err == nil {
+		t.Fatal("Expected the handshake of a non-member to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected status 403, got %v", resp)
+	}
+}
+
+func TestClientStopsPostingAfterRemoval(t *testing.T) {
+	srv, _, db := newTestWebsocketServerWithDB(t)
+	conn := dialTestChannel(t, srv, 1, 2, 40)
+
+	if _, err := db.Exec("DELETE FROM channel_members WHERE channel_id = 1 AND user_id = 2"); err != nil {
+		t.Fatalf("Failed to remove member: %v", err)
+	}
+	if err := conn.WriteMessage(gorillaws.TextMessage, chatFrame("still here?")); err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+
+	if err := readUntilError(t, conn); !gorillaws.IsCloseError(err, gorillaws.CloseNoStatusReceived, gorillaws.CloseAbnormalClosure) {
+		t.Fatalf("Expected the connection to be closed, got %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM messages").Scan(&count); err != nil {
+		t.Fatalf("Failed to count messages: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected the message of a removed member not to be stored, got %d messages", count)
+	}
+}
+
+func newTestWebsocketServer(t *testing.T) (*httptest.Server, *websocket.WebsocketHandler) {
+	t.Helper()
+	srv, wsHandler, _ := newTestWebsocketServerWithDB(t)
+	return srv, wsHandler
+}
+
+func newTestWebsocketServerWithDB(t *testing.T) (*httptest.Server, *websocket.WebsocketHandler, *sql.DB) {
+	t.Helper()
+	db := initializeTestDB(t)
+	wsHandler := websocket.NewWebsocketHandler(logger.NewMockLogger(), repository.New(db), db)
+
+	r := chi.NewRouter()
+	r.With(testIdentityMiddleware).Get("/ws/{channelId}", wsHandler.HandleWebSocket)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, wsHandler, db
+}
+
+// testIdentityMiddleware stands in for the real auth middleware and trusts the
+// uid/sid query params.
+func testIdentityMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := strconv.ParseInt(r.URL.Query().Get("uid"), 10, 64)
+		sessionID, _ := strconv.ParseInt(r.URL.Query().Get("sid"), 10, 64)
+		ctx := auth.WithIdentity(r.Context(), auth.Identity{UserID: userID, SessionID: sessionID})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func dialTestClient(t *testing.T, srv *httptest.Server, userID, sessionID int64) *gorillaws.Conn {
+	t.Helper()
+	return dialTestChannel(t, srv, 1, userID, sessionID)
+}
+
+func dialTestChannel(t *testing.T, srv *httptest.Server, channelID, userID, sessionID int64) *gorillaws.Conn {
+	t.Helper()
+	conn, _, err := gorillaws.DefaultDialer.Dial(testChannelURL(srv, channelID, userID, sessionID), nil)
+	if err != nil {
+		t.Fatalf("Failed to dial websocket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func testChannelURL(srv *httptest.Server, channelID, userID, sessionID int64) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/" + strconv.FormatInt(channelID, 10) +
+		"?uid=" + strconv.FormatInt(userID, 10) + "&sid=" + strconv.FormatInt(sessionID, 10)
+}
+
+func readUntilError(t *testing.T, conn *gorillaws.Conn) error {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return err
+		}
+	}
+}
+
+func initializeTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open in-memory database: %v", err)
+	}
+	// Every connection to :memory: is a separate database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	schema := `
+	CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT NOT NULL UNIQUE,
+		password TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		email TEXT UNIQUE,
+		username_key TEXT UNIQUE,
+		display_name TEXT,
+		bio TEXT,
+		avatar_url TEXT,
+		color VARCHAR(7),
+		role TEXT NOT NULL DEFAULT 'user',
+		disabled_at TIMESTAMP
+	);
+	CREATE TABLE channels (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT,
+		created_by INTEGER NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		is_private BOOLEAN NOT NULL DEFAULT FALSE,
+		topic TEXT,
+		archived_at TIMESTAMP,
+		kind TEXT NOT NULL DEFAULT 'channel' CHECK (kind IN ('channel', 'direct')),
+		direct_key TEXT UNIQUE,
+		category_id INTEGER,
+		position INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE channel_members (
+		channel_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'moderator', 'member')),
+		favorite BOOLEAN NOT NULL DEFAULT FALSE,
+		personal_position INTEGER,
+		PRIMARY KEY (channel_id, user_id)
+	);
+	CREATE TABLE messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		user_color VARCHAR(7) NOT NULL,
+		content TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		sequence INTEGER NOT NULL DEFAULT 0,
+		edited_at TIMESTAMP
+	);
+	CREATE TABLE message_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		message_id INTEGER NOT NULL,
+		content TEXT NOT NULL,
+		edited_by INTEGER,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	INSERT INTO users (id, username, password) VALUES (1, 'alice', 'hash'), (2, 'bob', 'hash');
+	INSERT INTO channels (id, name, created_by) VALUES (1, 'general', 1), (2, 'random', 1);
+	INSERT INTO channel_members (channel_id, user_id) VALUES (1, 1), (1, 2), (2, 1);`
+	if _, err := db.ExecContext(context.Background(), schema); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	return db
+}
+
+func TestHubUpdateProfile(t *testing.T) {
+	srv, wsHandler := newTestWebsocketServer(t)
+	conn := dialTestClient(t, srv, 1, 30)
+
+	// The join notification is sent once the hub has registered the client.
+	if msg := readTestMessage(t, conn); msg.Type != "Notification" {
+		t.Fatalf("Expected join notification, got %+v", msg)
+	}
+
+	wsHandler.Hub().UpdateProfile(1, profile.Profile{DisplayName: "Alice A.", Color: "#123456"})
+
+	if err := conn.WriteMessage(gorillaws.TextMessage, chatFrame("hello")); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	for {
+		msg := readTestMessage(t, conn)
+		if msg.Type != "Chat" {
+			continue
+		}
+		if msg.DisplayName != "Alice A." || msg.Color != "#123456" || msg.Username != "alice" {
+			t.Errorf("Expected the updated profile in the broadcast, got %+v", msg)
+		}
+		return
+	}
+}
+
+func TestChatMessagesCarryStoredIDs(t *testing.T) {
+	srv, _, db := newTestWebsocketServerWithDB(t)
+	// Sequences count per channel, so this one does not move channel 1 on.
+	if _, err := db.Exec("INSERT INTO messages (channel_id, user_id, user_color, content, sequence) VALUES (2, 1, '#000000', 'elsewhere', 1)"); err != nil {
+		t.Fatalf("Failed to insert message: %v", err)
+	}
+	conn := dialTestClient(t, srv, 1, 50)
+
+	var received []websocket.Message
+	for _, content := range []string{"first", "second"} {
+		if err := conn.WriteMessage(gorillaws.TextMessage, chatFrame(content)); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+		for {
+			msg := readTestMessage(t, conn)
+			if msg.Type == "Chat" {
+				received = append(received, msg)
+				break
+			}
+		}
+	}
+
+	for i, msg := range received {
+		var id, sequence int64
+		var createdAt time.Time
+		if err := db.QueryRow("SELECT id, sequence, created_at FROM messages WHERE content = ?", msg.Content).Scan(&id, &sequence, &createdAt); err != nil {
+			t.Fatalf("Failed to load stored message: %v", err)
+		}
+		if msg.ID != id || msg.Sequence != int64(i+1) || msg.Sequence != sequence {
+			t.Errorf("Expected id %d and sequence %d, got %+v", id, i+1, msg)
+		}
+		if msg.Timestamp != createdAt.UTC().Format(time.RFC3339) {
+			t.Errorf("Expected the stored timestamp %s, got %s", createdAt.UTC().Format(time.RFC3339), msg.Timestamp)
+		}
+	}
+}
+
+func TestClientFramesAreEnveloped(t *testing.T) {
+	srv, _, db := newTestWebsocketServerWithDB(t)
+	result, err := db.Exec("INSERT INTO messages (channel_id, user_id, user_color, content, sequence) VALUES (1, 1, '#000000', 'original', 1)")
+	if err != nil {
+		t.Fatalf("Failed to insert message: %v", err)
+	}
+	id, _ := result.LastInsertId()
+	conn := dialTestClient(t, srv, 1, 80)
+	if msg := readTestMessage(t, conn); msg.Type != "Notification" {
+		t.Fatalf("Expected join notification, got %+v", msg)
+	}
+
+	// Text that looks like an edit frame is posted as it is.
+	lookalike := fmt.Sprintf(`{"type":"Edit","id":%d,"content":"hijacked"}`, id)
+	if err := conn.WriteMessage(gorillaws.TextMessage, chatFrame(lookalike)); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	if msg := readTestMessage(t, conn); msg.Type != "Chat" || msg.Content != lookalike {
+		t.Fatalf("Expected the text to be posted, got %+v", msg)
+	}
+	var content string
+	if err := db.QueryRow("SELECT content FROM messages WHERE id = ?", id).Scan(&content); err != nil {
+		t.Fatalf("Failed to load stored message: %v", err)
+	}
+	if content != "original" {
+		t.Errorf("Expected the message to be left alone, got %q", content)
+	}
+
+	for _, frame := range []string{"plain text", `{"type":"Shout","content":"hi"}`} {
+		if err := conn.WriteMessage(gorillaws.TextMessage, []byte(frame)); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+		if msg := readTestMessage(t, conn); msg.Type != "Error" {
+			t.Errorf("Expected %q to be refused, got %+v", frame, msg)
+		}
+	}
+}
+
+// chatFrame wraps content in the envelope clients post messages with.
+func chatFrame(content string) []byte {
+	data, _ := json.Marshal(map[string]string{"type": "Message", "content": content})
+	return data
+}
+
+func readTestMessage(t *testing.T, conn *gorillaws.Conn) websocket.Message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg websocket.Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	return msg
+}
+
+func TestHubUpdateChannel(t *testing.T) {
+	srv, wsHandler := newTestWebsocketServer(t)
+	conn := dialTestChannel(t, srv, 1, 1, 50)
+	other := dialTestChannel(t, srv, 2, 1, 50)
+
+	if msg := readTestMessage(t, conn); msg.Type != "Notification" {
+		t.Fatalf("Expected join notification, got %+v", msg)
+	}
+	if msg := readTestMessage(t, other); msg.Type != "Notification" {
+		t.Fatalf("Expected join notification, got %+v", msg)
+	}
+
+	wsHandler.Hub().UpdateChannel(1, channelsettings.Settings{Name: "general", Topic: "Release on Friday"})
+
+	msg := readTestMessage(t, conn)
+	if msg.Type != "ChannelUpdated" || msg.ChannelID != 1 || msg.Channel == nil || msg.Channel.Topic != "Release on Friday" {
+		t.Fatalf("Expected the channel update, got %+v", msg)
+	}
+
+	other.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, data, err := other.ReadMessage(); err == nil {
+		t.Errorf("Expected clients of other channels not to get the update, got %s", data)
+	}
+}
+
+func TestArchivedChannelRejectsMessages(t *testing.T) {
+	srv, _, db := newTestWebsocketServerWithDB(t)
+	conn := dialTestChannel(t, srv, 1, 1, 60)
+
+	if msg := readTestMessage(t, conn); msg.Type != "Notification" {
+		t.Fatalf("Expected join notification, got %+v", msg)
+	}
+	if _, err := db.Exec("UPDATE channels SET archived_at = CURRENT_TIMESTAMP WHERE id = 1"); err != nil {
+		t.Fatalf("Failed to archive channel: %v", err)
+	}
+
+	if err := conn.WriteMessage(gorillaws.TextMessage, chatFrame("anyone?")); err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if msg := readTestMessage(t, conn); msg.Type != "Error" || msg.ChannelID != 1 {
+		t.Fatalf("Expected an error frame, got %+v", msg)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM messages").Scan(&count); err != nil {
+		t.Fatalf("Failed to count messages: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no message to be stored in an archived channel, got %d", count)
+	}
+}
+
+func TestClientEditsMessages(t *testing.T) {
+	srv, _, db := newTestWebsocketServerWithDB(t)
+	result, err := db.Exec("INSERT INTO messages (channel_id, user_id, user_color, content, sequence) VALUES (1, 1, '#000000', 'helo', 1)")
+	if err != nil {
+		t.Fatalf("Failed to insert message: %v", err)
+	}
+	id, _ := result.LastInsertId()
+	alice := dialTestChannel(t, srv, 1, 1, 70)
+	if msg := readTestMessage(t, alice); msg.Type != "Notification" {
+		t.Fatalf("Expected join notification, got %+v", msg)
+	}
+	bob := dialTestChannel(t, srv, 1, 2, 71)
+	for _, conn := range []*gorillaws.Conn{alice, bob} {
+		if msg := readTestMessage(t, conn); msg.Type != "Notification" {
+			t.Fatalf("Expected join notification, got %+v", msg)
+		}
+	}
+
+	frame := func(content string) []byte {
+		return []byte(fmt.Sprintf(`{"type": "Edit", "id": %d, "content": %q}`, id, content))
+	}
+	if err := bob.WriteMessage(gorillaws.TextMessage, frame("mine now")); err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	if msg := readTestMessage(t, bob); msg.Type != "Error" {
+		t.Fatalf("Expected members not to edit other messages, got %+v", msg)
+	}
+
+	if err := alice.WriteMessage(gorillaws.TextMessage, frame(" hello ")); err != nil {
+		t.Fatalf("Failed to write message: %v", err)
+	}
+	for _, conn := range []*gorillaws.Conn{alice, bob} {
+		msg := readTestMessage(t, conn)
+		if msg.Type != "MessageEdited" || msg.ID != id || msg.Content != "hello" || msg.EditedAt == "" {
+			t.Fatalf("Expected the edited message, got %+v", msg)
+		}
+	}
+
+	// Sending the same content again is not announced, so the next message
+	// is the edit after it.
+	for _, content := range []string{"hello", "hello all"} {
+		if err := alice.WriteMessage(gorillaws.TextMessage, frame(content)); err != nil {
+			t.Fatalf("Failed to write message: %v", err)
+		}
+	}
+	for _, conn := range []*gorillaws.Conn{alice, bob} {
+		if msg := readTestMessage(t, conn); msg.Type != "MessageEdited" || msg.Content != "hello all" {
+			t.Fatalf("Expected only the second edit, got %+v", msg)
+		}
+	}
+
+	var content string
+	var revisions int
+	if err := db.QueryRow("SELECT content, (SELECT COUNT(*) FROM message_revisions WHERE message_id = messages.id) FROM messages WHERE id = ?", id).Scan(&content, &revisions); err != nil {
+		t.Fatalf("Failed to load stored message: %v", err)
+	}
+	if content != "hello all" || revisions != 2 {
+		t.Errorf("Expected the new content and two revisions, got %q and %d", content, revisions)
+	}
+}
+
+func TestHubDeleteChannel(t *testing.T) {
+	srv, wsHandler, db := newTestWebsocketServerWithDB(t)
+	conn := dialTestChannel(t, srv, 1, 1, 70)
+	other := dialTestChannel(t, srv, 2, 1, 70)
+
+	if msg := readTestMessage(t, conn); msg.Type != "Notification" {
+		t.Fatalf("Expected join notification, got %+v", msg)
+	}
+	if msg := readTestMessage(t, other); msg.Type != "Notification" {
+		t.Fatalf("Expected join notification, got %+v", msg)
+	}
+
+	for _, stmt := range []string{"DELETE FROM channel_members WHERE channel_id = 1", "DELETE FROM channels WHERE id = 1"} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to delete channel: %v", err)
+		}
+	}
+	wsHandler.Hub().DeleteChannel(1)
+
+	if msg := readTestMessage(t, conn); msg.Type != "ChannelDeleted" || msg.ChannelID != 1 {
+		t.Fatalf("Expected the channel deletion, got %+v", msg)
+	}
+	if err := readUntilError(t, conn); !gorillaws.IsCloseError(err, websocket.CloseChannelDeleted) {
+		t.Fatalf("Expected close code %d, got %v", websocket.CloseChannelDeleted, err)
+	}
+
+	other.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, data, err := other.ReadMessage(); err == nil {
+		t.Errorf("Expected clients of other channels to stay connected quietly, got %s", data)
+	}
+
+	_, resp, err := gorillaws.DefaultDialer.Dial(testChannelURL(srv, 1, 1, 70), nil)
+	if err == nil {
+		t.Fatal("Expected the handshake to a deleted channel to fail")
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected status 404, got %v", resp)
+	}
+}
+
+func TestHubUpdateChannelOwner(t *testing.T) {
+	srv, wsHandler := newTestWebsocketServer(t)
+	conn := dialTestChannel(t, srv, 1, 1, 80)
+
+	if msg := readTestMessage(t, conn); msg.Type != "Notification" {
+		t.Fatalf("Expected join notification, got %+v", msg)
+	}
+
+	wsHandler.Hub().UpdateChannelOwner(1, 2, "bob")
+
+	msg := readTestMessage(t, conn)
+	if msg.Type != "OwnerChanged" || msg.ChannelID != 1 || msg.UserID == nil || *msg.UserID != 2 || msg.Username != "bob" {
+		t.Fatalf("Expected the owner change, got %+v", msg)
+	}
+}
+
+func TestHubNotifyChannel(t *testing.T) {
+	srv, wsHandler := newTestWebsocketServer(t)
+	conn := dialTestChannel(t, srv, 1, 1, 80)
+
+	if msg := readTestMessage(t, conn); msg.Type != "Notification" {
+		t.Fatalf("Expected join notification, got %+v", msg)
+	}
+
+	wsHandler.Hub().NotifyChannel(2, "not for this channel")
+	wsHandler.Hub().NotifyChannel(1, "bob joined through an invite link")
+
+	msg := readTestMessage(t, conn)
+	if msg.Type != "Notification" || msg.ChannelID != 1 || msg.Content != "bob joined through an invite link" {
+		t.Fatalf("Expected the notification, got %+v", msg)
+	}
+}
+
+func TestHubRegistersWhileNotificationsQueue(t *testing.T) {
+	srv, wsHandler, db := newTestWebsocketServerWithDB(t)
+
+	// A channel of its own, so the joins and leaves below reach no other test.
+	if _, err := db.Exec(`INSERT INTO channels (id, name, created_by) VALUES (3, 'busy', 1);
+		INSERT INTO channel_members (channel_id, user_id) VALUES (3, 1);`); err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	// Keep the notification buffer full from outside the hub, as invite
+	// redemptions do, while clients connect and leave.
+	done := make(chan struct{})
+	defer close(done)
+	for range 4 {
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					wsHandler.Hub().NotifyChannel(99, "bob joined through an invite link")
+				}
+			}
+		}()
+	}
+
+	for i := range int64(20) {
+		conn := dialTestChannel(t, srv, 3, 1, 90+i)
+		if msg := readTestMessage(t, conn); msg.Type != "Notification" || msg.ChannelID != 3 {
+			t.Fatalf("Expected join notification, got %+v", msg)
+		}
+		conn.Close()
+	}
+}
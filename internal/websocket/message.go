@@ -1,40 +1,134 @@
 package websocket
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/fortega2/real-time-chat/internal/channelsettings"
+)
 
 const (
-	chatType         = "Chat"
-	notificationType = "Notification"
+	chatType           = "Chat"
+	notificationType   = "Notification"
+	channelUpdatedType = "ChannelUpdated"
+	channelDeletedType = "ChannelDeleted"
+	ownerChangedType   = "OwnerChanged"
+	messageEditedType  = "MessageEdited"
+	errorType          = "Error"
 )
 
 type Message struct {
-	Type      string `json:"type"`
-	UserID    *int   `json:"userId,omitempty"`
-	Username  string `json:"username,omitempty"`
-	Content   string `json:"content"`
-	Timestamp string `json:"timestamp"`
+	Type string `json:"type"`
+	// ID and Sequence are only set on Chat messages, once they are stored.
+	// Sequence counts the messages of a channel from 1 without gaps, so a
+	// client that sees a jump has missed messages.
+	ID          int64  `json:"id,omitempty"`
+	Sequence    int64  `json:"sequence,omitempty"`
+	UserID      *int   `json:"userId,omitempty"`
+	Username    string `json:"username,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+	AvatarURL   string `json:"avatarUrl,omitempty"`
+	Content     string `json:"content"`
+	Timestamp   string `json:"timestamp"`
+	// EditedAt is only set on MessageEdited messages.
+	EditedAt  string `json:"editedAt,omitempty"`
 	Color     string `json:"color"`
 	ChannelID int    `json:"channelId"`
+	// Channel is only set on ChannelUpdated messages.
+	Channel *ChannelInfo `json:"channel,omitempty"`
+}
+
+// ChannelInfo is what clients show in the header of a channel.
+type ChannelInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Topic       string `json:"topic"`
 }
 
 func NewChatMessage(user *User, typeMsg, content string, channelID int) Message {
 	return Message{
-		Type:      typeMsg,
-		UserID:    &user.ID,
-		Username:  user.Username,
+		Type:        typeMsg,
+		UserID:      &user.ID,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		AvatarURL:   user.AvatarURL,
+		Content:     content,
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Color:       user.Color,
+		ChannelID:   channelID,
+	}
+}
+
+func NewNotificationMessage(content string, channelID int) Message {
+	return Message{
+		Type:      notificationType,
 		Content:   content,
 		Timestamp: time.Now().Format(time.RFC3339),
-		Color:     user.Color,
+		Color:     "#666666",
 		ChannelID: channelID,
 	}
 }
 
-func NewNotificationMessage(content string, channelID int) Message {
+// NewErrorMessage tells a single client why its last message was rejected.
+func NewErrorMessage(content string, channelID int) Message {
 	return Message{
-		Type:      notificationType,
+		Type:      errorType,
 		Content:   content,
 		Timestamp: time.Now().Format(time.RFC3339),
 		Color:     "#666666",
 		ChannelID: channelID,
 	}
 }
+
+func NewChannelUpdatedMessage(channelID int, s channelsettings.Settings) Message {
+	return Message{
+		Type:      channelUpdatedType,
+		Timestamp: time.Now().Format(time.RFC3339),
+		ChannelID: channelID,
+		Channel: &ChannelInfo{
+			Name:        s.Name,
+			Description: s.Description,
+			Topic:       s.Topic,
+		},
+	}
+}
+
+// NewChannelDeletedMessage is the last message on a channel before the server
+// closes the connections to it.
+func NewChannelDeletedMessage(channelID int) Message {
+	return Message{
+		Type:      channelDeletedType,
+		Content:   "This channel has been deleted",
+		Timestamp: time.Now().Format(time.RFC3339),
+		Color:     "#666666",
+		ChannelID: channelID,
+	}
+}
+
+// NewOwnerChangedMessage announces the new owner of a channel. UserID and
+// Username are the new owner.
+func NewOwnerChangedMessage(channelID, ownerID int, username string) Message {
+	return Message{
+		Type:      ownerChangedType,
+		UserID:    &ownerID,
+		Username:  username,
+		Content:   fmt.Sprintf("%s is now the owner of this channel", username),
+		Timestamp: time.Now().Format(time.RFC3339),
+		Color:     "#666666",
+		ChannelID: channelID,
+	}
+}
+
+// NewMessageEditedMessage carries the new content of the stored message id,
+// so clients can replace their copy in place. Timestamp stays the time the
+// message was posted.
+func NewMessageEditedMessage(channelID int, id int64, content string, createdAt, editedAt time.Time) Message {
+	return Message{
+		Type:      messageEditedType,
+		ID:        id,
+		Content:   content,
+		Timestamp: createdAt.UTC().Format(time.RFC3339),
+		EditedAt:  editedAt.UTC().Format(time.RFC3339),
+		ChannelID: channelID,
+	}
+}
@@ -1,34 +1,51 @@
 package websocket
 
 import (
-	"math/rand"
 	"time"
+
+	"github.com/fortega2/real-time-chat/internal/profile"
 )
 
 type User struct {
-	ID       int       `json:"id"`
-	Username string    `json:"username"`
-	Color    string    `json:"color"`
-	JoinedAt time.Time `json:"joinedAt"`
+	ID          int       `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"displayName,omitempty"`
+	AvatarURL   string    `json:"avatarUrl,omitempty"`
+	Color       string    `json:"color"`
+	JoinedAt    time.Time `json:"joinedAt"`
 }
 
-var (
-	randomColors = []string{
-		"#FF6B6B", "#4ECDC4", "#45B7D1", "#96CEB4", "#FFEAA7",
-		"#DDA0DD", "#98D8C8", "#F7DC6F", "#BB8FCE", "#85C1E9",
-		"#F8C471", "#82E0AA", "#F1948A", "#85C1E9", "#D7DBDD",
-	}
-)
-
+// NewUser returns a user without a profile, shown with the default color for
+// its ID.
 func NewUser(id int, username string) *User {
+	return NewUserWithProfile(id, username, profile.Profile{})
+}
+
+func NewUserWithProfile(id int, username string, p profile.Profile) *User {
 	return &User{
-		ID:       id,
-		Username: username,
-		Color:    generateRandomColor(),
-		JoinedAt: time.Now(),
+		ID:          id,
+		Username:    username,
+		DisplayName: p.DisplayName,
+		AvatarURL:   p.AvatarURL,
+		Color:       profile.ColorOr(p.Color, int64(id)),
+		JoinedAt:    time.Now(),
+	}
+}
+
+// Name is how the user is shown in notifications.
+func (u *User) Name() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
 	}
+	return u.Username
 }
 
-func generateRandomColor() string {
-	return randomColors[rand.Intn(len(randomColors))]
+// withProfile returns a copy of u with profile p. Users are shared with the
+// client goroutines, so they are replaced rather than changed.
+func (u *User) withProfile(p profile.Profile) *User {
+	updated := *u
+	updated.DisplayName = p.DisplayName
+	updated.AvatarURL = p.AvatarURL
+	updated.Color = profile.ColorOr(p.Color, int64(u.ID))
+	return &updated
 }
@@ -3,6 +3,7 @@ package websocket_test
 import (
 	"testing"
 
+	"github.com/fortega2/real-time-chat/internal/profile"
 	"github.com/fortega2/real-time-chat/internal/websocket"
 )
 
@@ -14,7 +15,7 @@ func TestNewUser(t *testing.T) {
 	}
 
 	if user.Color == "" {
-		t.Error("Expected a random color, got an empty string")
+		t.Error("Expected a default color, got an empty string")
 	}
 
 	if user.ID <= 0 {
@@ -82,3 +83,17 @@ func TestUserColorIsValid(t *testing.T) {
 		t.Errorf("Expected color length 7, got %d for color '%s'", len(user.Color), user.Color)
 	}
 }
+
+func TestNewUserColorIsStable(t *testing.T) {
+	if websocket.NewUser(7, "seven").Color != websocket.NewUser(7, "seven").Color {
+		t.Error("Expected the same user to get the same color on every connection")
+	}
+
+	user := websocket.NewUserWithProfile(7, "seven", profile.Profile{DisplayName: "Seven", Color: "#ABCDEF"})
+	if user.Color != "#ABCDEF" || user.DisplayName != "Seven" {
+		t.Errorf("Expected the chosen profile, got %+v", user)
+	}
+	if user.Name() != "Seven" {
+		t.Errorf("Expected the display name to be shown, got %s", user.Name())
+	}
+}